@@ -3,26 +3,41 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math/big"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	"github.com/xiaomait/backend/internal/alert"
 	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/cache"
 	"github.com/xiaomait/backend/internal/config"
 	"github.com/xiaomait/backend/internal/handler"
+	"github.com/xiaomait/backend/internal/health"
+	"github.com/xiaomait/backend/internal/metadata"
+	"github.com/xiaomait/backend/internal/metrics"
+	"github.com/xiaomait/backend/internal/middleware"
+	"github.com/xiaomait/backend/internal/pii"
+	"github.com/xiaomait/backend/internal/pricing"
+	"github.com/xiaomait/backend/internal/realtime"
 	"github.com/xiaomait/backend/internal/repository"
 	"github.com/xiaomait/backend/internal/service"
+	"github.com/xiaomait/backend/internal/webhook"
 )
 
 func main() {
@@ -49,56 +64,294 @@ func main() {
 	}
 	log.Println("✓ Database connected successfully")
 
-	// 初始化区块链客户端
-	blockchainClient, err := blockchain.NewClient(cfg.EthereumRPC, cfg.MarketplaceAddress)
+	// 启动自检：表结构或 Postgres 版本不匹配时立即失败
+	if cfg.RunStartupSelftest {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := repository.SelfTest(ctx, db, cfg.VolumeAmountSource)
+		cancel()
+		if err != nil {
+			log.Fatalf("Startup self-test failed:\n%v", err)
+		}
+		log.Println("✓ Startup self-test passed")
+	}
+
+	// 存量地址统一为小写，按地址的查询只匹配规范形式
+	if cfg.NormalizeAddressesOnStartup {
+		normalized, conflicts, err := repository.NormalizeAddressCase(db)
+		if err != nil {
+			log.Fatalf("Address normalization failed: %v", err)
+		}
+		for _, conflict := range conflicts {
+			log.Printf("⚠ Address normalization skipped %s rows %v: duplicate key (%s) after lowercasing, merge them manually",
+				conflict.Table, conflict.IDs, conflict.Key)
+		}
+		log.Printf("✓ Address normalization done (%d rows updated)", normalized)
+	}
+
+	// 加载市场合约各版本 ABI
+	marketABIs, err := blockchain.LoadABIRegistry(cfg.MarketplaceABIVersions)
+	if err != nil {
+		log.Fatalf("Failed to load marketplace ABIs: %v", err)
+	}
+
+	// 初始化区块链客户端（节点限流时整个客户端自适应降速）
+	var rpcThrottle *blockchain.Throttle
+	if cfg.EnableRPCThrottle {
+		rpcThrottle = blockchain.NewThrottle(cfg.RPCThrottleBaseDelay, cfg.RPCThrottleMaxDelay)
+	}
+	blockchainClient, err := blockchain.NewClient(cfg.RPCEndpoints(), cfg.MarketplaceAddress, marketABIs, blockchain.ListenerOptions{
+		BufferSize:    cfg.EventBufferSize,
+		FullWait:      cfg.EventBufferFullWait,
+		CatchUpBlocks: cfg.SyncBatchSize,
+	}, rpcThrottle)
 	if err != nil {
 		log.Fatalf("Failed to initialize blockchain client: %v", err)
 	}
-	log.Println("✓ Blockchain client initialized")
+	log.Printf("✓ Blockchain client initialized (%d RPC endpoints)", blockchainClient.Endpoints())
+
+	// 事件监听与 RPC 健康检查在关闭时取消；关闭区块链客户端前等待监听器处理完已收到的事件
+	listenerCtx, stopListeners := context.WithCancel(context.Background())
+	defer stopListeners()
+	var listeners sync.WaitGroup
+
+	if blockchainClient.Endpoints() > 1 {
+		go blockchainClient.StartHealthCheck(listenerCtx, cfg.RPCHealthCheckInterval)
+	}
+
+	// 限制对外元数据抓取的并发及可访问的主机
+	metadata.SetMaxConcurrentFetches(cfg.MetadataMaxConcurrentFetches)
+	metadata.SetHostPolicy(metadata.NewHostPolicy(cfg.MetadataHosts(), cfg.MetadataAllowedSchemes))
 
 	// 初始化仓储层
 	nftRepo := repository.NewNFTRepository(db)
 	listingRepo := repository.NewListingRepository(db)
-	txRepo := repository.NewTransactionRepository(db)
+	txRepo := repository.NewTransactionRepository(db, cfg.VolumeAmountSource)
+	collectionRepo := repository.NewCollectionRepository(db)
+	holderRepo := repository.NewHolderRepository(db)
+	failedEventRepo := repository.NewFailedEventRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	offerRepo := repository.NewOfferRepository(db)
+	bidRepo := repository.NewBidRepository(db)
+	nftLikeRepo := repository.NewNFTLikeRepository(db)
+	notificationPrefRepo := repository.NewNotificationPreferenceRepository(db)
+	syncStateRepo := repository.NewSyncStateRepository(db)
+	indexTx := repository.NewIndexTxRepository(db, cfg.VolumeAmountSource)
+
+	repository.SetMaxResults(cfg.MaxQueryResults)
+
+	// 个人信息加密：在后台将旧版本密文及加密上线前的明文邮箱用当前密钥加密
+	if cfg.PIIEncryptionKey != "" {
+		keys, err := cfg.PIIKeys()
+		if err != nil {
+			log.Fatalf("Invalid PII encryption keys: %v", err)
+		}
+		keyring, err := pii.NewKeyring(uint32(cfg.PIIEncryptionKeyVersion), keys)
+		if err != nil {
+			log.Fatalf("Failed to initialize PII encryption: %v", err)
+		}
+		repository.SetPIIKeyring(keyring)
+		log.Printf("✓ PII encryption enabled (key v%d)", cfg.PIIEncryptionKeyVersion)
+
+		go rotatePIIKeys(userRepo)
+	}
+
+	// 初始化 Redis（缓存、限流计数等跨实例共享的状态），不可用时回退到进程内实现
+	redisClient := initRedis(cfg)
+
+	// 初始化缓存（详情/列表/统计接口使用 stale-while-revalidate），有 Redis 时多实例共享；
+	// 都未启用时 swr 为 nil，直接查询数据库
+	var cacheStore cache.Store
+	switch {
+	case redisClient != nil:
+		cacheStore = cache.NewRedisStore(redisClient)
+	case cfg.EnableMemoryCache:
+		cacheStore = cache.NewMemoryStore()
+	}
+	var swr *cache.SWR
+	if cacheStore != nil {
+		swr = cache.NewSWR(cacheStore, cfg.CacheSoftTTL, cfg.CacheTTL)
+	}
+
+	// 请求路径上的链上调用经熔断器，RPC 故障时快速失败并按策略降级
+	rpcBreaker := blockchain.NewBreaker(cfg.RPCBreakerThreshold, cfg.RPCBreakerCooldown)
+	guardedClient := blockchain.NewBreakerClient(blockchainClient, rpcBreaker)
 
 	// 初始化服务层
-	nftService := service.NewNFTService(nftRepo, blockchainClient)
-	listingService := service.NewListingService(listingRepo, blockchainClient)
-	txService := service.NewTransactionService(txRepo, blockchainClient)
+	feeService := service.NewFeeService(collectionRepo, cfg.PlatformFeeBps)
+	// 浏览去重标记须对所有实例可见，有 Redis 时存 Redis
+	var viewDedup cache.Store = cache.NewMemoryStore()
+	if redisClient != nil {
+		viewDedup = cache.NewRedisStore(redisClient)
+	}
+	viewCounter := service.NewViewCounter(nftRepo, viewDedup, cfg.ViewDedupWindow, cfg.ViewCounterQueueSize, cfg.ViewCounterWorkers, cfg.ViewCounterRetries)
+	imageURLs := service.NewImageURLRewriter(collectionRepo, cfg.IPFSGatewayPrefix())
+	nftMetadata := service.NewNFTMetadataService(nftRepo, guardedClient, swr, cfg.IPFSGatewayPrefix(), cfg.NFTMetadataMaxBytes, cfg.NFTMetadataQueueSize, cfg.NFTMetadataWorkers)
+	nftService := service.NewNFTService(nftRepo, nftLikeRepo, guardedClient, viewCounter, imageURLs, swr, nftMetadata)
+	listingPolicy := service.NewCollectionListingPolicy(collectionRepo, cfg.RequireVerifiedCollection)
+	// 挂单的 NFT 没有记录时创建占位记录并异步抓取元数据
+	var nftStubs *service.NFTMetadataService
+	if cfg.EnableNFTStubs {
+		nftStubs = nftMetadata
+	}
+	// 缓存最新区块号，用于交易响应的 is_final
+	chainHead := service.NewChainHead(guardedClient, cfg.BlockConfirmations)
+	go startChainHeadRefresher(chainHead, cfg.ChainHeadRefreshInterval)
+	// 实时事件在达到 BlockConfirmations 前留在确认队列中，检测链重组
+	confirmations := service.NewConfirmationTracker(blockchainClient, txRepo, indexTx, chainHead, swr)
+	if restored, err := confirmations.Restore(); err != nil {
+		log.Printf("Warning: failed to restore pending sales: %v", err)
+	} else if restored > 0 {
+		log.Printf("Restored %d pending sales awaiting confirmation", restored)
+	}
+	if confirmations.Enabled() {
+		go startConfirmationWorker(confirmations, cfg.ChainHeadRefreshInterval)
+	}
+
+	minBidIncrementWei, _ := new(big.Int).SetString(cfg.MinBidIncrementWei, 10)
+	bidIncrements := service.NewBidIncrementPolicy(collectionRepo, cfg.MinBidIncrementBps, minBidIncrementWei)
+	listingService := service.NewListingService(listingRepo, txRepo, nftRepo, indexTx, guardedClient, swr, feeService, listingPolicy, nftStubs, chainHead, confirmations, bidRepo, bidIncrements, cfg.UnverifiedListingPolicy, service.SellerRefreshOptions{
+		Workers:       cfg.SellerRefreshWorkers,
+		RatePerSecond: float64(cfg.SellerRefreshRPS),
+	})
+	txService := service.NewTransactionService(txRepo, listingRepo, nftRepo, indexTx, blockchainClient, feeService, chainHead, confirmations, cfg.SaleDedupeWindowBlocks)
+	offerService := service.NewOfferService(offerRepo, listingRepo, nftRepo, bidIncrements)
+	notificationPrefs := service.NewNotificationPreferenceService(notificationPrefRepo)
+	// 登录 nonce 须对所有实例可见，有 Redis 时存 Redis
+	var nonceStore cache.Store = cache.NewMemoryStore()
+	if redisClient != nil {
+		nonceStore = cache.NewRedisStore(redisClient)
+	}
+	authService := service.NewAuthService(nonceStore, cfg.SIWENonceTTL, cfg.SIWEDomain, cfg.ChainID)
+	collectionService := service.NewCollectionService(collectionRepo, holderRepo, bidIncrements)
+	priceService := service.NewPriceService(newPriceSource(cfg), cache.NewSWR(cache.NewMemoryStore(), cfg.PriceCacheTTL, 10*cfg.PriceCacheTTL), cfg.PriceCurrencies)
+	indexerService := service.NewIndexerService(blockchainClient, indexTx, syncStateRepo, nftRepo, feeService, blockchain.NewBlockTimeEstimator(blockchainClient, cfg.AvgBlockTime), cfg.MarketplaceAddress, cfg.SyncBatchSize, cfg.BackfillBatchSize)
+
+	// 事件处理失败写入死信表，按配置告警
+	var deadLetterNotifier alert.Notifier
+	if cfg.EnableDeadLetterAlert {
+		deadLetterNotifier = newDeadLetterNotifier(cfg)
+	}
+	deadLetters := service.NewDeadLetterService(failedEventRepo, deadLetterNotifier, cfg.DeadLetterAlertThreshold, cfg.DeadLetterAlertWindow)
+
+	// 实时推送 hub（事件监听发布，WebSocket 客户端订阅）
+	hub := realtime.NewHub()
+
+	// 地板价变动推送给系列订阅者及可选 Webhook
+	floorWatch := service.NewFloorWatchService(listingRepo, cfg.FloorChangeThresholdBps, cfg.FloorChangeDebounce, newFloorChangePublisher(hub, cfg.FloorChangeWebhookURL))
 
 	// 初始化处理器
-	nftHandler := handler.NewNFTHandler(nftService)
-	listingHandler := handler.NewListingHandler(listingService)
-	txHandler := handler.NewTransactionHandler(txService)
+	handler.SetPaginationCountDefault(cfg.PaginationWithCount)
+	nftHandler := handler.NewNFTHandler(nftService, cfg.NFTListIncludeMetadata)
+	listingHandler := handler.NewListingHandler(listingService, priceService, cfg.ListingAsOfMaxLookback)
+	txHandler := handler.NewTransactionHandler(txService, priceService)
+	offerHandler := handler.NewOfferHandler(offerService)
+	wsHandler := handler.NewWSHandler(hub, cfg.AllowedOrigins)
+	collectionHandler := handler.NewCollectionHandler(collectionService)
+	adminHandler := handler.NewAdminHandler(indexerService, collectionService, txService, imageURLs, nftService, feeService, cfg.AdminResyncMaxBlocks)
+	userHandler := handler.NewUserHandler(notificationPrefs)
+	contractHandler := handler.NewContractHandler(cfg.MarketplaceAddress, cfg.NFTContractAddress, cfg.ChainID, cfg.MarketplaceABIPath)
+	authHandler := handler.NewAuthHandler(authService, cfg.JWTSecret, cfg.JWTExpiration)
 
-	// 启动区块链事件监听器
-	if cfg.IsDevelopment() || cfg.IsStaging() {
-		go startEventListener(blockchainClient, listingService, txService)
-		log.Println("✓ Event listeners started")
+	// 启动时回填停机期间的事件，再一次性对账修正链上已成交/取消的挂单；
+	// 对账须在回填之后执行，否则会基于未追平的索引状态修改挂单；
+	// 两者都只在索引进程中执行，多个 API 副本同时启动时不会重复回填/对账
+	indexerRole := cfg.IndexerOnly || cfg.IsDevelopment() || cfg.IsStaging()
+	runBackfill := cfg.BackfillOnStartup && indexerRole
+	runReconcile := cfg.ReconcileOnStartup && indexerRole
+	if runBackfill || runReconcile {
+		go func() {
+			if runBackfill {
+				backfillOnStartup(blockchainClient, indexerService, cfg.StartBlock, cfg.BlockConfirmations)
+			}
+			if runReconcile {
+				reconcileOnStartup(listingService, cfg.ReconcileMaxChecks)
+			}
+		}()
 	}
 
-	// 初始化 Gin 路由
-	router := setupRouter(cfg, nftHandler, listingHandler, txHandler)
+	// 熔断恢复后校验熔断期间创建的挂单；启动时补做上次进程遗留的
+	rpcBreaker.OnClose(func() { verifyUnverifiedListings(listingService) })
+	go verifyUnverifiedListings(listingService)
 
-	// 创建 HTTP 服务器
-	srv := &http.Server{
-		Addr:           fmt.Sprintf(":%s", cfg.ServerPort),
-		Handler:        router,
-		ReadTimeout:    15 * time.Second,
-		WriteTimeout:   15 * time.Second,
-		MaxHeaderBytes: 1 << 20,
+	// 定期清理长期未确认/失败的交易
+	if cfg.EnableTxRetention {
+		go startTxRetentionSweeper(txService, cfg.TxRetentionInterval, cfg.TxPendingRetention, cfg.TxFailedRetention)
 	}
 
-	// 启动服务器
-	go func() {
-		log.Printf("🚀 Server starting on http://localhost:%s", cfg.ServerPort)
-		log.Printf("📊 Health check: http://localhost:%s/health", cfg.ServerPort)
-		log.Printf("📚 API docs: http://localhost:%s/api/v1", cfg.ServerPort)
+	// 定期归档超过保留期的已售/已取消挂单
+	if cfg.EnableListingArchival {
+		go startListingArchiver(listingService, cfg.ListingArchiveInterval, cfg.ListingArchiveAfter, cfg.ListingArchiveBatchSize)
+	}
+
+	// 定期检查地板价变动
+	if cfg.EnableFloorWatch {
+		go startFloorWatcher(floorWatch, cfg.FloorWatchInterval)
+	}
+
+	// 定期刷新热门系列物化视图
+	if cfg.EnableTrendingRefresh {
+		go startTrendingRefresher(collectionService, cfg.TrendingRefreshInterval)
+	}
+
+	// 健康检查（API 与独立索引进程共用）；只有独立索引进程在索引落后超过阈值时不就绪，
+	// API 实例不推进索引进度，不能因此被摘除
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get sql.DB: %v", err)
+	}
+	var indexerLag health.LagFunc
+	if cfg.IndexerOnly {
+		indexerLag = indexerService.Lag
+	}
+	checker := health.NewChecker(sqlDB, blockchainClient, indexerLag, cfg.IndexerMaxLagBlocks)
 
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+	var srv *http.Server
+	if cfg.IndexerOnly {
+		// 独立索引进程：仅运行事件监听和健康检查服务
+		startEventListener(listenerCtx, &listeners, blockchainClient, indexerService.StreamCursor, listingService, txService, notificationPrefs, deadLetters, hub)
+		go startCursorAdvancer(listenerCtx, indexerService, chainHead, cfg.BlockConfirmations, cfg.IndexerCursorAdvanceInterval)
+		log.Println("✓ Event listeners started (indexer only)")
+
+		srv = health.NewServer(fmt.Sprintf(":%s", cfg.IndexerHealthPort), checker)
+
+		go func() {
+			log.Printf("🩺 Indexer health server starting on http://localhost:%s/health/ready", cfg.IndexerHealthPort)
+
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start health server: %v", err)
+			}
+		}()
+	} else {
+		// 启动区块链事件监听器
+		if cfg.IsDevelopment() || cfg.IsStaging() {
+			startEventListener(listenerCtx, &listeners, blockchainClient, indexerService.StreamCursor, listingService, txService, notificationPrefs, deadLetters, hub)
+			go startCursorAdvancer(listenerCtx, indexerService, chainHead, cfg.BlockConfirmations, cfg.IndexerCursorAdvanceInterval)
+			log.Println("✓ Event listeners started")
 		}
-	}()
+
+		// 初始化 Gin 路由
+		router := setupRouter(cfg, checker, nftHandler, listingHandler, txHandler, offerHandler, contractHandler, collectionHandler, adminHandler, userHandler, authHandler, wsHandler, redisClient)
+
+		// 创建 HTTP 服务器
+		srv = &http.Server{
+			Addr:           fmt.Sprintf(":%s", cfg.ServerPort),
+			Handler:        router,
+			ReadTimeout:    15 * time.Second,
+			WriteTimeout:   15 * time.Second,
+			MaxHeaderBytes: 1 << 20,
+		}
+
+		// 启动服务器
+		go func() {
+			log.Printf("🚀 Server starting on http://localhost:%s", cfg.ServerPort)
+			log.Printf("📊 Health check: http://localhost:%s/health", cfg.ServerPort)
+			log.Printf("📚 API docs: http://localhost:%s/api/v1", cfg.ServerPort)
+
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start server: %v", err)
+			}
+		}()
+	}
 
 	// 启动 Metrics 服务器（如果启用）
 	if cfg.EnableMetrics {
@@ -115,16 +368,32 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// HTTP 关闭超时（仍有慢请求）不影响后续排空
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
 	}
 
-	// 关闭数据库连接
-	sqlDB, err := db.DB()
-	if err == nil {
-		sqlDB.Close()
+	// 排空使用独立的期限，不被 HTTP 关闭耗掉的时间挤占
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.ShutdownDrainTimeout)
+	defer cancelDrain()
+
+	// 停止事件监听，等待已收到的事件写入完成（其间可能入队元数据抓取）
+	stopListeners()
+	if err := waitGroup(drainCtx, &listeners); err != nil {
+		log.Printf("Event listeners did not stop: %v", err)
 	}
 
+	// 请求已处理完，写入剩余的浏览计数后再关闭数据库
+	if err := viewCounter.Close(drainCtx); err != nil {
+		log.Printf("View counter did not drain: %v", err)
+	}
+	if err := nftMetadata.Close(drainCtx); err != nil {
+		log.Printf("NFT metadata queue did not drain: %v", err)
+	}
+
+	// 关闭数据库连接
+	sqlDB.Close()
+
 	// 关闭区块链客户端
 	blockchainClient.Close()
 
@@ -153,7 +422,7 @@ func initDB(cfg *config.Config) (*gorm.DB, error) {
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
-		PrepareStmt:              true, // 预编译 SQL
+		PrepareStmt:              cfg.DBPrepareStmt, // 预编译 SQL
 		DisableNestedTransaction: false,
 	})
 	if err != nil {
@@ -188,8 +457,9 @@ func initDB(cfg *config.Config) (*gorm.DB, error) {
 		log.Println("✓ Database auto-migration completed")
 	}*/
 
-	// 打印连接池状态
+	// 打印连接池状态，并持续导出连接池与预编译语句缓存指标
 	printDBStats(sqlDB)
+	metrics.RegisterDBStats(sqlDB, preparedStmtCount(db))
 
 	return db, nil
 }
@@ -200,6 +470,7 @@ func autoMigrate(db *gorm.DB) error {
 		&repository.NFT{},
 		&repository.Listing{},
 		&repository.Transaction{},
+		&repository.Collection{},
 		// 添加其他模型...
 	)
 }
@@ -214,34 +485,96 @@ func printDBStats(db *sql.DB) {
 	log.Printf("  - Idle: %d", stats.Idle)
 }
 
+// preparedStmtCount 返回 GORM 预编译语句缓存大小的读取函数，未启用 PrepareStmt 时返回 nil
+func preparedStmtCount(db *gorm.DB) func() int {
+	prepared, ok := db.ConnPool.(*gorm.PreparedStmtDB)
+	if !ok {
+		return nil
+	}
+	return func() int {
+		prepared.Mux.RLock()
+		defer prepared.Mux.RUnlock()
+		return len(prepared.Stmts)
+	}
+}
+
 // setupRouter 设置路由
 func setupRouter(
 	cfg *config.Config,
+	checker *health.Checker,
 	nftHandler *handler.NFTHandler,
 	listingHandler *handler.ListingHandler,
 	txHandler *handler.TransactionHandler,
+	offerHandler *handler.OfferHandler,
+	contractHandler *handler.ContractHandler,
+	collectionHandler *handler.CollectionHandler,
+	adminHandler *handler.AdminHandler,
+	userHandler *handler.UserHandler,
+	authHandler *handler.AuthHandler,
+	wsHandler *handler.WSHandler,
+	redisClient *redis.Client,
 ) *gin.Engine {
 	// 设置 Gin 模式
 	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	if err := handler.RegisterValidators(); err != nil {
+		log.Fatalf("Failed to register validators: %v", err)
+	}
+
 	router := gin.New()
 
-	// 中间件
-	router.Use(gin.Logger())
+	// 只信任配置的代理转发的 X-Forwarded-For，否则 ClientIP 取连接的对端地址
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// 中间件：请求 ID 最先设置，访问日志、错误响应与后续日志均带上
+	router.Use(middleware.RequestID())
+	router.Use(gin.LoggerWithFormatter(middleware.AccessLogFormatter))
 	router.Use(gin.Recovery())
 
+	// 请求数与耗时指标（在过载保护、限流之前，被拒绝的请求也计入）
+	if cfg.EnableMetrics {
+		router.Use(middleware.Metrics())
+	}
+
 	// CORS 配置
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.AllowedOrigins,
 		AllowMethods:     cfg.AllowedMethods,
 		AllowHeaders:     cfg.AllowedHeaders,
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    cfg.ExposedHeaders,
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// 过载保护：限制同时处理的请求数，健康检查与 WebSocket 长连接不占名额
+	if cfg.EnableConcurrencyLimit {
+		router.Use(middleware.ConcurrencyLimit(int64(cfg.InFlightLimit()), cfg.InFlightQueueTimeout, "/health", "/api/v1/ws"))
+	}
+
+	// 限流
+	if cfg.EnableRateLimit {
+		router.Use(middleware.RateLimit(newRateLimiter(cfg, redisClient)))
+	}
+
+	// 按路由设置 Cache-Control，未配置的接口与个性化请求一律 no-store
+	router.Use(middleware.CacheHeaders(map[string]time.Duration{
+		"/info":                   cfg.HTTPCacheInfoMaxAge,
+		"/api/v1/contract":        cfg.HTTPCacheContractMaxAge,
+		"/api/v1/collections/top": cfg.HTTPCacheCollectionsMaxAge,
+		"/api/v1/collections/:address/bid-increment":     cfg.HTTPCacheCollectionsMaxAge,
+		"/api/v1/stats/collections/:address":             cfg.HTTPCacheCollectionsMaxAge,
+		"/api/v1/stats/collections/:address/price-bands": cfg.HTTPCacheCollectionsMaxAge,
+	}))
+
+	// 响应编码协商（JSON / msgpack）
+	if cfg.EnableMsgpack {
+		router.Use(handler.MsgpackNegotiation())
+	}
+
 	// 限制请求体大小
 	router.MaxMultipartMemory = cfg.MaxRequestBodySize
 
@@ -254,6 +587,22 @@ func setupRouter(
 		})
 	})
 
+	router.GET("/health/live", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "ok",
+			"timestamp": time.Now().UTC(),
+		})
+	})
+
+	router.GET("/health/ready", func(c *gin.Context) {
+		report, ready := checker.Ready(c.Request.Context())
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
+
 	// 系统信息
 	router.GET("/info", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -267,12 +616,31 @@ func setupRouter(
 	// API 路由
 	v1 := router.Group("/api/v1")
 	{
+		// 实时推送
+		v1.GET("/ws", wsHandler.Subscribe)
+
+		// 合约信息（地址 + ABI）
+		v1.GET("/contract", contractHandler.GetContract)
+
+		// 钱包登录（EIP-4361），校验通过后签发 JWT
+		auth := v1.Group("/auth")
+		{
+			auth.POST("/nonce", authHandler.Nonce)
+			auth.POST("/verify", authHandler.Verify)
+		}
+
 		// NFT 路由
 		nfts := v1.Group("/nfts")
 		{
 			nfts.GET("", nftHandler.GetNFTs)
-			nfts.GET("/:id", nftHandler.GetNFT)
-			nfts.POST("", nftHandler.CreateNFT)
+			nfts.GET("/search", nftHandler.SearchNFTs)
+			nfts.GET("/:id", middleware.OptionalJWTAuth(cfg.JWTSecret), nftHandler.GetNFT)
+			nfts.GET("/:id/similar", nftHandler.GetSimilarNFTs)
+			nfts.GET("/:id/liked", nftHandler.GetLikeStatus)
+			nfts.POST("/:id/like", middleware.JWTAuth(cfg.JWTSecret), nftHandler.LikeNFT)
+			nfts.POST("/:id/unlike", middleware.JWTAuth(cfg.JWTSecret), nftHandler.UnlikeNFT)
+			nfts.POST("/:id/refresh", middleware.JWTAuth(cfg.JWTSecret), nftHandler.RefreshMetadata)
+			nfts.POST("", middleware.JWTAuth(cfg.JWTSecret), nftHandler.CreateNFT)
 			nfts.GET("/user/:address", nftHandler.GetUserNFTs)
 			nfts.GET("/contract/:address", nftHandler.GetNFTsByContract)
 		}
@@ -282,10 +650,29 @@ func setupRouter(
 		{
 			listings.GET("", listingHandler.GetActiveListings)
 			listings.GET("/:id", listingHandler.GetListing)
-			listings.POST("", listingHandler.CreateListing)
-			listings.DELETE("/:id", listingHandler.CancelListing)
+			listings.GET("/by-tx/:hash", listingHandler.GetListingByTx)
+			listings.GET("/item/:itemId", listingHandler.GetListingByItemID)
+			listings.GET("/as-of", listingHandler.GetListingsAsOf)
+			listings.POST("", middleware.JWTAuth(cfg.JWTSecret), listingHandler.CreateListing)
+			listings.DELETE("/:id", middleware.JWTAuth(cfg.JWTSecret), listingHandler.CancelListing)
+			listings.GET("/:id/offers", offerHandler.GetListingOffers)
+			listings.POST("/:id/bids", middleware.JWTAuth(cfg.JWTSecret), listingHandler.PlaceBid)
+			listings.GET("/:id/bids", listingHandler.GetBids)
 			listings.GET("/user/:address", listingHandler.GetUserListings)
+			listings.POST("/user/:address/refresh", middleware.JWTAuth(cfg.JWTSecret), listingHandler.RefreshUserListings)
 			listings.GET("/search", listingHandler.SearchListings)
+			listings.GET("/fee-preview", listingHandler.PreviewProceeds)
+			listings.POST("/status", listingHandler.GetListingStatuses)
+		}
+
+		// 出价路由
+		offers := v1.Group("/offers")
+		{
+			offers.POST("", middleware.JWTAuth(cfg.JWTSecret), offerHandler.CreateOffer)
+			offers.DELETE("/:id", middleware.JWTAuth(cfg.JWTSecret), offerHandler.CancelOffer)
+			offers.GET("/nft/:contract/:tokenId", offerHandler.GetNFTOffers)
+			offers.GET("/nft/:contract/:tokenId/best", offerHandler.GetBestOffer)
+			offers.GET("/user/:address", middleware.JWTAuth(cfg.JWTSecret), offerHandler.GetUserOffers)
 		}
 
 		// 交易路由
@@ -297,68 +684,467 @@ func setupRouter(
 			transactions.GET("/nft/:contract/:tokenId", txHandler.GetNFTTransactions)
 		}
 
+		// 系列路由
+		collections := v1.Group("/collections")
+		{
+			collections.GET("/top", collectionHandler.GetTopCollections)
+			collections.GET("/:address/bid-increment", collectionHandler.GetBidIncrement)
+			collections.GET("/:address/snapshot", collectionHandler.GetHolderSnapshot)
+		}
+
 		// 市场统计
 		stats := v1.Group("/stats")
 		{
 			stats.GET("", listingHandler.GetMarketStats)
+			stats.GET("/volume", txHandler.GetDailyVolume)
+			stats.GET("/transactions", txHandler.GetTransactionStats)
 			stats.GET("/collections/:address", listingHandler.GetCollectionStats)
+			stats.POST("/collections/batch", listingHandler.GetCollectionStatsBatch)
+			stats.GET("/collections/:address/price-bands", listingHandler.GetPriceBands)
+		}
+
+		// 用户设置（需 JWT 认证，只能访问自己的地址）
+		users := v1.Group("/users/:address", middleware.JWTAuth(cfg.JWTSecret))
+		{
+			users.GET("/notification-preferences", userHandler.GetNotificationPreferences)
+			users.PUT("/notification-preferences", userHandler.UpdateNotificationPreferences)
+		}
+
+		// 运维管理（需 ADMIN_API_TOKEN）
+		if cfg.AdminAPIToken != "" {
+			admin := v1.Group("/admin", middleware.AdminAuth(cfg.AdminAPIToken))
+			{
+				admin.POST("/indexer/resync", adminHandler.ResyncBlocks)
+				admin.POST("/collections/trending/refresh", adminHandler.RefreshTrending)
+				admin.PUT("/collections/:address/image-cdn", adminHandler.SetImageCDNBase)
+				admin.PUT("/collections/:address/fee", adminHandler.SetFeeOverride)
+				admin.GET("/transactions/export", adminHandler.ExportTransactions)
+				// 按 nft_likes 重算 like_count；view_count 没有逐次浏览记录，本身即为唯一数据来源，无法重算
+				admin.POST("/nfts/recompute", adminHandler.RecomputeAllNFTLikes)
+				admin.POST("/nfts/:id/recompute", adminHandler.RecomputeNFTLikes)
+			}
 		}
 	}
 
 	return router
 }
 
-// startEventListener 启动事件监听器
+// startEventListener 启动事件监听器，ctx 结束后各监听器处理完已收到的事件再退出，退出时调用 wg.Done
 func startEventListener(
+	ctx context.Context,
+	wg *sync.WaitGroup,
 	client *blockchain.Client,
+	cursor blockchain.CursorFunc,
 	listingService *service.ListingService,
 	txService *service.TransactionService,
+	notificationPrefs *service.NotificationPreferenceService,
+	deadLetters *service.DeadLetterService,
+	hub *realtime.Hub,
 ) {
-	// 创建可取消的 context
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	log.Println("Starting blockchain event listener...")
 	
 	// 监听 MarketItemCreated 事件
+	wg.Add(1)
 	go func() {
-		events := client.ListenMarketItemCreated(ctx)
+		defer wg.Done()
+		events := client.ListenMarketItemCreated(ctx, cursor)
 		log.Println("MarketItemCreated listener started")
 		for event := range events {
 			log.Printf("📝 MarketItemCreated: ItemID=%d, Price=%s",
 				event.ItemId, event.Price.String())
 
-			if err := listingService.UpdateFromEvent(event); err != nil {
+			listing, err := listingService.UpdateFromEvent(event)
+			if err != nil {
 				log.Printf("Error updating listing from event: %v", err)
+				deadLetters.Record("MarketItemCreated", event.Raw, event, err)
+				metrics.IndexerEventsProcessed.WithLabelValues("MarketItemCreated", "error").Inc()
+				continue
 			}
+			metrics.IndexerEventsProcessed.WithLabelValues("MarketItemCreated", "ok").Inc()
+
+			hub.Publish(realtime.Event{
+				Type:   realtime.EventListing,
+				Topics: []string{realtime.TopicNewListings, realtime.CollectionTopic(listing.NFTContract)},
+				Data:   listing,
+			})
 		}
 	}()
 
 	// 监听 MarketItemSold 事件
+	wg.Add(1)
 	go func() {
-		events := client.ListenMarketItemSold(ctx)
+		defer wg.Done()
+		events := client.ListenMarketItemSold(ctx, cursor)
 		for event := range events {
 			log.Printf("💰 MarketItemSold: ItemID=%d, Buyer=%s",
 				event.ItemId, event.Buyer.Hex())
 
-			if err := txService.RecordSale(event); err != nil {
+			tx, err := txService.RecordSale(event)
+			if errors.Is(err, service.ErrDuplicateSale) {
+				log.Printf("Skipping sale event: %v", err)
+				metrics.IndexerEventsProcessed.WithLabelValues("MarketItemSold", "duplicate").Inc()
+				continue
+			}
+			if err != nil {
 				log.Printf("Error recording sale: %v", err)
+				deadLetters.Record("MarketItemSold", event.Raw, event, err)
+				metrics.IndexerEventsProcessed.WithLabelValues("MarketItemSold", "error").Inc()
+				continue
+			}
+			metrics.IndexerEventsProcessed.WithLabelValues("MarketItemSold", "ok").Inc()
+
+			// 推送给全局成交、该合约的订阅者，以及通知偏好允许的卖家、买家
+			topics := []string{realtime.TopicNewSales, realtime.CollectionTopic(tx.NFTContract)}
+			for _, address := range notificationPrefs.Recipients(ctx, service.NotifySale, service.ChannelWebSocket, tx.FromAddress, tx.ToAddress) {
+				topics = append(topics, realtime.AddressTopic(address))
 			}
+			hub.Publish(realtime.Event{
+				Type:   realtime.EventSale,
+				Topics: topics,
+				Data:   tx,
+			})
+		}
+	}()
+
+	// 监听 MarketItemCanceled 事件
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		events := client.ListenMarketItemCancelled(ctx, cursor)
+		for event := range events {
+			log.Printf("🚫 MarketItemCanceled: ItemID=%d", event.ItemId)
+
+			if err := listingService.CancelFromEvent(event); err != nil {
+				log.Printf("Error cancelling listing from event: %v", err)
+				deadLetters.Record("MarketItemCanceled", event.Raw, event, err)
+				metrics.IndexerEventsProcessed.WithLabelValues("MarketItemCanceled", "error").Inc()
+				continue
+			}
+			metrics.IndexerEventsProcessed.WithLabelValues("MarketItemCanceled", "ok").Inc()
 		}
 	}()
 
 	log.Println("✓ Event listeners are running")
 }
 
+// waitGroup 等待 wg 完成，ctx 先结束时返回 ctx.Err()
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// initRedis 按配置连接 Redis；未启用或连接失败时返回 nil，由调用方回退到进程内实现
+func initRedis(cfg *config.Config) *redis.Client {
+	if !cfg.EnableRedisCache {
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.GetRedisAddr(),
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Printf("Warning: Redis unavailable at %s, falling back to in-memory stores: %v", cfg.GetRedisAddr(), err)
+		client.Close()
+		return nil
+	}
+
+	log.Printf("✓ Redis connected at %s", cfg.GetRedisAddr())
+	return client
+}
+
+// newRateLimiter 有 Redis 时使用 Redis 计数（多实例共享配额），否则使用进程内令牌桶
+func newRateLimiter(cfg *config.Config, redisClient *redis.Client) middleware.RateLimiter {
+	if redisClient != nil {
+		return middleware.NewRedisRateLimiter(redisClient, cfg.RateLimitPerMinute)
+	}
+	return middleware.NewMemoryRateLimiter(cfg.RateLimitPerMinute)
+}
+
+// newPriceSource 按配置创建 ETH 法币汇率来源
+func newPriceSource(cfg *config.Config) pricing.Source {
+	if cfg.PriceSource == "coinmarketcap" {
+		return pricing.NewCoinMarketCap(cfg.CoinMarketCapAPIKey)
+	}
+	return pricing.NewCoinGecko()
+}
+
+// newDeadLetterNotifier 按配置的渠道组装死信告警通知（渠道已在配置校验时检查）
+func newDeadLetterNotifier(cfg *config.Config) alert.Notifier {
+	var notifiers alert.Multi
+	for _, channel := range cfg.DeadLetterAlertChannels {
+		switch channel {
+		case "log":
+			notifiers = append(notifiers, alert.LogNotifier{})
+		case "webhook":
+			notifiers = append(notifiers, alert.NewWebhookNotifier(cfg.DeadLetterAlertWebhook))
+		case "email":
+			notifiers = append(notifiers, &alert.EmailNotifier{
+				Host:     cfg.SMTPHost,
+				Port:     cfg.SMTPPort,
+				User:     cfg.SMTPUser,
+				Password: cfg.SMTPPassword,
+				From:     cfg.SMTPFrom,
+				To:       cfg.DeadLetterAlertEmailTo,
+			})
+		}
+	}
+	return notifiers
+}
+
+// reconcileOnStartup 启动时对账活跃挂单
+func reconcileOnStartup(listingService *service.ListingService, maxChecks int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	log.Println("Reconciling active listings with chain...")
+
+	result, err := listingService.ReconcileActiveListings(ctx, maxChecks)
+	if err != nil {
+		log.Printf("Startup reconciliation failed: %v", err)
+		return
+	}
+
+	log.Printf("✓ Startup reconciliation done: checked=%d sold=%d cancelled=%d unchanged=%d skipped=%d",
+		result.Checked, result.Sold, result.Cancelled, result.Unchanged, result.Skipped)
+}
+
+// rotatePIIKeys 用当前密钥重新加密旧密钥加密的用户邮箱
+func rotatePIIKeys(userRepo *repository.UserRepository) {
+	rotated, err := userRepo.RotateEmailKeys(500)
+	if err != nil {
+		log.Printf("PII key rotation stopped after %d rows: %v", rotated, err)
+		return
+	}
+	if rotated > 0 {
+		log.Printf("✓ PII key rotation done: re-encrypted=%d", rotated)
+	}
+}
+
+// verifyUnverifiedListings 对未经链上校验的挂单补做校验
+func verifyUnverifiedListings(listingService *service.ListingService) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := listingService.VerifyUnverifiedListings(ctx)
+	if err != nil {
+		log.Printf("Unverified listing verification stopped: %v", err)
+	}
+	if result != nil && result.Verified+result.Invalid+result.Failed > 0 {
+		log.Printf("✓ Unverified listings checked: verified=%d invalid=%d failed=%d",
+			result.Verified, result.Invalid, result.Failed)
+	}
+}
+
+// backfillOnStartup 启动时回填 startBlock 到最新已确认区块之间的事件
+func backfillOnStartup(client *blockchain.Client, indexerService *service.IndexerService, startBlock, confirmations uint64) {
+	ctx := context.Background()
+
+	head, err := client.GetBlockNumber(ctx)
+	if err != nil {
+		log.Printf("Backfill skipped: failed to get block number: %v", err)
+		return
+	}
+	// 从各事件流中最低的索引进度续传；该区块可能只入库了部分事件，因此包含在内（回填可重复执行）
+	fromBlock := startBlock
+	last, ok, err := indexerService.GetLastSyncedBlock()
+	if err != nil {
+		log.Printf("Backfill skipped: %v", err)
+		return
+	}
+	if ok && last > fromBlock {
+		fromBlock = last
+	}
+
+	if head < confirmations || head-confirmations < fromBlock {
+		return
+	}
+	toBlock := head - confirmations
+
+	log.Printf("Backfilling blocks %d-%d...", fromBlock, toBlock)
+	started := time.Now()
+
+	result, err := indexerService.Backfill(ctx, fromBlock, toBlock)
+	if err != nil {
+		log.Printf("Backfill failed: %v", err)
+		return
+	}
+
+	log.Printf("✓ Backfill done in %s: created=%d sold=%d",
+		time.Since(started).Round(time.Millisecond), result.Created, result.Sold)
+}
+
+// startTxRetentionSweeper 按固定间隔清理超过保留期的交易
+func startTxRetentionSweeper(txService *service.TransactionService, interval, pendingRetention, failedRetention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := txService.PurgeStaleTransactions(context.Background(), pendingRetention, failedRetention)
+		if err != nil {
+			log.Printf("Transaction retention sweep failed: %v", err)
+		} else if result.Pending > 0 || result.Failed > 0 {
+			log.Printf("🧹 Purged stale transactions: pending=%d failed=%d", result.Pending, result.Failed)
+		}
+
+		<-ticker.C
+	}
+}
+
+// startListingArchiver 按固定间隔归档超过保留期的已售/已取消挂单
+func startListingArchiver(listingService *service.ListingService, interval, archiveAfter time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		archived, err := listingService.ArchiveSettledListings(context.Background(), archiveAfter, batchSize)
+		if err != nil {
+			log.Printf("Listing archival sweep failed: %v", err)
+		}
+		if archived > 0 {
+			log.Printf("🧹 Archived settled listings: %d", archived)
+		}
+
+		<-ticker.C
+	}
+}
+
+// startTrendingRefresher 按固定间隔刷新热门系列物化视图
+func startTrendingRefresher(collectionService *service.CollectionService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := collectionService.RefreshTrending(context.Background()); err != nil {
+			log.Printf("Trending collections refresh failed: %v", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// startChainHeadRefresher 按固定间隔刷新缓存的最新区块号
+func startChainHeadRefresher(chainHead *service.ChainHead, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := chainHead.Refresh(ctx); err != nil {
+			log.Printf("Chain head refresh failed: %v", err)
+		}
+		cancel()
+
+		<-ticker.C
+	}
+}
+
+// startCursorAdvancer 按固定间隔将索引进度推进到已确认区块（区块段内没有市场事件时），
+// 使长时间没有事件时就绪检查的延迟不会一直增长
+func startCursorAdvancer(ctx context.Context, indexerService *service.IndexerService, chainHead *service.ChainHead, confirmations uint64, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		head := chainHead.Head()
+		if head <= confirmations {
+			continue
+		}
+		runCtx, cancel := context.WithTimeout(ctx, interval)
+		_, err := indexerService.AdvanceIdle(runCtx, head-confirmations)
+		cancel()
+		if err != nil {
+			log.Printf("Sync cursor advance failed: %v", err)
+		}
+	}
+}
+
+// startConfirmationWorker 按固定间隔处理确认队列中已达到确认数的事件
+func startConfirmationWorker(tracker *service.ConfirmationTracker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		result, err := tracker.Process(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Confirmation check failed: %v", err)
+		}
+		if result.Reorged > 0 || result.Moved > 0 {
+			log.Printf("Reorg detected: reverted=%d moved=%d confirmed=%d", result.Reorged, result.Moved, result.Confirmed)
+		}
+	}
+}
+
+// startFloorWatcher 按固定间隔检查各系列地板价变动
+func startFloorWatcher(floorWatch *service.FloorWatchService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := floorWatch.Check(context.Background()); err != nil {
+			log.Printf("Floor price check failed: %v", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// newFloorChangePublisher 将地板价变动推送到系列主题，配置了 Webhook 时异步转发
+func newFloorChangePublisher(hub *realtime.Hub, webhookURL string) func(service.FloorChange) {
+	var sender *webhook.Sender
+	if webhookURL != "" {
+		sender = webhook.NewSender(webhookURL)
+	}
+
+	return func(change service.FloorChange) {
+		log.Printf("📉 Floor changed: %s %s -> %s (%+d bps)", change.NFTContract, change.OldFloor, change.NewFloor, change.ChangeBps)
+
+		hub.Publish(realtime.Event{
+			Type:   realtime.EventFloorChanged,
+			Topics: []string{realtime.CollectionTopic(change.NFTContract)},
+			Data:   change,
+		})
+
+		if sender == nil {
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := sender.Send(ctx, realtime.EventFloorChanged, change); err != nil {
+				log.Printf("Failed to send floor change webhook: %v", err)
+			}
+		}()
+	}
+}
+
 // startMetricsServer 启动 Metrics 服务器
 func startMetricsServer(port string) {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
-		// 这里可以集成 Prometheus metrics
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, "# Metrics endpoint\n")
-	})
+	mux.Handle("/metrics", promhttp.Handler())
 
 	addr := fmt.Sprintf(":%s", port)
 	log.Printf("📊 Metrics server starting on http://localhost:%s/metrics", port)
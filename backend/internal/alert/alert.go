@@ -0,0 +1,124 @@
+// Package alert 运维告警渠道（日志、Webhook、邮件）
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Alert 告警内容
+type Alert struct {
+	Title   string        `json:"title"`
+	Count   int64         `json:"count"`
+	Window  time.Duration `json:"window"`
+	Samples []string      `json:"samples"` // 部分错误信息样例
+	At      time.Time     `json:"at"`
+}
+
+// Notifier 告警渠道
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// LogNotifier 以带 [ALERT] 标记的日志输出告警，便于日志系统检索
+type LogNotifier struct{}
+
+// Notify 输出告警日志
+func (LogNotifier) Notify(ctx context.Context, a Alert) error {
+	log.Printf("[ALERT] %s: %d in last %s; samples: %s", a.Title, a.Count, a.Window, strings.Join(a.Samples, " | "))
+	return nil
+}
+
+// WebhookNotifier 以 JSON POST 发送告警
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier 创建 Webhook 告警渠道
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify 发送告警
+func (n *WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(struct {
+		Alert
+		Window string `json:"window"`
+	}{Alert: a, Window: a.Window.String()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier 通过 SMTP 发送告警邮件
+type EmailNotifier struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify 发送告警邮件
+func (n *EmailNotifier) Notify(ctx context.Context, a Alert) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", n.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&body, "Subject: [ALERT] %s\r\n\r\n", a.Title)
+	fmt.Fprintf(&body, "%d occurrences in the last %s (as of %s).\r\n\r\nSamples:\r\n", a.Count, a.Window, a.At.UTC().Format(time.RFC3339))
+	for _, sample := range a.Samples {
+		fmt.Fprintf(&body, "- %s\r\n", sample)
+	}
+
+	var auth smtp.Auth
+	if n.User != "" {
+		auth = smtp.PlainAuth("", n.User, n.Password, n.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", n.Host, n.Port)
+	if err := smtp.SendMail(addr, auth, n.From, n.To, []byte(body.String())); err != nil {
+		return fmt.Errorf("failed to send alert email: %w", err)
+	}
+	return nil
+}
+
+// Multi 依次通知所有渠道，单个渠道失败不影响其他渠道
+type Multi []Notifier
+
+// Notify 通知所有渠道，返回合并的错误
+func (m Multi) Notify(ctx context.Context, a Alert) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
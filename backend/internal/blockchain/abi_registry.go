@@ -0,0 +1,169 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ABIVersion 某一版本的市场合约 ABI
+type ABIVersion struct {
+	Version string
+	ABI     abi.ABI
+}
+
+// versionedEvent 按 topic ID 索引的事件定义
+type versionedEvent struct {
+	version string
+	event   abi.Event
+}
+
+// ABIRegistry 市场合约多版本 ABI。合约升级可能修改事件签名（如新增字段），
+// 日志按 topic ID 匹配对应版本解码；方法调用使用定义了该方法的最新版本
+type ABIRegistry struct {
+	versions []ABIVersion // 按从旧到新排列
+	events   map[common.Hash]versionedEvent
+}
+
+// NewABIRegistry 创建 ABI 注册表，versions 按从旧到新排列。
+// 签名未变的事件在各版本中 topic ID 相同，记为首次引入该签名的版本
+func NewABIRegistry(versions ...ABIVersion) (*ABIRegistry, error) {
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("at least one ABI version is required")
+	}
+
+	r := &ABIRegistry{events: make(map[common.Hash]versionedEvent)}
+	seen := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		if v.Version == "" || seen[v.Version] {
+			return nil, fmt.Errorf("ABI version %q is empty or duplicated", v.Version)
+		}
+		seen[v.Version] = true
+		r.versions = append(r.versions, v)
+
+		for _, event := range v.ABI.Events {
+			if _, ok := r.events[event.ID]; !ok {
+				r.events[event.ID] = versionedEvent{version: v.Version, event: event}
+			}
+		}
+	}
+	return r, nil
+}
+
+// LoadABIRegistry 从配置加载 ABI 注册表，spec 格式为 "v1=path/v1.json,v2=path/v2.json"（从旧到新）
+func LoadABIRegistry(spec string) (*ABIRegistry, error) {
+	var versions []ABIVersion
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		version, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid ABI version entry %q, expected version=path", entry)
+		}
+
+		file, err := os.Open(strings.TrimSpace(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open ABI %s: %w", version, err)
+		}
+		parsed, err := abi.JSON(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ABI %s: %w", version, err)
+		}
+
+		versions = append(versions, ABIVersion{Version: strings.TrimSpace(version), ABI: parsed})
+	}
+
+	return NewABIRegistry(versions...)
+}
+
+// Latest 最新版本号
+func (r *ABIRegistry) Latest() string {
+	return r.versions[len(r.versions)-1].Version
+}
+
+// EventIDs 事件在所有版本中的 topic ID（去重），用于日志过滤
+func (r *ABIRegistry) EventIDs(name string) []common.Hash {
+	var ids []common.Hash
+	for id, ev := range r.events {
+		if ev.event.Name == name {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Method 返回定义了该方法的最新版本 ABI
+func (r *ABIRegistry) Method(name string) (abi.ABI, error) {
+	for i := len(r.versions) - 1; i >= 0; i-- {
+		if _, ok := r.versions[i].ABI.Methods[name]; ok {
+			return r.versions[i].ABI, nil
+		}
+	}
+	return abi.ABI{}, fmt.Errorf("method %s not found in any ABI version", name)
+}
+
+// DecodedEvent 按版本解码的事件日志，Fields 包含 indexed 与非 indexed 参数
+type DecodedEvent struct {
+	Name    string
+	Version string
+	Fields  map[string]interface{}
+}
+
+// Decode 按日志 topic ID 匹配版本并解码全部参数
+func (r *ABIRegistry) Decode(vLog types.Log) (*DecodedEvent, error) {
+	if len(vLog.Topics) == 0 {
+		return nil, fmt.Errorf("log has no topics")
+	}
+
+	ev, ok := r.events[vLog.Topics[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown event topic %s", vLog.Topics[0].Hex())
+	}
+
+	var indexed abi.Arguments
+	for _, arg := range ev.event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(vLog.Topics)-1 != len(indexed) {
+		return nil, fmt.Errorf("unexpected topic count %d for %s %s", len(vLog.Topics), ev.event.Name, ev.version)
+	}
+
+	fields := make(map[string]interface{}, len(ev.event.Inputs))
+	if err := ev.event.Inputs.NonIndexed().UnpackIntoMap(fields, vLog.Data); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s %s: %w", ev.event.Name, ev.version, err)
+	}
+	if err := abi.ParseTopicsIntoMap(fields, indexed, vLog.Topics[1:]); err != nil {
+		return nil, fmt.Errorf("failed to parse topics of %s %s: %w", ev.event.Name, ev.version, err)
+	}
+
+	return &DecodedEvent{Name: ev.event.Name, Version: ev.version, Fields: fields}, nil
+}
+
+// BigInt 读取 uint/int 参数
+func (e *DecodedEvent) BigInt(name string) (*big.Int, error) {
+	v, ok := e.Fields[name].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("%s %s: field %s missing or not an integer", e.Name, e.Version, name)
+	}
+	return v, nil
+}
+
+// Address 读取 address 参数
+func (e *DecodedEvent) Address(name string) (common.Address, error) {
+	v, ok := e.Fields[name].(common.Address)
+	if !ok {
+		return common.Address{}, fmt.Errorf("%s %s: field %s missing or not an address", e.Name, e.Version, name)
+	}
+	return v, nil
+}
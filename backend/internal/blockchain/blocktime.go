@@ -0,0 +1,112 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxCachedBlockTimes 精确时间戳缓存上限，超出后清空重建
+const maxCachedBlockTimes = 10000
+
+// BlockTimeSource 获取区块的精确时间戳
+type BlockTimeSource interface {
+	GetBlockTime(ctx context.Context, blockNumber uint64) (time.Time, error)
+}
+
+// BlockTimeEstimator 区块时间估算：只获取少量参考区块头，中间区块按平均出块时间插值
+type BlockTimeEstimator struct {
+	source       BlockTimeSource
+	avgBlockTime time.Duration // 为 0 时根据参考区块自动推算
+
+	mu    sync.Mutex
+	exact map[uint64]time.Time
+}
+
+// NewBlockTimeEstimator 创建区块时间估算器，avgBlockTime <= 0 表示自动推算
+func NewBlockTimeEstimator(source BlockTimeSource, avgBlockTime time.Duration) *BlockTimeEstimator {
+	return &BlockTimeEstimator{
+		source:       source,
+		avgBlockTime: avgBlockTime,
+		exact:        make(map[uint64]time.Time),
+	}
+}
+
+// Exact 获取区块的精确时间戳（需要准确时间的场景，如销售）
+func (e *BlockTimeEstimator) Exact(ctx context.Context, blockNumber uint64) (time.Time, error) {
+	e.mu.Lock()
+	ts, ok := e.exact[blockNumber]
+	e.mu.Unlock()
+	if ok {
+		return ts, nil
+	}
+
+	ts, err := e.source.GetBlockTime(ctx, blockNumber)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get block %d time: %w", blockNumber, err)
+	}
+
+	e.mu.Lock()
+	if len(e.exact) >= maxCachedBlockTimes {
+		e.exact = make(map[uint64]time.Time)
+	}
+	e.exact[blockNumber] = ts
+	e.mu.Unlock()
+
+	return ts, nil
+}
+
+// BlockClock 区块范围内的时间戳插值
+type BlockClock struct {
+	fromBlock uint64
+	fromTime  time.Time
+	toBlock   uint64
+	toTime    time.Time
+	perBlock  time.Duration
+}
+
+// At 估算区块时间戳，结果限制在参考区块时间范围内
+func (c *BlockClock) At(blockNumber uint64) time.Time {
+	if blockNumber <= c.fromBlock {
+		return c.fromTime
+	}
+	if blockNumber >= c.toBlock && !c.toTime.IsZero() {
+		return c.toTime
+	}
+
+	ts := c.fromTime.Add(time.Duration(blockNumber-c.fromBlock) * c.perBlock)
+	if !c.toTime.IsZero() && ts.After(c.toTime) {
+		return c.toTime
+	}
+	return ts
+}
+
+// ForRange 获取区块范围 [fromBlock, toBlock] 的插值时钟，仅请求两端的区块头
+func (e *BlockTimeEstimator) ForRange(ctx context.Context, fromBlock, toBlock uint64) (*BlockClock, error) {
+	fromTime, err := e.Exact(ctx, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	clock := &BlockClock{
+		fromBlock: fromBlock,
+		fromTime:  fromTime,
+		toBlock:   toBlock,
+		perBlock:  e.avgBlockTime,
+	}
+	if toBlock <= fromBlock {
+		return clock, nil
+	}
+
+	toTime, err := e.Exact(ctx, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	clock.toTime = toTime
+	if clock.perBlock <= 0 {
+		clock.perBlock = toTime.Sub(fromTime) / time.Duration(toBlock-fromBlock)
+	}
+
+	return clock, nil
+}
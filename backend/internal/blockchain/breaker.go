@@ -0,0 +1,234 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiaomait/backend/internal/metrics"
+)
+
+// ErrCircuitOpen 熔断器打开时直接拒绝链上调用
+var ErrCircuitOpen = errors.New("blockchain circuit breaker is open")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 正常放行
+	BreakerOpen                         // 拒绝所有调用，冷却期后转为半开
+	BreakerHalfOpen                     // 放行一个探测调用，成功则关闭，失败则重新打开
+)
+
+// String 状态名称
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker 链上调用熔断器：连续失败 threshold 次后打开，cooldown 后半开探测
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	onClose   []func()
+}
+
+// NewBreaker 创建熔断器
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+
+	b := &Breaker{threshold: threshold, cooldown: cooldown}
+	metrics.BlockchainBreakerState.Set(float64(BreakerClosed))
+	return b
+}
+
+// OnClose 注册熔断器从打开恢复为关闭时的回调（在新 goroutine 中执行）
+func (b *Breaker) OnClose(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onClose = append(b.onClose, fn)
+}
+
+// State 当前状态
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow 判断是否放行调用，半开状态同一时间只放行一个探测
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			break
+		}
+		b.setState(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			break
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+
+	metrics.BlockchainBreakerRejected.Inc()
+	return ErrCircuitOpen
+}
+
+// record 记录调用结果，调用方取消的请求不计入失败
+func (b *Breaker) record(err error) {
+	if errors.Is(err, context.Canceled) {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.setState(BreakerClosed)
+			for _, fn := range b.onClose {
+				go fn()
+			}
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// setState 切换状态并更新指标，调用方持有锁
+func (b *Breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	metrics.BlockchainBreakerState.Set(float64(state))
+	metrics.BlockchainBreakerTransitions.WithLabelValues(state.String()).Inc()
+}
+
+// guard 经熔断器执行链上调用
+func guard[T any](b *Breaker, call func() (T, error)) (T, error) {
+	if err := b.allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+	result, err := call()
+	b.record(err)
+	return result, err
+}
+
+// BreakerClient 带熔断的区块链客户端，用于请求路径上的链上校验
+type BreakerClient struct {
+	client  BlockchainClient
+	breaker *Breaker
+}
+
+var _ BlockchainClient = (*BreakerClient)(nil)
+
+// NewBreakerClient 创建带熔断的区块链客户端
+func NewBreakerClient(client BlockchainClient, breaker *Breaker) *BreakerClient {
+	return &BreakerClient{client: client, breaker: breaker}
+}
+
+// GetBlockNumber 获取当前区块号
+func (c *BreakerClient) GetBlockNumber(ctx context.Context) (uint64, error) {
+	return guard(c.breaker, func() (uint64, error) { return c.client.GetBlockNumber(ctx) })
+}
+
+// GetBlockTime 获取区块时间戳
+func (c *BreakerClient) GetBlockTime(ctx context.Context, blockNumber uint64) (time.Time, error) {
+	return guard(c.breaker, func() (time.Time, error) { return c.client.GetBlockTime(ctx, blockNumber) })
+}
+
+// GetBlockHash 获取区块哈希
+func (c *BreakerClient) GetBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	return guard(c.breaker, func() (common.Hash, error) { return c.client.GetBlockHash(ctx, blockNumber) })
+}
+
+// GetMarketItem 获取市场项详情
+func (c *BreakerClient) GetMarketItem(ctx context.Context, itemId *big.Int) (*MarketItem, error) {
+	return guard(c.breaker, func() (*MarketItem, error) { return c.client.GetMarketItem(ctx, itemId) })
+}
+
+// FetchActiveItemIDs 获取链上活跃市场项 ID
+func (c *BreakerClient) FetchActiveItemIDs(ctx context.Context) ([]*big.Int, error) {
+	return guard(c.breaker, func() ([]*big.Int, error) { return c.client.FetchActiveItemIDs(ctx) })
+}
+
+// GetTransactionReceipt 获取交易回执
+func (c *BreakerClient) GetTransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return guard(c.breaker, func() (*types.Receipt, error) { return c.client.GetTransactionReceipt(ctx, txHash) })
+}
+
+// OwnerOf 查询 Token 持有者
+func (c *BreakerClient) OwnerOf(ctx context.Context, nftContract common.Address, tokenId *big.Int) (common.Address, error) {
+	return guard(c.breaker, func() (common.Address, error) { return c.client.OwnerOf(ctx, nftContract, tokenId) })
+}
+
+// TokenURI 查询 Token 元数据 URI
+func (c *BreakerClient) TokenURI(ctx context.Context, nftContract common.Address, tokenId *big.Int) (string, error) {
+	return guard(c.breaker, func() (string, error) { return c.client.TokenURI(ctx, nftContract, tokenId) })
+}
+
+// DetectTokenStandard 通过 ERC-165 检测合约代币标准
+func (c *BreakerClient) DetectTokenStandard(ctx context.Context, contract common.Address) (TokenStandard, error) {
+	return guard(c.breaker, func() (TokenStandard, error) { return c.client.DetectTokenStandard(ctx, contract) })
+}
+
+// FetchMarketEvents 查询区块范围内的市场事件
+func (c *BreakerClient) FetchMarketEvents(ctx context.Context, fromBlock, toBlock uint64) ([]*MarketItemCreatedEvent, []*MarketItemSoldEvent, error) {
+	type events struct {
+		created []*MarketItemCreatedEvent
+		sold    []*MarketItemSoldEvent
+	}
+	result, err := guard(c.breaker, func() (events, error) {
+		created, sold, err := c.client.FetchMarketEvents(ctx, fromBlock, toBlock)
+		return events{created, sold}, err
+	})
+	return result.created, result.sold, err
+}
+
+// FilterMarketLogs 查询区块范围内某个市场事件的日志
+func (c *BreakerClient) FilterMarketLogs(ctx context.Context, event string, fromBlock, toBlock uint64) ([]types.Log, error) {
+	return guard(c.breaker, func() ([]types.Log, error) {
+		return c.client.FilterMarketLogs(ctx, event, fromBlock, toBlock)
+	})
+}
@@ -2,19 +2,22 @@ package blockchain
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"math/big"
+	"net/http"
+	"reflect"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/xiaomait/backend/internal/metrics"
 )
 
 // MarketItemCreatedEvent 市场项创建事件
@@ -24,121 +27,196 @@ type MarketItemCreatedEvent struct {
 	TokenId     *big.Int
 	Seller      common.Address
 	Price       *big.Int
+	Version     string    // 解码所用的合约 ABI 版本
+	Raw         types.Log // 原始日志（交易哈希、区块号、日志索引）
 }
 
 // MarketItemSoldEvent 市场项售出事件
 type MarketItemSoldEvent struct {
-	ItemId *big.Int
-	Buyer  common.Address
-	Price  *big.Int
+	ItemId  *big.Int
+	Buyer   common.Address
+	Price   *big.Int
+	Version string    // 解码所用的合约 ABI 版本
+	Raw     types.Log // 原始日志（交易哈希、区块号、日志索引）
+}
+
+// MarketItemCancelledEvent 市场项取消事件（合约事件名为 MarketItemCanceled）
+type MarketItemCancelledEvent struct {
+	ItemId  *big.Int
+	Seller  common.Address // 事件未包含卖家时取交易发送方（合约只允许卖家取消），查询失败时为零地址
+	Version string         // 解码所用的合约 ABI 版本
+	Raw     types.Log      // 原始日志（交易哈希、区块号、日志索引）
+}
+
+// 市场合约事件名，同时作为各事件流索引进度的键
+const (
+	EventMarketItemCreated  = "MarketItemCreated"
+	EventMarketItemSold     = "MarketItemSold"
+	EventMarketItemCanceled = "MarketItemCanceled"
+)
+
+// MarketEvents 实时监听的全部市场事件
+var MarketEvents = []string{EventMarketItemCreated, EventMarketItemSold, EventMarketItemCanceled}
+
+// CursorFunc 返回事件流已入库到的区块，尚无进度时 ok 为 false；监听器重新订阅后从这里补齐
+type CursorFunc func(event string) (block uint64, ok bool, err error)
+
+// BlockchainClient 服务层依赖的区块链客户端接口，便于测试时替换为 mock 实现
+type BlockchainClient interface {
+	GetBlockNumber(ctx context.Context) (uint64, error)
+	GetBlockTime(ctx context.Context, blockNumber uint64) (time.Time, error)
+	GetBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error)
+	GetMarketItem(ctx context.Context, itemId *big.Int) (*MarketItem, error)
+	FetchActiveItemIDs(ctx context.Context) ([]*big.Int, error)
+	GetTransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	OwnerOf(ctx context.Context, nftContract common.Address, tokenId *big.Int) (common.Address, error)
+	TokenURI(ctx context.Context, nftContract common.Address, tokenId *big.Int) (string, error)
+	FetchMarketEvents(ctx context.Context, fromBlock, toBlock uint64) ([]*MarketItemCreatedEvent, []*MarketItemSoldEvent, error)
+	FilterMarketLogs(ctx context.Context, event string, fromBlock, toBlock uint64) ([]types.Log, error)
+	DetectTokenStandard(ctx context.Context, contract common.Address) (TokenStandard, error)
+}
+
+// ListenerOptions 事件监听缓冲配置
+type ListenerOptions struct {
+	BufferSize    int           // 事件通道缓冲大小
+	FullWait      time.Duration // 缓冲区满时等待消费者的最长时间，超时后丢弃事件（可通过回填/对账恢复）
+	CatchUpBlocks uint64        // 重新订阅后补齐日志时每次 eth_getLogs 查询的区块数
 }
 
 // Client 区块链客户端
 type Client struct {
-	ethClient       *ethclient.Client
+	endpoints       *endpointPool
 	marketplaceAddr common.Address
-	contractABI     abi.ABI
+	marketABIs      *ABIRegistry
+	erc721ABI       abi.ABI
+	listenerOpts    ListenerOptions
 }
 
-// 合约 ABI (简化版本)
-const marketplaceABI = `[
+var _ BlockchainClient = (*Client)(nil)
+
+// ERC721 ABI（仅包含用到的方法，uri 为 ERC1155 元数据方法，supportsInterface 为 ERC-165 方法）
+const erc721ABI = `[
 	{
-		"anonymous": false,
 		"inputs": [
-			{"indexed": true, "name": "itemId", "type": "uint256"},
-			{"indexed": true, "name": "nftContract", "type": "address"},
-			{"indexed": true, "name": "tokenId", "type": "uint256"},
-			{"indexed": false, "name": "seller", "type": "address"},
-			{"indexed": false, "name": "price", "type": "uint256"}
+			{"name": "tokenId", "type": "uint256"}
+		],
+		"name": "ownerOf",
+		"outputs": [
+			{"name": "", "type": "address"}
 		],
-		"name": "MarketItemCreated",
-		"type": "event"
+		"stateMutability": "view",
+		"type": "function"
 	},
 	{
-		"anonymous": false,
 		"inputs": [
-			{"indexed": true, "name": "itemId", "type": "uint256"},
-			{"indexed": true, "name": "buyer", "type": "address"},
-			{"indexed": false, "name": "price", "type": "uint256"}
+			{"name": "tokenId", "type": "uint256"}
+		],
+		"name": "tokenURI",
+		"outputs": [
+			{"name": "", "type": "string"}
 		],
-		"name": "MarketItemSold",
-		"type": "event"
+		"stateMutability": "view",
+		"type": "function"
 	},
 	{
 		"inputs": [
-			{"name": "itemId", "type": "uint256"}
+			{"name": "id", "type": "uint256"}
 		],
-		"name": "getMarketItem",
+		"name": "uri",
 		"outputs": [
-			{
-				"components": [
-					{"name": "itemId", "type": "uint256"},
-					{"name": "nftContract", "type": "address"},
-					{"name": "tokenId", "type": "uint256"},
-					{"name": "seller", "type": "address"},
-					{"name": "owner", "type": "address"},
-					{"name": "price", "type": "uint256"},
-					{"name": "sold", "type": "bool"},
-					{"name": "listedAt", "type": "uint256"}
-				],
-				"name": "",
-				"type": "tuple"
-			}
+			{"name": "", "type": "string"}
 		],
 		"stateMutability": "view",
 		"type": "function"
 	},
 	{
-		"inputs": [],
-		"name": "fetchActiveItems",
+		"inputs": [
+			{"name": "interfaceId", "type": "bytes4"}
+		],
+		"name": "supportsInterface",
 		"outputs": [
-			{
-				"components": [
-					{"name": "itemId", "type": "uint256"},
-					{"name": "nftContract", "type": "address"},
-					{"name": "tokenId", "type": "uint256"},
-					{"name": "seller", "type": "address"},
-					{"name": "owner", "type": "address"},
-					{"name": "price", "type": "uint256"},
-					{"name": "sold", "type": "bool"},
-					{"name": "listedAt", "type": "uint256"}
-				],
-				"name": "",
-				"type": "tuple[]"
-			}
+			{"name": "", "type": "bool"}
 		],
 		"stateMutability": "view",
 		"type": "function"
 	}
 ]`
 
-// NewClient 创建新的区块链客户端
-func NewClient(rpcURL, marketplaceAddress string) (*Client, error) {
-	client, err := ethclient.Dial(rpcURL)
+// NewClient 创建新的区块链客户端，rpcURLs 为按优先级排列的节点（节点故障时自动切换），marketABIs 为市场合约
+// 各版本 ABI；throttle 不为 nil 时 HTTP(S) 节点的所有请求共享该自适应限速（WebSocket 连接不经过 HTTP 传输，不受限速）
+func NewClient(rpcURLs []string, marketplaceAddress string, marketABIs *ABIRegistry, listenerOpts ListenerOptions, throttle *Throttle) (*Client, error) {
+	var opts []rpc.ClientOption
+	if throttle != nil {
+		opts = append(opts, rpc.WithHTTPClient(&http.Client{
+			Transport: &throttledTransport{next: http.DefaultTransport, throttle: throttle},
+		}))
+	}
+	endpoints, err := dialEndpoints(rpcURLs, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
 	}
 
-	contractABI, err := abi.JSON(strings.NewReader(marketplaceABI))
+	nftABI, err := abi.JSON(strings.NewReader(erc721ABI))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse contract ABI: %w", err)
+		return nil, fmt.Errorf("failed to parse ERC721 ABI: %w", err)
 	}
 
 	return &Client{
-		ethClient:       client,
+		endpoints:       endpoints,
 		marketplaceAddr: common.HexToAddress(marketplaceAddress),
-		contractABI:     contractABI,
+		marketABIs:      marketABIs,
+		erc721ABI:       nftABI,
+		listenerOpts:    listenerOpts,
 	}, nil
 }
 
 // GetBlockNumber 获取当前区块号
 func (c *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
-	return c.ethClient.BlockNumber(ctx)
+	return withEndpoint(ctx, c.endpoints, func(eth *ethclient.Client) (uint64, error) {
+		return eth.BlockNumber(ctx)
+	})
+}
+
+// GetBlockTime 获取区块时间戳
+func (c *Client) GetBlockTime(ctx context.Context, blockNumber uint64) (time.Time, error) {
+	header, err := c.headerByNumber(ctx, blockNumber)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+// GetBlockHash 获取规范链上指定高度的区块哈希，用于检测重组
+func (c *Client) GetBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	header, err := c.headerByNumber(ctx, blockNumber)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Hash(), nil
+}
+
+// headerByNumber 获取区块头
+func (c *Client) headerByNumber(ctx context.Context, blockNumber uint64) (*types.Header, error) {
+	return withEndpoint(ctx, c.endpoints, func(eth *ethclient.Client) (*types.Header, error) {
+		return eth.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	})
+}
+
+// callContract 执行只读合约调用（最新区块）
+func (c *Client) callContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	return withEndpoint(ctx, c.endpoints, func(eth *ethclient.Client) ([]byte, error) {
+		return eth.CallContract(ctx, msg, nil)
+	})
 }
 
 // GetMarketItem 获取市场项详情
-func (c *Client) GetMarketItem(ctx context.Context, itemId *big.Int) (map[string]interface{}, error) {
-	data, err := c.contractABI.Pack("getMarketItem", itemId)
+func (c *Client) GetMarketItem(ctx context.Context, itemId *big.Int) (*MarketItem, error) {
+	contractABI, err := c.marketABIs.Method("getMarketItem")
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := contractABI.Pack("getMarketItem", itemId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pack data: %w", err)
 	}
@@ -148,89 +226,107 @@ func (c *Client) GetMarketItem(ctx context.Context, itemId *big.Int) (map[string
 		Data: data,
 	}
 
-	result, err := c.ethClient.CallContract(ctx, msg, nil)
+	result, err := c.callContract(ctx, msg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call contract: %w", err)
 	}
-	// 使用 UnpackIntoMap 方法
-	resultMap := make(map[string]interface{})
-	err = c.contractABI.UnpackIntoMap(resultMap, "getMarketItem", result)
+
+	values, err := contractABI.Unpack("getMarketItem", result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unpack result: %w", err)
 	}
-
-	log.Printf("Market item data: %+v", resultMap)
-
-	// 解析特殊的 resultMap 结构
-	var itemData interface{}
-	for _, value := range resultMap {
-		itemData = value
-		break // 只取第一个值
+	if len(values) == 0 {
+		return nil, fmt.Errorf("failed to unpack result: empty output")
 	}
 
-	log.Printf("Market itemData: %+v", itemData)
-
-	// 定义结构体类型
-	/*type MarketItemStruct struct {
-		ItemId      *big.Int       `json:"itemId"`
-		NftContract common.Address `json:"nftContract"`
-		TokenId     *big.Int       `json:"tokenId"`
-		Seller      common.Address `json:"seller"`
-		Owner       common.Address `json:"owner"`
-		Price       *big.Int       `json:"price"`
-		Sold        bool           `json:"sold"`
-		ListedAt    *big.Int       `json:"listedAt"`
-	}*/
-	return ConvertViaJSON(itemData)
+	// ABI 解包得到字段相同的匿名结构体，类型不一致时（ABI 与结构体不匹配）返回错误而不是 panic
+	tuple := reflect.ValueOf(values[0])
+	itemType := reflect.TypeOf(MarketItem{})
+	if !tuple.Type().ConvertibleTo(itemType) {
+		return nil, fmt.Errorf("unexpected market item type %s", tuple.Type())
+	}
+	item := tuple.Convert(itemType).Interface().(MarketItem)
+	return &item, nil
+}
 
+// MarketItem 链上市场项，字段顺序与合约 MarketItem 结构体一致，用于 ABI 元组解包
+type MarketItem struct {
+	ItemId      *big.Int       `json:"itemId"`
+	NftContract common.Address `json:"nftContract"`
+	TokenId     *big.Int       `json:"tokenId"`
+	Seller      common.Address `json:"seller"`
+	Owner       common.Address `json:"owner"`
+	Price       *big.Int       `json:"price"`
+	Sold        bool           `json:"sold"`
+	ListedAt    *big.Int       `json:"listedAt"`
 }
 
-// 方法3：JSON 方式（最通用）
-func ConvertViaJSON(itemData interface{}) (map[string]interface{}, error) {
-	jsonBytes, err := json.Marshal(itemData)
+// FetchActiveItemIDs 获取链上所有活跃市场项的 ItemID
+func (c *Client) FetchActiveItemIDs(ctx context.Context) ([]*big.Int, error) {
+	contractABI, err := c.marketABIs.Method("fetchActiveItems")
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal: %w", err)
+		return nil, err
 	}
 
-	var rawMap map[string]interface{}
-	err = json.Unmarshal(jsonBytes, &rawMap)
+	data, err := contractABI.Pack("fetchActiveItems")
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal: %w", err)
+		return nil, fmt.Errorf("failed to pack data: %w", err)
 	}
 
-	// 转换为蛇形命名
-	result := make(map[string]interface{})
-	for key, value := range rawMap {
-		result[key] = value
+	msg := ethereum.CallMsg{
+		To:   &c.marketplaceAddr,
+		Data: data,
 	}
 
-	return result, nil
-}
+	result, err := c.callContract(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call contract: %w", err)
+	}
 
-// 辅助函数：将驼峰命名转为蛇形命名
-func toSnakeCase(str string) string {
-	var result []rune
-	for i, r := range str {
-		if i > 0 && 'A' <= r && r <= 'Z' {
-			result = append(result, '_')
-		}
-		result = append(result, unicode.ToLower(r))
+	values, err := contractABI.Unpack("fetchActiveItems", result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack result: %w", err)
+	}
+	if len(values) == 0 {
+		return nil, nil
 	}
-	return string(result)
+
+	items := *abi.ConvertType(values[0], new([]MarketItem)).(*[]MarketItem)
+
+	ids := make([]*big.Int, len(items))
+	for i, item := range items {
+		ids[i] = item.ItemId
+	}
+
+	return ids, nil
 }
 
-// ListenMarketItemCreated 监听 MarketItemCreated 事件（带重连机制）
-func (c *Client) ListenMarketItemCreated(ctx context.Context) <-chan *MarketItemCreatedEvent {
-	eventChan := make(chan *MarketItemCreatedEvent)
+// ListenMarketItemCreated 监听 MarketItemCreated 事件（带重连机制，重连后按 cursor 补齐断线期间的日志）
+func (c *Client) ListenMarketItemCreated(ctx context.Context, cursor CursorFunc) <-chan *MarketItemCreatedEvent {
+	eventChan := make(chan *MarketItemCreatedEvent, c.listenerOpts.BufferSize)
 
 	go func() {
 		defer close(eventChan)
 
 		query := ethereum.FilterQuery{
 			Addresses: []common.Address{c.marketplaceAddr},
-			Topics:    [][]common.Hash{{c.contractABI.Events["MarketItemCreated"].ID}},
+			Topics:    [][]common.Hash{c.marketABIs.EventIDs("MarketItemCreated")},
+		}
+
+		handle := func(vLog types.Log) {
+			event, err := c.parseMarketItemCreated(vLog)
+			if err != nil {
+				log.Printf("Failed to unpack MarketItemCreated event: %v", err)
+				return
+			}
+
+			if !deliver(eventChan, event, "MarketItemCreated", c.listenerOpts.FullWait) {
+				log.Printf("Dropped MarketItemCreated event: tx=%s item=%s (buffer full)",
+					vLog.TxHash.Hex(), event.ItemId.String())
+			}
 		}
 
+		connected := false
 		for {
 			// 检查 context 是否已取消
 			select {
@@ -241,14 +337,19 @@ func (c *Client) ListenMarketItemCreated(ctx context.Context) <-chan *MarketItem
 			}
 
 			logs := make(chan types.Log)
-			sub, err := c.ethClient.SubscribeFilterLogs(ctx, query, logs)
+			sub, endpoint, err := c.endpoints.subscribeFilterLogs(ctx, query, logs)
 			if err != nil {
 				log.Printf("Failed to subscribe to MarketItemCreated logs, retrying in 5s: %v", err)
-				time.Sleep(5 * time.Second)
+				sleepCtx(ctx, 5*time.Second)
 				continue
 			}
 
 			log.Println("MarketItemCreated listener connected")
+			// 重新订阅：断线期间的日志不会再推送，从游标处补齐
+			if connected {
+				c.catchUp(ctx, "MarketItemCreated", cursor, handle)
+			}
+			connected = true
 
 			// 处理事件循环
 		eventLoop:
@@ -260,23 +361,13 @@ func (c *Client) ListenMarketItemCreated(ctx context.Context) <-chan *MarketItem
 					return
 				case err := <-sub.Err():
 					log.Printf("MarketItemCreated subscription error: %v, reconnecting...", err)
+					metrics.IndexerListenerReconnects.WithLabelValues("MarketItemCreated").Inc()
 					sub.Unsubscribe()
-					time.Sleep(5 * time.Second)
+					c.endpoints.markFailed(endpoint, err) // 换到下一个健康节点重新订阅
+					sleepCtx(ctx, 5*time.Second)
 					break eventLoop // 退出内层循环，重新订阅
 				case vLog := <-logs:
-					event := &MarketItemCreatedEvent{}
-					err := c.contractABI.UnpackIntoInterface(event, "MarketItemCreated", vLog.Data)
-					if err != nil {
-						log.Printf("Failed to unpack MarketItemCreated event: %v", err)
-						continue
-					}
-
-					// 解析 indexed 参数
-					event.ItemId = new(big.Int).SetBytes(vLog.Topics[1].Bytes())
-					event.NftContract = common.BytesToAddress(vLog.Topics[2].Bytes())
-					event.TokenId = new(big.Int).SetBytes(vLog.Topics[3].Bytes())
-
-					eventChan <- event
+					handle(vLog)
 				}
 			}
 		}
@@ -285,18 +376,32 @@ func (c *Client) ListenMarketItemCreated(ctx context.Context) <-chan *MarketItem
 	return eventChan
 }
 
-// ListenMarketItemSold 监听 MarketItemSold 事件（带重连机制）
-func (c *Client) ListenMarketItemSold(ctx context.Context) <-chan *MarketItemSoldEvent {
-	eventChan := make(chan *MarketItemSoldEvent)
+// ListenMarketItemSold 监听 MarketItemSold 事件（带重连机制，重连后按 cursor 补齐断线期间的日志）
+func (c *Client) ListenMarketItemSold(ctx context.Context, cursor CursorFunc) <-chan *MarketItemSoldEvent {
+	eventChan := make(chan *MarketItemSoldEvent, c.listenerOpts.BufferSize)
 
 	go func() {
 		defer close(eventChan)
 
 		query := ethereum.FilterQuery{
 			Addresses: []common.Address{c.marketplaceAddr},
-			Topics:    [][]common.Hash{{c.contractABI.Events["MarketItemSold"].ID}},
+			Topics:    [][]common.Hash{c.marketABIs.EventIDs("MarketItemSold")},
+		}
+
+		handle := func(vLog types.Log) {
+			event, err := c.parseMarketItemSold(vLog)
+			if err != nil {
+				log.Printf("Failed to unpack MarketItemSold event: %v", err)
+				return
+			}
+
+			if !deliver(eventChan, event, "MarketItemSold", c.listenerOpts.FullWait) {
+				log.Printf("Dropped MarketItemSold event: tx=%s item=%s (buffer full)",
+					vLog.TxHash.Hex(), event.ItemId.String())
+			}
 		}
 
+		connected := false
 		for {
 			// 检查 context 是否已取消
 			select {
@@ -307,14 +412,19 @@ func (c *Client) ListenMarketItemSold(ctx context.Context) <-chan *MarketItemSol
 			}
 
 			logs := make(chan types.Log)
-			sub, err := c.ethClient.SubscribeFilterLogs(ctx, query, logs)
+			sub, endpoint, err := c.endpoints.subscribeFilterLogs(ctx, query, logs)
 			if err != nil {
 				log.Printf("Failed to subscribe to MarketItemSold logs, retrying in 5s: %v", err)
-				time.Sleep(5 * time.Second)
+				sleepCtx(ctx, 5*time.Second)
 				continue
 			}
 
 			log.Println("MarketItemSold listener connected")
+			// 重新订阅：断线期间的日志不会再推送，从游标处补齐
+			if connected {
+				c.catchUp(ctx, "MarketItemSold", cursor, handle)
+			}
+			connected = true
 
 			// 处理事件循环
 		eventLoop:
@@ -326,22 +436,96 @@ func (c *Client) ListenMarketItemSold(ctx context.Context) <-chan *MarketItemSol
 					return
 				case err := <-sub.Err():
 					log.Printf("MarketItemSold subscription error: %v, reconnecting...", err)
+					metrics.IndexerListenerReconnects.WithLabelValues("MarketItemSold").Inc()
+					sub.Unsubscribe()
+					c.endpoints.markFailed(endpoint, err) // 换到下一个健康节点重新订阅
+					sleepCtx(ctx, 5*time.Second)
+					break eventLoop // 退出内层循环，重新订阅
+				case vLog := <-logs:
+					handle(vLog)
+				}
+			}
+		}
+	}()
+
+	return eventChan
+}
+
+// ListenMarketItemCancelled 监听 MarketItemCanceled 事件（带重连机制，重连后按 cursor 补齐断线期间的日志）。ABI 中没有该事件时不监听，
+// 返回已关闭的通道
+func (c *Client) ListenMarketItemCancelled(ctx context.Context, cursor CursorFunc) <-chan *MarketItemCancelledEvent {
+	eventChan := make(chan *MarketItemCancelledEvent, c.listenerOpts.BufferSize)
+
+	eventIDs := c.marketABIs.EventIDs("MarketItemCanceled")
+	if len(eventIDs) == 0 {
+		log.Println("MarketItemCanceled is not defined in any marketplace ABI version, cancel listener disabled")
+		close(eventChan)
+		return eventChan
+	}
+
+	go func() {
+		defer close(eventChan)
+
+		query := ethereum.FilterQuery{
+			Addresses: []common.Address{c.marketplaceAddr},
+			Topics:    [][]common.Hash{eventIDs},
+		}
+
+		handle := func(vLog types.Log) {
+			event, err := c.parseMarketItemCancelled(ctx, vLog)
+			if err != nil {
+				log.Printf("Failed to unpack MarketItemCanceled event: %v", err)
+				return
+			}
+
+			if !deliver(eventChan, event, "MarketItemCanceled", c.listenerOpts.FullWait) {
+				log.Printf("Dropped MarketItemCanceled event: tx=%s item=%s (buffer full)",
+					vLog.TxHash.Hex(), event.ItemId.String())
+			}
+		}
+
+		connected := false
+		for {
+			// 检查 context 是否已取消
+			select {
+			case <-ctx.Done():
+				log.Println("MarketItemCanceled listener stopped")
+				return
+			default:
+			}
+
+			logs := make(chan types.Log)
+			sub, endpoint, err := c.endpoints.subscribeFilterLogs(ctx, query, logs)
+			if err != nil {
+				log.Printf("Failed to subscribe to MarketItemCanceled logs, retrying in 5s: %v", err)
+				sleepCtx(ctx, 5*time.Second)
+				continue
+			}
+
+			log.Println("MarketItemCanceled listener connected")
+			// 重新订阅：断线期间的日志不会再推送，从游标处补齐
+			if connected {
+				c.catchUp(ctx, "MarketItemCanceled", cursor, handle)
+			}
+			connected = true
+
+			// 处理事件循环
+		eventLoop:
+			for {
+				select {
+				case <-ctx.Done():
+					sub.Unsubscribe()
+					log.Println("MarketItemCanceled listener stopped")
+					return
+				case err := <-sub.Err():
+					log.Printf("MarketItemCanceled subscription error: %v, reconnecting...", err)
+					metrics.IndexerListenerReconnects.WithLabelValues("MarketItemCanceled").Inc()
 					sub.Unsubscribe()
-					time.Sleep(5 * time.Second)
+					c.endpoints.markFailed(endpoint, err) // 换到下一个健康节点重新订阅
+					sleepCtx(ctx, 5*time.Second)
 					break eventLoop // 退出内层循环，重新订阅
 				case vLog := <-logs:
-					event := &MarketItemSoldEvent{}
-					err := c.contractABI.UnpackIntoInterface(event, "MarketItemSold", vLog.Data)
-					if err != nil {
-						log.Printf("Failed to unpack MarketItemSold event: %v", err)
-						continue
-					}
-
-					// 解析 indexed 参数
-					event.ItemId = new(big.Int).SetBytes(vLog.Topics[1].Bytes())
-					event.Buyer = common.BytesToAddress(vLog.Topics[2].Bytes())
-
-					eventChan <- event
+					handle(vLog)
 				}
 			}
 		}
@@ -350,12 +534,336 @@ func (c *Client) ListenMarketItemSold(ctx context.Context) <-chan *MarketItemSol
 	return eventChan
 }
 
+// catchUp 补齐重新订阅前错过的日志：按 CatchUpBlocks 分段查询 [游标, 最新区块] 并逐条交给 handle。
+// 游标所在区块可能只处理了部分日志，因此包含该区块；重复的日志由入库侧去重。没有游标时不补齐（由启动回填负责）
+func (c *Client) catchUp(ctx context.Context, event string, cursor CursorFunc, handle func(types.Log)) {
+	if cursor == nil {
+		return
+	}
+	from, ok, err := cursor(event)
+	if err != nil {
+		log.Printf("%s catch-up skipped: %v", event, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	head, err := c.GetBlockNumber(ctx)
+	if err != nil {
+		log.Printf("%s catch-up skipped: %v", event, err)
+		return
+	}
+
+	chunk := c.listenerOpts.CatchUpBlocks
+	if chunk == 0 {
+		chunk = 1000
+	}
+	for start := from; start <= head; start += chunk {
+		end := start + chunk - 1
+		if end > head || end < start {
+			end = head
+		}
+
+		logs, err := c.FilterMarketLogs(ctx, event, start, end)
+		if err != nil {
+			log.Printf("%s catch-up stopped at block %d: %v", event, start, err)
+			return
+		}
+		for _, vLog := range logs {
+			handle(vLog)
+		}
+
+		if end == head {
+			break
+		}
+	}
+	log.Printf("%s listener caught up blocks %d-%d", event, from, head)
+}
+
+// deliver 写入事件通道：缓冲区满时最多等待 fullWait，仍未被消费则丢弃并计数。
+// 关闭时（ctx 已取消）消费者仍在排空通道，因此不因 ctx 结束提前放弃
+func deliver[T any](ch chan<- T, event T, name string, fullWait time.Duration) bool {
+	defer func() {
+		metrics.IndexerEventBufferUsed.WithLabelValues(name).Set(float64(len(ch)))
+	}()
+
+	select {
+	case ch <- event:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(fullWait)
+	defer timer.Stop()
+
+	select {
+	case ch <- event:
+		return true
+	case <-timer.C:
+		metrics.IndexerEventsDropped.WithLabelValues(name).Inc()
+		return false
+	}
+}
+
+// sleepCtx 等待 d 或直到 ctx 结束，返回是否等满 d
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// parseMarketItemCreated 按日志对应的 ABI 版本解析 MarketItemCreated（新版本新增的字段忽略）
+func (c *Client) parseMarketItemCreated(vLog types.Log) (*MarketItemCreatedEvent, error) {
+	decoded, err := c.marketABIs.Decode(vLog)
+	if err != nil {
+		return nil, err
+	}
+	if decoded.Name != "MarketItemCreated" {
+		return nil, fmt.Errorf("unexpected event %s", decoded.Name)
+	}
+
+	event := &MarketItemCreatedEvent{Version: decoded.Version, Raw: vLog}
+	if event.ItemId, err = decoded.BigInt("itemId"); err != nil {
+		return nil, err
+	}
+	if event.NftContract, err = decoded.Address("nftContract"); err != nil {
+		return nil, err
+	}
+	if event.TokenId, err = decoded.BigInt("tokenId"); err != nil {
+		return nil, err
+	}
+	if event.Seller, err = decoded.Address("seller"); err != nil {
+		return nil, err
+	}
+	if event.Price, err = decoded.BigInt("price"); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// parseMarketItemSold 按日志对应的 ABI 版本解析 MarketItemSold（新版本新增的字段忽略）
+func (c *Client) parseMarketItemSold(vLog types.Log) (*MarketItemSoldEvent, error) {
+	decoded, err := c.marketABIs.Decode(vLog)
+	if err != nil {
+		return nil, err
+	}
+	if decoded.Name != "MarketItemSold" {
+		return nil, fmt.Errorf("unexpected event %s", decoded.Name)
+	}
+
+	event := &MarketItemSoldEvent{Version: decoded.Version, Raw: vLog}
+	if event.ItemId, err = decoded.BigInt("itemId"); err != nil {
+		return nil, err
+	}
+	if event.Buyer, err = decoded.Address("buyer"); err != nil {
+		return nil, err
+	}
+	if event.Price, err = decoded.BigInt("price"); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// parseMarketItemCancelled 按日志对应的 ABI 版本解析 MarketItemCanceled；事件不含卖家时查询交易发送方
+func (c *Client) parseMarketItemCancelled(ctx context.Context, vLog types.Log) (*MarketItemCancelledEvent, error) {
+	decoded, err := c.marketABIs.Decode(vLog)
+	if err != nil {
+		return nil, err
+	}
+	if decoded.Name != "MarketItemCanceled" {
+		return nil, fmt.Errorf("unexpected event %s", decoded.Name)
+	}
+
+	event := &MarketItemCancelledEvent{Version: decoded.Version, Raw: vLog}
+	if event.ItemId, err = decoded.BigInt("itemId"); err != nil {
+		return nil, err
+	}
+	if event.Seller, err = decoded.Address("seller"); err != nil {
+		event.Seller = c.logSender(ctx, vLog)
+	}
+
+	return event, nil
+}
+
+// logSender 查询日志所在交易的发送方，失败时返回零地址
+func (c *Client) logSender(ctx context.Context, vLog types.Log) common.Address {
+	sender, err := withEndpoint(ctx, c.endpoints, func(eth *ethclient.Client) (common.Address, error) {
+		tx, _, err := eth.TransactionByHash(ctx, vLog.TxHash)
+		if err != nil {
+			return common.Address{}, err
+		}
+		return eth.TransactionSender(ctx, tx, vLog.BlockHash, vLog.TxIndex)
+	})
+	if err == nil {
+		return sender
+	}
+	log.Printf("Failed to resolve sender of tx %s: %v", vLog.TxHash.Hex(), err)
+	return common.Address{}
+}
+
+// FetchMarketEvents 查询区块范围 [fromBlock, toBlock] 内的创建和售出事件（按链上顺序，包含所有 ABI 版本）
+func (c *Client) FetchMarketEvents(ctx context.Context, fromBlock, toBlock uint64) ([]*MarketItemCreatedEvent, []*MarketItemSoldEvent, error) {
+	createdIDs := c.marketABIs.EventIDs("MarketItemCreated")
+	soldIDs := c.marketABIs.EventIDs("MarketItemSold")
+
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: []common.Address{c.marketplaceAddr},
+		Topics:    [][]common.Hash{append(append([]common.Hash{}, createdIDs...), soldIDs...)},
+	}
+
+	logs, err := withEndpoint(ctx, c.endpoints, func(eth *ethclient.Client) ([]types.Log, error) {
+		return eth.FilterLogs(ctx, query)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to filter logs: %w", err)
+	}
+
+	var created []*MarketItemCreatedEvent
+	var sold []*MarketItemSoldEvent
+	for _, vLog := range logs {
+		if vLog.Removed || len(vLog.Topics) == 0 {
+			continue
+		}
+
+		switch {
+		case containsHash(createdIDs, vLog.Topics[0]):
+			event, err := c.parseMarketItemCreated(vLog)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to unpack MarketItemCreated at %s: %w", vLog.TxHash.Hex(), err)
+			}
+			created = append(created, event)
+		case containsHash(soldIDs, vLog.Topics[0]):
+			event, err := c.parseMarketItemSold(vLog)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to unpack MarketItemSold at %s: %w", vLog.TxHash.Hex(), err)
+			}
+			sold = append(sold, event)
+		}
+	}
+
+	return created, sold, nil
+}
+
+// FilterMarketLogs 查询区块范围 [fromBlock, toBlock] 内某个市场事件的日志（包含所有 ABI 版本，已移除的日志除外）
+func (c *Client) FilterMarketLogs(ctx context.Context, event string, fromBlock, toBlock uint64) ([]types.Log, error) {
+	eventIDs := c.marketABIs.EventIDs(event)
+	if len(eventIDs) == 0 {
+		// 空的 topic 列表会匹配任意日志
+		return nil, nil
+	}
+
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
+		Addresses: []common.Address{c.marketplaceAddr},
+		Topics:    [][]common.Hash{eventIDs},
+	}
+
+	logs, err := withEndpoint(ctx, c.endpoints, func(eth *ethclient.Client) ([]types.Log, error) {
+		return eth.FilterLogs(ctx, query)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter %s logs: %w", event, err)
+	}
+
+	live := logs[:0]
+	for _, vLog := range logs {
+		if !vLog.Removed {
+			live = append(live, vLog)
+		}
+	}
+	return live, nil
+}
+
+// containsHash 判断 topic ID 是否在列表中
+func containsHash(ids []common.Hash, id common.Hash) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnerOf 查询 ERC721 Token 的当前持有者
+func (c *Client) OwnerOf(ctx context.Context, nftContract common.Address, tokenId *big.Int) (common.Address, error) {
+	data, err := c.erc721ABI.Pack("ownerOf", tokenId)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to pack data: %w", err)
+	}
+
+	msg := ethereum.CallMsg{
+		To:   &nftContract,
+		Data: data,
+	}
+
+	result, err := c.callContract(ctx, msg)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to call contract: %w", err)
+	}
+
+	var owner common.Address
+	if err := c.erc721ABI.UnpackIntoInterface(&owner, "ownerOf", result); err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack result: %w", err)
+	}
+
+	return owner, nil
+}
+
+// TokenURI 查询 Token 元数据 URI：先尝试 ERC721 tokenURI，失败或为空时回退到 ERC1155 uri
+func (c *Client) TokenURI(ctx context.Context, nftContract common.Address, tokenId *big.Int) (string, error) {
+	uri, err := c.callStringMethod(ctx, nftContract, "tokenURI", tokenId)
+	if err == nil && uri != "" {
+		return uri, nil
+	}
+
+	uri, fallbackErr := c.callStringMethod(ctx, nftContract, "uri", tokenId)
+	if fallbackErr != nil {
+		if err != nil {
+			return "", fmt.Errorf("tokenURI: %v; uri: %w", err, fallbackErr)
+		}
+		return "", fallbackErr
+	}
+	return uri, nil
+}
+
+// callStringMethod 调用返回 string 的单参数 view 方法
+func (c *Client) callStringMethod(ctx context.Context, contract common.Address, method string, tokenId *big.Int) (string, error) {
+	data, err := c.erc721ABI.Pack(method, tokenId)
+	if err != nil {
+		return "", fmt.Errorf("failed to pack data: %w", err)
+	}
+
+	result, err := c.callContract(ctx, ethereum.CallMsg{To: &contract, Data: data})
+	if err != nil {
+		return "", fmt.Errorf("failed to call %s: %w", method, err)
+	}
+
+	var value string
+	if err := c.erc721ABI.UnpackIntoInterface(&value, method, result); err != nil {
+		return "", fmt.Errorf("failed to unpack %s result: %w", method, err)
+	}
+	return value, nil
+}
+
 // GetTransactionReceipt 获取交易回执
 func (c *Client) GetTransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
-	return c.ethClient.TransactionReceipt(ctx, txHash)
+	return withEndpoint(ctx, c.endpoints, func(eth *ethclient.Client) (*types.Receipt, error) {
+		return eth.TransactionReceipt(ctx, txHash)
+	})
 }
 
 // Close 关闭客户端
 func (c *Client) Close() {
-	c.ethClient.Close()
+	c.endpoints.close()
 }
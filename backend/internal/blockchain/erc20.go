@@ -0,0 +1,33 @@
+package blockchain
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// erc20TransferTopic ERC-20 Transfer(address,address,uint256) 事件签名
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// NetTransferred 汇总交易回执中 token 转入 recipient 的 ERC-20 数量，
+// 用于推导收取转账手续费的支付代币的实际到账金额；没有匹配的转账时返回 nil
+func NetTransferred(logs []*types.Log, token, recipient common.Address) *big.Int {
+	var total *big.Int
+	for _, vLog := range logs {
+		// ERC-721 Transfer 签名相同但 tokenId 为 indexed（4 个 topic），据此区分
+		if vLog.Address != token || len(vLog.Topics) != 3 || vLog.Topics[0] != erc20TransferTopic {
+			continue
+		}
+		if common.BytesToAddress(vLog.Topics[2].Bytes()) != recipient {
+			continue
+		}
+
+		if total == nil {
+			total = new(big.Int)
+		}
+		total.Add(total, new(big.Int).SetBytes(vLog.Data))
+	}
+	return total
+}
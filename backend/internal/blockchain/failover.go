@@ -0,0 +1,240 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/xiaomait/backend/internal/metrics"
+)
+
+// rpcLimitExceededCode 节点限流时返回的 JSON-RPC 错误码，换节点重试
+const rpcLimitExceededCode = -32005
+
+// rpcEndpoint 一个 RPC 节点
+type rpcEndpoint struct {
+	name    string // 日志与指标中使用的节点名（不含路径，避免泄露 API Key）
+	client  *ethclient.Client
+	healthy atomic.Bool
+}
+
+// setHealthy 更新节点健康状态
+func (e *rpcEndpoint) setHealthy(healthy bool) {
+	e.healthy.Store(healthy)
+	if healthy {
+		metrics.BlockchainEndpointHealthy.WithLabelValues(e.name).Set(1)
+	} else {
+		metrics.BlockchainEndpointHealthy.WithLabelValues(e.name).Set(0)
+	}
+}
+
+// endpointPool 按配置顺序排列的 RPC 节点（第一个为主节点）：调用或订阅因节点故障失败时切换到下一个健康节点，
+// 健康检查发现排在前面的节点恢复后切回
+type endpointPool struct {
+	endpoints []*rpcEndpoint
+	current   atomic.Int32
+}
+
+// dialEndpoints 连接所有节点，连接失败的节点跳过并记录日志，全部失败时返回错误
+func dialEndpoints(rpcURLs []string, opts []rpc.ClientOption) (*endpointPool, error) {
+	pool := &endpointPool{}
+	var lastErr error
+	for _, rawURL := range rpcURLs {
+		name := endpointName(rawURL)
+		rpcClient, err := rpc.DialOptions(context.Background(), rawURL, opts...)
+		if err != nil {
+			log.Printf("Warning: failed to connect to RPC endpoint %s: %v", name, err)
+			lastErr = err
+			continue
+		}
+		endpoint := &rpcEndpoint{name: name, client: ethclient.NewClient(rpcClient)}
+		endpoint.setHealthy(true)
+		pool.endpoints = append(pool.endpoints, endpoint)
+	}
+	if len(pool.endpoints) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("no RPC endpoint configured")
+		}
+		return nil, lastErr
+	}
+	return pool, nil
+}
+
+// endpointName 节点 URL 的 scheme://host 部分
+func endpointName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "endpoint"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// candidates 本次调用依次尝试的节点：从当前节点开始的健康节点，其后为不健康的节点（全部故障时仍逐个尝试）
+func (p *endpointPool) candidates() []int {
+	n := len(p.endpoints)
+	start := int(p.current.Load())
+	order := make([]int, 0, n)
+	var unhealthy []int
+	for k := 0; k < n; k++ {
+		i := (start + k) % n
+		if p.endpoints[i].healthy.Load() {
+			order = append(order, i)
+		} else {
+			unhealthy = append(unhealthy, i)
+		}
+	}
+	return append(order, unhealthy...)
+}
+
+// use 将 i 设为当前节点
+func (p *endpointPool) use(i int) {
+	previous := int(p.current.Swap(int32(i)))
+	if previous != i {
+		metrics.BlockchainEndpointFailovers.Inc()
+		log.Printf("RPC endpoint switched: %s -> %s", p.endpoints[previous].name, p.endpoints[i].name)
+	}
+}
+
+// markFailed 标记节点故障，若为当前节点则切换到下一个健康节点
+func (p *endpointPool) markFailed(i int, err error) {
+	endpoint := p.endpoints[i]
+	if endpoint.healthy.Load() {
+		log.Printf("RPC endpoint %s marked unhealthy: %v", endpoint.name, err)
+	}
+	endpoint.setHealthy(false)
+
+	if int(p.current.Load()) != i {
+		return
+	}
+	for k := 1; k < len(p.endpoints); k++ {
+		next := (i + k) % len(p.endpoints)
+		if p.endpoints[next].healthy.Load() {
+			p.use(next)
+			return
+		}
+	}
+}
+
+// isEndpointFailure 错误是否由节点本身导致（连接、HTTP 错误、限流），换节点重试可能成功；
+// 合约 revert、记录不存在等节点正常返回的错误不切换
+func isEndpointFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ethereum.NotFound) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.ErrorCode() == rpcLimitExceededCode
+	}
+	return true
+}
+
+// withEndpoint 在当前节点上执行 fn，节点故障时依次换到其他节点重试，成功的节点成为当前节点
+func withEndpoint[T any](ctx context.Context, p *endpointPool, fn func(eth *ethclient.Client) (T, error)) (T, error) {
+	var zero T
+	var err error
+	for _, i := range p.candidates() {
+		var result T
+		result, err = fn(p.endpoints[i].client)
+		if err == nil {
+			if !p.endpoints[i].healthy.Load() {
+				p.endpoints[i].setHealthy(true)
+			}
+			p.use(i)
+			return result, nil
+		}
+		if ctx.Err() != nil || !isEndpointFailure(err) {
+			return zero, err
+		}
+		p.markFailed(i, err)
+	}
+	return zero, err
+}
+
+// subscribeFilterLogs 在第一个支持订阅的健康节点上订阅日志，返回所用节点的下标，订阅出错时由调用方 markFailed。
+// 不支持订阅的节点（HTTP）跳过，不视为故障
+func (p *endpointPool) subscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, logs chan<- types.Log) (ethereum.Subscription, int, error) {
+	var err error
+	for _, i := range p.candidates() {
+		var sub ethereum.Subscription
+		sub, err = p.endpoints[i].client.SubscribeFilterLogs(ctx, query, logs)
+		if err == nil {
+			return sub, i, nil
+		}
+		if ctx.Err() != nil {
+			return nil, i, err
+		}
+		if !errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			p.markFailed(i, err)
+		}
+	}
+	return nil, -1, err
+}
+
+// checkHealth 用 BlockNumber 探测所有节点，排在当前节点之前的节点恢复时切回
+func (p *endpointPool) checkHealth(ctx context.Context, timeout time.Duration) {
+	for i, endpoint := range p.endpoints {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		_, err := endpoint.client.BlockNumber(callCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case err != nil && endpoint.healthy.Load():
+			p.markFailed(i, err)
+		case err == nil && !endpoint.healthy.Load():
+			log.Printf("RPC endpoint %s recovered", endpoint.name)
+			endpoint.setHealthy(true)
+		}
+	}
+
+	for i, endpoint := range p.endpoints {
+		if i >= int(p.current.Load()) {
+			return
+		}
+		if endpoint.healthy.Load() {
+			p.use(i)
+			return
+		}
+	}
+}
+
+// close 关闭所有节点连接
+func (p *endpointPool) close() {
+	for _, endpoint := range p.endpoints {
+		endpoint.client.Close()
+	}
+}
+
+// StartHealthCheck 每隔 interval 探测所有 RPC 节点，直到 ctx 结束
+func (c *Client) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.endpoints.checkHealth(ctx, interval)
+		}
+	}
+}
+
+// Endpoints 已连接的 RPC 节点数
+func (c *Client) Endpoints() int {
+	return len(c.endpoints.endpoints)
+}
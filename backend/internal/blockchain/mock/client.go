@@ -0,0 +1,123 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiaomait/backend/internal/blockchain"
+)
+
+// Client 区块链客户端的 mock 实现，未设置的方法返回错误
+type Client struct {
+	GetBlockNumberFunc        func(ctx context.Context) (uint64, error)
+	GetBlockTimeFunc          func(ctx context.Context, blockNumber uint64) (time.Time, error)
+	GetBlockHashFunc          func(ctx context.Context, blockNumber uint64) (common.Hash, error)
+	GetMarketItemFunc         func(ctx context.Context, itemId *big.Int) (*blockchain.MarketItem, error)
+	FetchActiveItemIDsFunc    func(ctx context.Context) ([]*big.Int, error)
+	GetTransactionReceiptFunc func(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	OwnerOfFunc               func(ctx context.Context, nftContract common.Address, tokenId *big.Int) (common.Address, error)
+	TokenURIFunc              func(ctx context.Context, nftContract common.Address, tokenId *big.Int) (string, error)
+	FetchMarketEventsFunc     func(ctx context.Context, fromBlock, toBlock uint64) ([]*blockchain.MarketItemCreatedEvent, []*blockchain.MarketItemSoldEvent, error)
+	FilterMarketLogsFunc      func(ctx context.Context, event string, fromBlock, toBlock uint64) ([]types.Log, error)
+	DetectTokenStandardFunc   func(ctx context.Context, contract common.Address) (blockchain.TokenStandard, error)
+}
+
+var _ blockchain.BlockchainClient = (*Client)(nil)
+
+// GetBlockNumber 获取当前区块号
+func (m *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
+	if m.GetBlockNumberFunc == nil {
+		return 0, errNotImplemented("GetBlockNumber")
+	}
+	return m.GetBlockNumberFunc(ctx)
+}
+
+// GetBlockTime 获取区块时间戳
+func (m *Client) GetBlockTime(ctx context.Context, blockNumber uint64) (time.Time, error) {
+	if m.GetBlockTimeFunc == nil {
+		return time.Time{}, errNotImplemented("GetBlockTime")
+	}
+	return m.GetBlockTimeFunc(ctx, blockNumber)
+}
+
+// GetBlockHash 获取区块哈希
+func (m *Client) GetBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	if m.GetBlockHashFunc == nil {
+		return common.Hash{}, errNotImplemented("GetBlockHash")
+	}
+	return m.GetBlockHashFunc(ctx, blockNumber)
+}
+
+// GetMarketItem 获取市场项详情
+func (m *Client) GetMarketItem(ctx context.Context, itemId *big.Int) (*blockchain.MarketItem, error) {
+	if m.GetMarketItemFunc == nil {
+		return nil, errNotImplemented("GetMarketItem")
+	}
+	return m.GetMarketItemFunc(ctx, itemId)
+}
+
+// FetchActiveItemIDs 获取链上活跃市场项 ID
+func (m *Client) FetchActiveItemIDs(ctx context.Context) ([]*big.Int, error) {
+	if m.FetchActiveItemIDsFunc == nil {
+		return nil, errNotImplemented("FetchActiveItemIDs")
+	}
+	return m.FetchActiveItemIDsFunc(ctx)
+}
+
+// GetTransactionReceipt 获取交易回执
+func (m *Client) GetTransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if m.GetTransactionReceiptFunc == nil {
+		return nil, errNotImplemented("GetTransactionReceipt")
+	}
+	return m.GetTransactionReceiptFunc(ctx, txHash)
+}
+
+// OwnerOf 查询 Token 持有者
+func (m *Client) OwnerOf(ctx context.Context, nftContract common.Address, tokenId *big.Int) (common.Address, error) {
+	if m.OwnerOfFunc == nil {
+		return common.Address{}, errNotImplemented("OwnerOf")
+	}
+	return m.OwnerOfFunc(ctx, nftContract, tokenId)
+}
+
+// TokenURI 查询 Token 元数据 URI
+func (m *Client) TokenURI(ctx context.Context, nftContract common.Address, tokenId *big.Int) (string, error) {
+	if m.TokenURIFunc == nil {
+		return "", errNotImplemented("TokenURI")
+	}
+	return m.TokenURIFunc(ctx, nftContract, tokenId)
+}
+
+// FetchMarketEvents 查询区块范围内的市场事件
+func (m *Client) FetchMarketEvents(ctx context.Context, fromBlock, toBlock uint64) ([]*blockchain.MarketItemCreatedEvent, []*blockchain.MarketItemSoldEvent, error) {
+	if m.FetchMarketEventsFunc == nil {
+		return nil, nil, errNotImplemented("FetchMarketEvents")
+	}
+	return m.FetchMarketEventsFunc(ctx, fromBlock, toBlock)
+}
+
+// FilterMarketLogs 查询区块范围内某个市场事件的日志
+func (m *Client) FilterMarketLogs(ctx context.Context, event string, fromBlock, toBlock uint64) ([]types.Log, error) {
+	if m.FilterMarketLogsFunc == nil {
+		return nil, errNotImplemented("FilterMarketLogs")
+	}
+	return m.FilterMarketLogsFunc(ctx, event, fromBlock, toBlock)
+}
+
+// DetectTokenStandard 检测合约代币标准
+func (m *Client) DetectTokenStandard(ctx context.Context, contract common.Address) (blockchain.TokenStandard, error) {
+	if m.DetectTokenStandardFunc == nil {
+		return "", errNotImplemented("DetectTokenStandard")
+	}
+	return m.DetectTokenStandardFunc(ctx, contract)
+}
+
+// errNotImplemented 未设置 mock 方法时返回的错误
+func errNotImplemented(method string) error {
+	return fmt.Errorf("mock: %s not implemented", method)
+}
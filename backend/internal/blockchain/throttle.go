@@ -0,0 +1,197 @@
+package blockchain
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/metrics"
+)
+
+// rateLimitPeekBytes 检查 JSON-RPC 错误时最多预读的响应字节数（错误响应很短，不读取完整的大响应）
+const rateLimitPeekBytes = 512
+
+// rateLimitMarkers 节点以 HTTP 200 返回的限流错误中常见的片段（小写匹配）
+var rateLimitMarkers = [][]byte{
+	[]byte("rate limit"),
+	[]byte("too many requests"),
+	[]byte("-32005"),
+}
+
+// Throttle 整个 RPC 客户端共享的自适应限速：收到限流响应时请求间隔从 base 开始翻倍，
+// 最大 max；请求成功后间隔逐步缩短，降到 base 以下时取消限速
+type Throttle struct {
+	mu       sync.Mutex
+	base     time.Duration
+	max      time.Duration
+	interval time.Duration // 当前请求最小间隔，0 表示不限速
+	next     time.Time     // 下一个请求最早的发出时间
+}
+
+// NewThrottle 创建自适应限速器
+func NewThrottle(base, max time.Duration) *Throttle {
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	if max < base {
+		max = base
+	}
+
+	metrics.BlockchainThrottleInterval.Set(0)
+	return &Throttle{base: base, max: max}
+}
+
+// Interval 当前请求最小间隔
+func (t *Throttle) Interval() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.interval
+}
+
+// Wait 按当前间隔为请求预留发出时间并等待，ctx 结束时返回错误
+func (t *Throttle) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	if t.interval == 0 && !t.next.After(now) {
+		t.mu.Unlock()
+		return nil
+	}
+	at := t.next
+	if at.Before(now) {
+		at = now
+	}
+	t.next = at.Add(t.interval)
+	t.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnRateLimited 记录一次限流：间隔翻倍，并暂停请求至少 retryAfter（节点要求的等待时间，
+// 未知时为 0，最多按 max 计）
+func (t *Throttle) OnRateLimited(retryAfter time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.interval == 0 {
+		t.interval = t.base
+	} else {
+		t.interval *= 2
+	}
+	if t.interval > t.max {
+		t.interval = t.max
+	}
+
+	pause := t.interval
+	if retryAfter > pause {
+		pause = retryAfter
+	}
+	if pause > t.max {
+		pause = t.max
+	}
+	if until := time.Now().Add(pause); until.After(t.next) {
+		t.next = until
+	}
+
+	metrics.BlockchainRateLimited.Inc()
+	metrics.BlockchainThrottleInterval.Set(t.interval.Seconds())
+}
+
+// OnSuccess 记录一次成功请求：间隔缩短 1/10，低于 base 时取消限速
+func (t *Throttle) OnSuccess() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.interval == 0 {
+		return
+	}
+	t.interval -= t.interval / 10
+	if t.interval < t.base {
+		t.interval = 0
+	}
+	metrics.BlockchainThrottleInterval.Set(t.interval.Seconds())
+}
+
+// throttledTransport 在每个 HTTP RPC 请求前等待限速，并根据响应调整限速
+type throttledTransport struct {
+	next     http.RoundTripper
+	throttle *Throttle
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.throttle.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.throttle.OnRateLimited(parseRetryAfter(resp.Header.Get("Retry-After")))
+		return resp, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	// 部分节点以 200 + JSON-RPC 错误返回限流，预读响应开头判断
+	body := bufio.NewReaderSize(resp.Body, rateLimitPeekBytes)
+	head, _ := body.Peek(rateLimitPeekBytes)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+
+	if isRateLimitBody(head) {
+		t.throttle.OnRateLimited(0)
+	} else {
+		t.throttle.OnSuccess()
+	}
+	return resp, nil
+}
+
+// isRateLimitBody 响应开头是否为限流错误
+func isRateLimitBody(head []byte) bool {
+	if !bytes.Contains(head, []byte(`"error"`)) {
+		return false
+	}
+	lower := bytes.ToLower(head)
+	for _, marker := range rateLimitMarkers {
+		if bytes.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或 HTTP 日期），无法解析时返回 0
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
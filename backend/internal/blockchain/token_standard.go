@@ -0,0 +1,71 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// TokenStandard NFT 合约实现的代币标准
+type TokenStandard string
+
+// NFT 合约代币标准
+const (
+	TokenStandardERC721  TokenStandard = "erc721"
+	TokenStandardERC1155 TokenStandard = "erc1155"
+	TokenStandardUnknown TokenStandard = "unknown" // 未实现 ERC-165，或未声明支持上述两种接口
+)
+
+// ERC-165 接口 ID
+var (
+	erc721InterfaceID  = [4]byte{0x80, 0xac, 0x58, 0xcd}
+	erc1155InterfaceID = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+)
+
+// DetectTokenStandard 通过 ERC-165 supportsInterface 判断合约是 ERC721 还是 ERC1155。
+// 合约 revert 或返回值无法解析时视为不支持；节点故障等调用失败时返回错误
+func (c *Client) DetectTokenStandard(ctx context.Context, contract common.Address) (TokenStandard, error) {
+	for _, candidate := range []struct {
+		standard    TokenStandard
+		interfaceID [4]byte
+	}{
+		{TokenStandardERC721, erc721InterfaceID},
+		{TokenStandardERC1155, erc1155InterfaceID},
+	} {
+		supported, err := c.supportsInterface(ctx, contract, candidate.interfaceID)
+		if err != nil {
+			return "", err
+		}
+		if supported {
+			return candidate.standard, nil
+		}
+	}
+	return TokenStandardUnknown, nil
+}
+
+// supportsInterface 调用 ERC-165 supportsInterface
+func (c *Client) supportsInterface(ctx context.Context, contract common.Address, interfaceID [4]byte) (bool, error) {
+	data, err := c.erc721ABI.Pack("supportsInterface", interfaceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to pack data: %w", err)
+	}
+
+	result, err := c.callContract(ctx, ethereum.CallMsg{To: &contract, Data: data})
+	if err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) && !isEndpointFailure(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to call supportsInterface: %w", err)
+	}
+
+	var supported bool
+	if err := c.erc721ABI.UnpackIntoInterface(&supported, "supportsInterface", result); err != nil {
+		return false, nil
+	}
+	return supported, nil
+}
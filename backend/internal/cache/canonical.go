@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// CanonicalJSON 规范化 JSON 编码：同一逻辑内容总是得到相同字节。
+// encoding/json 对 map 键排序，但 json.RawMessage、自定义 MarshalJSON 等嵌入的 JSON 原样输出，
+// 其键顺序和空白取决于来源（如请求体原文与 Postgres jsonb 读回的文本不同）。这里先按普通编码，
+// 再解码为通用结构（数字保留原文，不经 float64）并重新编码，使各层对象的键都按字典序排列、无多余空白
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, fmt.Errorf("failed to decode canonical json: %w", err)
+	}
+
+	return json.Marshal(tree)
+}
+
+// ContentHash 规范化 JSON 的 SHA-256（十六进制），用于 ETag 和按内容计算的缓存键
+func ContentHash(v interface{}) (string, error) {
+	canonical, err := CanonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// refreshTimeout 后台刷新的超时时间
+const refreshTimeout = 30 * time.Second
+
+// generationPrefix 命名空间代号的键前缀
+const generationPrefix = "gen:"
+
+// envelope 缓存值及写入时间
+type envelope struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// SWR stale-while-revalidate 缓存：
+// 未超过 softTTL 直接返回；超过 softTTL 但未超过 hardTTL 时返回旧值并异步刷新；
+// 超过 hardTTL 或未命中时同步加载。同一 key 的加载通过 singleflight 合并。
+type SWR struct {
+	store   Store
+	softTTL time.Duration
+	hardTTL time.Duration
+	group   singleflight.Group
+}
+
+// NewSWR 创建 stale-while-revalidate 缓存
+func NewSWR(store Store, softTTL, hardTTL time.Duration) *SWR {
+	if hardTTL < softTTL {
+		hardTTL = softTTL
+	}
+	return &SWR{
+		store:   store,
+		softTTL: softTTL,
+		hardTTL: hardTTL,
+	}
+}
+
+// Fetch 读取缓存，必要时调用 load 加载。c 为 nil 时直接调用 load。
+func Fetch[T any](ctx context.Context, c *SWR, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	if c == nil {
+		return load(ctx)
+	}
+
+	var zero T
+
+	raw, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		log.Printf("Cache get %s failed: %v", key, err)
+	}
+
+	if ok {
+		var entry envelope
+		var value T
+		if err := json.Unmarshal(raw, &entry); err == nil && json.Unmarshal(entry.Value, &value) == nil {
+			if time.Since(entry.StoredAt) >= c.softTTL {
+				// 已过软过期时间：返回旧值并在后台刷新
+				c.group.DoChan(key, func() (interface{}, error) {
+					refreshCtx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+					defer cancel()
+					return c.load(refreshCtx, key, func(ctx context.Context) (interface{}, error) {
+						return load(ctx)
+					})
+				})
+			}
+			return value, nil
+		}
+	}
+
+	result, err, _ := c.group.Do(key, func() (interface{}, error) {
+		return c.load(ctx, key, func(ctx context.Context) (interface{}, error) {
+			return load(ctx)
+		})
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	value, ok := result.(T)
+	if !ok {
+		return zero, fmt.Errorf("cache: unexpected value type %T for key %s", result, key)
+	}
+	return value, nil
+}
+
+// load 调用加载函数并写入缓存
+func (c *SWR) load(ctx context.Context, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Cache marshal %s failed: %v", key, err)
+		return value, nil
+	}
+
+	raw, err := json.Marshal(envelope{StoredAt: time.Now(), Value: payload})
+	if err != nil {
+		log.Printf("Cache marshal %s failed: %v", key, err)
+		return value, nil
+	}
+
+	if err := c.store.Set(ctx, key, raw, c.hardTTL); err != nil {
+		log.Printf("Cache set %s failed: %v", key, err)
+	}
+
+	return value, nil
+}
+
+// Generation 返回命名空间当前的代号，用于拼接分页等无法逐个删除的缓存键。
+// 未 Bump 过或代号已过期时返回 "0"；c 为 nil 时也返回 "0"
+func (c *SWR) Generation(ctx context.Context, namespace string) string {
+	if c == nil {
+		return "0"
+	}
+	raw, ok, err := c.store.Get(ctx, generationPrefix+namespace)
+	if err != nil {
+		log.Printf("Cache get generation %s failed: %v", namespace, err)
+	}
+	if !ok {
+		return "0"
+	}
+	return string(raw)
+}
+
+// Bump 更换命名空间的代号，旧代号下的缓存键不再被读取，随 hardTTL 过期。
+// 代号本身保留 2*hardTTL，保证回落到 "0" 时旧的 "0" 代缓存已过期
+func (c *SWR) Bump(ctx context.Context, namespace string) {
+	if c == nil {
+		return
+	}
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := c.store.Set(ctx, generationPrefix+namespace, []byte(generation), 2*c.hardTTL); err != nil {
+		log.Printf("Cache bump generation %s failed: %v", namespace, err)
+	}
+}
+
+// Invalidate 删除缓存项
+func (c *SWR) Invalidate(ctx context.Context, keys ...string) {
+	if c == nil {
+		return
+	}
+	if err := c.store.Delete(ctx, keys...); err != nil {
+		log.Printf("Cache delete %v failed: %v", keys, err)
+	}
+}
@@ -1,17 +1,26 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"math/big"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/xiaomait/backend/internal/metadata"
+	"github.com/xiaomait/backend/internal/pii"
 )
 
 // Config 应用配置结构
 type Config struct {
 	// 服务器配置
-	ServerPort  string
-	Environment string // development, staging, production
+	ServerPort           string
+	Environment          string        // development, staging, production
+	ShutdownDrainTimeout time.Duration // 关闭时等待事件监听、浏览计数、元数据队列排空的时间，与 HTTP 关闭超时分开计算
 
 	// 数据库配置
 	DBHost     string
@@ -28,6 +37,15 @@ type Config struct {
 	DBConnMaxLifetime time.Duration
 	DBConnMaxIdleTime time.Duration
 
+	// 预编译语句缓存：每条不同的 SQL 在每个连接上各缓存一份，动态条件较多时可关闭
+	DBPrepareStmt bool
+
+	// 启动自检：执行各类依赖 Postgres 特性的代表性查询，失败时拒绝启动
+	RunStartupSelftest bool
+
+	// 启动时将 nfts/listings/transactions 中非小写的地址改写为小写（可重复执行，全部迁移后可关闭）
+	NormalizeAddressesOnStartup bool
+
 	// Redis 配置
 	RedisHost     string
 	RedisPort     string
@@ -35,30 +53,126 @@ type Config struct {
 	RedisDB       int
 
 	// 区块链配置
-	EthereumRPC        string
-	MarketplaceAddress string
-	NFTContractAddress string
-	ChainID            int64
+	EthereumRPC            string
+	MarketplaceAddress     string
+	NFTContractAddress     string
+	ChainID                int64
+	MarketplaceABIPath     string
+	MarketplaceABIVersions string // 市场合约各版本 ABI，格式 v1=path,v2=path（从旧到新），用于解码升级前后的事件
+	PlatformFeeBps         int64  // 全局平台费率（基点），系列可通过 fee_bps_override 覆盖
+	VolumeAmountSource     string // 交易额统计口径：listed（挂单价格）或 net（实际到账金额）
+	MinBidIncrementBps     int64  // 出价最小加价比例（基点），系列可覆盖
+	MinBidIncrementWei     string // 出价最小加价绝对值（wei），与比例取较大者
+
+	// 挂单准入：开启后仅已验证系列可以通过 API 挂单
+	RequireVerifiedCollection bool
+
+	// 链上调用熔断配置
+	RPCBreakerThreshold     int           // 连续失败多少次后熔断
+	RPCBreakerCooldown      time.Duration // 熔断后多久放行探测调用
+	UnverifiedListingPolicy string        // 熔断时的挂单策略：reject（拒绝）、trust（信任请求并标记未验证）、queue（暂不上架，待验证）
+
+	// 备用 RPC 节点：ETHEREUM_RPC 故障时按顺序切换，健康检查按间隔探测所有节点并在主节点恢复后切回
+	RPCFallbacks           []string
+	RPCHealthCheckInterval time.Duration
+
+	// RPC 限流自适应限速：节点返回 429 时请求间隔从 base 开始翻倍，成功后逐步恢复
+	EnableRPCThrottle    bool
+	RPCThrottleBaseDelay time.Duration
+	RPCThrottleMaxDelay  time.Duration
 
 	// 区块链同步配置
 	StartBlock          uint64
 	BlockConfirmations  uint64
 	SyncBatchSize       uint64
 	EventProcessWorkers int
+	EventBufferSize     int           // 事件监听通道缓冲大小
+	EventBufferFullWait time.Duration // 缓冲区满时等待消费的最长时间，超时丢弃事件
+	BackfillOnStartup   bool          // 启动时从上次索引进度（无记录时为 StartBlock）回填到已确认区块
+	BackfillBatchSize   int           // 回填时每条 INSERT 语句的行数
+	AvgBlockTime        time.Duration // 回填时估算区块时间戳用的平均出块时间，0 表示自动推算
+
+	// 交易响应 is_final（距最新区块达到 BlockConfirmations）所用最新区块号的刷新间隔
+	ChainHeadRefreshInterval time.Duration
+
+	// 启动时与链上活跃挂单对账；默认关闭，与回填一样只在索引进程（或开发/预发环境）执行，避免多副本同时对账
+	ReconcileOnStartup bool
+	ReconcileMaxChecks int
+
+	// 卖家手动刷新挂单状态
+	SellerRefreshWorkers int // 并发查询链上市场项的 worker 数
+	SellerRefreshRPS     int // 所有刷新请求合计每秒最多查询的市场项数
+
+	// 交易保留策略（清理长期未确认/失败的交易）
+	EnableTxRetention   bool
+	TxRetentionInterval time.Duration
+	TxPendingRetention  time.Duration
+	TxFailedRetention   time.Duration
+
+	// 挂单归档（已售/已取消超过保留期后不再出现在默认查询中）
+	EnableListingArchival   bool
+	ListingArchiveInterval  time.Duration
+	ListingArchiveAfter     time.Duration
+	ListingArchiveBatchSize int
+
+	// 成交事件去重：同一市场项在该区块半径内已有成交时跳过（防止事件重放/重组重放重复计入交易额）
+	SaleDedupeWindowBlocks uint64
+
+	// 历史挂单查询（GET /listings/as-of）最多回溯的时长，0 表示不限制
+	ListingAsOfMaxLookback time.Duration
+
+	// 热门系列物化视图刷新
+	EnableTrendingRefresh   bool
+	TrendingRefreshInterval time.Duration
+
+	// 地板价变动通知（推送 floor_changed 到 WebSocket 系列主题，可选 Webhook）
+	EnableFloorWatch        bool
+	FloorWatchInterval      time.Duration
+	FloorChangeThresholdBps int64         // 相对上次通知的变动达到该基点数才通知
+	FloorChangeDebounce     time.Duration // 同一系列两次通知的最小间隔
+	FloorChangeWebhookURL   string        // 为空时只推送 WebSocket
+
+	// 独立索引进程配置（仅运行事件监听，不提供 API）
+	IndexerOnly         bool
+	IndexerHealthPort   string
+	IndexerMaxLagBlocks uint64
+	// 长时间没有市场事件时，按该间隔检查并把索引进度推进到已确认区块
+	IndexerCursorAdvanceInterval time.Duration
 
 	// API 配置
 	RateLimitPerMinute int
 	MaxPageSize        int
 	DefaultPageSize    int
+	EnableMsgpack      bool // 允许通过 Accept: application/msgpack 获取 msgpack 响应
+
+	// 非分页查询（如卖家全部挂单、最近交易、热门 NFT）单次返回行数的硬上限
+	MaxQueryResults int
+
+	// 浏览类分页接口（挂单、NFT、交易列表及搜索）默认是否执行 COUNT 查询，请求可用 with_count 覆盖
+	PaginationWithCount bool
+
+	// 过载保护：限制同时处理的请求数，饱和时返回 503
+	EnableConcurrencyLimit bool
+	MaxInFlightRequests    int           // 0 表示按 DB_MAX_OPEN_CONNS 的 2 倍计算
+	InFlightQueueTimeout   time.Duration // 饱和时排队等待空位的最长时间
+
+	// 运维管理接口（ADMIN_API_TOKEN 为空时不注册）
+	AdminAPIToken        string
+	AdminResyncMaxBlocks uint64
 
 	// JWT 配置
 	JWTSecret     string
 	JWTExpiration time.Duration
 
+	// 钱包登录（EIP-4361），SIWEDomain 为空时不校验消息中的域名（仅限非生产环境）
+	SIWENonceTTL time.Duration
+	SIWEDomain   string
+
 	// CORS 配置
 	AllowedOrigins []string
 	AllowedMethods []string
 	AllowedHeaders []string
+	ExposedHeaders []string
 
 	// 文件存储配置
 	StorageProvider string // local, s3, ipfs
@@ -68,6 +182,26 @@ type Config struct {
 	S3SecretKey     string
 	IPFSGateway     string
 
+	// 元数据抓取配置
+	MetadataMaxConcurrentFetches int64 // 全进程对外元数据请求的并发上限
+	NFTListIncludeMetadata       bool  // NFT 列表接口默认是否返回 metadata（可用 include_metadata 覆盖）
+
+	// 挂单的 NFT 没有记录时创建占位记录，并异步抓取元数据（有界队列，满时跳过抓取）
+	EnableNFTStubs       bool
+	NFTMetadataQueueSize int
+	NFTMetadataWorkers   int
+	NFTMetadataMaxBytes  int64
+
+	// 浏览计数异步写入（有界队列，满时丢弃计数）
+	ViewCounterQueueSize int
+	ViewCounterWorkers   int
+	ViewCounterRetries   int
+	ViewDedupWindow      time.Duration // 同一浏览者在窗口内对同一 NFT 只计一次，0 表示不去重
+
+	// 元数据/图片抓取白名单（防止恶意 tokenURI 访问内网服务）
+	MetadataAllowedHosts   []string // 允许的主机，支持 *.example.com；未配置时见 MetadataHosts
+	MetadataAllowedSchemes []string
+
 	// 日志配置
 	LogLevel  string // debug, info, warn, error
 	LogFormat string // json, text
@@ -84,6 +218,11 @@ type Config struct {
 	AlchemyAPIKey       string
 	CoinMarketCapAPIKey string
 
+	// 法币价格：ETH 兑法币汇率来源及支持的币种
+	PriceSource     string   // coingecko、coinmarketcap
+	PriceCurrencies []string // 允许通过 ?currencies= 请求的币种
+	PriceCacheTTL   time.Duration
+
 	// 邮件配置
 	SMTPHost     string
 	SMTPPort     int
@@ -91,23 +230,48 @@ type Config struct {
 	SMTPPassword string
 	SMTPFrom     string
 
+	// 死信告警：窗口内处理失败的事件数达到阈值时通知运维
+	EnableDeadLetterAlert    bool
+	DeadLetterAlertThreshold int64
+	DeadLetterAlertWindow    time.Duration
+	DeadLetterAlertChannels  []string // log、webhook、email
+	DeadLetterAlertWebhook   string
+	DeadLetterAlertEmailTo   []string
+
 	// 缓存配置
 	CacheTTL          time.Duration
+	CacheSoftTTL      time.Duration // 超过后返回旧值并异步刷新（stale-while-revalidate）
 	EnableRedisCache  bool
 	EnableMemoryCache bool
 
+	// HTTP 缓存头（Cache-Control: public, max-age），0 表示 no-store
+	HTTPCacheInfoMaxAge        time.Duration // /info
+	HTTPCacheContractMaxAge    time.Duration // /api/v1/contract
+	HTTPCacheCollectionsMaxAge time.Duration // 热门系列、系列统计与加价规则
+
 	// 安全配置
 	EnableRateLimit    bool
 	TrustedProxies     []string
 	MaxRequestBodySize int64
+
+	// 个人信息加密（AES-256-GCM），未配置时拒绝写入邮箱等个人信息
+	PIIEncryptionKey        string   // base64 编码的 32 字节密钥
+	PIIEncryptionKeyVersion int      // 当前密钥版本，随密文一起存储
+	PIIPreviousKeys         []string // 轮换前的旧密钥，格式 版本=base64密钥，仅用于解密
+
+	// 加载过程中解析失败的环境变量
+	envErrors []error
 }
 
 // Load 从环境变量加载配置
 func Load() *Config {
-	return &Config{
+	env := &envReader{}
+
+	cfg := &Config{
 		// 服务器配置
-		ServerPort:  getEnv("SERVER_PORT", "8080"),
-		Environment: getEnv("ENVIRONMENT", "development"),
+		ServerPort:           getEnv("SERVER_PORT", "8080"),
+		Environment:          getEnv("ENVIRONMENT", "development"),
+		ShutdownDrainTimeout: env.getEnvAsDuration("SHUTDOWN_DRAIN_TIMEOUT", 45*time.Second),
 
 		// 数据库配置
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -119,42 +283,152 @@ func Load() *Config {
 		DBTimezone: getEnv("DB_TIMEZONE", "UTC"),
 
 		// 数据库连接池配置
-		DBMaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-		DBMaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
-		DBConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
-		DBConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 10*time.Minute),
+		DBMaxOpenConns:    env.getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    env.getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
+		DBConnMaxLifetime: env.getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+		DBConnMaxIdleTime: env.getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 10*time.Minute),
+
+		// 预编译语句缓存
+		DBPrepareStmt: env.getEnvAsBool("DB_PREPARE_STMT", true),
+
+		// 启动自检
+		RunStartupSelftest: env.getEnvAsBool("RUN_STARTUP_SELFTEST", false),
+
+		// 地址大小写迁移
+		NormalizeAddressesOnStartup: env.getEnvAsBool("NORMALIZE_ADDRESSES_ON_STARTUP", true),
 
 		// Redis 配置
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
 		RedisPort:     getEnv("REDIS_PORT", "6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       getEnvAsInt("REDIS_DB", 0),
+		RedisDB:       env.getEnvAsInt("REDIS_DB", 0),
 
 		// 区块链配置
-		EthereumRPC:        getEnv("ETHEREUM_RPC", ""),
-		MarketplaceAddress: getEnv("MARKETPLACE_ADDRESS", ""),
-		NFTContractAddress: getEnv("NFT_CONTRACT_ADDRESS", ""),
-		ChainID:            getEnvAsInt64("CHAIN_ID", 11155111),
+		EthereumRPC:            getEnv("ETHEREUM_RPC", ""),
+		MarketplaceAddress:     getEnv("MARKETPLACE_ADDRESS", ""),
+		NFTContractAddress:     getEnv("NFT_CONTRACT_ADDRESS", ""),
+		ChainID:                env.getEnvAsInt64("CHAIN_ID", 11155111),
+		MarketplaceABIPath:     getEnv("MARKETPLACE_ABI_PATH", "abi/NFTMarketplace.json"),
+		MarketplaceABIVersions: getEnv("MARKETPLACE_ABI_VERSIONS", "v1=abi/marketplace/v1.json"),
+		PlatformFeeBps:         env.getEnvAsInt64("PLATFORM_FEE_BPS", 250),
+		VolumeAmountSource:     getEnv("VOLUME_AMOUNT_SOURCE", "listed"),
+		MinBidIncrementBps:     env.getEnvAsInt64("MIN_BID_INCREMENT_BPS", 500),
+		MinBidIncrementWei:     getEnv("MIN_BID_INCREMENT_WEI", "0"),
+
+		// 挂单准入
+		RequireVerifiedCollection: env.getEnvAsBool("REQUIRE_VERIFIED_COLLECTION", false),
+
+		// 链上调用熔断配置
+		RPCBreakerThreshold:     env.getEnvAsInt("RPC_BREAKER_THRESHOLD", 5),
+		RPCBreakerCooldown:      env.getEnvAsDuration("RPC_BREAKER_COOLDOWN", 30*time.Second),
+		UnverifiedListingPolicy: getEnv("UNVERIFIED_LISTING_POLICY", "reject"),
+
+		// 备用 RPC 节点
+		RPCFallbacks:           getEnvAsSlice("ETHEREUM_RPC_FALLBACKS", []string{}),
+		RPCHealthCheckInterval: env.getEnvAsDuration("RPC_HEALTH_CHECK_INTERVAL", 30*time.Second),
+
+		// RPC 限流自适应限速
+		EnableRPCThrottle:    env.getEnvAsBool("ENABLE_RPC_THROTTLE", true),
+		RPCThrottleBaseDelay: env.getEnvAsDuration("RPC_THROTTLE_BASE_DELAY", 100*time.Millisecond),
+		RPCThrottleMaxDelay:  env.getEnvAsDuration("RPC_THROTTLE_MAX_DELAY", 10*time.Second),
 
 		// 区块链同步配置
-		StartBlock:          getEnvAsUint64("START_BLOCK", 0),
-		BlockConfirmations:  getEnvAsUint64("BLOCK_CONFIRMATIONS", 12),
-		SyncBatchSize:       getEnvAsUint64("SYNC_BATCH_SIZE", 1000),
-		EventProcessWorkers: getEnvAsInt("EVENT_PROCESS_WORKERS", 5),
+		StartBlock:          env.getEnvAsUint64("START_BLOCK", 0),
+		BlockConfirmations:  env.getEnvAsUint64("BLOCK_CONFIRMATIONS", 12),
+		SyncBatchSize:       env.getEnvAsUint64("SYNC_BATCH_SIZE", 1000),
+		EventProcessWorkers: env.getEnvAsInt("EVENT_PROCESS_WORKERS", 5),
+		EventBufferSize:     env.getEnvAsInt("EVENT_BUFFER_SIZE", 256),
+		EventBufferFullWait: env.getEnvAsDuration("EVENT_BUFFER_FULL_WAIT", 30*time.Second),
+		BackfillOnStartup:   env.getEnvAsBool("BACKFILL_ON_STARTUP", false),
+		BackfillBatchSize:   env.getEnvAsInt("BACKFILL_BATCH_SIZE", 500),
+		AvgBlockTime:        env.getEnvAsDuration("AVG_BLOCK_TIME", 0),
+
+		ChainHeadRefreshInterval: env.getEnvAsDuration("CHAIN_HEAD_REFRESH_INTERVAL", 12*time.Second),
+
+		// 启动对账配置
+		ReconcileOnStartup: env.getEnvAsBool("RECONCILE_ON_STARTUP", false),
+		ReconcileMaxChecks: env.getEnvAsInt("RECONCILE_MAX_CHECKS", 500),
+
+		// 卖家刷新配置
+		SellerRefreshWorkers: env.getEnvAsInt("SELLER_REFRESH_WORKERS", 4),
+		SellerRefreshRPS:     env.getEnvAsInt("SELLER_REFRESH_RPS", 10),
+
+		// 交易保留策略
+		EnableTxRetention:   env.getEnvAsBool("ENABLE_TX_RETENTION", true),
+		TxRetentionInterval: env.getEnvAsDuration("TX_RETENTION_INTERVAL", 1*time.Hour),
+		TxPendingRetention:  env.getEnvAsDuration("TX_PENDING_RETENTION", 7*24*time.Hour),
+		TxFailedRetention:   env.getEnvAsDuration("TX_FAILED_RETENTION", 90*24*time.Hour),
+
+		// 挂单归档
+		EnableListingArchival:   env.getEnvAsBool("ENABLE_LISTING_ARCHIVAL", true),
+		ListingArchiveInterval:  env.getEnvAsDuration("LISTING_ARCHIVE_INTERVAL", 1*time.Hour),
+		ListingArchiveAfter:     env.getEnvAsDuration("LISTING_ARCHIVE_AFTER", 30*24*time.Hour),
+		ListingArchiveBatchSize: env.getEnvAsInt("LISTING_ARCHIVE_BATCH_SIZE", 1000),
+
+		// 成交事件去重
+		SaleDedupeWindowBlocks: env.getEnvAsUint64("SALE_DEDUPE_WINDOW_BLOCKS", 64),
+
+		// 历史挂单查询
+		ListingAsOfMaxLookback: env.getEnvAsDuration("LISTING_AS_OF_MAX_LOOKBACK", 365*24*time.Hour),
+
+		// 热门系列刷新
+		EnableTrendingRefresh:   env.getEnvAsBool("ENABLE_TRENDING_REFRESH", true),
+		TrendingRefreshInterval: env.getEnvAsDuration("TRENDING_REFRESH_INTERVAL", 10*time.Minute),
+
+		// 地板价变动通知
+		EnableFloorWatch:        env.getEnvAsBool("ENABLE_FLOOR_WATCH", true),
+		FloorWatchInterval:      env.getEnvAsDuration("FLOOR_WATCH_INTERVAL", 1*time.Minute),
+		FloorChangeThresholdBps: env.getEnvAsInt64("FLOOR_CHANGE_THRESHOLD_BPS", 500),
+		FloorChangeDebounce:     env.getEnvAsDuration("FLOOR_CHANGE_DEBOUNCE", 10*time.Minute),
+		FloorChangeWebhookURL:   getEnv("FLOOR_CHANGE_WEBHOOK_URL", ""),
+
+		// 独立索引进程配置
+		IndexerOnly:         env.getEnvAsBool("INDEXER_ONLY", false),
+		IndexerHealthPort:   getEnv("INDEXER_HEALTH_PORT", "8081"),
+		IndexerMaxLagBlocks: env.getEnvAsUint64("INDEXER_MAX_LAG_BLOCKS", 100),
+
+		IndexerCursorAdvanceInterval: env.getEnvAsDuration("INDEXER_CURSOR_ADVANCE_INTERVAL", time.Minute),
 
 		// API 配置
-		RateLimitPerMinute: getEnvAsInt("RATE_LIMIT_PER_MINUTE", 100),
-		MaxPageSize:        getEnvAsInt("MAX_PAGE_SIZE", 100),
-		DefaultPageSize:    getEnvAsInt("DEFAULT_PAGE_SIZE", 20),
+		RateLimitPerMinute: env.getEnvAsInt("RATE_LIMIT_PER_MINUTE", 100),
+		MaxPageSize:        env.getEnvAsInt("MAX_PAGE_SIZE", 100),
+		DefaultPageSize:    env.getEnvAsInt("DEFAULT_PAGE_SIZE", 20),
+		EnableMsgpack:      env.getEnvAsBool("ENABLE_MSGPACK", true),
+
+		MaxQueryResults: env.getEnvAsInt("MAX_QUERY_RESULTS", 1000),
+
+		PaginationWithCount: env.getEnvAsBool("PAGINATION_WITH_COUNT", true),
+
+		// 过载保护
+		EnableConcurrencyLimit: env.getEnvAsBool("ENABLE_CONCURRENCY_LIMIT", true),
+		MaxInFlightRequests:    env.getEnvAsInt("MAX_INFLIGHT_REQUESTS", 0),
+		InFlightQueueTimeout:   env.getEnvAsDuration("INFLIGHT_QUEUE_TIMEOUT", 100*time.Millisecond),
+
+		// 运维管理接口
+		AdminAPIToken:        getEnv("ADMIN_API_TOKEN", ""),
+		AdminResyncMaxBlocks: env.getEnvAsUint64("ADMIN_RESYNC_MAX_BLOCKS", 10000),
 
 		// JWT 配置
 		JWTSecret:     getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-		JWTExpiration: getEnvAsDuration("JWT_EXPIRATION", 24*time.Hour),
+		JWTExpiration: env.getEnvAsDuration("JWT_EXPIRATION", 24*time.Hour),
+
+		// 钱包登录
+		SIWENonceTTL: env.getEnvAsDuration("SIWE_NONCE_TTL", 5*time.Minute),
+		SIWEDomain:   getEnv("SIWE_DOMAIN", ""),
 
 		// CORS 配置
 		AllowedOrigins: getEnvAsSlice("ALLOWED_ORIGINS", []string{"*"}),
 		AllowedMethods: getEnvAsSlice("ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		AllowedHeaders: getEnvAsSlice("ALLOWED_HEADERS", []string{"Origin", "Content-Type", "Authorization"}),
+		AllowedHeaders: getEnvAsSlice("ALLOWED_HEADERS", []string{"Origin", "Content-Type", "Authorization", "X-Request-ID"}),
+		ExposedHeaders: getEnvAsSlice("EXPOSED_HEADERS", []string{
+			"Content-Length",
+			"Link",
+			"X-Request-ID",
+			"X-RateLimit-Limit",
+			"X-RateLimit-Remaining",
+			"X-RateLimit-Reset",
+			"Retry-After",
+		}),
 
 		// 文件存储配置
 		StorageProvider: getEnv("STORAGE_PROVIDER", "local"),
@@ -164,14 +438,34 @@ func Load() *Config {
 		S3SecretKey:     getEnv("S3_SECRET_KEY", ""),
 		IPFSGateway:     getEnv("IPFS_GATEWAY", "https://ipfs.io"),
 
+		// 元数据抓取配置
+		MetadataMaxConcurrentFetches: env.getEnvAsInt64("METADATA_MAX_CONCURRENT_FETCHES", 16),
+		NFTListIncludeMetadata:       env.getEnvAsBool("NFT_LIST_INCLUDE_METADATA", true),
+
+		// NFT 占位记录
+		EnableNFTStubs:       env.getEnvAsBool("ENABLE_NFT_STUBS", true),
+		NFTMetadataQueueSize: env.getEnvAsInt("NFT_METADATA_QUEUE_SIZE", 1000),
+		NFTMetadataWorkers:   env.getEnvAsInt("NFT_METADATA_WORKERS", 2),
+		NFTMetadataMaxBytes:  env.getEnvAsInt64("NFT_METADATA_MAX_BYTES", 1<<20),
+
+		// 浏览计数
+		ViewCounterQueueSize: env.getEnvAsInt("VIEW_COUNTER_QUEUE_SIZE", 10000),
+		ViewCounterWorkers:   env.getEnvAsInt("VIEW_COUNTER_WORKERS", 4),
+		ViewCounterRetries:   env.getEnvAsInt("VIEW_COUNTER_RETRIES", 3),
+		ViewDedupWindow:      env.getEnvAsDuration("VIEW_DEDUP_WINDOW", 30*time.Minute),
+
+		// 元数据抓取白名单
+		MetadataAllowedHosts:   getEnvAsSlice("METADATA_ALLOWED_HOSTS", nil),
+		MetadataAllowedSchemes: getEnvAsSlice("METADATA_ALLOWED_SCHEMES", []string{"https"}),
+
 		// 日志配置
 		LogLevel:  getEnv("LOG_LEVEL", "info"),
 		LogFormat: getEnv("LOG_FORMAT", "json"),
 
 		// 监控配置
-		EnableMetrics: getEnvAsBool("ENABLE_METRICS", true),
+		EnableMetrics: env.getEnvAsBool("ENABLE_METRICS", true),
 		MetricsPort:   getEnv("METRICS_PORT", "9090"),
-		EnablePprof:   getEnvAsBool("ENABLE_PPROF", false),
+		EnablePprof:   env.getEnvAsBool("ENABLE_PPROF", false),
 		PprofPort:     getEnv("PPROF_PORT", "6060"),
 
 		// 第三方服务
@@ -180,23 +474,118 @@ func Load() *Config {
 		AlchemyAPIKey:       getEnv("ALCHEMY_API_KEY", ""),
 		CoinMarketCapAPIKey: getEnv("COINMARKETCAP_API_KEY", ""),
 
+		// 法币价格
+		PriceSource:     getEnv("PRICE_SOURCE", "coingecko"),
+		PriceCurrencies: getEnvAsSlice("PRICE_CURRENCIES", []string{"USD", "EUR", "GBP", "JPY", "CNY"}),
+		PriceCacheTTL:   env.getEnvAsDuration("PRICE_CACHE_TTL", time.Minute),
+
 		// 邮件配置
 		SMTPHost:     getEnv("SMTP_HOST", ""),
-		SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+		SMTPPort:     env.getEnvAsInt("SMTP_PORT", 587),
 		SMTPUser:     getEnv("SMTP_USER", ""),
 		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
 		SMTPFrom:     getEnv("SMTP_FROM", "noreply@nftmarketplace.com"),
 
+		// 死信告警
+		EnableDeadLetterAlert:    env.getEnvAsBool("ENABLE_DEAD_LETTER_ALERT", false),
+		DeadLetterAlertThreshold: env.getEnvAsInt64("DEAD_LETTER_ALERT_THRESHOLD", 10),
+		DeadLetterAlertWindow:    env.getEnvAsDuration("DEAD_LETTER_ALERT_WINDOW", 15*time.Minute),
+		DeadLetterAlertChannels:  getEnvAsSlice("DEAD_LETTER_ALERT_CHANNELS", []string{"log"}),
+		DeadLetterAlertWebhook:   getEnv("DEAD_LETTER_ALERT_WEBHOOK_URL", ""),
+		DeadLetterAlertEmailTo:   getEnvAsSlice("DEAD_LETTER_ALERT_EMAIL_TO", []string{}),
+
 		// 缓存配置
-		CacheTTL:          getEnvAsDuration("CACHE_TTL", 5*time.Minute),
-		EnableRedisCache:  getEnvAsBool("ENABLE_REDIS_CACHE", true),
-		EnableMemoryCache: getEnvAsBool("ENABLE_MEMORY_CACHE", true),
+		CacheTTL:          env.getEnvAsDuration("CACHE_TTL", 5*time.Minute),
+		CacheSoftTTL:      env.getEnvAsDuration("CACHE_SOFT_TTL", 30*time.Second),
+		EnableRedisCache:  env.getEnvAsBool("ENABLE_REDIS_CACHE", true),
+		EnableMemoryCache: env.getEnvAsBool("ENABLE_MEMORY_CACHE", true),
+
+		// HTTP 缓存头
+		HTTPCacheInfoMaxAge:        env.getEnvAsDuration("HTTP_CACHE_INFO_MAX_AGE", 5*time.Minute),
+		HTTPCacheContractMaxAge:    env.getEnvAsDuration("HTTP_CACHE_CONTRACT_MAX_AGE", time.Hour),
+		HTTPCacheCollectionsMaxAge: env.getEnvAsDuration("HTTP_CACHE_COLLECTIONS_MAX_AGE", time.Minute),
 
 		// 安全配置
-		EnableRateLimit:    getEnvAsBool("ENABLE_RATE_LIMIT", true),
+		EnableRateLimit:    env.getEnvAsBool("ENABLE_RATE_LIMIT", true),
 		TrustedProxies:     getEnvAsSlice("TRUSTED_PROXIES", []string{}),
-		MaxRequestBodySize: getEnvAsInt64("MAX_REQUEST_BODY_SIZE", 10*1024*1024), // 10MB
+		MaxRequestBodySize: env.getEnvAsInt64("MAX_REQUEST_BODY_SIZE", 10*1024*1024), // 10MB
+
+		// 个人信息加密
+		PIIEncryptionKey:        getEnv("PII_ENCRYPTION_KEY", ""),
+		PIIEncryptionKeyVersion: env.getEnvAsInt("PII_ENCRYPTION_KEY_VERSION", 1),
+		PIIPreviousKeys:         getEnvAsSlice("PII_PREVIOUS_KEYS", []string{}),
 	}
+
+	cfg.envErrors = env.errs
+	return cfg
+}
+
+// RPCEndpoints 按优先级排列的 RPC 节点：ETHEREUM_RPC 在前，其后为 ETHEREUM_RPC_FALLBACKS
+func (c *Config) RPCEndpoints() []string {
+	return append([]string{c.EthereumRPC}, c.RPCFallbacks...)
+}
+
+// IPFSGatewayPrefix IPFS 内容的网关前缀（形如 https://ipfs.io/ipfs/），供 metadata.NormalizeURI 使用
+func (c *Config) IPFSGatewayPrefix() string {
+	gateway := strings.TrimSuffix(c.IPFSGateway, "/")
+	if !strings.HasSuffix(gateway, "/ipfs") {
+		gateway += "/ipfs"
+	}
+	return gateway + "/"
+}
+
+// MetadataHosts 元数据抓取允许的主机，未配置时为 IPFS 网关主机及常用公共网关
+func (c *Config) MetadataHosts() []string {
+	if len(c.MetadataAllowedHosts) > 0 {
+		return c.MetadataAllowedHosts
+	}
+
+	hosts := append([]string{}, metadata.DefaultAllowedHosts...)
+	if u, err := url.Parse(c.IPFSGateway); err == nil && u.Hostname() != "" {
+		hosts = append(hosts, u.Hostname())
+	}
+	return hosts
+}
+
+// InFlightLimit 同时处理的请求数上限，未配置时取 DB 连接池大小的 2 倍
+// （部分请求不访问数据库，留出余量；访问数据库的请求仍由连接池排队）
+func (c *Config) InFlightLimit() int {
+	if c.MaxInFlightRequests > 0 {
+		return c.MaxInFlightRequests
+	}
+	if c.DBMaxOpenConns > 0 {
+		return 2 * c.DBMaxOpenConns
+	}
+	return 100
+}
+
+// PIIKeys 按版本解析个人信息加密密钥（当前密钥与旧密钥）
+func (c *Config) PIIKeys() (map[uint32][]byte, error) {
+	if c.PIIEncryptionKeyVersion < 1 {
+		return nil, fmt.Errorf("PII_ENCRYPTION_KEY_VERSION must be positive")
+	}
+	current, err := pii.ParseKey(c.PIIEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("PII_ENCRYPTION_KEY: %w", err)
+	}
+	keys := map[uint32][]byte{uint32(c.PIIEncryptionKeyVersion): current}
+
+	for _, entry := range c.PIIPreviousKeys {
+		versionStr, encoded, ok := strings.Cut(entry, "=")
+		version, err := strconv.ParseUint(versionStr, 10, 32)
+		if !ok || err != nil || version == 0 {
+			return nil, fmt.Errorf("PII_PREVIOUS_KEYS entries must be version=base64key")
+		}
+		if _, exists := keys[uint32(version)]; exists {
+			return nil, fmt.Errorf("PII_PREVIOUS_KEYS repeats key version %d", version)
+		}
+		key, err := pii.ParseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("PII_PREVIOUS_KEYS version %d: %w", version, err)
+		}
+		keys[uint32(version)] = key
+	}
+	return keys, nil
 }
 
 // GetDSN 返回数据库 DSN 连接字符串
@@ -235,6 +624,10 @@ func (c *Config) IsStaging() bool {
 
 // Validate 验证配置
 func (c *Config) Validate() error {
+	if len(c.envErrors) > 0 {
+		return errors.Join(c.envErrors...)
+	}
+
 	if c.DBHost == "" {
 		return fmt.Errorf("DB_HOST is required")
 	}
@@ -251,9 +644,148 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("MARKETPLACE_ADDRESS is required")
 	}
 
+	if c.PlatformFeeBps < 0 || c.PlatformFeeBps > 1000 {
+		return fmt.Errorf("PLATFORM_FEE_BPS must be between 0 and 1000")
+	}
+
+	if c.VolumeAmountSource != "listed" && c.VolumeAmountSource != "net" {
+		return fmt.Errorf("VOLUME_AMOUNT_SOURCE must be listed or net")
+	}
+
+	if c.MinBidIncrementBps < 0 || c.MinBidIncrementBps > 10000 {
+		return fmt.Errorf("MIN_BID_INCREMENT_BPS must be between 0 and 10000")
+	}
+
+	if wei, ok := new(big.Int).SetString(c.MinBidIncrementWei, 10); !ok || wei.Sign() < 0 {
+		return fmt.Errorf("MIN_BID_INCREMENT_WEI must be a non-negative integer")
+	}
+
+	if c.RPCBreakerThreshold < 1 || c.RPCBreakerCooldown <= 0 {
+		return fmt.Errorf("RPC_BREAKER_THRESHOLD and RPC_BREAKER_COOLDOWN must be positive")
+	}
+
+	if len(c.RPCFallbacks) > 0 && c.RPCHealthCheckInterval <= 0 {
+		return fmt.Errorf("RPC_HEALTH_CHECK_INTERVAL must be positive")
+	}
+
+	if c.EnableRPCThrottle && (c.RPCThrottleBaseDelay <= 0 || c.RPCThrottleMaxDelay < c.RPCThrottleBaseDelay) {
+		return fmt.Errorf("RPC_THROTTLE_BASE_DELAY must be positive and not exceed RPC_THROTTLE_MAX_DELAY")
+	}
+
+	switch c.UnverifiedListingPolicy {
+	case "reject", "trust", "queue":
+	default:
+		return fmt.Errorf("UNVERIFIED_LISTING_POLICY must be reject, trust or queue")
+	}
+
+	if c.EnableDeadLetterAlert {
+		if c.DeadLetterAlertThreshold < 1 || c.DeadLetterAlertWindow <= 0 {
+			return fmt.Errorf("DEAD_LETTER_ALERT_THRESHOLD and DEAD_LETTER_ALERT_WINDOW must be positive")
+		}
+		for _, channel := range c.DeadLetterAlertChannels {
+			switch channel {
+			case "log":
+			case "webhook":
+				if c.DeadLetterAlertWebhook == "" {
+					return fmt.Errorf("DEAD_LETTER_ALERT_WEBHOOK_URL is required for the webhook channel")
+				}
+			case "email":
+				if c.SMTPHost == "" || len(c.DeadLetterAlertEmailTo) == 0 {
+					return fmt.Errorf("SMTP_HOST and DEAD_LETTER_ALERT_EMAIL_TO are required for the email channel")
+				}
+			default:
+				return fmt.Errorf("unknown DEAD_LETTER_ALERT_CHANNELS entry %q", channel)
+			}
+		}
+	}
+
+	switch c.PriceSource {
+	case "coingecko":
+	case "coinmarketcap":
+		if c.CoinMarketCapAPIKey == "" {
+			return fmt.Errorf("COINMARKETCAP_API_KEY is required when PRICE_SOURCE is coinmarketcap")
+		}
+	default:
+		return fmt.Errorf("PRICE_SOURCE must be coingecko or coinmarketcap")
+	}
+
+	if c.PriceCacheTTL <= 0 {
+		return fmt.Errorf("PRICE_CACHE_TTL must be positive")
+	}
+
+	if c.EnableConcurrencyLimit && (c.MaxInFlightRequests < 0 || c.InFlightQueueTimeout < 0) {
+		return fmt.Errorf("MAX_INFLIGHT_REQUESTS and INFLIGHT_QUEUE_TIMEOUT must not be negative")
+	}
+
+	if c.PIIEncryptionKey != "" {
+		if _, err := c.PIIKeys(); err != nil {
+			return err
+		}
+	}
+
+	if c.EnableNFTStubs && (c.NFTMetadataQueueSize < 1 || c.NFTMetadataWorkers < 1 || c.NFTMetadataMaxBytes < 1) {
+		return fmt.Errorf("NFT_METADATA_QUEUE_SIZE, NFT_METADATA_WORKERS and NFT_METADATA_MAX_BYTES must be positive")
+	}
+
+	if c.ViewCounterQueueSize < 1 || c.ViewCounterWorkers < 1 || c.ViewCounterRetries < 0 {
+		return fmt.Errorf("VIEW_COUNTER_QUEUE_SIZE and VIEW_COUNTER_WORKERS must be positive, VIEW_COUNTER_RETRIES must not be negative")
+	}
+	if c.ViewDedupWindow < 0 {
+		return fmt.Errorf("VIEW_DEDUP_WINDOW must not be negative")
+	}
+
+	for _, scheme := range c.MetadataAllowedSchemes {
+		if scheme != "http" && scheme != "https" {
+			return fmt.Errorf("METADATA_ALLOWED_SCHEMES only supports http and https, got %q", scheme)
+		}
+	}
+
+	if c.EnableTxRetention && (c.TxRetentionInterval <= 0 || c.TxPendingRetention <= 0 || c.TxFailedRetention <= 0) {
+		return fmt.Errorf("TX_RETENTION_INTERVAL, TX_PENDING_RETENTION and TX_FAILED_RETENTION must be positive")
+	}
+
+	if c.EnableListingArchival && (c.ListingArchiveInterval <= 0 || c.ListingArchiveAfter <= 0 || c.ListingArchiveBatchSize <= 0) {
+		return fmt.Errorf("LISTING_ARCHIVE_INTERVAL, LISTING_ARCHIVE_AFTER and LISTING_ARCHIVE_BATCH_SIZE must be positive")
+	}
+
+	if c.ChainHeadRefreshInterval <= 0 {
+		return fmt.Errorf("CHAIN_HEAD_REFRESH_INTERVAL must be positive")
+	}
+
+	if c.IndexerCursorAdvanceInterval <= 0 {
+		return fmt.Errorf("INDEXER_CURSOR_ADVANCE_INTERVAL must be positive")
+	}
+
+	if c.MaxQueryResults <= 0 {
+		return fmt.Errorf("MAX_QUERY_RESULTS must be positive")
+	}
+
+	if c.SIWENonceTTL <= 0 {
+		return fmt.Errorf("SIWE_NONCE_TTL must be positive")
+	}
+
+	if c.ShutdownDrainTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_DRAIN_TIMEOUT must be positive")
+	}
+
+	if c.ListingAsOfMaxLookback < 0 {
+		return fmt.Errorf("LISTING_AS_OF_MAX_LOOKBACK must not be negative")
+	}
+
+	if c.EnableTrendingRefresh && c.TrendingRefreshInterval <= 0 {
+		return fmt.Errorf("TRENDING_REFRESH_INTERVAL must be positive")
+	}
+
+	if c.EnableFloorWatch && (c.FloorWatchInterval <= 0 || c.FloorChangeThresholdBps <= 0 || c.FloorChangeDebounce < 0) {
+		return fmt.Errorf("FLOOR_WATCH_INTERVAL and FLOOR_CHANGE_THRESHOLD_BPS must be positive, FLOOR_CHANGE_DEBOUNCE must not be negative")
+	}
+
 	if c.IsProduction() && c.JWTSecret == "your-secret-key-change-in-production" {
 		return fmt.Errorf("JWT_SECRET must be changed in production")
 	}
+	if c.IsProduction() && c.SIWEDomain == "" {
+		return fmt.Errorf("SIWE_DOMAIN is required in production")
+	}
 
 	return nil
 }
@@ -264,10 +796,12 @@ func (c *Config) Print() {
 	fmt.Printf("Environment: %s\n", c.Environment)
 	fmt.Printf("Server Port: %s\n", c.ServerPort)
 	fmt.Printf("Database: %s@%s:%s/%s\n", c.DBUser, c.DBHost, c.DBPort, c.DBName)
+	fmt.Printf("Prepared Statements: %v\n", c.DBPrepareStmt)
 	fmt.Printf("Redis: %s:%s\n", c.RedisHost, c.RedisPort)
 	fmt.Printf("Ethereum RPC: %s\n", c.EthereumRPC)
 	fmt.Printf("Marketplace Address: %s\n", c.MarketplaceAddress)
 	fmt.Printf("Chain ID: %d\n", c.ChainID)
+	fmt.Printf("Indexer Only: %v\n", c.IndexerOnly)
 	fmt.Printf("Log Level: %s\n", c.LogLevel)
 	fmt.Printf("Metrics Enabled: %v\n", c.EnableMetrics)
 	fmt.Println("=================================")
@@ -283,49 +817,91 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// envReader 读取带类型的环境变量，收集本次 Load 中已设置但无法解析的值
+type envReader struct {
+	errs []error
+}
+
 // getEnvAsInt 获取整数类型的环境变量
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := os.Getenv(key)
-	if value, err := strconv.Atoi(valueStr); err == nil {
-		return value
+func (e *envReader) getEnvAsInt(key string, defaultValue int) int {
+	valueStr, ok := lookupEnv(key)
+	if !ok {
+		return defaultValue
 	}
-	return defaultValue
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		e.recordError(key, valueStr, err)
+		return defaultValue
+	}
+	return value
 }
 
 // getEnvAsInt64 获取 int64 类型的环境变量
-func getEnvAsInt64(key string, defaultValue int64) int64 {
-	valueStr := os.Getenv(key)
-	if value, err := strconv.ParseInt(valueStr, 10, 64); err == nil {
-		return value
+func (e *envReader) getEnvAsInt64(key string, defaultValue int64) int64 {
+	valueStr, ok := lookupEnv(key)
+	if !ok {
+		return defaultValue
 	}
-	return defaultValue
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil {
+		e.recordError(key, valueStr, err)
+		return defaultValue
+	}
+	return value
 }
 
 // getEnvAsUint64 获取 uint64 类型的环境变量
-func getEnvAsUint64(key string, defaultValue uint64) uint64 {
-	valueStr := os.Getenv(key)
-	if value, err := strconv.ParseUint(valueStr, 10, 64); err == nil {
-		return value
+func (e *envReader) getEnvAsUint64(key string, defaultValue uint64) uint64 {
+	valueStr, ok := lookupEnv(key)
+	if !ok {
+		return defaultValue
 	}
-	return defaultValue
+	value, err := strconv.ParseUint(valueStr, 10, 64)
+	if err != nil {
+		e.recordError(key, valueStr, err)
+		return defaultValue
+	}
+	return value
 }
 
 // getEnvAsBool 获取布尔类型的环境变量
-func getEnvAsBool(key string, defaultValue bool) bool {
-	valueStr := os.Getenv(key)
-	if value, err := strconv.ParseBool(valueStr); err == nil {
-		return value
+func (e *envReader) getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr, ok := lookupEnv(key)
+	if !ok {
+		return defaultValue
 	}
-	return defaultValue
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		e.recordError(key, valueStr, err)
+		return defaultValue
+	}
+	return value
 }
 
 // getEnvAsDuration 获取时间间隔类型的环境变量
-func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	valueStr := os.Getenv(key)
-	if value, err := time.ParseDuration(valueStr); err == nil {
-		return value
+func (e *envReader) getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr, ok := lookupEnv(key)
+	if !ok {
+		return defaultValue
 	}
-	return defaultValue
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		e.recordError(key, valueStr, err)
+		return defaultValue
+	}
+	return value
+}
+
+// lookupEnv 获取环境变量，ok 表示已设置且非空；与 getEnv 一致，空字符串按未设置处理并回退到默认值
+func lookupEnv(key string) (string, bool) {
+	value := os.Getenv(key)
+	return value, value != ""
+}
+
+// recordError 记录已设置但无法解析的环境变量，由 Validate 统一报告
+func (e *envReader) recordError(key, value string, err error) {
+	log.Printf("Warning: invalid value %q for %s: %v", value, key, err)
+	e.errs = append(e.errs, fmt.Errorf("invalid value %q for %s: %w", value, key, err))
 }
 
 // getEnvAsSlice 获取字符串切片类型的环境变量（逗号分隔）
@@ -335,13 +911,7 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 		return defaultValue
 	}
 
-	var result []string
-	for _, v := range splitAndTrim(valueStr, ",") {
-		if v != "" {
-			result = append(result, v)
-		}
-	}
-
+	result := splitAndTrim(valueStr, ",")
 	if len(result) == 0 {
 		return defaultValue
 	}
@@ -349,50 +919,13 @@ func getEnvAsSlice(key string, defaultValue []string) []string {
 	return result
 }
 
-// splitAndTrim 分割字符串并去除空格
+// splitAndTrim 分割字符串并去除各项首尾空白，丢弃空项
 func splitAndTrim(s, sep string) []string {
 	var result []string
-	for _, item := range splitString(s, sep) {
-		if trimmed := trimSpace(item); trimmed != "" {
+	for _, item := range strings.Split(s, sep) {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
 			result = append(result, trimmed)
 		}
 	}
 	return result
 }
-
-// splitString 简单的字符串分割
-func splitString(s, sep string) []string {
-	if s == "" {
-		return []string{}
-	}
-
-	var result []string
-	start := 0
-
-	for i := 0; i < len(s); i++ {
-		if s[i:i+len(sep)] == sep {
-			result = append(result, s[start:i])
-			start = i + len(sep)
-			i += len(sep) - 1
-		}
-	}
-
-	result = append(result, s[start:])
-	return result
-}
-
-// trimSpace 去除字符串首尾空格
-func trimSpace(s string) string {
-	start := 0
-	end := len(s)
-
-	for start < end && (s[start] == ' ' || s[start] == '\t' || s[start] == '\n' || s[start] == '\r') {
-		start++
-	}
-
-	for end > start && (s[end-1] == ' ' || s[end-1] == '\t' || s[end-1] == '\n' || s[end-1] == '\r') {
-		end--
-	}
-
-	return s[start:end]
-}
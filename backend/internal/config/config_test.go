@@ -0,0 +1,151 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setRequiredEnv 设置 Validate 必需的环境变量
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("ETHEREUM_RPC", "http://localhost:8545")
+	t.Setenv("MARKETPLACE_ADDRESS", "0x0000000000000000000000000000000000000001")
+}
+
+func TestValidateEnvValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string // 为空表示校验通过
+	}{
+		{"defaults", nil, ""},
+		{"valid overrides", map[string]string{"CHAIN_ID": "1", "ENABLE_METRICS": "false", "CACHE_TTL": "30s"}, ""},
+		{"invalid int", map[string]string{"CHAIN_ID": "mainnet"}, "CHAIN_ID"},
+		{"invalid uint", map[string]string{"SYNC_BATCH_SIZE": "-1"}, "SYNC_BATCH_SIZE"},
+		{"invalid bool", map[string]string{"ENABLE_METRICS": "maybe"}, "ENABLE_METRICS"},
+		{"invalid duration", map[string]string{"CACHE_TTL": "60"}, "CACHE_TTL"},
+		{"empty int uses default", map[string]string{"CHAIN_ID": ""}, ""},
+		{"empty bool uses default", map[string]string{"ENABLE_METRICS": ""}, ""},
+		{"fee out of range", map[string]string{"PLATFORM_FEE_BPS": "1001"}, "PLATFORM_FEE_BPS"},
+		{"missing rpc", map[string]string{"ETHEREUM_RPC": ""}, "ETHEREUM_RPC is required"},
+		{"production without siwe domain", map[string]string{"ENVIRONMENT": "production", "JWT_SECRET": "s3cret"}, "SIWE_DOMAIN"},
+		{"production with siwe domain", map[string]string{"ENVIRONMENT": "production", "JWT_SECRET": "s3cret", "SIWE_DOMAIN": "market.example"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			err := Load().Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("Validate() = nil, want error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// FOO= 与未设置一致，回退到默认值
+func TestEmptyValueUsesDefault(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CACHE_TTL", "")
+	t.Setenv("PLATFORM_FEE_BPS", "")
+
+	cfg := Load()
+	if cfg.CacheTTL != 5*time.Minute {
+		t.Errorf("CacheTTL = %v, want default %v", cfg.CacheTTL, 5*time.Minute)
+	}
+	if cfg.PlatformFeeBps != 250 {
+		t.Errorf("PlatformFeeBps = %d, want default 250", cfg.PlatformFeeBps)
+	}
+}
+
+func TestInvalidValueFallsBackToDefault(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CACHE_TTL", "soon")
+
+	if got := Load().CacheTTL; got != 5*time.Minute {
+		t.Errorf("CacheTTL = %v, want default %v", got, 5*time.Minute)
+	}
+}
+
+// 一次 Load 的解析错误不能带到下一次 Load
+func TestLoadDoesNotLeakEnvErrors(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CHAIN_ID", "mainnet")
+	bad := Load()
+
+	t.Setenv("CHAIN_ID", "1")
+	good := Load()
+
+	if err := good.Validate(); err != nil {
+		t.Fatalf("second Load Validate() = %v, want nil", err)
+	}
+	if err := bad.Validate(); err == nil || !strings.Contains(err.Error(), "CHAIN_ID") {
+		t.Fatalf("first Load Validate() = %v, want CHAIN_ID error", err)
+	}
+}
+
+func TestSplitAndTrim(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		sep  string
+		want []string
+	}{
+		{"single", "a", ",", []string{"a"}},
+		{"trims items", " a , b ,c ", ",", []string{"a", "b", "c"}},
+		{"drops empty items", "a,,b", ",", []string{"a", "b"}},
+		{"trailing separator", "a,b,", ",", []string{"a", "b"}},
+		{"leading separator", ",a", ",", []string{"a"}},
+		{"only separators", ",,,", ",", nil},
+		{"only whitespace", "  ", ",", nil},
+		{"empty", "", ",", nil},
+		{"multi-byte separator", "a::b::", "::", []string{"a", "b"}},
+		{"separator longer than input", "a", ":::", []string{"a"}},
+		{"partial separator at end", "a::b:", "::", []string{"a", "b:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitAndTrim(tt.s, tt.sep); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitAndTrim(%q, %q) = %q, want %q", tt.s, tt.sep, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvAsSlice(t *testing.T) {
+	defaults := []string{"*"}
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"unset uses default", "", defaults},
+		{"single", "https://a.example", []string{"https://a.example"}},
+		{"list with spaces", "https://a.example, https://b.example", []string{"https://a.example", "https://b.example"}},
+		{"trailing comma", "10.0.0.1,", []string{"10.0.0.1"}},
+		{"only commas uses default", ",,", defaults},
+		{"only whitespace uses default", " , ", defaults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_LIST", tt.value)
+			if got := getEnvAsSlice("TEST_LIST", defaults); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getEnvAsSlice(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/service"
+)
+
+// AdminHandler 运维管理处理器
+type AdminHandler struct {
+	indexer        *service.IndexerService
+	collections    *service.CollectionService
+	transactions   *service.TransactionService
+	images         *service.ImageURLRewriter
+	nfts           *service.NFTService
+	fees           *service.FeeService
+	maxResyncRange uint64
+}
+
+// NewAdminHandler 创建运维管理处理器
+func NewAdminHandler(indexer *service.IndexerService, collections *service.CollectionService, transactions *service.TransactionService, images *service.ImageURLRewriter, nfts *service.NFTService, fees *service.FeeService, maxResyncRange uint64) *AdminHandler {
+	return &AdminHandler{
+		indexer:        indexer,
+		collections:    collections,
+		transactions:   transactions,
+		images:         images,
+		nfts:           nfts,
+		fees:           fees,
+		maxResyncRange: maxResyncRange,
+	}
+}
+
+// ResyncRequest 重新同步区块范围请求
+type ResyncRequest struct {
+	FromBlock uint64 `json:"from_block"`
+	ToBlock   uint64 `json:"to_block" binding:"required"`
+}
+
+// ResyncBlocks 重新回填指定区块范围
+// @Summary 重新回填区块范围内的市场事件（不修改同步进度）
+// @Tags Admin
+// @Param body body ResyncRequest true "区块范围"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/indexer/resync [post]
+func (h *AdminHandler) ResyncBlocks(c *gin.Context) {
+	var req ResyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.FromBlock > req.ToBlock {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidBlockRange, nil)
+		return
+	}
+
+	if req.ToBlock-req.FromBlock+1 > h.maxResyncRange {
+		respondError(c, http.StatusBadRequest, i18n.ErrBlockRangeTooLarge, nil, h.maxResyncRange)
+		return
+	}
+
+	result, err := h.indexer.Backfill(c.Request.Context(), req.FromBlock, req.ToBlock)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, struct {
+			*APIError
+			Partial *service.BackfillResult `json:"partial"`
+		}{newAPIError(c, i18n.ErrResyncBlocks, err), result})
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data":    result,
+		"message": "Resync completed",
+	})
+}
+
+// RefreshTrending 立即刷新热门系列物化视图
+// @Summary 强制刷新热门系列
+// @Tags Admin
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/collections/trending/refresh [post]
+func (h *AdminHandler) RefreshTrending(c *gin.Context) {
+	if err := h.collections.RefreshTrending(c.Request.Context()); err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrRefreshTrending, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"message": "Trending collections refreshed",
+	})
+}
+
+// ImageCDNRequest 设置系列图片 CDN 前缀请求，image_cdn_base 为 null 或空时恢复使用全局网关
+type ImageCDNRequest struct {
+	ImageCDNBase *string `json:"image_cdn_base"`
+}
+
+// SetImageCDNBase 设置系列图片 CDN 前缀
+// @Summary 设置系列 IPFS 图片改写到的 CDN 前缀（主机须在元数据抓取白名单中）
+// @Tags Admin
+// @Param address path string true "合约地址"
+// @Param body body ImageCDNRequest true "CDN 前缀"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/collections/{address}/image-cdn [put]
+func (h *AdminHandler) SetImageCDNBase(c *gin.Context) {
+	var req ImageCDNRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	address := c.Param("address")
+	err := h.images.SetCDNBase(c.Request.Context(), address, req.ImageCDNBase)
+	if errors.Is(err, service.ErrInvalidImageCDNBase) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidImageCDNBase, err)
+		return
+	}
+	if repository.IsNotFound(err) {
+		respondError(c, http.StatusNotFound, i18n.ErrCollectionNotFound, err)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrSetImageCDNBase, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": gin.H{
+			"contract_address": address,
+			"image_cdn_base":   req.ImageCDNBase,
+		},
+		"message": "Image CDN base updated",
+	})
+}
+
+// FeeOverrideRequest 设置系列费率覆盖请求，fee_bps 为 null 时恢复全局费率
+type FeeOverrideRequest struct {
+	FeeBps *int64 `json:"fee_bps"`
+}
+
+// SetFeeOverride 设置系列平台费率覆盖
+// @Summary 设置系列的平台费率覆盖（基点，0 到 MaxFeeBps），null 恢复全局费率
+// @Tags Admin
+// @Param address path string true "合约地址"
+// @Param body body FeeOverrideRequest true "费率"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/collections/{address}/fee [put]
+func (h *AdminHandler) SetFeeOverride(c *gin.Context) {
+	var req FeeOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	address := c.Param("address")
+	err := h.fees.SetFeeOverride(c.Request.Context(), address, req.FeeBps)
+	if errors.Is(err, service.ErrFeeOutOfRange) {
+		respondError(c, http.StatusBadRequest, i18n.ErrFeeOutOfRange, err, service.MaxFeeBps)
+		return
+	}
+	if err != nil {
+		respondLookupError(c, err, i18n.ErrCollectionNotFound, i18n.ErrSetFeeOverride)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": gin.H{
+			"contract_address": address,
+			"fee_bps":          req.FeeBps,
+		},
+		"message": "Fee override updated",
+	})
+}
+
+// RecomputeNFTLikes 按点赞表重算 NFT 的点赞数。
+// view_count 不参与重算：浏览只按次计数、没有逐次记录，该列本身即为唯一数据来源
+// @Summary 按 nft_likes 重算单个 NFT 的 like_count，返回重算前后的值
+// @Tags Admin
+// @Param id path int true "NFT ID"
+// @Success 200 {object} service.LikeRecount
+// @Router /api/v1/admin/nfts/{id}/recompute [post]
+func (h *AdminHandler) RecomputeNFTLikes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidNFTID, nil)
+		return
+	}
+
+	recount, err := h.nfts.RecomputeLikes(c.Request.Context(), uint(id))
+	if err != nil {
+		respondLookupError(c, err, i18n.ErrNFTNotFound, i18n.ErrRecomputeLikes)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": recount,
+	})
+}
+
+// RecomputeAllNFTLikes 按点赞表重算所有 NFT 的点赞数
+// @Summary 按 nft_likes 重算所有 like_count 不一致的 NFT，返回修正数量
+// @Tags Admin
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/nfts/recompute [post]
+func (h *AdminHandler) RecomputeAllNFTLikes(c *gin.Context) {
+	fixed, err := h.nfts.RecomputeAllLikes(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrRecomputeLikes, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": gin.H{
+			"updated": fixed,
+		},
+	})
+}
+
+// ExportTransactions 流式导出交易
+// @Summary 按 ID 升序流式导出满足过滤条件的全部交易
+// @Tags Admin
+// @Param filter query string false "过滤表达式，语法同 GET /api/v1/transactions"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/transactions/export [get]
+func (h *AdminHandler) ExportTransactions(c *gin.Context) {
+	filter, err := repository.ParseTxFilter(c.Query("filter"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidFilter, err, err.Error())
+		return
+	}
+
+	var cursor uint
+	streamJSONArray(c, i18n.ErrExportTransactions, func(ctx context.Context) ([]*service.TransactionResponse, error) {
+		txs, err := h.transactions.ExportTransactions(ctx, filter, cursor, streamPageSize)
+		if len(txs) > 0 {
+			cursor = txs[len(txs)-1].ID
+		}
+		return txs, err
+	})
+}
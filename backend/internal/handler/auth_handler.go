@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/middleware"
+	"github.com/xiaomait/backend/internal/service"
+)
+
+// AuthHandler 钱包登录处理器
+type AuthHandler struct {
+	service   *service.AuthService
+	jwtSecret string
+	jwtTTL    time.Duration
+}
+
+// NewAuthHandler 创建钱包登录处理器，登录成功后签发有效期为 jwtTTL 的 JWT
+func NewAuthHandler(service *service.AuthService, jwtSecret string, jwtTTL time.Duration) *AuthHandler {
+	return &AuthHandler{service: service, jwtSecret: jwtSecret, jwtTTL: jwtTTL}
+}
+
+// NonceRequest 登录 nonce 请求
+type NonceRequest struct {
+	Address string `json:"address" binding:"required,eth_addr"`
+}
+
+// VerifyRequest SIWE 登录请求
+type VerifyRequest struct {
+	Address   string `json:"address" binding:"required,eth_addr"`
+	Nonce     string `json:"nonce" binding:"required"`
+	Message   string `json:"message" binding:"required"`   // 钱包签名的 EIP-4361 消息原文
+	Signature string `json:"signature" binding:"required"` // personal_sign 签名（0x 开头十六进制）
+}
+
+// Nonce 签发登录 nonce
+// @Summary 签发与地址绑定的一次性登录 nonce（用于 EIP-4361 消息）
+// @Tags Auth
+// @Accept json
+// @Param request body NonceRequest true "钱包地址"
+// @Success 200 {object} service.SIWENonce
+// @Router /api/v1/auth/nonce [post]
+func (h *AuthHandler) Nonce(c *gin.Context) {
+	var req NonceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	nonce, err := h.service.IssueNonce(c.Request.Context(), req.Address)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrIssueNonce, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": nonce,
+	})
+}
+
+// Verify 校验 SIWE 签名并签发 JWT
+// @Summary 校验 Sign-In with Ethereum 签名，成功后返回 JWT
+// @Tags Auth
+// @Accept json
+// @Param request body VerifyRequest true "地址、nonce、消息与签名"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/auth/verify [post]
+func (h *AuthHandler) Verify(c *gin.Context) {
+	var req VerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	address, err := h.service.Verify(c.Request.Context(), req.Address, req.Nonce, req.Message, req.Signature)
+	switch {
+	case errors.Is(err, service.ErrInvalidSIWEMessage):
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidSIWEMessage, err)
+		return
+	case errors.Is(err, service.ErrInvalidNonce):
+		respondError(c, http.StatusUnauthorized, i18n.ErrInvalidNonce, err)
+		return
+	case errors.Is(err, service.ErrSignatureMismatch):
+		respondError(c, http.StatusUnauthorized, i18n.ErrSignatureMismatch, err)
+		return
+	case err != nil:
+		respondError(c, http.StatusInternalServerError, i18n.ErrIssueToken, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(h.jwtTTL)
+	token, err := middleware.IssueToken(h.jwtSecret, address, h.jwtTTL)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrIssueToken, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": gin.H{
+			"address":    address,
+			"token":      token,
+			"expires_at": expiresAt,
+		},
+	})
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/service"
+)
+
+// CollectionHandler 系列处理器
+type CollectionHandler struct {
+	service *service.CollectionService
+}
+
+// NewCollectionHandler 创建系列处理器
+func NewCollectionHandler(service *service.CollectionService) *CollectionHandler {
+	return &CollectionHandler{service: service}
+}
+
+// GetBidIncrement 获取系列最小加价规则
+// @Summary 获取系列出价最小加价规则及下一口最低出价
+// @Tags Collection
+// @Param address path string true "合约地址"
+// @Param current query string false "当前最高出价（wei）"
+// @Success 200 {object} service.BidIncrementQuote
+// @Router /api/v1/collections/{address}/bid-increment [get]
+func (h *CollectionHandler) GetBidIncrement(c *gin.Context) {
+	address, ok := addressParam(c, "address", i18n.ErrInvalidContractAddress)
+	if !ok {
+		return
+	}
+
+	current := new(big.Int)
+	if raw := c.Query("current"); raw != "" {
+		if _, ok := current.SetString(raw, 10); !ok || current.Sign() < 0 {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidAmount, nil)
+			return
+		}
+	}
+
+	quote, err := h.service.GetBidIncrement(c.Request.Context(), address, current)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetBidIncrement, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": quote,
+	})
+}
+
+// GetHolderSnapshot 导出系列持有者快照
+// @Summary 导出系列当前持有者快照（用于空投）。未索引 ERC-721 Transfer 事件，指定 block 时返回 501
+// @Tags Collection
+// @Param address path string true "合约地址"
+// @Param block query int false "区块高度（暂不支持）"
+// @Param format query string false "json 或 csv（流式下载）" default(json)
+// @Success 200 {object} map[string]interface{}
+// @Failure 501 {object} map[string]interface{}
+// @Router /api/v1/collections/{address}/snapshot [get]
+func (h *CollectionHandler) GetHolderSnapshot(c *gin.Context) {
+	address, ok := addressParam(c, "address", i18n.ErrInvalidContractAddress)
+	if !ok {
+		return
+	}
+
+	// 交易表只有市场成交，没有铸造与转移记录，历史持有关系无法正确重建
+	if raw := c.Query("block"); raw != "" {
+		if _, err := strconv.ParseUint(raw, 10, 64); err != nil {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidBlockNumber, nil)
+			return
+		}
+		respondError(c, http.StatusNotImplemented, i18n.ErrHistoricalSnapshotUnsupported, nil)
+		return
+	}
+
+	if c.DefaultQuery("format", "json") == "csv" {
+		h.streamHolderCSV(c, address)
+		return
+	}
+
+	holders := []repository.HolderBalance{}
+	err := h.service.StreamHolderSnapshot(c.Request.Context(), address, func(holder repository.HolderBalance) error {
+		holders = append(holders, holder)
+		return nil
+	})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrHolderSnapshot, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data":          holders,
+		"nft_contract":  address,
+		"total_holders": len(holders),
+	})
+}
+
+// streamHolderCSV 以 CSV 流式输出持有者快照，写出首行后出错只能中断连接
+func (h *CollectionHandler) streamHolderCSV(c *gin.Context, address string) {
+	filename := fmt.Sprintf("holders-%s-latest.csv", strings.ToLower(address))
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"owner", "token_count"})
+
+	rows := 0
+	err := h.service.StreamHolderSnapshot(c.Request.Context(), address, func(holder repository.HolderBalance) error {
+		if err := w.Write([]string{holder.Owner, strconv.FormatInt(holder.TokenCount, 10)}); err != nil {
+			return err
+		}
+		rows++
+		if rows%1000 == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	w.Flush()
+
+	if err != nil {
+		log.Printf("Holder snapshot stream for %s aborted after %d rows: %v", address, rows, err)
+		c.Abort()
+	}
+}
+
+// GetTopCollections 获取热门系列
+// @Summary 按时间窗口成交额获取热门系列
+// @Tags Collection
+// @Param window query string false "时间窗口 24h/7d/30d" default(24h)
+// @Param limit query int false "数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/collections/top [get]
+func (h *CollectionHandler) GetTopCollections(c *gin.Context) {
+	window := c.DefaultQuery("window", "24h")
+	valid := false
+	for _, w := range service.TrendingWindows {
+		if w == window {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidWindow, nil)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	collections, err := h.service.GetTopCollections(c.Request.Context(), window, limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetTopCollections, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data":   collections,
+		"window": window,
+	})
+}
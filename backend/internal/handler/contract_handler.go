@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+)
+
+// frontendABIFunctions 前端构建交易所需的合约方法（上架 / 购买 / 取消）
+var frontendABIFunctions = map[string]bool{
+	"createMarketItem": true,
+	"createMarketSale": true,
+	"cancelMarketItem": true,
+}
+
+// ContractHandler 合约信息处理器
+type ContractHandler struct {
+	marketplaceAddress string
+	nftContractAddress string
+	chainID            int64
+	abi                []json.RawMessage
+	abiErr             error
+}
+
+// NewContractHandler 创建合约信息处理器，从 abiPath 读取合约 ABI
+func NewContractHandler(marketplaceAddress, nftContractAddress string, chainID int64, abiPath string) *ContractHandler {
+	h := &ContractHandler{
+		marketplaceAddress: marketplaceAddress,
+		nftContractAddress: nftContractAddress,
+		chainID:            chainID,
+	}
+
+	h.abi, h.abiErr = loadABIFragments(abiPath)
+	if h.abiErr != nil {
+		log.Printf("Warning: contract ABI unavailable: %v", h.abiErr)
+	}
+
+	return h
+}
+
+// loadABIFragments 读取 ABI 文件并筛选前端需要的方法
+func loadABIFragments(path string) ([]json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ABI file: %w", err)
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse ABI file: %w", err)
+	}
+
+	fragments := make([]json.RawMessage, 0, len(frontendABIFunctions))
+	for _, entry := range entries {
+		var meta struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(entry, &meta); err != nil {
+			return nil, fmt.Errorf("failed to parse ABI entry: %w", err)
+		}
+		if meta.Type == "function" && frontendABIFunctions[meta.Name] {
+			fragments = append(fragments, entry)
+		}
+	}
+
+	return fragments, nil
+}
+
+// GetContract 获取合约地址和 ABI
+// @Summary 获取市场合约地址、链 ID 及上架/购买/取消方法 ABI
+// @Tags Contract
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/contract [get]
+func (h *ContractHandler) GetContract(c *gin.Context) {
+	if h.abiErr != nil {
+		respondError(c, http.StatusServiceUnavailable, i18n.ErrContractABIUnavailable, h.abiErr)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": gin.H{
+			"marketplace_address":  h.marketplaceAddress,
+			"nft_contract_address": h.nftContractAddress,
+			"chain_id":             h.chainID,
+			"abi":                  h.abi,
+		},
+	})
+}
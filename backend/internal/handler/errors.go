@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/requestid"
+)
+
+// APIError 结构化错误响应：code 稳定不变，error 为按 Accept-Language 本地化的提示，
+// request_id 与服务端日志中的 request_id 对应
+type APIError struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"error"`
+	Details   string       `json:"details,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// newAPIError 按请求语言构造错误，args 用于填充提示模板
+func newAPIError(c *gin.Context, code string, err error, args ...interface{}) *APIError {
+	apiErr := &APIError{
+		Code:      code,
+		Message:   i18n.Message(i18n.Negotiate(c.GetHeader("Accept-Language")), code, args...),
+		RequestID: requestid.FromContext(c.Request.Context()),
+	}
+	if err != nil {
+		apiErr.Details = err.Error()
+	}
+	return apiErr
+}
+
+// respondError 写出本地化的结构化错误，err 非空时作为 details 返回；5xx 错误同时记录日志
+func respondError(c *gin.Context, status int, code string, err error, args ...interface{}) {
+	if status >= http.StatusInternalServerError {
+		requestid.Logf(c.Request.Context(), "%s %s: %d %s: %v", c.Request.Method, c.FullPath(), status, code, err)
+	}
+	c.Header("Cache-Control", "no-store")
+	respond(c, status, newAPIError(c, code, err, args...))
+}
+
+// respondLookupError 单对象查询失败：记录不存在时返回 404（不附带内部错误信息），其他错误返回 500
+func respondLookupError(c *gin.Context, err error, notFoundCode, failedCode string) {
+	if repository.IsNotFound(err) {
+		respondError(c, http.StatusNotFound, notFoundCode, nil)
+		return
+	}
+	respondError(c, http.StatusInternalServerError, failedCode, err)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/cache"
+)
+
+// respondWithETag 按响应内容的规范化 JSON 计算弱 ETag（JSON 与 msgpack 编码语义相同，共用同一 ETag），
+// 与 If-None-Match 匹配时返回 304 不带响应体。计算失败时照常返回，不设置 ETag
+func respondWithETag(c *gin.Context, status int, body interface{}) {
+	hash, err := cache.ContentHash(body)
+	if err != nil {
+		log.Printf("Failed to compute ETag for %s: %v", c.Request.URL.Path, err)
+		respond(c, status, body)
+		return
+	}
+
+	etag := `W/"` + hash + `"`
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	respond(c, status, body)
+}
+
+// etagMatches If-None-Match 是否包含该 ETag（弱比较，忽略 W/ 前缀）
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -1,21 +1,33 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/middleware"
+	"github.com/xiaomait/backend/internal/repository"
 	"github.com/xiaomait/backend/internal/service"
 )
 
 // ListingHandler 挂单处理器
 type ListingHandler struct {
 	service *service.ListingService
+	prices  *service.PriceService // 为 nil 时忽略 ?currencies=
+
+	asOfMaxLookback time.Duration // 历史挂单查询最多回溯的时长，0 表示不限制
 }
 
 // NewListingHandler 创建挂单处理器
-func NewListingHandler(service *service.ListingService) *ListingHandler {
-	return &ListingHandler{service: service}
+func NewListingHandler(service *service.ListingService, prices *service.PriceService, asOfMaxLookback time.Duration) *ListingHandler {
+	return &ListingHandler{service: service, prices: prices, asOfMaxLookback: asOfMaxLookback}
 }
 
 // GetActiveListings 获取活跃挂单
@@ -23,6 +35,10 @@ func NewListingHandler(service *service.ListingService) *ListingHandler {
 // @Tags Listing
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param status query string false "状态 active/pending/sold/cancelled/invalid" default(active)
+// @Param sort query string false "排序 recent/oldest/price_asc/price_desc" default(recent)
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
+// @Param with_count query bool false "是否返回总数，false 时不执行 COUNT 查询，total 为 null（默认由 PAGINATION_WITH_COUNT 配置）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/listings [get]
 func (h *ListingHandler) GetActiveListings(c *gin.Context) {
@@ -35,139 +51,482 @@ func (h *ListingHandler) GetActiveListings(c *gin.Context) {
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 20
 	}
+	withCount := withCountParam(c)
 
-	listings, total, err := h.service.GetActiveListings(c.Request.Context(), page, pageSize)
+	filter, ok := listingFilterParams(c)
+	if !ok {
+		return
+	}
+
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
+
+	listings, total, err := h.service.GetActiveListings(c.Request.Context(), filter, page, pageSize, withCount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get active listings",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetActiveListings, err)
 		return
 	}
+	for _, item := range listings {
+		item.Prices = rates.Convert(item.Price)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": listings,
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       total,
-			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
+	respond(c, http.StatusOK, gin.H{
+		"data":       listings,
+		"pagination": paginationMeta(page, pageSize, total, withCount),
 	})
 }
 
+// listingFilterParams 解析挂单列表的 status 与 sort 参数，无效时写入 400 响应并返回 false
+func listingFilterParams(c *gin.Context) (repository.ListingFilter, bool) {
+	filter := repository.ListingFilter{
+		Status: c.DefaultQuery("status", "active"),
+		Sort:   c.DefaultQuery("sort", repository.ListingSortRecent),
+	}
+
+	if !oneOf(filter.Status, service.ListingStatuses) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidStatus, nil, strings.Join(service.ListingStatuses, ", "))
+		return filter, false
+	}
+	if !oneOf(filter.Sort, service.ListingSorts) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidSort, nil, strings.Join(service.ListingSorts, ", "))
+		return filter, false
+	}
+	return filter, true
+}
+
 // GetListing 获取单个挂单
-// @Summary 获取挂单详情
+// @Summary 获取挂单详情（按数据库 ID）
 // @Tags Listing
-// @Param id path int true "Listing ID"
+// @Param id path int true "Listing ID（数据库 ID，非市场合约 itemId）"
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
 // @Success 200 {object} service.ListingResponse
 // @Router /api/v1/listings/{id} [get]
 func (h *ListingHandler) GetListing(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid listing ID",
-		})
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidListingID, nil)
+		return
+	}
+
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
 		return
 	}
 
 	listing, err := h.service.GetListing(c.Request.Context(), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Listing not found",
-			"details": err.Error(),
-		})
+		respondLookupError(c, err, i18n.ErrListingNotFound, i18n.ErrGetListing)
 		return
 	}
+	listing.Prices = rates.Convert(listing.Price)
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": listing,
 	})
 }
 
+// GetListingByItemID 按市场合约 itemId 获取挂单
+// @Summary 获取挂单详情（按市场合约 itemId）
+// @Tags Listing
+// @Param itemId path int true "市场合约 itemId"
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
+// @Success 200 {object} service.ListingResponse
+// @Router /api/v1/listings/item/{itemId} [get]
+func (h *ListingHandler) GetListingByItemID(c *gin.Context) {
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidItemID, nil)
+		return
+	}
+
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
+
+	listing, err := h.service.GetListingByItemID(c.Request.Context(), itemID)
+	if err != nil {
+		respondLookupError(c, err, i18n.ErrListingNotFound, i18n.ErrGetListing)
+		return
+	}
+	listing.Prices = rates.Convert(listing.Price)
+
+	respond(c, http.StatusOK, gin.H{
+		"data": listing,
+	})
+}
+
+// txHashPattern 0x 开头的 32 字节十六进制交易哈希
+var txHashPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// GetListingByTx 按交易哈希查找挂单
+// @Summary 按交易哈希查找挂单及其成交记录
+// @Tags Listing
+// @Param hash path string true "挂单创建交易或成交交易的哈希"
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
+// @Success 200 {object} service.ListingTxLookup
+// @Router /api/v1/listings/by-tx/{hash} [get]
+func (h *ListingHandler) GetListingByTx(c *gin.Context) {
+	txHash := c.Param("hash")
+	if !txHashPattern.MatchString(txHash) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidTxHash, nil)
+		return
+	}
+
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
+
+	lookup, err := h.service.GetListingByTx(c.Request.Context(), txHash)
+	if err != nil {
+		respondLookupError(c, err, i18n.ErrListingNotFound, i18n.ErrGetListing)
+		return
+	}
+	if lookup.Listing != nil {
+		lookup.Listing.Prices = rates.Convert(lookup.Listing.Price)
+	}
+	if lookup.Sale != nil {
+		lookup.Sale.Prices = rates.Convert(lookup.Sale.Value)
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": lookup,
+	})
+}
+
 // CreateListing 创建挂单
 // @Summary 创建挂单
 // @Tags Listing
 // @Accept json
 // @Param listing body service.CreateListingRequest true "挂单信息"
+// @Param Authorization header string true "Bearer <JWT>"
 // @Success 201 {object} service.ListingResponse
 // @Router /api/v1/listings [post]
 func (h *ListingHandler) CreateListing(c *gin.Context) {
 	var req service.CreateListingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+	if !bindAuthAddress(c, &req.Seller) {
 		return
 	}
 
 	listing, err := h.service.CreateListing(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidAuctionEnd) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidAuctionEnd, nil, int(service.MaxAuctionDuration.Hours()/24))
+		return
+	}
+	if errors.Is(err, service.ErrAuctionFieldsOnFixedPrice) {
+		respondError(c, http.StatusBadRequest, i18n.ErrAuctionOnlyFields, nil)
+		return
+	}
+	if errors.Is(err, service.ErrCollectionNotVerified) {
+		respondError(c, http.StatusForbidden, i18n.ErrCollectionNotVerified, nil, req.NFTContract)
+		return
+	}
+	if errors.Is(err, service.ErrListingPriceMismatch) {
+		respondError(c, http.StatusBadRequest, i18n.ErrListingPriceMismatch, err, req.Price)
+		return
+	}
+	if errors.Is(err, service.ErrListingTokenMismatch) {
+		respondError(c, http.StatusBadRequest, i18n.ErrListingTokenMismatch, err)
+		return
+	}
+	if errors.Is(err, service.ErrListingSellerMismatch) {
+		respondError(c, http.StatusForbidden, i18n.ErrListingSellerMismatch, err)
+		return
+	}
+	if errors.Is(err, blockchain.ErrCircuitOpen) {
+		respondError(c, http.StatusServiceUnavailable, i18n.ErrBlockchainUnavailable, err)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create listing",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrCreateListing, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	respond(c, http.StatusCreated, gin.H{
 		"data":    listing,
 		"message": "Listing created successfully",
 	})
 }
 
+// GetListingStatuses 批量查询 Token 挂单状态
+// @Summary 批量查询 Token 是否在售及价格
+// @Tags Listing
+// @Param tokens body []service.TokenStatusRequest true "Token 列表"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/listings/status [post]
+func (h *ListingHandler) GetListingStatuses(c *gin.Context) {
+	var req []service.TokenStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(req) == 0 || len(req) > service.MaxListingStatusBatch {
+		respondError(c, http.StatusBadRequest, i18n.ErrBatchSizeOutOfRange, nil, service.MaxListingStatusBatch)
+		return
+	}
+
+	statuses, err := h.service.GetListingStatuses(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetListingStatuses, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": statuses,
+	})
+}
+
 // CancelListing 取消挂单
 // @Summary 取消挂单
 // @Tags Listing
 // @Param id path int true "Listing ID"
+// @Param Authorization header string true "Bearer <JWT>，须为挂单卖家"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/listings/{id} [delete]
 func (h *ListingHandler) CancelListing(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid listing ID",
-		})
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidListingID, nil)
 		return
 	}
 
-	// TODO: 从 JWT 或请求中获取用户地址
-	seller := c.GetHeader("X-User-Address")
+	// 由 JWTAuth 中间件认证
+	seller := middleware.AuthAddress(c)
 	if seller == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User address is required",
-		})
+		respondError(c, http.StatusUnauthorized, i18n.ErrUnauthorized, nil)
 		return
 	}
 
-	if err := h.service.CancelListing(c.Request.Context(), uint(id), seller); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to cancel listing",
-			"details": err.Error(),
-		})
+	err = h.service.CancelListing(c.Request.Context(), uint(id), seller)
+	switch {
+	case errors.Is(err, service.ErrNotSeller):
+		respondError(c, http.StatusForbidden, i18n.ErrForbidden, nil)
+		return
+	case err != nil:
+		respondLookupError(c, err, i18n.ErrListingNotFound, i18n.ErrCancelListing)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"message": "Listing cancelled successfully",
 	})
 }
 
+// PlaceBid 对拍卖挂单竞价
+// @Summary 对拍卖挂单竞价（竞价方为 JWT 认证地址），须不低于起拍价与保留价并满足系列最小加价规则
+// @Tags Listing
+// @Accept json
+// @Param id path int true "Listing ID"
+// @Param Authorization header string true "Bearer <JWT>"
+// @Param request body service.PlaceBidRequest true "竞价金额（wei）"
+// @Success 201 {object} service.BidResponse
+// @Router /api/v1/listings/{id}/bids [post]
+func (h *ListingHandler) PlaceBid(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidListingID, nil)
+		return
+	}
+
+	var req service.PlaceBidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// 由 JWTAuth 中间件认证
+	bidder := middleware.AuthAddress(c)
+	if bidder == "" {
+		respondError(c, http.StatusUnauthorized, i18n.ErrUnauthorized, nil)
+		return
+	}
+
+	bid, err := h.service.PlaceBid(c.Request.Context(), uint(id), bidder, &req)
+	var tooLow *service.BidTooLowError
+	switch {
+	case errors.Is(err, service.ErrInvalidBidAmount):
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidAmount, nil)
+		return
+	case errors.Is(err, service.ErrNotAuction):
+		respondError(c, http.StatusBadRequest, i18n.ErrNotAuction, nil)
+		return
+	case errors.Is(err, service.ErrAuctionNotActive):
+		respondError(c, http.StatusConflict, i18n.ErrAuctionNotActive, nil)
+		return
+	case errors.Is(err, service.ErrAuctionEnded):
+		respondError(c, http.StatusConflict, i18n.ErrAuctionEnded, nil)
+		return
+	case errors.Is(err, service.ErrBidOnOwnListing):
+		respondError(c, http.StatusForbidden, i18n.ErrBidOnOwnListing, nil)
+		return
+	case errors.As(err, &tooLow):
+		respondError(c, http.StatusBadRequest, i18n.ErrBidTooLow, nil, tooLow.MinimumBid.String())
+		return
+	case err != nil:
+		respondLookupError(c, err, i18n.ErrListingNotFound, i18n.ErrPlaceBid)
+		return
+	}
+
+	respond(c, http.StatusCreated, gin.H{
+		"data": bid,
+	})
+}
+
+// GetBids 获取拍卖挂单的竞价记录
+// @Summary 分页获取挂单的竞价记录（最新优先）
+// @Tags Listing
+// @Param id path int true "Listing ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/listings/{id}/bids [get]
+func (h *ListingHandler) GetBids(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidListingID, nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	bids, total, err := h.service.GetBids(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		respondLookupError(c, err, i18n.ErrListingNotFound, i18n.ErrGetBids)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data":       bids,
+		"pagination": paginationMeta(page, pageSize, total, true),
+	})
+}
+
+// RefreshUserListings 按链上状态立即刷新卖家的活跃挂单
+// @Summary 卖家触发的挂单状态刷新，返回状态有变化的挂单
+// @Tags Listing
+// @Param address path string true "卖家地址，须为认证地址"
+// @Param Authorization header string true "Bearer <JWT>"
+// @Success 200 {object} service.SellerRefreshResult
+// @Router /api/v1/listings/user/{address}/refresh [post]
+func (h *ListingHandler) RefreshUserListings(c *gin.Context) {
+	address, ok := addressParam(c, "address", i18n.ErrInvalidSellerAddress)
+	if !ok {
+		return
+	}
+	if address != middleware.AuthAddress(c) {
+		respondError(c, http.StatusForbidden, i18n.ErrAddressMismatch, nil)
+		return
+	}
+
+	result, err := h.service.RefreshSellerListings(c.Request.Context(), address)
+	if errors.Is(err, blockchain.ErrCircuitOpen) {
+		respondError(c, http.StatusServiceUnavailable, i18n.ErrBlockchainUnavailable, err)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrRefreshListings, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+// GetListingsAsOf 获取指定历史时刻处于活跃状态的挂单
+// @Summary 获取历史时刻的活跃挂单
+// @Tags Listing
+// @Param t query string true "时间点，RFC3339 或 Unix 秒"
+// @Param contract query string false "合约地址"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/listings/as-of [get]
+func (h *ListingHandler) GetListingsAsOf(c *gin.Context) {
+	at, ok := parseTimestamp(c.Query("t"))
+	if !ok || at.After(time.Now()) || (h.asOfMaxLookback > 0 && time.Since(at) > h.asOfMaxLookback) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidTimestamp, nil)
+		return
+	}
+
+	contract := c.Query("contract")
+	if contract != "" && !common.IsHexAddress(contract) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidAddress, nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	listings, total, err := h.service.GetListingsAsOf(c.Request.Context(), at, contract, page, pageSize)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetListingsAsOf, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data":  listings,
+		"as_of": at,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// parseTimestamp 解析 RFC3339 或 Unix 秒时间戳
+func parseTimestamp(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
 // GetUserListings 获取用户的挂单
 // @Summary 获取用户的挂单
 // @Tags Listing
 // @Param address path string true "用户地址"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param include_archived query bool false "是否包含已归档的已售/已取消挂单" default(false)
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/listings/user/{address} [get]
 func (h *ListingHandler) GetUserListings(c *gin.Context) {
-	address := c.Param("address")
-	if address == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Address is required",
-		})
+	address, ok := addressParam(c, "address", i18n.ErrInvalidAddress)
+	if !ok {
 		return
 	}
 
@@ -181,16 +540,23 @@ func (h *ListingHandler) GetUserListings(c *gin.Context) {
 		pageSize = 20
 	}
 
-	listings, total, err := h.service.GetUserListings(c.Request.Context(), address, page, pageSize)
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
+
+	includeArchived, _ := strconv.ParseBool(c.Query("include_archived"))
+
+	listings, total, err := h.service.GetUserListings(c.Request.Context(), address, includeArchived, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get user listings",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetUserListings, err)
 		return
 	}
+	for _, item := range listings {
+		item.Prices = rates.Convert(item.Price)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": listings,
 		"pagination": gin.H{
 			"page":        page,
@@ -205,16 +571,41 @@ func (h *ListingHandler) GetUserListings(c *gin.Context) {
 // @Summary 搜索挂单
 // @Tags Listing
 // @Param contract query string false "合约地址"
-// @Param min_price query string false "最低价格"
-// @Param max_price query string false "最高价格"
+// @Param seller query string false "卖家地址"
+// @Param min_price query string false "最低价格（wei，支持科学计数法如 1e18）"
+// @Param max_price query string false "最高价格（wei，支持科学计数法如 1e18）"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
+// @Param with_count query bool false "是否返回总数，false 时不执行 COUNT 查询，total 为 null（默认由 PAGINATION_WITH_COUNT 配置）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/listings/search [get]
 func (h *ListingHandler) SearchListings(c *gin.Context) {
-	contract := c.Query("contract")
-	minPrice := c.Query("min_price")
-	maxPrice := c.Query("max_price")
+	filter := repository.ListingSearchFilter{
+		MinPrice: c.Query("min_price"),
+		MaxPrice: c.Query("max_price"),
+	}
+	if err := filter.NormalizePrices(); err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidFilter, err, err.Error())
+		return
+	}
+
+	if contract := c.Query("contract"); contract != "" {
+		address, ok := normalizeAddress(contract)
+		if !ok {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidContractAddress, nil)
+			return
+		}
+		filter.NFTContract = address
+	}
+	if seller := c.Query("seller"); seller != "" {
+		address, ok := normalizeAddress(seller)
+		if !ok {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidSellerAddress, nil)
+			return
+		}
+		filter.Seller = address
+	}
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
@@ -225,21 +616,31 @@ func (h *ListingHandler) SearchListings(c *gin.Context) {
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 20
 	}
+	withCount := withCountParam(c)
+
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
+
+	listings, total, err := h.service.SearchListings(c.Request.Context(), filter, page, pageSize, withCount)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrSearchListings, err)
+		return
+	}
+	for _, item := range listings {
+		item.Prices = rates.Convert(item.Price)
+	}
 
-	// TODO: 实现搜索逻辑
-	c.JSON(http.StatusOK, gin.H{
-		"data": []interface{}{},
+	respond(c, http.StatusOK, gin.H{
+		"data": listings,
 		"filters": gin.H{
-			"contract":  contract,
-			"min_price": minPrice,
-			"max_price": maxPrice,
-		},
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       0,
-			"total_pages": 0,
+			"contract":  filter.NFTContract,
+			"seller":    filter.Seller,
+			"min_price": filter.MinPrice,
+			"max_price": filter.MaxPrice,
 		},
+		"pagination": paginationMeta(page, pageSize, total, withCount),
 	})
 }
 
@@ -251,14 +652,11 @@ func (h *ListingHandler) SearchListings(c *gin.Context) {
 func (h *ListingHandler) GetMarketStats(c *gin.Context) {
 	stats, err := h.service.GetMarketStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get market stats",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetMarketStats, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": stats,
 	})
 }
@@ -270,23 +668,118 @@ func (h *ListingHandler) GetMarketStats(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/stats/collections/{address} [get]
 func (h *ListingHandler) GetCollectionStats(c *gin.Context) {
-	address := c.Param("address")
-	if address == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Contract address is required",
-		})
-		return
-	}
-
-	// TODO: 实现系列统计逻辑
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"contract_address": address,
-			"total_items":      0,
-			"active_listings":  0,
-			"floor_price":      "0",
-			"total_volume":     "0",
-			"owners":           0,
-		},
+	address, ok := addressParam(c, "address", i18n.ErrInvalidContractAddress)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.GetCollectionStats(c.Request.Context(), address)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetCollectionStats, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": stats,
+	})
+}
+
+// GetCollectionStatsBatch 批量获取系列统计
+// @Summary 批量获取多个系列的统计信息
+// @Tags Stats
+// @Param addresses body []string true "合约地址列表"
+// @Success 200 {array} service.CollectionStats
+// @Router /api/v1/stats/collections/batch [post]
+func (h *ListingHandler) GetCollectionStatsBatch(c *gin.Context) {
+	var addresses []string
+	if err := c.ShouldBindJSON(&addresses); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(addresses) == 0 || len(addresses) > service.MaxCollectionStatsBatch {
+		respondError(c, http.StatusBadRequest, i18n.ErrAddressBatchOutOfRange, nil, service.MaxCollectionStatsBatch)
+		return
+	}
+	for i, address := range addresses {
+		normalized, ok := normalizeAddress(address)
+		if !ok {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidContractAddress, nil)
+			return
+		}
+		addresses[i] = normalized
+	}
+
+	stats, err := h.service.GetCollectionStatsBatch(c.Request.Context(), addresses)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetCollectionStats, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": stats,
+	})
+}
+
+// GetPriceBands 获取系列活跃挂单价格分布
+// @Summary 获取系列活跃挂单价格分布直方图
+// @Tags Stats
+// @Param address path string true "合约地址"
+// @Param buckets query int false "区间数量（1-50）" default(10)
+// @Param scale query string false "刻度 linear/log" default(linear)
+// @Success 200 {object} service.PriceBands
+// @Router /api/v1/stats/collections/{address}/price-bands [get]
+func (h *ListingHandler) GetPriceBands(c *gin.Context) {
+	address, ok := addressParam(c, "address", i18n.ErrInvalidContractAddress)
+	if !ok {
+		return
+	}
+
+	buckets, err := strconv.Atoi(c.DefaultQuery("buckets", "10"))
+	if err != nil || buckets < 1 || buckets > service.MaxPriceBands {
+		respondError(c, http.StatusBadRequest, i18n.ErrBucketCountOutOfRange, err, service.MaxPriceBands)
+		return
+	}
+
+	scale := c.DefaultQuery("scale", service.PriceBandScaleLinear)
+	if !oneOf(scale, service.PriceBandScales) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidScale, nil, strings.Join(service.PriceBandScales, ", "))
+		return
+	}
+
+	bands, err := h.service.GetPriceBands(c.Request.Context(), address, buckets, scale)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetPriceBands, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": bands,
+	})
+}
+
+// PreviewProceeds 预览卖家到手金额
+// @Summary 按系列费率预览平台费和卖家到手金额
+// @Tags Listing
+// @Param nft_contract query string true "NFT 合约地址"
+// @Param price query string true "价格（wei）"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/listings/fee-preview [get]
+func (h *ListingHandler) PreviewProceeds(c *gin.Context) {
+	nftContract := c.Query("nft_contract")
+	price := c.Query("price")
+	if nftContract == "" || price == "" {
+		respondError(c, http.StatusBadRequest, i18n.ErrFeePreviewParams, nil)
+		return
+	}
+
+	quote, err := h.service.PreviewProceeds(c.Request.Context(), nftContract, price)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrPreviewProceeds, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": quote,
 	})
 }
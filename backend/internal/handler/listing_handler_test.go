@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xiaomait/backend/internal/blockchain/mock"
+	"github.com/xiaomait/backend/internal/middleware"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/repository/memory"
+	"github.com/xiaomait/backend/internal/service"
+)
+
+const (
+	testJWTSecret  = "test-jwt-secret"
+	testAdminToken = "test-admin-token"
+	testSeller     = "0x00000000000000000000000000000000000000A2"
+	testOther      = "0x00000000000000000000000000000000000000B3"
+)
+
+// issueTestToken 用测试密钥为 address 签发有效期 ttl 的 JWT（ttl 为负时已过期）
+func issueTestToken(t *testing.T, address string, ttl time.Duration) string {
+	t.Helper()
+
+	token, err := middleware.IssueToken(testJWTSecret, address, ttl)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	return token
+}
+
+// newTestRouter 按 setupRouter 的方式挂载受保护路由：取消挂单需钱包 JWT，管理接口需管理令牌
+func newTestRouter(t *testing.T) (*gin.Engine, *memory.ListingStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	listings := memory.NewListingStore()
+	if err := listings.Create(&repository.Listing{ItemID: 1, NFTContract: "0x00000000000000000000000000000000000000a1", TokenID: "7", Seller: testSeller, Price: "100", Status: "active"}); err != nil {
+		t.Fatalf("create listing: %v", err)
+	}
+	s := service.NewListingService(listings, memory.NewTransactionStore(), memory.NewNFTStore(), nil, &mock.Client{}, nil, nil, nil, nil, nil, nil, nil, nil, "", service.SellerRefreshOptions{})
+
+	router := gin.New()
+	router.DELETE("/api/v1/listings/:id", middleware.JWTAuth(testJWTSecret), NewListingHandler(s, nil, 0).CancelListing)
+	router.GET("/api/v1/admin/ping", middleware.AdminAuth(testAdminToken), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router, listings
+}
+
+func TestProtectedRoutes(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization func(t *testing.T) string
+		wantStatus    int
+		wantCancelled bool
+	}{
+		{"seller token", http.MethodDelete, "/api/v1/listings/1", func(t *testing.T) string {
+			return "Bearer " + issueTestToken(t, testSeller, time.Hour)
+		}, http.StatusOK, true},
+		{"missing token", http.MethodDelete, "/api/v1/listings/1", func(t *testing.T) string {
+			return ""
+		}, http.StatusUnauthorized, false},
+		{"expired token", http.MethodDelete, "/api/v1/listings/1", func(t *testing.T) string {
+			return "Bearer " + issueTestToken(t, testSeller, -time.Minute)
+		}, http.StatusUnauthorized, false},
+		{"token signed with other secret", http.MethodDelete, "/api/v1/listings/1", func(t *testing.T) string {
+			token, err := middleware.IssueToken("other-secret", testSeller, time.Hour)
+			if err != nil {
+				t.Fatalf("IssueToken: %v", err)
+			}
+			return "Bearer " + token
+		}, http.StatusUnauthorized, false},
+		{"admin token on wallet route", http.MethodDelete, "/api/v1/listings/1", func(t *testing.T) string {
+			return "Bearer " + testAdminToken
+		}, http.StatusUnauthorized, false},
+		{"other wallet", http.MethodDelete, "/api/v1/listings/1", func(t *testing.T) string {
+			return "Bearer " + issueTestToken(t, testOther, time.Hour)
+		}, http.StatusForbidden, false},
+		{"wallet token on admin route", http.MethodGet, "/api/v1/admin/ping", func(t *testing.T) string {
+			return "Bearer " + issueTestToken(t, testSeller, time.Hour)
+		}, http.StatusUnauthorized, false},
+		{"admin token on admin route", http.MethodGet, "/api/v1/admin/ping", func(t *testing.T) string {
+			return "Bearer " + testAdminToken
+		}, http.StatusNoContent, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, listings := newTestRouter(t)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if auth := tt.authorization(t); auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			listing, err := listings.GetByItemID(1)
+			if err != nil {
+				t.Fatalf("GetByItemID: %v", err)
+			}
+			if cancelled := listing.Status == "cancelled"; cancelled != tt.wantCancelled {
+				t.Errorf("listing status = %s, want cancelled %v", listing.Status, tt.wantCancelled)
+			}
+		})
+	}
+}
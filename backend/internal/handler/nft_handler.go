@@ -1,21 +1,39 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/middleware"
+	"github.com/xiaomait/backend/internal/repository"
 	"github.com/xiaomait/backend/internal/service"
 )
 
 // NFTHandler NFT 处理器
 type NFTHandler struct {
-	service *service.NFTService
+	service         *service.NFTService
+	includeMetadata bool // 列表接口未指定 include_metadata 时的默认值
 }
 
 // NewNFTHandler 创建 NFT 处理器
-func NewNFTHandler(service *service.NFTService) *NFTHandler {
-	return &NFTHandler{service: service}
+func NewNFTHandler(service *service.NFTService, includeMetadata bool) *NFTHandler {
+	return &NFTHandler{
+		service:         service,
+		includeMetadata: includeMetadata,
+	}
+}
+
+// includeMetadataParam 解析 include_metadata 查询参数，缺省或无效时使用配置的默认值
+func (h *NFTHandler) includeMetadataParam(c *gin.Context) bool {
+	include, err := strconv.ParseBool(c.Query("include_metadata"))
+	if err != nil {
+		return h.includeMetadata
+	}
+	return include
 }
 
 // GetNFTs 获取 NFT 列表
@@ -23,6 +41,8 @@ func NewNFTHandler(service *service.NFTService) *NFTHandler {
 // @Tags NFT
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param include_metadata query bool false "是否返回 metadata（默认由 NFT_LIST_INCLUDE_METADATA 配置）"
+// @Param with_count query bool false "是否返回总数，false 时不执行 COUNT 查询，total 为 null（默认由 PAGINATION_WITH_COUNT 配置）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/nfts [get]
 func (h *NFTHandler) GetNFTs(c *gin.Context) {
@@ -35,24 +55,17 @@ func (h *NFTHandler) GetNFTs(c *gin.Context) {
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 20
 	}
+	withCount := withCountParam(c)
 
-	nfts, total, err := h.service.GetNFTs(c.Request.Context(), page, pageSize)
+	nfts, total, err := h.service.GetNFTs(c.Request.Context(), page, pageSize, h.includeMetadataParam(c), withCount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get NFTs",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetNFTs, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": nfts,
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       total,
-			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
+	respond(c, http.StatusOK, gin.H{
+		"data":       nfts,
+		"pagination": paginationMeta(page, pageSize, total, withCount),
 	})
 }
 
@@ -60,27 +73,98 @@ func (h *NFTHandler) GetNFTs(c *gin.Context) {
 // @Summary 获取 NFT 详情
 // @Tags NFT
 // @Param id path int true "NFT ID"
+// @Param If-None-Match header string false "上次响应的 ETag，内容未变时返回 304"
 // @Success 200 {object} service.NFTResponse
+// @Success 304 "内容未变化"
 // @Router /api/v1/nfts/{id} [get]
 func (h *NFTHandler) GetNFT(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid NFT ID",
-		})
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidNFTID, nil)
 		return
 	}
 
-	nft, err := h.service.GetNFT(c.Request.Context(), uint(id))
+	nft, err := h.service.GetNFT(c.Request.Context(), uint(id), viewerKey(c))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "NFT not found",
-			"details": err.Error(),
-		})
+		respondLookupError(c, err, i18n.ErrNFTNotFound, i18n.ErrGetNFT)
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, gin.H{
+		"data": nft,
+	})
+}
+
+// viewerKey 浏览去重使用的浏览者标识：带 JWT 时为认证地址，否则为客户端 IP
+func viewerKey(c *gin.Context) string {
+	if address := middleware.AuthAddress(c); address != "" {
+		return "addr:" + address
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// GetSimilarNFTs 获取属性相似的 NFT
+// @Summary 获取同系列中共同属性最多的 NFT
+// @Tags NFT
+// @Param id path int true "NFT ID"
+// @Param limit query int false "数量" default(10)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/nfts/{id}/similar [get]
+func (h *NFTHandler) GetSimilarNFTs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidNFTID, nil)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	nfts, err := h.service.GetSimilarNFTs(c.Request.Context(), uint(id), limit)
+	if err != nil {
+		respondLookupError(c, err, i18n.ErrNFTNotFound, i18n.ErrGetSimilarNFTs)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": nfts,
+	})
+}
+
+// RefreshMetadata 重新抓取 NFT 元数据，仅限持有者
+// @Summary 按 tokenURI 重新抓取并更新 NFT 元数据（名称、描述、图片、属性）
+// @Tags NFT
+// @Param id path int true "NFT ID"
+// @Param Authorization header string true "Bearer <JWT>"
+// @Success 200 {object} service.NFTResponse
+// @Router /api/v1/nfts/{id}/refresh [post]
+func (h *NFTHandler) RefreshMetadata(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidNFTID, nil)
+		return
+	}
+
+	nft, err := h.service.RefreshMetadata(c.Request.Context(), uint(id), middleware.AuthAddress(c))
+	switch {
+	case repository.IsNotFound(err):
+		respondError(c, http.StatusNotFound, i18n.ErrNFTNotFound, nil)
+		return
+	case errors.Is(err, service.ErrNotNFTOwner):
+		respondError(c, http.StatusForbidden, i18n.ErrForbidden, nil)
+		return
+	case errors.Is(err, blockchain.ErrCircuitOpen):
+		respondError(c, http.StatusServiceUnavailable, i18n.ErrBlockchainUnavailable, err)
+		return
+	case err != nil:
+		// 网关超时、响应过大或内容不是合法 JSON
+		respondError(c, http.StatusBadGateway, i18n.ErrRefreshMetadata, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": nft,
 	})
 }
@@ -90,28 +174,27 @@ func (h *NFTHandler) GetNFT(c *gin.Context) {
 // @Tags NFT
 // @Accept json
 // @Param nft body service.CreateNFTRequest true "NFT 信息"
+// @Param Authorization header string true "Bearer <JWT>"
 // @Success 201 {object} service.NFTResponse
 // @Router /api/v1/nfts [post]
 func (h *NFTHandler) CreateNFT(c *gin.Context) {
 	var req service.CreateNFTRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+	// 只能登记自己持有的 NFT，指定创作者时同样须为认证地址
+	if !bindAuthAddress(c, &req.Owner) || (req.Creator != "" && !bindAuthAddress(c, &req.Creator)) {
 		return
 	}
 
 	nft, err := h.service.CreateNFT(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create NFT",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrCreateNFT, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	respond(c, http.StatusCreated, gin.H{
 		"data":    nft,
 		"message": "NFT created successfully",
 	})
@@ -123,14 +206,12 @@ func (h *NFTHandler) CreateNFT(c *gin.Context) {
 // @Param address path string true "用户地址"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param include_metadata query bool false "是否返回 metadata（默认由 NFT_LIST_INCLUDE_METADATA 配置）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/nfts/user/{address} [get]
 func (h *NFTHandler) GetUserNFTs(c *gin.Context) {
-	address := c.Param("address")
-	if address == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Address is required",
-		})
+	address, ok := addressParam(c, "address", i18n.ErrInvalidAddress)
+	if !ok {
 		return
 	}
 
@@ -144,16 +225,13 @@ func (h *NFTHandler) GetUserNFTs(c *gin.Context) {
 		pageSize = 20
 	}
 
-	nfts, total, err := h.service.GetUserNFTs(c.Request.Context(), address, page, pageSize)
+	nfts, total, err := h.service.GetUserNFTs(c.Request.Context(), address, page, pageSize, h.includeMetadataParam(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get user NFTs",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetUserNFTs, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": nfts,
 		"pagination": gin.H{
 			"page":        page,
@@ -170,14 +248,13 @@ func (h *NFTHandler) GetUserNFTs(c *gin.Context) {
 // @Param address path string true "合约地址"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param include_metadata query bool false "是否返回 metadata（默认由 NFT_LIST_INCLUDE_METADATA 配置）"
+// @Param with_count query bool false "是否返回总数，false 时不执行 COUNT 查询，total 为 null（默认由 PAGINATION_WITH_COUNT 配置）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/nfts/contract/{address} [get]
 func (h *NFTHandler) GetNFTsByContract(c *gin.Context) {
-	address := c.Param("address")
-	if address == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Contract address is required",
-		})
+	address, ok := addressParam(c, "address", i18n.ErrInvalidContractAddress)
+	if !ok {
 		return
 	}
 
@@ -190,41 +267,39 @@ func (h *NFTHandler) GetNFTsByContract(c *gin.Context) {
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 20
 	}
+	withCount := withCountParam(c)
 
-	nfts, total, err := h.service.GetNFTsByContract(c.Request.Context(), address, page, pageSize)
+	nfts, total, err := h.service.GetNFTsByContract(c.Request.Context(), address, page, pageSize, h.includeMetadataParam(c), withCount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get NFTs by contract",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetNFTsByContract, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": nfts,
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       total,
-			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
+	respond(c, http.StatusOK, gin.H{
+		"data":       nfts,
+		"pagination": paginationMeta(page, pageSize, total, withCount),
 	})
 }
 
 // SearchNFTs 搜索 NFT
 // @Summary 搜索 NFT
 // @Tags NFT
-// @Param q query string true "搜索关键词"
+// @Param q query string false "搜索关键词（未指定属性条件时必填）"
+// @Param trait_type query []string false "属性名，可重复，与 trait_value 按顺序配对，多个条件为 AND" collectionFormat(multi)
+// @Param trait_value query []string false "属性值，可重复，与 trait_type 按顺序配对" collectionFormat(multi)
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param with_count query bool false "是否返回总数，false 时不执行 COUNT 查询，total 为 null（默认由 PAGINATION_WITH_COUNT 配置）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/nfts/search [get]
 func (h *NFTHandler) SearchNFTs(c *gin.Context) {
 	query := c.Query("q")
-	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Search query is required",
-		})
+	traits, ok := traitFilterParams(c)
+	if !ok {
+		return
+	}
+	if query == "" && len(traits) == 0 {
+		respondError(c, http.StatusBadRequest, i18n.ErrSearchQueryRequired, nil)
 		return
 	}
 
@@ -237,28 +312,41 @@ func (h *NFTHandler) SearchNFTs(c *gin.Context) {
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 20
 	}
+	withCount := withCountParam(c)
 
-	nfts, total, err := h.service.SearchNFTs(c.Request.Context(), query, page, pageSize)
+	nfts, total, err := h.service.SearchNFTs(c.Request.Context(), query, traits, page, pageSize, withCount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to search NFTs",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrSearchNFTs, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":  nfts,
-		"query": query,
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       total,
-			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
+	respond(c, http.StatusOK, gin.H{
+		"data":       nfts,
+		"query":      query,
+		"pagination": paginationMeta(page, pageSize, total, withCount),
 	})
 }
 
+// traitFilterParams 解析成对的 trait_type/trait_value 参数，不成对、为空或超过 MaxTraitFilters 时写入 400 响应并返回 false
+func traitFilterParams(c *gin.Context) ([]repository.TraitFilter, bool) {
+	types := c.QueryArray("trait_type")
+	values := c.QueryArray("trait_value")
+	if len(types) != len(values) || len(types) > service.MaxTraitFilters {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidTraitFilter, nil, service.MaxTraitFilters)
+		return nil, false
+	}
+
+	traits := make([]repository.TraitFilter, len(types))
+	for i := range types {
+		if types[i] == "" || values[i] == "" {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidTraitFilter, nil, service.MaxTraitFilters)
+			return nil, false
+		}
+		traits[i] = repository.TraitFilter{TraitType: types[i], Value: values[i]}
+	}
+	return traits, true
+}
+
 // GetTrendingNFTs 获取热门 NFT
 // @Summary 获取热门 NFT
 // @Tags NFT
@@ -273,70 +361,112 @@ func (h *NFTHandler) GetTrendingNFTs(c *gin.Context) {
 
 	nfts, err := h.service.GetTrendingNFTs(c.Request.Context(), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get trending NFTs",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetTrendingNFTs, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": nfts,
 	})
 }
 
 // LikeNFT 点赞 NFT
-// @Summary 点赞 NFT
+// @Summary 点赞 NFT（点赞方为 JWT 认证地址，重复点赞不重复计数）
 // @Tags NFT
 // @Param id path int true "NFT ID"
+// @Param Authorization header string true "Bearer <JWT>"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/nfts/{id}/like [post]
 func (h *NFTHandler) LikeNFT(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid NFT ID",
-		})
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidNFTID, nil)
 		return
 	}
 
-	if err := h.service.LikeNFT(c.Request.Context(), uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to like NFT",
-			"details": err.Error(),
-		})
+	// 由 JWTAuth 中间件认证
+	user := middleware.AuthAddress(c)
+	if user == "" {
+		respondError(c, http.StatusUnauthorized, i18n.ErrUnauthorized, nil)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	if err := h.service.LikeNFT(c.Request.Context(), uint(id), user); err != nil {
+		respondLookupError(c, err, i18n.ErrNFTNotFound, i18n.ErrLikeNFT)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
 		"message": "NFT liked successfully",
 	})
 }
 
 // UnlikeNFT 取消点赞 NFT
-// @Summary 取消点赞 NFT
+// @Summary 取消点赞 NFT（JWT 认证地址未点赞时不变）
 // @Tags NFT
 // @Param id path int true "NFT ID"
+// @Param Authorization header string true "Bearer <JWT>"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/nfts/{id}/unlike [post]
 func (h *NFTHandler) UnlikeNFT(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid NFT ID",
-		})
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidNFTID, nil)
+		return
+	}
+
+	// 由 JWTAuth 中间件认证
+	user := middleware.AuthAddress(c)
+	if user == "" {
+		respondError(c, http.StatusUnauthorized, i18n.ErrUnauthorized, nil)
 		return
 	}
 
-	if err := h.service.UnlikeNFT(c.Request.Context(), uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to unlike NFT",
-			"details": err.Error(),
-		})
+	if err := h.service.UnlikeNFT(c.Request.Context(), uint(id), user); err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrUnlikeNFT, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"message": "NFT unliked successfully",
 	})
 }
+
+// GetLikeStatus 查询地址是否已点赞 NFT
+// @Summary 查询地址是否已点赞 NFT，供 UI 显示点赞状态
+// @Tags NFT
+// @Param id path int true "NFT ID"
+// @Param address query string true "用户地址"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/nfts/{id}/liked [get]
+func (h *NFTHandler) GetLikeStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidNFTID, nil)
+		return
+	}
+
+	if c.Query("address") == "" {
+		respondError(c, http.StatusBadRequest, i18n.ErrAddressRequired, nil)
+		return
+	}
+	address, ok := normalizeAddress(c.Query("address"))
+	if !ok {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidAddress, nil)
+		return
+	}
+
+	liked, err := h.service.HasLiked(c.Request.Context(), uint(id), address)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetLikeStatus, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": gin.H{
+			"nft_id":  id,
+			"address": address,
+			"liked":   liked,
+		},
+	})
+}
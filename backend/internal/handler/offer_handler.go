@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/middleware"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/service"
+)
+
+// OfferHandler 出价处理器
+type OfferHandler struct {
+	service *service.OfferService
+}
+
+// NewOfferHandler 创建出价处理器
+func NewOfferHandler(service *service.OfferService) *OfferHandler {
+	return &OfferHandler{service: service}
+}
+
+// CreateOffer 创建出价
+// @Summary 对 NFT 出价（出价方为 JWT 认证地址），须满足系列最小加价规则
+// @Tags Offer
+// @Accept json
+// @Param Authorization header string true "Bearer <JWT>"
+// @Param request body service.CreateOfferRequest true "出价信息"
+// @Success 201 {object} service.OfferResponse
+// @Router /api/v1/offers [post]
+func (h *OfferHandler) CreateOffer(c *gin.Context) {
+	var req service.CreateOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// 由 JWTAuth 中间件认证
+	offerer := middleware.AuthAddress(c)
+	if offerer == "" {
+		respondError(c, http.StatusUnauthorized, i18n.ErrUnauthorized, nil)
+		return
+	}
+
+	offer, err := h.service.CreateOffer(c.Request.Context(), offerer, &req)
+	var tooLow *service.BidTooLowError
+	switch {
+	case errors.Is(err, service.ErrInvalidOfferPrice):
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidAmount, nil)
+		return
+	case errors.Is(err, service.ErrInvalidOfferExpiry):
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidOfferExpiry, nil, int(service.MaxOfferDuration.Hours()/24))
+		return
+	case errors.Is(err, service.ErrOfferOnOwnNFT):
+		respondError(c, http.StatusForbidden, i18n.ErrOfferOnOwnNFT, nil)
+		return
+	case errors.As(err, &tooLow):
+		respondError(c, http.StatusBadRequest, i18n.ErrBidTooLow, nil, tooLow.MinimumBid.String())
+		return
+	case err != nil:
+		respondError(c, http.StatusInternalServerError, i18n.ErrCreateOffer, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, gin.H{
+		"data": offer,
+	})
+}
+
+// CancelOffer 取消出价
+// @Summary 出价方取消仍有效的出价
+// @Tags Offer
+// @Param id path int true "Offer ID"
+// @Param Authorization header string true "Bearer <JWT>，须为出价方"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/offers/{id} [delete]
+func (h *OfferHandler) CancelOffer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidOfferID, nil)
+		return
+	}
+
+	offerer := middleware.AuthAddress(c)
+	if offerer == "" {
+		respondError(c, http.StatusUnauthorized, i18n.ErrUnauthorized, nil)
+		return
+	}
+
+	err = h.service.CancelOffer(c.Request.Context(), uint(id), offerer)
+	switch {
+	case errors.Is(err, service.ErrNotOfferer):
+		respondError(c, http.StatusForbidden, i18n.ErrForbidden, nil)
+		return
+	case errors.Is(err, service.ErrOfferNotActive):
+		respondError(c, http.StatusConflict, i18n.ErrOfferNotActive, nil)
+		return
+	case err != nil:
+		respondLookupError(c, err, i18n.ErrOfferNotFound, i18n.ErrCancelOffer)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"message": "Offer cancelled successfully",
+	})
+}
+
+// GetNFTOffers 获取 NFT 的出价
+// @Summary 分页获取 NFT 的出价
+// @Tags Offer
+// @Param contract path string true "NFT 合约地址"
+// @Param tokenId path string true "Token ID"
+// @Param status query string false "状态 active/expired/accepted/rejected/cancelled"
+// @Param sort query string false "排序 amount/recent" default(amount)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/offers/nft/{contract}/{tokenId} [get]
+func (h *OfferHandler) GetNFTOffers(c *gin.Context) {
+	contract, tokenID, ok := offerTokenParams(c)
+	if !ok {
+		return
+	}
+
+	filter, ok := offerFilterParams(c, repository.OfferSortAmount)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	offers, total, err := h.service.GetTokenOffers(c.Request.Context(), contract, tokenID, filter, page, pageSize)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetOffers, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": offers,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// GetBestOffer 获取 NFT 当前最高的有效出价
+// @Summary 获取 NFT 当前最高的有效（未过期）出价，没有时 data 为 null
+// @Tags Offer
+// @Param contract path string true "NFT 合约地址"
+// @Param tokenId path string true "Token ID"
+// @Success 200 {object} service.OfferResponse
+// @Router /api/v1/offers/nft/{contract}/{tokenId}/best [get]
+func (h *OfferHandler) GetBestOffer(c *gin.Context) {
+	contract, tokenID, ok := offerTokenParams(c)
+	if !ok {
+		return
+	}
+
+	offer, err := h.service.GetBestOffer(c.Request.Context(), contract, tokenID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetBestOffer, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": offer,
+	})
+}
+
+// offerTokenParams 解析路径中的合约地址与 Token ID，无效时写入 400 响应并返回 false
+func offerTokenParams(c *gin.Context) (contract, tokenID string, ok bool) {
+	contract = c.Param("contract")
+	tokenID = c.Param("tokenId")
+	if contract == "" || tokenID == "" {
+		respondError(c, http.StatusBadRequest, i18n.ErrContractTokenRequired, nil)
+		return "", "", false
+	}
+	if !common.IsHexAddress(contract) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidContractAddress, nil)
+		return "", "", false
+	}
+	return contract, tokenID, true
+}
+
+// GetListingOffers 获取挂单的出价
+// @Summary 分页获取挂单对应 NFT 的出价
+// @Tags Offer
+// @Param id path int true "Listing ID"
+// @Param status query string false "状态 active/expired/accepted/rejected/cancelled"
+// @Param sort query string false "排序 amount/recent" default(amount)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/listings/{id}/offers [get]
+func (h *OfferHandler) GetListingOffers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidListingID, nil)
+		return
+	}
+
+	filter, ok := offerFilterParams(c, repository.OfferSortAmount)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	offers, total, err := h.service.GetListingOffers(c.Request.Context(), uint(id), filter, page, pageSize)
+	if err != nil {
+		respondLookupError(c, err, i18n.ErrListingNotFound, i18n.ErrGetOffers)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": offers,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// GetUserOffers 获取当前用户发出的出价
+// @Summary 出价方查看自己的出价（含状态、过期时间及 NFT/挂单预览）
+// @Tags Offer
+// @Param address path string true "出价方地址"
+// @Param Authorization header string true "Bearer <JWT>，须为 address 本人"
+// @Param status query string false "状态 active/expired/accepted/rejected/cancelled"
+// @Param sort query string false "排序 amount/recent" default(recent)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/offers/user/{address} [get]
+func (h *OfferHandler) GetUserOffers(c *gin.Context) {
+	address := c.Param("address")
+	if !common.IsHexAddress(address) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidAddress, nil)
+		return
+	}
+
+	// 由 JWTAuth 中间件认证
+	user := middleware.AuthAddress(c)
+	if user == "" {
+		respondError(c, http.StatusUnauthorized, i18n.ErrUnauthorized, nil)
+		return
+	}
+	if !strings.EqualFold(user, address) {
+		respondError(c, http.StatusForbidden, i18n.ErrForbidden, nil)
+		return
+	}
+
+	filter, ok := offerFilterParams(c, repository.OfferSortRecent)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	offers, total, err := h.service.GetBidderOffers(c.Request.Context(), address, filter, page, pageSize)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetOffers, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": offers,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// offerFilterParams 解析 status、sort 参数（未指定 sort 时使用 defaultSort），无效时写入 400 响应并返回 false
+func offerFilterParams(c *gin.Context, defaultSort string) (repository.OfferFilter, bool) {
+	filter := repository.OfferFilter{
+		Status: c.Query("status"),
+		Sort:   c.DefaultQuery("sort", defaultSort),
+	}
+
+	if filter.Status != "" && !oneOf(filter.Status, service.OfferStatuses) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidStatus, nil, strings.Join(service.OfferStatuses, ", "))
+		return filter, false
+	}
+	if !oneOf(filter.Sort, service.OfferSorts) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidSort, nil, strings.Join(service.OfferSorts, ", "))
+		return filter, false
+	}
+	return filter, true
+}
+
+// oneOf 判断 value 是否为允许值之一
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countByDefault 支持 with_count 的分页接口在请求未指定时是否查询总数
+var countByDefault atomic.Bool
+
+func init() {
+	countByDefault.Store(true)
+}
+
+// SetPaginationCountDefault 设置 with_count 的默认值，应在启动时调用
+func SetPaginationCountDefault(withCount bool) {
+	countByDefault.Store(withCount)
+}
+
+// withCountParam 解析 ?with_count=，缺省或无法解析时使用部署默认值
+func withCountParam(c *gin.Context) bool {
+	if v, err := strconv.ParseBool(c.Query("with_count")); err == nil {
+		return v
+	}
+	return countByDefault.Load()
+}
+
+// paginationMeta 分页信息。withCount 为 false 时 total 和 total_pages 为 null，total 仅用于
+// 判断是否有下一页（见 repository.findPage）
+func paginationMeta(page, pageSize int, total int64, withCount bool) gin.H {
+	meta := gin.H{
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       nil,
+		"total_pages": nil,
+		"has_next":    total > int64(page)*int64(pageSize),
+	}
+	if withCount {
+		meta["total"] = total
+		meta["total_pages"] = (total + int64(pageSize) - 1) / int64(pageSize)
+	}
+	return meta
+}
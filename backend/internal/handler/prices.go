@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/service"
+)
+
+// fiatRates 解析 ?currencies= 并获取对应汇率。未请求或未启用价格服务时返回 nil；
+// 包含不支持的币种时写入 400 响应并返回 false
+func fiatRates(c *gin.Context, prices *service.PriceService) (service.FiatRates, bool) {
+	raw := c.Query("currencies")
+	if raw == "" || prices == nil {
+		return nil, true
+	}
+
+	currencies, err := prices.ParseCurrencies(raw)
+	if err != nil {
+		var unsupported *service.UnsupportedCurrencyError
+		if errors.As(err, &unsupported) {
+			respondError(c, http.StatusBadRequest, i18n.ErrUnsupportedCurrency, err, unsupported.Currency)
+		} else {
+			respondError(c, http.StatusBadRequest, i18n.ErrInvalidRequestBody, err)
+		}
+		return nil, false
+	}
+	return prices.EthRates(c.Request.Context(), currencies), true
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// msgpackContextKey 标记当前请求使用 msgpack 编码响应
+const msgpackContextKey = "response_msgpack"
+
+// MsgpackNegotiation 客户端发送 Accept: application/msgpack 时改用 msgpack 编码响应
+func MsgpackNegotiation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.Contains(c.GetHeader("Accept"), "application/msgpack") {
+			c.Set(msgpackContextKey, true)
+		}
+		c.Next()
+	}
+}
+
+// respond 按协商结果写出响应，默认使用 JSON
+func respond(c *gin.Context, status int, body interface{}) {
+	if c.GetBool(msgpackContextKey) {
+		c.Render(status, render.MsgPack{Data: body})
+		return
+	}
+	c.JSON(status, body)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamPageSize 流式响应每次从数据库读取的行数
+const streamPageSize = 500
+
+// streamJSONArray 流式写出 {"data":[...],"count":n,"complete":bool}：逐页调用 next 直到返回空页，
+// 每页写完后刷新，内存占用与总行数无关。首页读取失败时按普通错误响应返回；开始写出后出错
+// 只能结束数组并以 complete=false 标记结果不完整
+func streamJSONArray[T any](c *gin.Context, failedCode string, next func(ctx context.Context) ([]T, error)) {
+	ctx := c.Request.Context()
+
+	page, err := next(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, failedCode, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	enc := json.NewEncoder(w)
+	count := 0
+	complete := true
+
+	_, _ = w.WriteString(`{"data":[`)
+	for len(page) > 0 {
+		for _, item := range page {
+			if count > 0 {
+				_, _ = w.WriteString(",")
+			}
+			if err := enc.Encode(item); err != nil {
+				log.Printf("Stream %s: failed to encode item: %v", c.FullPath(), err)
+				complete = false
+				break
+			}
+			count++
+		}
+		w.Flush()
+
+		if !complete || ctx.Err() != nil {
+			complete = false
+			break
+		}
+		if page, err = next(ctx); err != nil {
+			log.Printf("Stream %s: aborted after %d items: %v", c.FullPath(), count, err)
+			complete = false
+			break
+		}
+	}
+
+	tail, _ := json.Marshal(gin.H{"count": count, "complete": complete})
+	_, _ = w.WriteString("],")
+	_, _ = w.Write(tail[1:])
+	w.Flush()
+}
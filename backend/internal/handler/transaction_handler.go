@@ -5,17 +5,20 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/repository"
 	"github.com/xiaomait/backend/internal/service"
 )
 
 // TransactionHandler 交易处理器
 type TransactionHandler struct {
 	service *service.TransactionService
+	prices  *service.PriceService // 为 nil 时忽略 ?currencies=
 }
 
 // NewTransactionHandler 创建交易处理器
-func NewTransactionHandler(service *service.TransactionService) *TransactionHandler {
-	return &TransactionHandler{service: service}
+func NewTransactionHandler(service *service.TransactionService, prices *service.PriceService) *TransactionHandler {
+	return &TransactionHandler{service: service, prices: prices}
 }
 
 // GetTransactions 获取交易列表
@@ -23,9 +26,18 @@ func NewTransactionHandler(service *service.TransactionService) *TransactionHand
 // @Tags Transaction
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param filter query string false "过滤表达式，如 type:sale,value_gt:1e18,from:0x...；字段 type/status/from/to/contract/token/value/block/time/primary，操作符 eq/gt/gte/lt/lte"
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
+// @Param with_count query bool false "是否返回总数，false 时不执行 COUNT 查询，total 为 null（默认由 PAGINATION_WITH_COUNT 配置）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/transactions [get]
 func (h *TransactionHandler) GetTransactions(c *gin.Context) {
+	filter, err := repository.ParseTxFilter(c.Query("filter"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidFilter, err, err.Error())
+		return
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
@@ -35,24 +47,31 @@ func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 	if pageSize < 1 || pageSize > 100 {
 		pageSize = 20
 	}
+	withCount := withCountParam(c)
+
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
 
-	transactions, total, err := h.service.GetTransactions(c.Request.Context(), page, pageSize)
+	var transactions []*service.TransactionResponse
+	var total int64
+	if len(filter.Conditions) > 0 {
+		transactions, total, err = h.service.GetFilteredTransactions(c.Request.Context(), filter, page, pageSize, withCount)
+	} else {
+		transactions, total, err = h.service.GetTransactions(c.Request.Context(), page, pageSize, withCount)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get transactions",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetTransactions, err)
 		return
 	}
+	for _, item := range transactions {
+		item.Prices = rates.Convert(item.Value)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": transactions,
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       total,
-			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
+	respond(c, http.StatusOK, gin.H{
+		"data":       transactions,
+		"pagination": paginationMeta(page, pageSize, total, withCount),
 	})
 }
 
@@ -60,27 +79,29 @@ func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 // @Summary 获取交易详情
 // @Tags Transaction
 // @Param hash path string true "交易哈希"
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
 // @Success 200 {object} service.TransactionResponse
 // @Router /api/v1/transactions/{hash} [get]
 func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	txHash := c.Param("hash")
 	if txHash == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Transaction hash is required",
-		})
+		respondError(c, http.StatusBadRequest, i18n.ErrTxHashRequired, nil)
+		return
+	}
+
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
 		return
 	}
 
 	transaction, err := h.service.GetTransaction(c.Request.Context(), txHash)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Transaction not found",
-			"details": err.Error(),
-		})
+		respondLookupError(c, err, i18n.ErrTransactionNotFound, i18n.ErrGetTransaction)
 		return
 	}
+	transaction.Prices = rates.Convert(transaction.Value)
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": transaction,
 	})
 }
@@ -91,14 +112,12 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 // @Param address path string true "用户地址"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/transactions/user/{address} [get]
 func (h *TransactionHandler) GetUserTransactions(c *gin.Context) {
-	address := c.Param("address")
-	if address == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Address is required",
-		})
+	address, ok := addressParam(c, "address", i18n.ErrInvalidAddress)
+	if !ok {
 		return
 	}
 
@@ -112,16 +131,21 @@ func (h *TransactionHandler) GetUserTransactions(c *gin.Context) {
 		pageSize = 20
 	}
 
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
+
 	transactions, total, err := h.service.GetUserTransactions(c.Request.Context(), address, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get user transactions",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetUserTransactions, err)
 		return
 	}
+	for _, item := range transactions {
+		item.Prices = rates.Convert(item.Value)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": transactions,
 		"pagination": gin.H{
 			"page":        page,
@@ -139,16 +163,17 @@ func (h *TransactionHandler) GetUserTransactions(c *gin.Context) {
 // @Param tokenId path string true "Token ID"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/transactions/nft/{contract}/{tokenId} [get]
 func (h *TransactionHandler) GetNFTTransactions(c *gin.Context) {
-	contract := c.Param("contract")
+	contract, ok := addressParam(c, "contract", i18n.ErrInvalidContractAddress)
+	if !ok {
+		return
+	}
 	tokenID := c.Param("tokenId")
-
-	if contract == "" || tokenID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Contract address and token ID are required",
-		})
+	if tokenID == "" {
+		respondError(c, http.StatusBadRequest, i18n.ErrContractTokenRequired, nil)
 		return
 	}
 
@@ -162,16 +187,21 @@ func (h *TransactionHandler) GetNFTTransactions(c *gin.Context) {
 		pageSize = 20
 	}
 
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
+
 	transactions, total, err := h.service.GetNFTTransactions(c.Request.Context(), contract, tokenID, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get NFT transactions",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetNFTTransactions, err)
 		return
 	}
+	for _, item := range transactions {
+		item.Prices = rates.Convert(item.Value)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": transactions,
 		"nft": gin.H{
 			"contract": contract,
@@ -190,6 +220,7 @@ func (h *TransactionHandler) GetNFTTransactions(c *gin.Context) {
 // @Summary 获取最近的交易
 // @Tags Transaction
 // @Param limit query int false "数量限制" default(10)
+// @Param currencies query string false "换算的法币币种，逗号分隔（如 USD,EUR）"
 // @Success 200 {object} map[string]interface{}
 // @Router /api/v1/transactions/recent [get]
 func (h *TransactionHandler) GetRecentTransactions(c *gin.Context) {
@@ -198,36 +229,63 @@ func (h *TransactionHandler) GetRecentTransactions(c *gin.Context) {
 		limit = 10
 	}
 
+	rates, ok := fiatRates(c, h.prices)
+	if !ok {
+		return
+	}
+
 	transactions, err := h.service.GetRecentTransactions(c.Request.Context(), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get recent transactions",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetRecentTxs, err)
 		return
 	}
+	for _, item := range transactions {
+		item.Prices = rates.Convert(item.Value)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": transactions,
 	})
 }
 
 // GetTransactionStats 获取交易统计
-// @Summary 获取交易统计信息
-// @Tags Transaction
-// @Success 200 {object} map[string]interface{}
-// @Router /api/v1/transactions/stats [get]
+// @Summary 获取交易汇总统计（各类型交易数、总交易额、成交均价）
+// @Tags Stats
+// @Success 200 {object} service.TransactionStats
+// @Router /api/v1/stats/transactions [get]
 func (h *TransactionHandler) GetTransactionStats(c *gin.Context) {
 	stats, err := h.service.GetTransactionStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get transaction stats",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetTxStats, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	respond(c, http.StatusOK, gin.H{
 		"data": stats,
 	})
 }
+
+// GetDailyVolume 获取每日交易额
+// @Summary 获取最近 N 天每日的成交笔数与交易额（UTC 日期倒序，无成交的日期不返回）
+// @Tags Stats
+// @Param days query int false "天数（1-365）" default(30)
+// @Success 200 {array} service.DailyVolumeResponse
+// @Router /api/v1/stats/volume [get]
+func (h *TransactionHandler) GetDailyVolume(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(service.DefaultVolumeDays)))
+	if err != nil || days < 1 || days > service.MaxVolumeDays {
+		respondError(c, http.StatusBadRequest, i18n.ErrDaysOutOfRange, err, service.MaxVolumeDays)
+		return
+	}
+
+	volumes, err := h.service.GetDailyVolume(c.Request.Context(), days)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetDailyVolume, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": volumes,
+		"days": days,
+	})
+}
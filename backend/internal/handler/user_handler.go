@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/middleware"
+	"github.com/xiaomait/backend/internal/service"
+)
+
+// UserHandler 用户设置处理器
+type UserHandler struct {
+	preferences *service.NotificationPreferenceService
+}
+
+// NewUserHandler 创建用户设置处理器
+func NewUserHandler(preferences *service.NotificationPreferenceService) *UserHandler {
+	return &UserHandler{preferences: preferences}
+}
+
+// GetNotificationPreferences 获取通知偏好
+// @Summary 获取地址的通知偏好（需 JWT 认证，只能读取自己的偏好）
+// @Tags User
+// @Param address path string true "用户地址"
+// @Param Authorization header string true "Bearer <JWT>"
+// @Success 200 {object} repository.NotificationPreferences
+// @Router /api/v1/users/{address}/notification-preferences [get]
+func (h *UserHandler) GetNotificationPreferences(c *gin.Context) {
+	address, ok := h.authorizedAddress(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.preferences.Get(c.Request.Context(), address)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrGetNotificationPreferences, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data": prefs,
+	})
+}
+
+// UpdateNotificationPreferences 更新通知偏好
+// @Summary 更新地址的通知偏好，未提供的字段保持不变（需 JWT 认证）
+// @Tags User
+// @Param address path string true "用户地址"
+// @Param Authorization header string true "Bearer <JWT>"
+// @Param body body service.UpdateNotificationPreferencesRequest true "通知偏好"
+// @Success 200 {object} repository.NotificationPreferences
+// @Router /api/v1/users/{address}/notification-preferences [put]
+func (h *UserHandler) UpdateNotificationPreferences(c *gin.Context) {
+	address, ok := h.authorizedAddress(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	prefs, err := h.preferences.Update(c.Request.Context(), address, &req)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, i18n.ErrUpdateNotificationPreferences, err)
+		return
+	}
+
+	respond(c, http.StatusOK, gin.H{
+		"data":    prefs,
+		"message": "Notification preferences updated",
+	})
+}
+
+// authorizedAddress 校验路径中的地址与认证地址一致
+func (h *UserHandler) authorizedAddress(c *gin.Context) (string, bool) {
+	address := c.Param("address")
+	if !common.IsHexAddress(address) {
+		respondError(c, http.StatusBadRequest, i18n.ErrInvalidAddress, nil)
+		return "", false
+	}
+	if !strings.EqualFold(address, middleware.AuthAddress(c)) {
+		respondError(c, http.StatusForbidden, i18n.ErrAddressMismatch, nil)
+		return "", false
+	}
+	return strings.ToLower(address), true
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/middleware"
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// RegisterValidators 注册自定义校验规则（eth_addr / wei），并使用 json 字段名报告错误
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	if err := v.RegisterValidation("eth_addr", validateEthAddress); err != nil {
+		return fmt.Errorf("failed to register eth_addr validator: %w", err)
+	}
+	if err := v.RegisterValidation("wei", validateWei); err != nil {
+		return fmt.Errorf("failed to register wei validator: %w", err)
+	}
+
+	return nil
+}
+
+// validateEthAddress 校验 0x 开头的 20 字节十六进制地址
+func validateEthAddress(fl validator.FieldLevel) bool {
+	return isEthAddress(fl.Field().String())
+}
+
+// isEthAddress 是否为 0x 开头的 20 字节十六进制地址（大小写不限）
+func isEthAddress(value string) bool {
+	return len(value) == 42 && strings.HasPrefix(value, "0x") && common.IsHexAddress(value)
+}
+
+// normalizeAddress 校验地址并转为小写，不合法时返回 false
+func normalizeAddress(value string) (string, bool) {
+	if !isEthAddress(value) {
+		return "", false
+	}
+	return strings.ToLower(value), true
+}
+
+// addressParam 读取路径参数 name 中的地址并转为小写，不合法时以 invalidCode 写入 400 响应并返回 false
+func addressParam(c *gin.Context, name, invalidCode string) (string, bool) {
+	address, ok := normalizeAddress(c.Param(name))
+	if !ok {
+		respondError(c, http.StatusBadRequest, invalidCode, nil)
+	}
+	return address, ok
+}
+
+// bindAuthAddress 校验请求体中的地址与认证地址一致并转为小写，为空时取认证地址；
+// 不一致时写入 403 响应并返回 false
+func bindAuthAddress(c *gin.Context, address *string) bool {
+	auth := middleware.AuthAddress(c)
+	if *address == "" {
+		*address = auth
+		return true
+	}
+	if !strings.EqualFold(*address, auth) {
+		respondError(c, http.StatusForbidden, i18n.ErrAddressMismatch, nil)
+		return false
+	}
+	*address = strings.ToLower(*address)
+	return true
+}
+
+// validateWei 校验十进制非负整数 wei 金额（不超过 uint256）
+func validateWei(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if value == "" || strings.TrimLeft(value, "0123456789") != "" {
+		return false
+	}
+	n, ok := new(big.Int).SetString(value, 10)
+	return ok && n.Cmp(repository.MaxUint256) <= 0
+}
+
+// respondBindError 将请求体绑定错误转换为按字段的结构化响应
+func respondBindError(c *gin.Context, status int, err error) {
+	apiErr := newAPIError(c, i18n.ErrInvalidRequestBody, nil)
+	apiErr.Errors = fieldErrors(err)
+	c.Header("Cache-Control", "no-store")
+	respond(c, status, apiErr)
+}
+
+// fieldErrors 提取字段级错误，无法定位字段时 field 为空
+func fieldErrors(err error) []FieldError {
+	// 数组请求体逐项校验，字段名加上下标前缀
+	var sliceErrs binding.SliceValidationError
+	if errors.As(err, &sliceErrs) {
+		var result []FieldError
+		for i, itemErr := range sliceErrs {
+			if itemErr == nil {
+				continue
+			}
+			for _, fe := range fieldErrors(itemErr) {
+				fe.Field = fmt.Sprintf("[%d].%s", i, fe.Field)
+				result = append(result, fe)
+			}
+		}
+		return result
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		result := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			result = append(result, FieldError{
+				Field:   fe.Field(),
+				Message: validationMessage(fe),
+			})
+		}
+		return result
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be of type %s", typeErr.Type),
+		}}
+	}
+
+	return []FieldError{{Message: err.Error()}}
+}
+
+// validationMessage 校验规则对应的提示信息
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "eth_addr":
+		return "must be a valid Ethereum address (0x followed by 40 hex characters)"
+	case "wei":
+		return "must be a non-negative integer amount in wei"
+	case "url":
+		return "must be a valid URL"
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+	}
+}
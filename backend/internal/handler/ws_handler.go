@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/xiaomait/backend/internal/realtime"
+)
+
+// WSHandler WebSocket 推送处理器
+type WSHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+// NewWSHandler 创建 WebSocket 处理器，allowedOrigins 与 CORS 配置一致（"*" 表示不限制）
+func NewWSHandler(hub *realtime.Hub, allowedOrigins []string) *WSHandler {
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[origin] = true
+	}
+
+	return &WSHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || origins["*"] || origins[origin]
+			},
+		},
+	}
+}
+
+// Subscribe 升级为 WebSocket 连接
+// @Summary 订阅实时事件，连接后发送 {"action":"subscribe","topics":["listings:new","sales:new","address:0x...","collection:0x..."]}
+// @Tags Realtime
+// @Router /api/v1/ws [get]
+func (h *WSHandler) Subscribe(c *gin.Context) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 已写出错误响应
+		return
+	}
+
+	realtime.Serve(h.hub, conn)
+}
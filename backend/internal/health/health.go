@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ChainHead 获取链上最新区块号
+type ChainHead interface {
+	GetBlockNumber(ctx context.Context) (uint64, error)
+}
+
+// LagFunc 根据链上最新区块计算索引落后的区块数
+type LagFunc func(ctx context.Context, head uint64) (uint64, error)
+
+// Checker 存活/就绪检查
+type Checker struct {
+	db     *sql.DB
+	chain  ChainHead
+	lag    LagFunc
+	maxLag uint64
+}
+
+// NewChecker 创建健康检查器，lag 为 nil 时跳过索引延迟检查
+func NewChecker(db *sql.DB, chain ChainHead, lag LagFunc, maxLag uint64) *Checker {
+	return &Checker{
+		db:     db,
+		chain:  chain,
+		lag:    lag,
+		maxLag: maxLag,
+	}
+}
+
+// Report 就绪检查结果
+type Report struct {
+	Status    string            `json:"status"`
+	Checks    map[string]string `json:"checks"`
+	LagBlocks *uint64           `json:"lag_blocks,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// Ready 检查数据库可达且索引延迟在阈值内
+func (c *Checker) Ready(ctx context.Context) (*Report, bool) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	report := &Report{
+		Status:    "ok",
+		Checks:    make(map[string]string),
+		Timestamp: time.Now().UTC(),
+	}
+	ready := true
+
+	if err := c.db.PingContext(ctx); err != nil {
+		report.Checks["database"] = err.Error()
+		ready = false
+	} else {
+		report.Checks["database"] = "ok"
+	}
+
+	head, err := c.chain.GetBlockNumber(ctx)
+	if err != nil {
+		report.Checks["blockchain"] = err.Error()
+		ready = false
+	} else {
+		report.Checks["blockchain"] = "ok"
+
+		if c.lag != nil {
+			lag, err := c.lag(ctx, head)
+			switch {
+			case err != nil:
+				report.Checks["indexer_lag"] = err.Error()
+				ready = false
+			case lag > c.maxLag:
+				report.LagBlocks = &lag
+				report.Checks["indexer_lag"] = fmt.Sprintf("%d blocks behind (max %d)", lag, c.maxLag)
+				ready = false
+			default:
+				report.LagBlocks = &lag
+				report.Checks["indexer_lag"] = "ok"
+			}
+		}
+	}
+
+	if !ready {
+		report.Status = "unavailable"
+	}
+
+	return report, ready
+}
+
+// NewServer 创建仅包含健康检查的 HTTP 服务器（用于无 API 的索引进程）
+func NewServer(addr string, checker *Checker) *http.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health/live", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status":    "ok",
+			"timestamp": time.Now().UTC(),
+		})
+	})
+
+	mux.HandleFunc("/health/ready", func(w http.ResponseWriter, r *http.Request) {
+		report, ready := checker.Ready(r.Context())
+		status := http.StatusOK
+		if !ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+
+	return &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+	}
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,358 @@
+package i18n
+
+// 错误码：稳定不变，供客户端判断；提示信息按语言从目录中取
+const (
+	ErrInvalidRequestBody     = "invalid_request_body"
+	ErrInvalidNFTID           = "invalid_nft_id"
+	ErrInvalidListingID       = "invalid_listing_id"
+	ErrInvalidItemID          = "invalid_item_id"
+	ErrInvalidSellerAddress   = "invalid_seller_address"
+	ErrAddressRequired        = "address_required"
+	ErrTxHashRequired         = "tx_hash_required"
+	ErrSearchQueryRequired    = "search_query_required"
+	ErrContractTokenRequired  = "contract_and_token_required"
+	ErrFeePreviewParams       = "fee_preview_params_required"
+	ErrInvalidBlockRange      = "invalid_block_range"
+	ErrBlockRangeTooLarge     = "block_range_too_large"
+	ErrBatchSizeOutOfRange    = "batch_size_out_of_range"
+	ErrAddressBatchOutOfRange = "address_batch_size_out_of_range"
+	ErrInvalidWindow          = "invalid_window"
+	ErrNFTNotFound            = "nft_not_found"
+	ErrListingNotFound        = "listing_not_found"
+	ErrTransactionNotFound    = "transaction_not_found"
+	ErrCollectionNotFound     = "collection_not_found"
+	ErrContractABIUnavailable = "contract_abi_unavailable"
+	ErrBlockchainUnavailable  = "blockchain_unavailable"
+	ErrUnauthorized           = "unauthorized"
+	ErrAddressMismatch        = "address_mismatch"
+	ErrForbidden              = "forbidden"
+	ErrRateLimited            = "rate_limited"
+	ErrServerOverloaded       = "server_overloaded"
+	ErrInvalidContractAddress = "invalid_contract_address"
+	ErrInvalidAddress         = "invalid_address"
+	ErrInvalidAmount          = "invalid_amount"
+	ErrBidTooLow              = "bid_too_low"
+	ErrInvalidBlockNumber     = "invalid_block_number"
+	ErrUnsupportedCurrency    = "unsupported_currency"
+	ErrInvalidStatus          = "invalid_status"
+	ErrInvalidSort            = "invalid_sort"
+	ErrCollectionNotVerified  = "collection_not_verified"
+	ErrInvalidScale           = "invalid_scale"
+	ErrBucketCountOutOfRange  = "bucket_count_out_of_range"
+	ErrInvalidTxHash          = "invalid_tx_hash"
+	ErrInvalidTimestamp       = "invalid_timestamp"
+	ErrInvalidFilter          = "invalid_filter"
+	ErrListingPriceMismatch   = "listing_price_mismatch"
+	ErrListingSellerMismatch  = "listing_seller_mismatch"
+	ErrListingTokenMismatch   = "listing_token_mismatch"
+	ErrInvalidImageCDNBase    = "invalid_image_cdn_base"
+	ErrFeeOutOfRange          = "fee_out_of_range"
+	ErrInvalidSIWEMessage     = "invalid_siwe_message"
+	ErrInvalidNonce           = "invalid_nonce"
+	ErrSignatureMismatch      = "signature_mismatch"
+	ErrInvalidOfferID         = "invalid_offer_id"
+	ErrOfferNotFound          = "offer_not_found"
+	ErrInvalidOfferExpiry     = "invalid_offer_expiry"
+	ErrOfferOnOwnNFT          = "offer_on_own_nft"
+	ErrOfferNotActive         = "offer_not_active"
+	ErrInvalidAuctionEnd      = "invalid_auction_end"
+	ErrAuctionOnlyFields      = "auction_only_fields"
+	ErrNotAuction             = "not_auction"
+	ErrAuctionNotActive       = "auction_not_active"
+	ErrAuctionEnded           = "auction_ended"
+	ErrBidOnOwnListing        = "bid_on_own_listing"
+	ErrInvalidTraitFilter     = "invalid_trait_filter"
+	ErrDaysOutOfRange         = "days_out_of_range"
+
+	ErrGetNFTs             = "get_nfts_failed"
+	ErrGetNFTsByContract   = "get_nfts_by_contract_failed"
+	ErrGetUserNFTs         = "get_user_nfts_failed"
+	ErrGetTrendingNFTs     = "get_trending_nfts_failed"
+	ErrSearchNFTs          = "search_nfts_failed"
+	ErrCreateNFT           = "create_nft_failed"
+	ErrLikeNFT             = "like_nft_failed"
+	ErrUnlikeNFT           = "unlike_nft_failed"
+	ErrGetActiveListings   = "get_active_listings_failed"
+	ErrGetListingsAsOf     = "get_listings_as_of_failed"
+	ErrGetUserListings     = "get_user_listings_failed"
+	ErrSearchListings      = "search_listings_failed"
+	ErrCreateListing       = "create_listing_failed"
+	ErrCancelListing       = "cancel_listing_failed"
+	ErrGetListingStatuses  = "get_listing_statuses_failed"
+	ErrPreviewProceeds     = "preview_proceeds_failed"
+	ErrGetMarketStats      = "get_market_stats_failed"
+	ErrGetCollectionStats  = "get_collection_stats_failed"
+	ErrGetTopCollections   = "get_top_collections_failed"
+	ErrGetTransactions     = "get_transactions_failed"
+	ErrGetUserTransactions = "get_user_transactions_failed"
+	ErrGetNFTTransactions  = "get_nft_transactions_failed"
+	ErrGetRecentTxs        = "get_recent_transactions_failed"
+	ErrGetTxStats          = "get_transaction_stats_failed"
+	ErrResyncBlocks        = "resync_blocks_failed"
+	ErrRefreshTrending     = "refresh_trending_failed"
+	ErrExportTransactions  = "export_transactions_failed"
+	ErrGetBidIncrement     = "get_bid_increment_failed"
+	ErrHolderSnapshot      = "holder_snapshot_failed"
+	ErrRefreshListings     = "refresh_listings_failed"
+	ErrGetNFT              = "get_nft_failed"
+	ErrGetSimilarNFTs      = "get_similar_nfts_failed"
+	ErrGetListing          = "get_listing_failed"
+	ErrGetTransaction      = "get_transaction_failed"
+	ErrGetOffers           = "get_offers_failed"
+	ErrGetPriceBands       = "get_price_bands_failed"
+	ErrSetImageCDNBase     = "set_image_cdn_base_failed"
+	ErrSetFeeOverride      = "set_fee_override_failed"
+	ErrIssueNonce          = "issue_nonce_failed"
+	ErrIssueToken          = "issue_token_failed"
+	ErrRefreshMetadata     = "refresh_metadata_failed"
+	ErrCreateOffer         = "create_offer_failed"
+	ErrCancelOffer         = "cancel_offer_failed"
+	ErrGetBestOffer        = "get_best_offer_failed"
+	ErrPlaceBid            = "place_bid_failed"
+	ErrGetBids             = "get_bids_failed"
+	ErrGetLikeStatus       = "get_like_status_failed"
+	ErrRecomputeLikes      = "recompute_likes_failed"
+	ErrGetDailyVolume      = "get_daily_volume_failed"
+
+	ErrGetNotificationPreferences    = "get_notification_preferences_failed"
+	ErrUpdateNotificationPreferences = "update_notification_preferences_failed"
+	ErrHistoricalSnapshotUnsupported = "historical_snapshot_unsupported"
+)
+
+// catalog 各语言的提示信息模板（fmt 格式），英文为兜底
+var catalog = map[string]map[string]string{
+	"en": {
+		ErrInvalidRequestBody:     "Invalid request body",
+		ErrInvalidNFTID:           "Invalid NFT ID",
+		ErrInvalidListingID:       "Invalid listing ID",
+		ErrInvalidItemID:          "Invalid market item ID",
+		ErrInvalidSellerAddress:   "Invalid seller address",
+		ErrAddressRequired:        "Address is required",
+		ErrTxHashRequired:         "Transaction hash is required",
+		ErrSearchQueryRequired:    "Search query is required",
+		ErrContractTokenRequired:  "Contract address and token ID are required",
+		ErrFeePreviewParams:       "nft_contract and price are required",
+		ErrInvalidBlockRange:      "from_block must not be greater than to_block",
+		ErrBlockRangeTooLarge:     "block range exceeds %d blocks",
+		ErrBatchSizeOutOfRange:    "Expected between 1 and %d tokens",
+		ErrAddressBatchOutOfRange: "Expected between 1 and %d contract addresses",
+		ErrInvalidWindow:          "Invalid window, expected one of 24h, 7d, 30d",
+		ErrNFTNotFound:            "NFT not found",
+		ErrListingNotFound:        "Listing not found",
+		ErrTransactionNotFound:    "Transaction not found",
+		ErrCollectionNotFound:     "Collection not found",
+		ErrContractABIUnavailable: "Contract ABI unavailable",
+		ErrBlockchainUnavailable:  "Blockchain node temporarily unavailable, please retry later",
+		ErrUnauthorized:           "Unauthorized",
+		ErrAddressMismatch:        "Authenticated address does not match the requested address",
+		ErrForbidden:              "Not allowed to act on another address",
+		ErrRateLimited:            "Rate limit exceeded",
+		ErrServerOverloaded:       "Server is busy, please retry later",
+		ErrInvalidContractAddress: "Invalid contract address",
+		ErrInvalidAddress:         "Invalid address",
+		ErrInvalidAmount:          "Amount must be a non-negative integer in wei",
+		ErrBidTooLow:              "Bid must be at least %s wei",
+		ErrInvalidBlockNumber:     "Invalid block number",
+		ErrUnsupportedCurrency:    "Unsupported currency: %s",
+		ErrInvalidStatus:          "Invalid status, expected one of: %s",
+		ErrInvalidSort:            "Invalid sort, expected one of: %s",
+		ErrCollectionNotVerified:  "Collection %s is not verified; only verified collections can be listed",
+		ErrInvalidScale:           "Invalid scale, expected one of: %s",
+		ErrBucketCountOutOfRange:  "Expected between 1 and %d buckets",
+		ErrInvalidTxHash:          "Invalid transaction hash",
+		ErrInvalidTimestamp:       "Invalid timestamp: expected a past RFC3339 time or Unix seconds within the allowed lookback",
+		ErrInvalidFilter:          "Invalid filter: %s",
+		ErrListingPriceMismatch:   "Price %s does not match the on-chain listing price",
+		ErrListingSellerMismatch:  "Authenticated address is not the seller of the on-chain listing",
+		ErrListingTokenMismatch:   "NFT contract or token ID does not match the on-chain listing",
+		ErrInvalidImageCDNBase:    "Invalid image CDN base: must be an absolute URL on an allowed metadata host",
+		ErrFeeOutOfRange:          "Fee must be between 0 and %d bps",
+		ErrInvalidSIWEMessage:     "Invalid Sign-In with Ethereum message",
+		ErrInvalidNonce:           "Nonce is invalid, expired or already used",
+		ErrSignatureMismatch:      "Signature does not match the address",
+		ErrInvalidOfferID:         "Invalid offer ID",
+		ErrOfferNotFound:          "Offer not found",
+		ErrInvalidOfferExpiry:     "expires_at must be in the future and at most %d days ahead",
+		ErrOfferOnOwnNFT:          "Cannot make an offer on an NFT you own",
+		ErrOfferNotActive:         "Only active offers can be cancelled",
+		ErrInvalidAuctionEnd:      "Auction end time must be in the future and within %d days",
+		ErrAuctionOnlyFields:      "auction_end_at and reserve_price are only allowed on auction listings",
+		ErrNotAuction:             "Listing is not an auction",
+		ErrAuctionNotActive:       "Auction is no longer active",
+		ErrAuctionEnded:           "Auction has ended",
+		ErrBidOnOwnListing:        "Cannot bid on your own listing",
+		ErrInvalidTraitFilter:     "trait_type and trait_value must be non-empty and given in pairs (at most %d)",
+		ErrDaysOutOfRange:         "Expected between 1 and %d days",
+
+		ErrGetNFTs:             "Failed to get NFTs",
+		ErrGetNFTsByContract:   "Failed to get NFTs by contract",
+		ErrGetUserNFTs:         "Failed to get user NFTs",
+		ErrGetTrendingNFTs:     "Failed to get trending NFTs",
+		ErrSearchNFTs:          "Failed to search NFTs",
+		ErrCreateNFT:           "Failed to create NFT",
+		ErrLikeNFT:             "Failed to like NFT",
+		ErrUnlikeNFT:           "Failed to unlike NFT",
+		ErrGetActiveListings:   "Failed to get active listings",
+		ErrGetListingsAsOf:     "Failed to get historical listings",
+		ErrGetUserListings:     "Failed to get user listings",
+		ErrSearchListings:      "Failed to search listings",
+		ErrCreateListing:       "Failed to create listing",
+		ErrCancelListing:       "Failed to cancel listing",
+		ErrGetListingStatuses:  "Failed to get listing statuses",
+		ErrPreviewProceeds:     "Failed to preview proceeds",
+		ErrGetMarketStats:      "Failed to get market stats",
+		ErrGetCollectionStats:  "Failed to get collection stats",
+		ErrGetTopCollections:   "Failed to get top collections",
+		ErrGetTransactions:     "Failed to get transactions",
+		ErrGetUserTransactions: "Failed to get user transactions",
+		ErrGetNFTTransactions:  "Failed to get NFT transactions",
+		ErrGetRecentTxs:        "Failed to get recent transactions",
+		ErrGetTxStats:          "Failed to get transaction stats",
+		ErrResyncBlocks:        "Failed to resync blocks",
+		ErrRefreshTrending:     "Failed to refresh trending collections",
+		ErrExportTransactions:  "Failed to export transactions",
+		ErrGetBidIncrement:     "Failed to get bid increment",
+		ErrHolderSnapshot:      "Failed to build holder snapshot",
+		ErrRefreshListings:     "Failed to refresh listings",
+		ErrGetNFT:              "Failed to get NFT",
+		ErrGetSimilarNFTs:      "Failed to get similar NFTs",
+		ErrGetListing:          "Failed to get listing",
+		ErrGetTransaction:      "Failed to get transaction",
+		ErrGetOffers:           "Failed to get offers",
+		ErrGetPriceBands:       "Failed to get price bands",
+		ErrSetImageCDNBase:     "Failed to set image CDN base",
+		ErrSetFeeOverride:      "Failed to set collection fee override",
+		ErrIssueNonce:          "Failed to issue nonce",
+		ErrIssueToken:          "Failed to issue token",
+		ErrRefreshMetadata:     "Failed to fetch NFT metadata from its token URI",
+		ErrCreateOffer:         "Failed to create offer",
+		ErrCancelOffer:         "Failed to cancel offer",
+		ErrGetBestOffer:        "Failed to get best offer",
+		ErrPlaceBid:            "Failed to place bid",
+		ErrGetBids:             "Failed to get bids",
+		ErrGetLikeStatus:       "Failed to get like status",
+		ErrRecomputeLikes:      "Failed to recompute likes",
+		ErrGetDailyVolume:      "Failed to get daily volume",
+
+		ErrGetNotificationPreferences:    "Failed to get notification preferences",
+		ErrUpdateNotificationPreferences: "Failed to update notification preferences",
+		ErrHistoricalSnapshotUnsupported: "Snapshots at a past block are not supported yet",
+	},
+	"zh": {
+		ErrInvalidRequestBody:     "请求体格式错误",
+		ErrInvalidNFTID:           "NFT ID 无效",
+		ErrInvalidListingID:       "挂单 ID 无效",
+		ErrInvalidItemID:          "市场项 ID 无效",
+		ErrInvalidSellerAddress:   "卖家地址无效",
+		ErrAddressRequired:        "地址不能为空",
+		ErrTxHashRequired:         "交易哈希不能为空",
+		ErrSearchQueryRequired:    "搜索关键词不能为空",
+		ErrContractTokenRequired:  "合约地址和 Token ID 不能为空",
+		ErrFeePreviewParams:       "nft_contract 和 price 不能为空",
+		ErrInvalidBlockRange:      "from_block 不能大于 to_block",
+		ErrBlockRangeTooLarge:     "区块范围超过 %d 个区块",
+		ErrBatchSizeOutOfRange:    "Token 数量须在 1 到 %d 之间",
+		ErrAddressBatchOutOfRange: "合约地址数量须在 1 到 %d 之间",
+		ErrInvalidWindow:          "时间窗口无效，可选值为 24h、7d、30d",
+		ErrNFTNotFound:            "NFT 不存在",
+		ErrListingNotFound:        "挂单不存在",
+		ErrTransactionNotFound:    "交易不存在",
+		ErrCollectionNotFound:     "系列不存在",
+		ErrContractABIUnavailable: "合约 ABI 不可用",
+		ErrBlockchainUnavailable:  "区块链节点暂不可用，请稍后重试",
+		ErrUnauthorized:           "未授权",
+		ErrAddressMismatch:        "认证地址与请求的地址不一致",
+		ErrForbidden:              "无权操作其他地址",
+		ErrRateLimited:            "请求过于频繁，请稍后再试",
+		ErrServerOverloaded:       "服务繁忙，请稍后重试",
+		ErrInvalidContractAddress: "合约地址无效",
+		ErrInvalidAddress:         "地址无效",
+		ErrInvalidAmount:          "金额须为非负整数（wei）",
+		ErrBidTooLow:              "出价不能低于 %s wei",
+		ErrInvalidBlockNumber:     "区块高度无效",
+		ErrUnsupportedCurrency:    "不支持的币种：%s",
+		ErrInvalidStatus:          "状态无效，可选值：%s",
+		ErrInvalidSort:            "排序方式无效，可选值：%s",
+		ErrCollectionNotVerified:  "系列 %s 未通过验证，仅允许已验证系列挂单",
+		ErrInvalidScale:           "刻度无效，可选值：%s",
+		ErrBucketCountOutOfRange:  "区间数量应在 1 到 %d 之间",
+		ErrInvalidTxHash:          "交易哈希无效",
+		ErrInvalidTimestamp:       "时间戳无效：应为允许回溯范围内的过去时间（RFC3339 或 Unix 秒）",
+		ErrInvalidFilter:          "过滤条件无效：%s",
+		ErrListingPriceMismatch:   "价格 %s 与链上挂单价格不一致",
+		ErrListingSellerMismatch:  "认证地址不是链上挂单的卖家",
+		ErrListingTokenMismatch:   "NFT 合约或 Token ID 与链上挂单不一致",
+		ErrInvalidImageCDNBase:    "图片 CDN 前缀无效：须为元数据白名单主机上的绝对 URL",
+		ErrFeeOutOfRange:          "费率须在 0 到 %d 基点之间",
+		ErrInvalidSIWEMessage:     "以太坊登录消息无效",
+		ErrInvalidNonce:           "nonce 无效、已过期或已使用",
+		ErrSignatureMismatch:      "签名与地址不匹配",
+		ErrInvalidOfferID:         "无效的出价 ID",
+		ErrOfferNotFound:          "出价不存在",
+		ErrInvalidOfferExpiry:     "过期时间须在未来且不超过 %d 天",
+		ErrOfferOnOwnNFT:          "不能对自己持有的 NFT 出价",
+		ErrOfferNotActive:         "只能取消有效的出价",
+		ErrInvalidAuctionEnd:      "拍卖截止时间须在未来且不超过 %d 天",
+		ErrAuctionOnlyFields:      "仅拍卖挂单可指定 auction_end_at 与 reserve_price",
+		ErrNotAuction:             "该挂单不是拍卖",
+		ErrAuctionNotActive:       "拍卖已结束或已下架",
+		ErrAuctionEnded:           "拍卖已截止",
+		ErrBidOnOwnListing:        "不能对自己的挂单竞价",
+		ErrInvalidTraitFilter:     "trait_type 与 trait_value 须成对出现且不能为空（最多 %d 组）",
+		ErrDaysOutOfRange:         "天数应在 1 到 %d 之间",
+
+		ErrGetNFTs:             "获取 NFT 列表失败",
+		ErrGetNFTsByContract:   "获取合约 NFT 失败",
+		ErrGetUserNFTs:         "获取用户 NFT 失败",
+		ErrGetTrendingNFTs:     "获取热门 NFT 失败",
+		ErrSearchNFTs:          "搜索 NFT 失败",
+		ErrCreateNFT:           "创建 NFT 失败",
+		ErrLikeNFT:             "点赞失败",
+		ErrUnlikeNFT:           "取消点赞失败",
+		ErrGetActiveListings:   "获取活跃挂单失败",
+		ErrGetListingsAsOf:     "获取历史挂单失败",
+		ErrGetUserListings:     "获取用户挂单失败",
+		ErrSearchListings:      "搜索挂单失败",
+		ErrCreateListing:       "创建挂单失败",
+		ErrCancelListing:       "取消挂单失败",
+		ErrGetListingStatuses:  "查询挂单状态失败",
+		ErrPreviewProceeds:     "预估到账金额失败",
+		ErrGetMarketStats:      "获取市场统计失败",
+		ErrGetCollectionStats:  "获取系列统计失败",
+		ErrGetTopCollections:   "获取热门系列失败",
+		ErrGetTransactions:     "获取交易列表失败",
+		ErrGetUserTransactions: "获取用户交易失败",
+		ErrGetNFTTransactions:  "获取 NFT 交易记录失败",
+		ErrGetRecentTxs:        "获取最近交易失败",
+		ErrGetTxStats:          "获取交易统计失败",
+		ErrResyncBlocks:        "重新同步区块失败",
+		ErrRefreshTrending:     "刷新热门系列失败",
+		ErrExportTransactions:  "导出交易失败",
+		ErrGetBidIncrement:     "获取最小加价规则失败",
+		ErrHolderSnapshot:      "生成持有者快照失败",
+		ErrRefreshListings:     "刷新挂单状态失败",
+		ErrGetNFT:              "获取 NFT 失败",
+		ErrGetSimilarNFTs:      "获取相似 NFT 失败",
+		ErrGetListing:          "获取挂单失败",
+		ErrGetTransaction:      "获取交易失败",
+		ErrGetOffers:           "获取出价失败",
+		ErrGetPriceBands:       "获取价格分布失败",
+		ErrSetImageCDNBase:     "设置图片 CDN 前缀失败",
+		ErrSetFeeOverride:      "设置系列费率失败",
+		ErrIssueNonce:          "签发 nonce 失败",
+		ErrIssueToken:          "签发令牌失败",
+		ErrRefreshMetadata:     "从 tokenURI 抓取 NFT 元数据失败",
+		ErrCreateOffer:         "创建出价失败",
+		ErrCancelOffer:         "取消出价失败",
+		ErrGetBestOffer:        "获取最高出价失败",
+		ErrPlaceBid:            "竞价失败",
+		ErrGetBids:             "获取竞价记录失败",
+		ErrGetLikeStatus:       "获取点赞状态失败",
+		ErrRecomputeLikes:      "重算点赞数失败",
+		ErrGetDailyVolume:      "获取每日交易额失败",
+
+		ErrGetNotificationPreferences:    "获取通知偏好失败",
+		ErrUpdateNotificationPreferences: "更新通知偏好失败",
+		ErrHistoricalSnapshotUnsupported: "暂不支持指定区块的历史快照",
+	},
+}
@@ -0,0 +1,51 @@
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale 未匹配到支持的语言时使用英文
+const DefaultLocale = "en"
+
+// supported 支持的语言，顺序需与 locales 一致，第一个为默认语言
+var (
+	supported = []language.Tag{language.English, language.Chinese}
+	locales   = []string{"en", "zh"}
+	matcher   = language.NewMatcher(supported)
+)
+
+// Negotiate 根据 Accept-Language 选择语言
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return DefaultLocale
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	return locales[index]
+}
+
+// Message 获取错误码在指定语言下的提示信息，缺失时回退到英文，再回退到错误码本身
+func Message(locale, code string, args ...interface{}) string {
+	template, ok := catalog[locale][code]
+	if !ok {
+		template, ok = catalog[DefaultLocale][code]
+	}
+	if !ok {
+		return code
+	}
+
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
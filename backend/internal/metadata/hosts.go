@@ -0,0 +1,217 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultAllowedHosts 默认允许抓取的公共 IPFS/Arweave 网关，"*." 前缀匹配任意子域名
+var DefaultAllowedHosts = []string{
+	"ipfs.io",
+	"*.ipfs.io",
+	"dweb.link",
+	"*.ipfs.dweb.link",
+	"cloudflare-ipfs.com",
+	"gateway.pinata.cloud",
+	"*.mypinata.cloud",
+	"nftstorage.link",
+	"*.ipfs.nftstorage.link",
+	"arweave.net",
+	"*.arweave.net",
+}
+
+var (
+	// ErrHostNotAllowed URI 的协议或主机不在允许列表中
+	ErrHostNotAllowed = errors.New("metadata host not allowed")
+	// ErrPrivateAddress 主机解析到内网、回环等非公网地址
+	ErrPrivateAddress = errors.New("metadata host resolves to a non-public address")
+)
+
+// HostPolicy 元数据与图片抓取允许的协议和主机，无论主机是否在列表中都拒绝非公网地址
+type HostPolicy struct {
+	schemes map[string]bool
+	hosts   map[string]bool
+	suffix  []string // "*.example.com" 形式，存为 ".example.com"
+}
+
+// NewHostPolicy 创建抓取白名单，hosts 支持 "*.example.com" 通配子域名
+func NewHostPolicy(hosts, schemes []string) *HostPolicy {
+	p := &HostPolicy{schemes: make(map[string]bool), hosts: make(map[string]bool)}
+	for _, scheme := range schemes {
+		if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+			p.schemes[scheme] = true
+		}
+	}
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		switch {
+		case host == "":
+		case strings.HasPrefix(host, "*."):
+			p.suffix = append(p.suffix, host[1:])
+		default:
+			p.hosts[host] = true
+		}
+	}
+	return p
+}
+
+// Allows URL 的协议和主机是否在白名单中（不做 DNS 解析）
+func (p *HostPolicy) Allows(u *url.URL) bool {
+	if !p.schemes[strings.ToLower(u.Scheme)] {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if p.hosts[host] {
+		return true
+	}
+	for _, suffix := range p.suffix {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	policyMu   sync.RWMutex
+	hostPolicy = NewHostPolicy(DefaultAllowedHosts, []string{"https"})
+)
+
+// SetHostPolicy 设置全局抓取白名单，应在启动时、发起抓取前调用
+func SetHostPolicy(p *HostPolicy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	hostPolicy = p
+}
+
+// CheckURL 校验 URL 在白名单中且主机只解析到公网地址，每次重定向都会重新校验
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid metadata url: %w", err)
+	}
+
+	policyMu.RLock()
+	p := hostPolicy
+	policyMu.RUnlock()
+
+	if !p.Allows(u) {
+		return fmt.Errorf("%w: %s://%s", ErrHostNotAllowed, u.Scheme, u.Host)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s -> %s", ErrPrivateAddress, u.Hostname(), addr.IP)
+		}
+	}
+	return nil
+}
+
+// CheckBaseURL 校验用于拼接资源地址的前缀（如系列图片 CDN）：须为不含查询参数的绝对 URL，
+// 且协议和主机在抓取白名单中（不做 DNS 解析）
+func CheckBaseURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("invalid base url: %q", rawURL)
+	}
+
+	policyMu.RLock()
+	p := hostPolicy
+	policyMu.RUnlock()
+
+	if !p.Allows(u) {
+		return fmt.Errorf("%w: %s://%s", ErrHostNotAllowed, u.Scheme, u.Host)
+	}
+	return nil
+}
+
+// blockedRanges 标准库判断之外仍需拒绝的地址段
+var blockedRanges = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",      // 本网络，部分系统上等同本机
+		"100.64.0.0/10",  // 运营商级 NAT 共享地址
+		"198.18.0.0/15",  // 网络设备基准测试
+		"240.0.0.0/4",    // 保留地址（含 255.255.255.255 广播）
+		"64:ff9b::/96",   // NAT64 知名前缀，经网关可转换为任意 IPv4 地址
+		"64:ff9b:1::/48", // NAT64 本地前缀
+	}
+	ranges := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ranges[i], _ = net.ParseCIDR(cidr)
+	}
+	return ranges
+}()
+
+// isPublicIP 排除回环、私有、链路本地（含 169.254.169.254 云元数据地址）、组播、未指定地址及 blockedRanges；
+// IPv4 映射地址（::ffff:a.b.c.d）按内嵌的 IPv4 地址判断
+func isPublicIP(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	} else if len(ip) != net.IPv6len {
+		return false
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, blocked := range blockedRanges {
+		if blocked.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// safeTransport 在建立连接时再次校验实际连接的 IP，防止校验后 DNS 重绑定到内网地址
+var safeTransport = func() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // 经代理时 Control 校验的是代理地址
+	transport.DialContext = dialer.DialContext
+	return transport
+}()
+
+// maxRedirects 与 net/http 默认重定向上限一致
+const maxRedirects = 10
+
+// guardClient 返回校验重定向目标的客户端副本；未指定 Transport 时使用校验连接地址的 safeTransport
+func guardClient(client *http.Client) *http.Client {
+	guarded := *client
+	if guarded.Transport == nil {
+		guarded.Transport = safeTransport
+	}
+	guarded.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return CheckURL(req.Context(), req.URL.String())
+	}
+	return &guarded
+}
@@ -0,0 +1,181 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// setTestHostPolicy 在测试期间替换全局白名单，结束后恢复默认值
+func setTestHostPolicy(t *testing.T, hosts, schemes []string) {
+	t.Helper()
+	SetHostPolicy(NewHostPolicy(hosts, schemes))
+	t.Cleanup(func() { SetHostPolicy(NewHostPolicy(DefaultAllowedHosts, []string{"https"})) })
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"1.1.1.1", true},
+		{"2606:4700:4700::1111", true},
+		{"198.17.255.255", true},
+		{"198.20.0.0", true},
+		{"223.255.255.255", true},
+
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"198.18.0.1", false},
+		{"198.19.255.255", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"fc00::1", false},
+		{"fe80::1", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:0.0.0.0", false},
+		{"::ffff:8.8.8.8", true},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::808:808", false},
+		{"64:ff9b:1::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("invalid test ip %q", tt.ip)
+			}
+			if got := isPublicIP(ip); got != tt.want {
+				t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+
+	if isPublicIP(nil) {
+		t.Error("isPublicIP(nil) = true, want false")
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	// IP 字面量不触发 DNS 查询
+	setTestHostPolicy(t, []string{"8.8.8.8", "127.0.0.1", "0.0.0.0", "198.18.0.1", "240.0.0.1",
+		"::ffff:127.0.0.1", "64:ff9b::a00:1", "*.example.com"}, []string{"https"})
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error // nil 表示通过
+	}{
+		{"public ip", "https://8.8.8.8/token/1", nil},
+		{"scheme not allowed", "http://8.8.8.8/token/1", ErrHostNotAllowed},
+		{"host not allowed", "https://1.1.1.1/token/1", ErrHostNotAllowed},
+		{"wildcard needs subdomain", "https://example.com/token/1", ErrHostNotAllowed},
+		{"loopback", "https://127.0.0.1/token/1", ErrPrivateAddress},
+		{"this network", "https://0.0.0.0/token/1", ErrPrivateAddress},
+		{"benchmark range", "https://198.18.0.1/token/1", ErrPrivateAddress},
+		{"reserved range", "https://240.0.0.1/token/1", ErrPrivateAddress},
+		{"ipv4-mapped loopback", "https://[::ffff:127.0.0.1]/token/1", ErrPrivateAddress},
+		{"nat64", "https://[64:ff9b::a00:1]/token/1", ErrPrivateAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckURL(context.Background(), tt.url)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("CheckURL(%q) = %v, want nil", tt.url, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckURL(%q) = %v, want %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := CheckURL(context.Background(), "https://[::1"); err == nil {
+		t.Error("CheckURL(malformed) = nil, want error")
+	}
+}
+
+func TestGuardClientRedirects(t *testing.T) {
+	setTestHostPolicy(t, []string{"8.8.8.8", "10.0.0.1", "169.254.169.254", "64:ff9b::a9fe:a9fe"}, []string{"http", "https"})
+
+	tests := []struct {
+		name     string
+		location string
+		wantErr  error
+	}{
+		{"private target", "http://10.0.0.1/latest", ErrPrivateAddress},
+		{"cloud metadata", "http://169.254.169.254/latest/meta-data", ErrPrivateAddress},
+		{"nat64 cloud metadata", "http://[64:ff9b::a9fe:a9fe]/latest/meta-data", ErrPrivateAddress},
+		{"host not allowed", "https://attacker.example/", ErrHostNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.RedirectHandler(tt.location, http.StatusFound))
+			defer srv.Close()
+
+			// 使用测试服务器自带的 Transport，仅检验重定向校验
+			client := guardClient(srv.Client())
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("redirect to %s followed, want %v", tt.location, tt.wantErr)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Get() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGuardClientCheckRedirect(t *testing.T) {
+	setTestHostPolicy(t, []string{"8.8.8.8"}, []string{"https"})
+	check := guardClient(&http.Client{}).CheckRedirect
+
+	req := &http.Request{URL: &url.URL{Scheme: "https", Host: "8.8.8.8", Path: "/token/1"}}
+	req = req.WithContext(context.Background())
+
+	if err := check(req, make([]*http.Request, maxRedirects-1)); err != nil {
+		t.Errorf("CheckRedirect(public, %d via) = %v, want nil", maxRedirects-1, err)
+	}
+	if err := check(req, make([]*http.Request, maxRedirects)); err == nil || !strings.Contains(err.Error(), "redirects") {
+		t.Errorf("CheckRedirect(public, %d via) = %v, want redirect limit error", maxRedirects, err)
+	}
+}
+
+// 未指定 Transport 时连接阶段再次校验实际 IP，防止 DNS 重绑定
+func TestGuardClientRejectsPrivateDial(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	resp, err := guardClient(&http.Client{}).Get(srv.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("Get(loopback) succeeded, want dial rejected")
+	}
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("Get(loopback) = %v, want %v", err, ErrPrivateAddress)
+	}
+}
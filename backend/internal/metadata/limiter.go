@@ -0,0 +1,97 @@
+// Package metadata 负责从外部网关抓取 NFT 元数据
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/xiaomait/backend/internal/metrics"
+	"golang.org/x/sync/semaphore"
+)
+
+// defaultMaxConcurrentFetches 未配置时的并发抓取上限
+const defaultMaxConcurrentFetches = 16
+
+var (
+	fetchMu  sync.RWMutex
+	fetchSem = newFetchSemaphore(defaultMaxConcurrentFetches)
+)
+
+// newFetchSemaphore 创建信号量并更新上限指标
+func newFetchSemaphore(limit int64) *semaphore.Weighted {
+	metrics.MetadataFetchLimit.Set(float64(limit))
+	return semaphore.NewWeighted(limit)
+}
+
+// SetMaxConcurrentFetches 设置全局并发抓取上限，应在启动时、发起抓取前调用
+func SetMaxConcurrentFetches(limit int64) {
+	if limit < 1 {
+		limit = defaultMaxConcurrentFetches
+	}
+
+	fetchMu.Lock()
+	defer fetchMu.Unlock()
+	fetchSem = newFetchSemaphore(limit)
+}
+
+// Acquire 获取一个抓取名额，所有对外元数据请求（刷新任务、导入、图片代理）都必须先调用
+func Acquire(ctx context.Context) (release func(), err error) {
+	fetchMu.RLock()
+	sem := fetchSem
+	fetchMu.RUnlock()
+
+	if err := sem.Acquire(ctx, 1); err != nil {
+		return nil, fmt.Errorf("failed to acquire metadata fetch slot: %w", err)
+	}
+	metrics.MetadataFetchInFlight.Inc()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			metrics.MetadataFetchInFlight.Dec()
+			sem.Release(1)
+		})
+	}, nil
+}
+
+// Fetch 在并发限制内 GET 指定 URL，读取至多 maxBytes 字节。
+// URL 及每次重定向的目标都必须通过抓取白名单校验
+func Fetch(ctx context.Context, client *http.Client, url string, maxBytes int64) ([]byte, error) {
+	if err := CheckURL(ctx, url); err != nil {
+		return nil, err
+	}
+
+	release, err := Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := guardClient(client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, fmt.Errorf("response from %s exceeds %d bytes", url, maxBytes)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ipfsPathPattern 匹配网关形式 https://host/ipfs/<cid>/...
+var ipfsPathPattern = regexp.MustCompile(`^https?://[^/]+/ipfs/(.+)$`)
+
+// cidPattern 匹配裸 CID（CIDv0 Qm... 或 CIDv1 bafy...）
+var cidPattern = regexp.MustCompile(`^(Qm[1-9A-HJ-NP-Za-km-z]{44}|bafy[a-z2-7]{50,})(/.*)?$`)
+
+// NormalizeURI 将 ipfs://、ar://、裸 CID 及其他网关地址统一为可请求的 URL，
+// IPFS 内容统一走配置的网关（gateway 形如 https://ipfs.io/ipfs/）
+func NormalizeURI(raw, gateway string) string {
+	uri := strings.TrimSpace(raw)
+	if gateway != "" && !strings.HasSuffix(gateway, "/") {
+		gateway += "/"
+	}
+
+	switch {
+	case strings.HasPrefix(uri, "data:"):
+		return uri
+	case strings.HasPrefix(uri, "ipfs://"):
+		path := strings.TrimPrefix(uri, "ipfs://")
+		path = strings.TrimPrefix(path, "ipfs/")
+		return gateway + path
+	case strings.HasPrefix(uri, "ar://"):
+		return "https://arweave.net/" + strings.TrimPrefix(uri, "ar://")
+	case cidPattern.MatchString(uri):
+		return gateway + uri
+	}
+
+	if m := ipfsPathPattern.FindStringSubmatch(uri); m != nil && gateway != "" {
+		return gateway + m[1]
+	}
+	return uri
+}
+
+// Candidates 根据合约返回的 URI 生成按优先级排列的元数据地址：
+//   - ERC1155 的 {id} 占位符替换为 64 位小写十六进制 ID；
+//   - 以 / 结尾的 baseURI 拼接十进制 tokenId；
+//   - 其余视为完整 URI，若末段不是 tokenId 则追加 baseURI+id 作为备选。
+func Candidates(raw string, tokenID *big.Int, gateway string) []string {
+	uri := NormalizeURI(raw, gateway)
+	if uri == "" || strings.HasPrefix(uri, "data:") {
+		return []string{uri}
+	}
+
+	if strings.Contains(uri, "{id}") {
+		return []string{strings.ReplaceAll(uri, "{id}", fmt.Sprintf("%064x", tokenID))}
+	}
+
+	id := tokenID.String()
+	if strings.HasSuffix(uri, "/") {
+		return []string{uri + id}
+	}
+
+	last := uri[strings.LastIndex(uri, "/")+1:]
+	last = strings.TrimSuffix(last, ".json")
+	if last == id || strings.EqualFold(last, fmt.Sprintf("%064x", tokenID)) {
+		return []string{uri}
+	}
+	return []string{uri, uri + "/" + id}
+}
+
+// FetchTokenMetadata 解析并抓取 Token 元数据 JSON，依次尝试各候选地址，支持 data: URI 内联元数据
+func FetchTokenMetadata(ctx context.Context, client *http.Client, raw string, tokenID *big.Int, gateway string, maxBytes int64) (map[string]interface{}, error) {
+	var lastErr error
+	for _, candidate := range Candidates(raw, tokenID, gateway) {
+		var body []byte
+		var err error
+		if strings.HasPrefix(candidate, "data:") {
+			body, err = decodeDataURI(candidate)
+		} else {
+			body, err = Fetch(ctx, client, candidate, maxBytes)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			lastErr = fmt.Errorf("invalid metadata JSON from %s: %w", candidate, err)
+			continue
+		}
+		return doc, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("empty token URI")
+	}
+	return nil, lastErr
+}
+
+// decodeDataURI 解码 data:[<mediatype>][;base64],<data>
+func decodeDataURI(uri string) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("malformed data URI")
+	}
+
+	if strings.HasSuffix(header, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			decoded, err = base64.RawStdEncoding.DecodeString(payload)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 data URI: %w", err)
+		}
+		return decoded, nil
+	}
+
+	decoded, err := url.PathUnescape(payload)
+	if err != nil {
+		return []byte(payload), nil
+	}
+	return []byte(decoded), nil
+}
@@ -0,0 +1,112 @@
+package metadata
+
+import (
+	"context"
+	"encoding/base64"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testGateway = "https://gateway.example/ipfs/"
+	testCIDv0   = "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"
+	testCIDv1   = "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"
+)
+
+func TestNormalizeURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		gateway string
+		want    string
+	}{
+		{"ipfs scheme", "ipfs://" + testCIDv0 + "/1.json", testGateway, testGateway + testCIDv0 + "/1.json"},
+		{"ipfs scheme with ipfs path", "ipfs://ipfs/" + testCIDv0, testGateway, testGateway + testCIDv0},
+		{"gateway without trailing slash", "ipfs://" + testCIDv0, "https://gateway.example/ipfs", testGateway + testCIDv0},
+		{"bare CIDv0", testCIDv0 + "/7", testGateway, testGateway + testCIDv0 + "/7"},
+		{"bare CIDv1", testCIDv1, testGateway, testGateway + testCIDv1},
+		{"other gateway", "https://cloudflare-ipfs.com/ipfs/" + testCIDv0 + "/3", testGateway, testGateway + testCIDv0 + "/3"},
+		{"other gateway kept without configured gateway", "https://cloudflare-ipfs.com/ipfs/" + testCIDv0, "", "https://cloudflare-ipfs.com/ipfs/" + testCIDv0},
+		{"arweave", "ar://abc123", testGateway, "https://arweave.net/abc123"},
+		{"https unchanged", "https://api.example.com/token/1", testGateway, "https://api.example.com/token/1"},
+		{"data unchanged", "data:application/json,{}", testGateway, "data:application/json,{}"},
+		{"whitespace trimmed", "  https://api.example.com/1  ", testGateway, "https://api.example.com/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeURI(tt.raw, tt.gateway); got != tt.want {
+				t.Errorf("NormalizeURI(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	hexID := strings.Repeat("0", 62) + "2a"
+
+	tests := []struct {
+		name    string
+		raw     string
+		tokenID int64
+		want    []string
+	}{
+		{"base URI", "https://api.example.com/meta/", 42, []string{"https://api.example.com/meta/42"}},
+		{"ipfs base URI", "ipfs://" + testCIDv0 + "/", 42, []string{testGateway + testCIDv0 + "/42"}},
+		{"erc1155 id placeholder", "https://api.example.com/{id}.json", 42, []string{"https://api.example.com/" + hexID + ".json"}},
+		{"erc1155 ipfs placeholder", "ipfs://" + testCIDv0 + "/{id}", 42, []string{testGateway + testCIDv0 + "/" + hexID}},
+		{"full URI ending in id", "https://api.example.com/meta/42", 42, []string{"https://api.example.com/meta/42"}},
+		{"full URI ending in id.json", "https://api.example.com/meta/42.json", 42, []string{"https://api.example.com/meta/42.json"}},
+		{"full URI ending in hex id", "https://api.example.com/meta/" + hexID, 42, []string{"https://api.example.com/meta/" + hexID}},
+		{"full URI without id", "https://api.example.com/meta", 42, []string{"https://api.example.com/meta", "https://api.example.com/meta/42"}},
+		{"data URI", "data:application/json,{}", 42, []string{"data:application/json,{}"}},
+		{"empty", "", 42, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Candidates(tt.raw, big.NewInt(tt.tokenID), testGateway)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Candidates(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchTokenMetadataDataURI(t *testing.T) {
+	doc := `{"name":"Token #1","image":"ipfs://img"}`
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{"base64", "data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(doc)), false},
+		{"base64 without padding", "data:application/json;base64," + base64.RawStdEncoding.EncodeToString([]byte(doc)), false},
+		{"percent encoded", "data:application/json,%7B%22name%22%3A%22Token%20%231%22%7D", false},
+		{"plain", "data:application/json," + doc, false},
+		{"malformed", "data:application/json", true},
+		{"invalid json", "data:application/json,not-json", true},
+		{"empty", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchTokenMetadata(context.Background(), nil, tt.raw, big.NewInt(1), testGateway, 1<<20)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FetchTokenMetadata() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchTokenMetadata() error = %v", err)
+			}
+			if got["name"] != "Token #1" {
+				t.Errorf("name = %v, want %q", got["name"], "Token #1")
+			}
+		})
+	}
+}
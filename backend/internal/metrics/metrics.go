@@ -0,0 +1,140 @@
+// Package metrics 定义应用的 Prometheus 指标
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// MetadataFetchLimit 元数据并发抓取上限
+	MetadataFetchLimit = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "metadata_fetch_concurrency_limit",
+		Help: "Maximum number of concurrent outbound metadata fetches.",
+	})
+
+	// IndexerEventBufferUsed 事件监听缓冲区当前占用
+	IndexerEventBufferUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "indexer_event_buffer_used",
+		Help: "Number of decoded chain events waiting in the listener buffer.",
+	}, []string{"event"})
+
+	// IndexerEventsDropped 缓冲区持续已满而丢弃的事件数
+	IndexerEventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_events_dropped_total",
+		Help: "Chain events dropped because the listener buffer stayed full.",
+	}, []string{"event"})
+
+	// MetadataFetchInFlight 正在进行的元数据抓取数量
+	MetadataFetchInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "metadata_fetch_in_flight",
+		Help: "Number of outbound metadata fetches currently holding a semaphore slot.",
+	})
+
+	// BlockchainBreakerState 链上调用熔断器状态（0 关闭，1 打开，2 半开）
+	BlockchainBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "blockchain_breaker_state",
+		Help: "Blockchain circuit breaker state: 0 closed, 1 open, 2 half-open.",
+	})
+
+	// BlockchainBreakerTransitions 熔断器状态切换次数
+	BlockchainBreakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "blockchain_breaker_transitions_total",
+		Help: "Blockchain circuit breaker state transitions, by new state.",
+	}, []string{"state"})
+
+	// BlockchainBreakerRejected 熔断器打开时被拒绝的链上调用数
+	BlockchainBreakerRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "blockchain_breaker_rejected_total",
+		Help: "Blockchain calls rejected without reaching the RPC because the breaker was open.",
+	})
+
+	// BlockchainThrottleInterval RPC 自适应限速当前的请求最小间隔（秒），0 表示未限速
+	BlockchainThrottleInterval = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "blockchain_rpc_throttle_interval_seconds",
+		Help: "Current minimum interval between outbound RPC requests imposed by adaptive throttling; 0 when not throttled.",
+	})
+
+	// BlockchainRateLimited RPC 节点返回限流（429 或限流错误）的次数
+	BlockchainRateLimited = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "blockchain_rpc_rate_limited_total",
+		Help: "RPC responses recognised as rate limiting (HTTP 429 or a rate-limit JSON-RPC error).",
+	})
+
+	// BlockchainEndpointHealthy 各 RPC 节点的健康状态（1 健康，0 故障）
+	BlockchainEndpointHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "blockchain_rpc_endpoint_healthy",
+		Help: "Health of each configured RPC endpoint: 1 healthy, 0 failing.",
+	}, []string{"endpoint"})
+
+	// BlockchainEndpointFailovers 当前 RPC 节点的切换次数
+	BlockchainEndpointFailovers = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "blockchain_rpc_endpoint_failovers_total",
+		Help: "Times the active RPC endpoint was switched, including switching back to a recovered endpoint.",
+	})
+
+	// HTTPInFlightRequests 正在处理的 HTTP 请求数（不含豁免路径）
+	HTTPInFlightRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_in_flight_requests",
+		Help: "HTTP requests currently holding a concurrency-limit slot.",
+	})
+
+	// HTTPRequestsShed 因并发已满被拒绝的请求数
+	HTTPRequestsShed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "http_requests_shed_total",
+		Help: "HTTP requests rejected with 503 because the in-flight limit was reached.",
+	})
+
+	// HTTPRequests HTTP 请求数，route 为路由模板（未匹配路由为 unmatched）
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration HTTP 请求耗时（秒）
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// IndexerListenerReconnects 事件订阅出错后重新订阅的次数
+	IndexerListenerReconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_listener_reconnects_total",
+		Help: "Times a chain event subscription failed and the listener resubscribed.",
+	}, []string{"event"})
+
+	// IndexerEventsProcessed 监听器处理的事件数，result 为 ok、error（已记入死信）或 duplicate
+	IndexerEventsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "indexer_events_processed_total",
+		Help: "Chain events handled by the live listeners, by event and result (ok, error, duplicate).",
+	}, []string{"event", "result"})
+
+	// NFTViewIncrementsDropped 浏览计数队列已满或已关闭而丢弃的计数
+	NFTViewIncrementsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nft_view_increments_dropped_total",
+		Help: "NFT view increments dropped because the counter queue was full or shutting down.",
+	})
+
+	// NFTViewIncrementsFailed 重试后仍写库失败的浏览计数
+	NFTViewIncrementsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "nft_view_increments_failed_total",
+		Help: "NFT view increments that could not be written after all retries.",
+	})
+)
+
+// RegisterDBStats 注册数据库连接池指标（go_sql_* 系列，持续反映 db.Stats()），
+// preparedStmts 不为 nil 时同时导出预编译语句缓存大小
+func RegisterDBStats(db *sql.DB, preparedStmts func() int) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+
+	if preparedStmts != nil {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "db_prepared_statements",
+			Help: "Number of statements held in the GORM prepared-statement cache; steady growth means dynamic SQL is leaking statements.",
+		}, func() float64 { return float64(preparedStmts()) })
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoStore 禁止任何缓存的 Cache-Control 值
+const NoStore = "no-store"
+
+// CacheHeaders 按路由模板（如 /api/v1/contract）设置 Cache-Control：
+// 配置了 max-age 的公开 GET 接口返回 public, max-age，其余一律 no-store。
+// 携带 Authorization 或 Cookie 的请求视为个性化响应，始终 private, no-store；
+// 错误响应由 handler 覆盖为 no-store，避免 CDN 缓存失败结果
+func CacheHeaders(maxAges map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch {
+		case c.GetHeader("Authorization") != "" || c.GetHeader("Cookie") != "":
+			c.Header("Cache-Control", "private, "+NoStore)
+		case c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead:
+			c.Header("Cache-Control", NoStore)
+		case maxAges[c.FullPath()] > 0:
+			c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAges[c.FullPath()].Seconds())))
+			// 响应编码随 Accept 协商（JSON / msgpack），缓存需区分
+			c.Writer.Header().Add("Vary", "Accept")
+		default:
+			c.Header("Cache-Control", NoStore)
+		}
+		c.Next()
+	}
+}
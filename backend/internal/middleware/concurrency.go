@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/xiaomait/backend/internal/i18n"
+	"github.com/xiaomait/backend/internal/metrics"
+)
+
+// ConcurrencyLimit 限制同时处理的请求数，防止突发流量耗尽数据库连接池导致连锁超时。
+// 已满时最多等待 queueTimeout，仍无空位则返回 503 并附带 Retry-After；
+// exempt 中的路径前缀（健康检查、长连接等）不占用名额
+func ConcurrencyLimit(limit int64, queueTimeout time.Duration, exempt ...string) gin.HandlerFunc {
+	sem := semaphore.NewWeighted(limit)
+
+	return func(c *gin.Context) {
+		for _, prefix := range exempt {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		if !acquireSlot(c.Request.Context(), sem, queueTimeout) {
+			metrics.HTTPRequestsShed.Inc()
+			c.Header("Retry-After", "1")
+			abortWithError(c, http.StatusServiceUnavailable, i18n.ErrServerOverloaded)
+			return
+		}
+		metrics.HTTPInFlightRequests.Inc()
+		defer func() {
+			metrics.HTTPInFlightRequests.Dec()
+			sem.Release(1)
+		}()
+
+		c.Next()
+	}
+}
+
+// acquireSlot 获取一个名额，已满时最多等待 timeout
+func acquireSlot(ctx context.Context, sem *semaphore.Weighted, timeout time.Duration) bool {
+	if sem.TryAcquire(1) {
+		return true
+	}
+	if timeout <= 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return sem.Acquire(ctx, 1) == nil
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xiaomait/backend/internal/i18n"
+)
+
+// abortWithError 中止请求并返回与 handler 一致的本地化结构化错误
+func abortWithError(c *gin.Context, status int, code string) {
+	body := gin.H{
+		"code":  code,
+		"error": i18n.Message(i18n.Negotiate(c.GetHeader("Accept-Language")), code),
+	}
+	if id := c.GetString(requestIDKey); id != "" {
+		body["request_id"] = id
+	}
+	c.Header("Cache-Control", NoStore)
+	c.AbortWithStatusJSON(status, body)
+}
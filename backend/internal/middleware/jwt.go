@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/xiaomait/backend/internal/i18n"
+)
+
+// authAddressKey 上下文中已认证钱包地址（小写）的键
+const authAddressKey = "auth_address"
+
+// bearerScheme Authorization 头中 JWT 的方案名
+const bearerScheme = "Bearer "
+
+// IssueToken 为钱包地址签发 HS256 JWT（sub 为小写地址），有效期 ttl
+func IssueToken(secret, address string, ttl time.Duration) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", errors.New("invalid wallet address")
+	}
+
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   strings.ToLower(common.HexToAddress(address).Hex()),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// JWTAuth 校验 Authorization: Bearer <JWT>（HS256，须带未过期的 exp，sub 为钱包地址），
+// 缺失、签名错误或已过期时返回 401。通过后可用 AuthAddress 获取认证地址
+func JWTAuth(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, ok := verifyToken(c.GetHeader("Authorization"), secret)
+		if !ok {
+			abortWithError(c, http.StatusUnauthorized, i18n.ErrUnauthorized)
+			return
+		}
+		c.Set(authAddressKey, address)
+		c.Next()
+	}
+}
+
+// OptionalJWTAuth 带有有效 JWT 时记录认证地址，缺失或无效时按匿名请求继续处理
+func OptionalJWTAuth(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if address, ok := verifyToken(c.GetHeader("Authorization"), secret); ok {
+			c.Set(authAddressKey, address)
+		}
+		c.Next()
+	}
+}
+
+// AuthAddress 当前请求已认证的钱包地址（小写），未认证时为空
+func AuthAddress(c *gin.Context) string {
+	return c.GetString(authAddressKey)
+}
+
+// verifyToken 解析并校验 JWT，返回小写地址
+func verifyToken(header, secret string) (string, bool) {
+	if !strings.HasPrefix(header, bearerScheme) {
+		return "", false
+	}
+
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(header, bearerScheme), &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || claims.ExpiresAt == nil || !common.IsHexAddress(claims.Subject) {
+		return "", false
+	}
+	return strings.ToLower(common.HexToAddress(claims.Subject).Hex()), true
+}
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/xiaomait/backend/internal/i18n"
+)
+
+// RateLimitResult 限流检查结果
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAt    time.Time     // 配额恢复满额的时间
+	RetryAfter time.Duration // 被拒绝时距离下一次可用配额的时间
+}
+
+// RateLimiter 限流器
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (*RateLimitResult, error)
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// MemoryRateLimiter 基于内存令牌桶的限流器（仅在单实例内生效）
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	rate      float64 // 每秒补充的令牌数
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimiter 创建内存限流器，limitPerMinute 为每分钟允许的请求数
+func NewMemoryRateLimiter(limitPerMinute int) *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		limit:     limitPerMinute,
+		rate:      float64(limitPerMinute) / 60,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 消耗一个令牌
+func (l *MemoryRateLimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(l.limit), lastRefill: now}
+		l.buckets[key] = bucket
+	}
+
+	// 按经过的时间补充令牌
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.tokens = math.Min(float64(l.limit), bucket.tokens+elapsed*l.rate)
+	bucket.lastRefill = now
+
+	result := &RateLimitResult{Limit: l.limit}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.durationFor(1 - bucket.tokens)
+	}
+
+	result.Remaining = int(bucket.tokens)
+	result.ResetAt = now.Add(l.durationFor(float64(l.limit) - bucket.tokens))
+
+	return result, nil
+}
+
+// durationFor 计算补充指定数量令牌所需的时间
+func (l *MemoryRateLimiter) durationFor(tokens float64) time.Duration {
+	return refillDuration(l.rate, tokens)
+}
+
+// refillDuration 按每秒 rate 个令牌计算补充 tokens 个令牌所需的时间
+func refillDuration(rate, tokens float64) time.Duration {
+	if rate <= 0 {
+		return time.Minute
+	}
+	return time.Duration(tokens / rate * float64(time.Second))
+}
+
+// sweep 清理已恢复满额的令牌桶，防止内存无限增长
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastRefill) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// redisTokenBucket 在 Redis 中原子地补充并消耗令牌：哈希保存剩余令牌数与上次补充时间（毫秒），
+// 返回 {是否放行, 剩余令牌数}。令牌数为小数，以字符串返回避免被 Redis 截断为整数
+var redisTokenBucket = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or limit
+local ts = tonumber(state[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateLimiter 基于 Redis 令牌桶的限流器，多实例共享同一份配额
+type RedisRateLimiter struct {
+	client *redis.Client
+	prefix string
+	limit  int
+	rate   float64 // 每秒补充的令牌数
+}
+
+// NewRedisRateLimiter 创建 Redis 限流器，limitPerMinute 为每分钟允许的请求数
+func NewRedisRateLimiter(client *redis.Client, limitPerMinute int) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client: client,
+		prefix: "ratelimit:",
+		limit:  limitPerMinute,
+		rate:   float64(limitPerMinute) / 60,
+	}
+}
+
+// Allow 消耗一个令牌；桶在恢复满额所需的时间后过期，不再占用 Redis 内存
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+	now := time.Now()
+	ttl := refillDuration(l.rate, float64(l.limit))
+
+	values, err := redisTokenBucket.Run(ctx, l.client, []string{l.prefix + key},
+		l.limit, l.rate/1000, now.UnixMilli(), ttl.Milliseconds()+1000).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	if len(values) != 2 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(remaining, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse remaining tokens: %w", err)
+	}
+
+	result := &RateLimitResult{
+		Allowed:   allowed == 1,
+		Limit:     l.limit,
+		Remaining: int(tokens),
+		ResetAt:   now.Add(refillDuration(l.rate, float64(l.limit)-tokens)),
+	}
+	if !result.Allowed {
+		result.RetryAfter = refillDuration(l.rate, 1-tokens)
+	}
+	return result, nil
+}
+
+// RateLimit 按客户端 IP 限流（仅信任 TrustedProxies 转发的 X-Forwarded-For），并在每个响应中返回 X-RateLimit-* 头
+func RateLimit(limiter RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := limiter.Allow(c.Request.Context(), c.ClientIP())
+		if err != nil {
+			// 限流器不可用时放行，避免影响正常请求
+			log.Printf("Rate limiter error: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			abortWithError(c, http.StatusTooManyRequests, i18n.ErrRateLimited)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xiaomait/backend/internal/requestid"
+)
+
+// requestIDKey 上下文中请求 ID 的键
+const requestIDKey = "request_id"
+
+// RequestID 沿用请求的 X-Request-ID（缺失或不合法时生成 UUID），写入 gin 上下文与 request context，
+// 并在响应头中返回
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+		c.Next()
+	}
+}
+
+// AccessLogFormatter 与 gin 默认格式一致的访问日志，末尾附加 request_id
+func AccessLogFormatter(param gin.LogFormatterParams) string {
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v request_id=%v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		param.Path,
+		param.Keys[requestIDKey],
+		param.ErrorMessage,
+	)
+}
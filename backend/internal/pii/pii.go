@@ -0,0 +1,127 @@
+// Package pii 个人信息字段级加密（AES-256-GCM，支持密钥轮换）
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeySize AES-256 密钥长度
+const KeySize = 32
+
+var (
+	// ErrNoKeyring 未配置加密密钥，拒绝以明文写入个人信息
+	ErrNoKeyring = errors.New("pii encryption key not configured")
+	// ErrUnknownKeyVersion 密文使用的密钥版本未配置（轮换时旧密钥需保留到数据重新加密完成）
+	ErrUnknownKeyVersion = errors.New("unknown pii key version")
+	// ErrMalformedCiphertext 密文格式无效
+	ErrMalformedCiphertext = errors.New("malformed pii ciphertext")
+)
+
+// Keyring 按版本保存的密钥：始终用当前版本加密，按密文中的版本号选择密钥解密。
+// 密文格式为 v<版本>:<base64(nonce|密文)>
+type Keyring struct {
+	current uint32
+	aeads   map[uint32]cipher.AEAD
+}
+
+// NewKeyring 创建密钥环，keys 必须包含 current 版本
+func NewKeyring(current uint32, keys map[uint32][]byte) (*Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("pii key version %d not provided", current)
+	}
+
+	k := &Keyring{current: current, aeads: make(map[uint32]cipher.AEAD, len(keys))}
+	for version, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("pii key version %d must be %d bytes", version, KeySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.aeads[version] = aead
+	}
+	return k, nil
+}
+
+// ParseKey 解析 base64 编码的密钥
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pii key encoding: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("pii key must be %d bytes", KeySize)
+	}
+	return key, nil
+}
+
+// CurrentVersion 当前加密使用的密钥版本
+func (k *Keyring) CurrentVersion() uint32 {
+	return k.current
+}
+
+// Encrypt 使用当前密钥加密
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return fmt.Sprintf("v%d:%s", k.current, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt 按密文中的版本号选择密钥解密
+func (k *Keyring) Decrypt(stored string) (string, error) {
+	version, payload, err := splitVersion(stored)
+	if err != nil {
+		return "", err
+	}
+	aead, ok := k.aeads[version]
+	if !ok {
+		return "", fmt.Errorf("%w: v%d", ErrUnknownKeyVersion, version)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt pii (key v%d): %w", version, err)
+	}
+	return string(plaintext), nil
+}
+
+// IsCiphertext 判断存储值是否为带版本号的密文（加密上线前写入的明文不是）
+func IsCiphertext(stored string) bool {
+	_, _, err := splitVersion(stored)
+	return err == nil
+}
+
+// splitVersion 拆分密文中的版本号与数据
+func splitVersion(stored string) (uint32, string, error) {
+	prefix, payload, ok := strings.Cut(stored, ":")
+	if !ok || !strings.HasPrefix(prefix, "v") {
+		return 0, "", ErrMalformedCiphertext
+	}
+	version, err := strconv.ParseUint(prefix[1:], 10, 32)
+	if err != nil {
+		return 0, "", ErrMalformedCiphertext
+	}
+	return uint32(version), payload, nil
+}
@@ -0,0 +1,130 @@
+// Package pricing ETH 兑法币汇率来源（CoinGecko、CoinMarketCap）
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Source ETH 汇率来源
+type Source interface {
+	// EthRates 返回 1 ETH 兑各币种的价格（十进制字符串），获取失败的币种不出现在结果中
+	EthRates(ctx context.Context, currencies []string) (map[string]string, error)
+}
+
+// CoinGecko 通过 CoinGecko simple/price 接口获取汇率，一次请求获取所有币种
+type CoinGecko struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewCoinGecko 创建 CoinGecko 汇率来源
+func NewCoinGecko() *CoinGecko {
+	return &CoinGecko{
+		BaseURL: "https://api.coingecko.com/api/v3",
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// EthRates 获取 ETH 汇率
+func (g *CoinGecko) EthRates(ctx context.Context, currencies []string) (map[string]string, error) {
+	query := url.Values{}
+	query.Set("ids", "ethereum")
+	query.Set("vs_currencies", strings.ToLower(strings.Join(currencies, ",")))
+
+	var body map[string]map[string]json.Number
+	if err := getJSON(ctx, g.Client, g.BaseURL+"/simple/price?"+query.Encode(), nil, &body); err != nil {
+		return nil, fmt.Errorf("failed to fetch coingecko prices: %w", err)
+	}
+
+	rates := make(map[string]string, len(currencies))
+	for _, currency := range currencies {
+		if price, ok := body["ethereum"][strings.ToLower(currency)]; ok {
+			rates[currency] = price.String()
+		}
+	}
+	return rates, nil
+}
+
+// CoinMarketCap 通过 CoinMarketCap quotes/latest 接口获取汇率。
+// 基础套餐每次请求只允许一个 convert 币种，因此逐个币种请求。
+type CoinMarketCap struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+// NewCoinMarketCap 创建 CoinMarketCap 汇率来源
+func NewCoinMarketCap(apiKey string) *CoinMarketCap {
+	return &CoinMarketCap{
+		BaseURL: "https://pro-api.coinmarketcap.com/v1",
+		APIKey:  apiKey,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// EthRates 获取 ETH 汇率，单个币种失败时跳过，全部失败时返回最后一个错误
+func (m *CoinMarketCap) EthRates(ctx context.Context, currencies []string) (map[string]string, error) {
+	headers := map[string]string{"X-CMC_PRO_API_KEY": m.APIKey}
+
+	rates := make(map[string]string, len(currencies))
+	var lastErr error
+	for _, currency := range currencies {
+		query := url.Values{}
+		query.Set("symbol", "ETH")
+		query.Set("convert", currency)
+
+		var body struct {
+			Data map[string]struct {
+				Quote map[string]struct {
+					Price json.Number `json:"price"`
+				} `json:"quote"`
+			} `json:"data"`
+		}
+		if err := getJSON(ctx, m.Client, m.BaseURL+"/cryptocurrency/quotes/latest?"+query.Encode(), headers, &body); err != nil {
+			log.Printf("Failed to fetch ETH/%s from coinmarketcap: %v", currency, err)
+			lastErr = err
+			continue
+		}
+		if quote, ok := body.Data["ETH"].Quote[currency]; ok && quote.Price != "" {
+			rates[currency] = quote.Price.String()
+		}
+	}
+
+	if len(rates) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("failed to fetch coinmarketcap prices: %w", lastErr)
+	}
+	return rates, nil
+}
+
+// getJSON 发送 GET 请求并解码 JSON 响应，数字保留为 json.Number 避免精度损失
+func getJSON(ctx context.Context, client *http.Client, rawURL string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	return dec.Decode(out)
+}
@@ -0,0 +1,221 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	sendBufferSize   = 64               // 每个连接的待发送消息上限，满了即断开慢客户端
+	maxTopicsPerConn = 50               // 单连接最多订阅的主题数
+	maxMessageSize   = 4096             // 客户端消息大小上限
+	writeWait        = 10 * time.Second // 单次写超时
+	pongWait         = 60 * time.Second // 等待 pong 的超时
+	pingPeriod       = pongWait * 9 / 10
+)
+
+// Client 一个 WebSocket 连接及其订阅
+type Client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	send   chan []byte
+	mu     sync.RWMutex
+	topics map[string]bool
+	sendMu sync.Mutex
+	closed bool
+}
+
+// clientMessage 客户端发来的订阅控制消息
+type clientMessage struct {
+	Action string   `json:"action"` // subscribe, unsubscribe
+	Topics []string `json:"topics"`
+}
+
+// Serve 接管已升级的连接，阻塞直到连接关闭
+func Serve(hub *Hub, conn *websocket.Conn) {
+	c := &Client{
+		hub:    hub,
+		conn:   conn,
+		send:   make(chan []byte, sendBufferSize),
+		topics: make(map[string]bool),
+	}
+
+	hub.register(c)
+	defer hub.unregister(c)
+
+	go c.writePump()
+	c.readPump()
+}
+
+// matches 是否订阅了任一主题
+func (c *Client) matches(topics []string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, topic := range topics {
+		if c.topics[topic] {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue 非阻塞写入发送队列，队列已满时断开该客户端
+func (c *Client) enqueue(msg []byte) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	select {
+	case c.send <- msg:
+	default:
+		log.Printf("Realtime client too slow, disconnecting %s", c.conn.RemoteAddr())
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// close 关闭发送队列，writePump 随后关闭连接
+func (c *Client) close() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// readPump 处理订阅消息
+func (c *Client) readPump() {
+	defer func() {
+		c.close()
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg clientMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("Realtime client read error: %v", err)
+			}
+			return
+		}
+
+		c.reply(c.handle(msg))
+	}
+}
+
+// handle 处理一条订阅控制消息并返回回执
+func (c *Client) handle(msg clientMessage) map[string]interface{} {
+	topics := make([]string, 0, len(msg.Topics))
+	for _, raw := range msg.Topics {
+		topic, err := normalizeTopic(raw)
+		if err != nil {
+			return map[string]interface{}{"type": "error", "message": err.Error()}
+		}
+		topics = append(topics, topic)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch msg.Action {
+	case "subscribe":
+		for _, topic := range topics {
+			if !c.topics[topic] && len(c.topics) >= maxTopicsPerConn {
+				return map[string]interface{}{
+					"type":    "error",
+					"message": fmt.Sprintf("at most %d topics per connection", maxTopicsPerConn),
+				}
+			}
+			c.topics[topic] = true
+		}
+	case "unsubscribe":
+		for _, topic := range topics {
+			delete(c.topics, topic)
+		}
+	default:
+		return map[string]interface{}{"type": "error", "message": "unknown action: " + msg.Action}
+	}
+
+	subscribed := make([]string, 0, len(c.topics))
+	for topic := range c.topics {
+		subscribed = append(subscribed, topic)
+	}
+	return map[string]interface{}{"type": "subscriptions", "topics": subscribed}
+}
+
+// reply 发送控制回执
+func (c *Client) reply(body map[string]interface{}) {
+	msg, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	c.enqueue(msg)
+}
+
+// writePump 将队列中的消息写到连接，并定期发送 ping
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// normalizeTopic 校验并规范化主题（listings:new、sales:new、address:<地址>、collection:<合约地址>）
+func normalizeTopic(raw string) (string, error) {
+	if raw == TopicNewListings || raw == TopicNewSales {
+		return raw, nil
+	}
+
+	kind, value, ok := strings.Cut(raw, ":")
+	if !ok || !common.IsHexAddress(value) {
+		return "", fmt.Errorf("invalid topic: %s", raw)
+	}
+
+	switch kind {
+	case "address":
+		return AddressTopic(value), nil
+	case "collection":
+		return CollectionTopic(value), nil
+	default:
+		return "", fmt.Errorf("unknown topic: %s", raw)
+	}
+}
@@ -0,0 +1,84 @@
+// Package realtime 提供进程内的 WebSocket 推送 hub
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+)
+
+// 事件类型
+const (
+	EventListing      = "listing.created"
+	EventSale         = "sale"
+	EventOfferCreated = "offer.created"
+	EventOutbid       = "auction.outbid"
+	EventFloorChanged = "floor_changed"
+)
+
+// 全局主题：不区分合约的新挂单与成交
+const (
+	TopicNewListings = "listings:new"
+	TopicNewSales    = "sales:new"
+)
+
+// AddressTopic 钱包地址主题：推送与该地址相关的成交、出价、被超价
+func AddressTopic(address string) string {
+	return "address:" + strings.ToLower(address)
+}
+
+// CollectionTopic 合约主题：推送该合约下的事件
+func CollectionTopic(contract string) string {
+	return "collection:" + strings.ToLower(contract)
+}
+
+// Event 推送给客户端的事件，Topics 决定哪些订阅者收到
+type Event struct {
+	Type   string      `json:"type"`
+	Topics []string    `json:"-"`
+	Data   interface{} `json:"data"`
+}
+
+// Hub 管理连接并按主题分发事件
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+}
+
+// NewHub 创建 hub
+func NewHub() *Hub {
+	return &Hub{clients: make(map[*Client]struct{})}
+}
+
+// register 注册客户端
+func (h *Hub) register(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+}
+
+// unregister 移除客户端
+func (h *Hub) unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, c)
+}
+
+// Publish 将事件发送给订阅了任一相关主题的客户端，不会因慢客户端阻塞
+func (h *Hub) Publish(event Event) {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal realtime event: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for c := range h.clients {
+		if c.matches(event.Topics) {
+			c.enqueue(msg)
+		}
+	}
+}
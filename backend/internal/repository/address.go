@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NormalizeAddress 地址的规范形式（小写十六进制），写入与查询 nfts/listings/transactions/offers/bids 时统一使用
+func NormalizeAddress(address string) string {
+	return strings.ToLower(address)
+}
+
+// NormalizeAddresses 将 NFT 的地址字段转为规范形式
+func (n *NFT) NormalizeAddresses() {
+	n.ContractAddress = NormalizeAddress(n.ContractAddress)
+	n.Owner = NormalizeAddress(n.Owner)
+	n.Creator = NormalizeAddress(n.Creator)
+}
+
+// BeforeSave 写入前规范化地址
+func (n *NFT) BeforeSave(*gorm.DB) error {
+	n.NormalizeAddresses()
+	return nil
+}
+
+// NormalizeAddresses 将挂单的地址字段转为规范形式
+func (l *Listing) NormalizeAddresses() {
+	l.NFTContract = NormalizeAddress(l.NFTContract)
+	l.Seller = NormalizeAddress(l.Seller)
+}
+
+// BeforeSave 写入前规范化地址
+func (l *Listing) BeforeSave(*gorm.DB) error {
+	l.NormalizeAddresses()
+	return nil
+}
+
+// NormalizeAddresses 将交易的地址字段转为规范形式
+func (t *Transaction) NormalizeAddresses() {
+	t.NFTContract = NormalizeAddress(t.NFTContract)
+	t.FromAddress = NormalizeAddress(t.FromAddress)
+	t.ToAddress = NormalizeAddress(t.ToAddress)
+}
+
+// BeforeSave 写入前规范化地址
+func (t *Transaction) BeforeSave(*gorm.DB) error {
+	t.NormalizeAddresses()
+	return nil
+}
+
+// NormalizeAddresses 将出价的地址字段转为规范形式
+func (o *Offer) NormalizeAddresses() {
+	o.NFTContract = NormalizeAddress(o.NFTContract)
+	o.Offerer = NormalizeAddress(o.Offerer)
+}
+
+// BeforeSave 写入前规范化地址
+func (o *Offer) BeforeSave(*gorm.DB) error {
+	o.NormalizeAddresses()
+	return nil
+}
+
+// NormalizeAddresses 将竞价的地址字段转为规范形式
+func (b *Bid) NormalizeAddresses() {
+	b.Bidder = NormalizeAddress(b.Bidder)
+}
+
+// BeforeSave 写入前规范化地址
+func (b *Bid) BeforeSave(*gorm.DB) error {
+	b.NormalizeAddresses()
+	return nil
+}
+
+// addressColumns 需要规范化的地址列；uniqueKey 为包含地址列的唯一约束，
+// 规范化后会相互冲突的行保持原样并报告，由人工合并
+var addressColumns = []struct {
+	table     string
+	columns   []string
+	uniqueKey []string
+}{
+	{"nfts", []string{"contract_address", "owner", "creator"}, []string{"contract_address", "token_id"}},
+	{"listings", []string{"nft_contract", "seller"}, nil},
+	{"transactions", []string{"nft_contract", "from_address", "to_address"}, nil},
+	{"offers", []string{"nft_contract", "offerer"}, nil},
+	{"bids", []string{"bidder"}, nil},
+}
+
+// AddressConflict 规范化后唯一键相同的一组行
+type AddressConflict struct {
+	Table string
+	Key   string // 规范化后的唯一键，各列以逗号分隔
+	IDs   []uint
+}
+
+// NormalizeAddressCase 将已有行中非小写的地址改写为规范形式，返回修改的行数及因唯一键冲突未修改的行；可重复执行
+func NormalizeAddressCase(db *gorm.DB) (int64, []AddressConflict, error) {
+	var total int64
+	var conflicts []AddressConflict
+	for _, t := range addressColumns {
+		sets := make([]string, len(t.columns))
+		conds := make([]string, len(t.columns))
+		for i, col := range t.columns {
+			sets[i] = fmt.Sprintf("%s = LOWER(%s)", col, col)
+			conds[i] = fmt.Sprintf("%s <> LOWER(%s)", col, col)
+		}
+		where := "(" + strings.Join(conds, " OR ") + ")"
+
+		if len(t.uniqueKey) > 0 {
+			found, err := findAddressConflicts(db, t.table, t.columns, t.uniqueKey)
+			if err != nil {
+				return total, conflicts, err
+			}
+			conflicts = append(conflicts, found...)
+			where += " AND NOT EXISTS (" + uniqueKeyMatch(t.table, t.columns, t.uniqueKey) + ")"
+		}
+
+		result := db.Exec(fmt.Sprintf("UPDATE %s SET %s WHERE %s", t.table, strings.Join(sets, ", "), where))
+		if result.Error != nil {
+			return total, conflicts, fmt.Errorf("failed to normalize %s addresses: %w", t.table, result.Error)
+		}
+		total += result.RowsAffected
+	}
+	return total, conflicts, nil
+}
+
+// normalizedKey 唯一键各列的规范化表达式，地址列取小写
+func normalizedKey(alias string, addressCols, key []string) []string {
+	exprs := make([]string, len(key))
+	for i, col := range key {
+		exprs[i] = alias + "." + col
+		for _, addr := range addressCols {
+			if addr == col {
+				exprs[i] = "LOWER(" + exprs[i] + ")"
+				break
+			}
+		}
+	}
+	return exprs
+}
+
+// uniqueKeyMatch 查找与当前行规范化后唯一键相同的其他行的子查询
+func uniqueKeyMatch(table string, addressCols, key []string) string {
+	self := normalizedKey(table, addressCols, key)
+	other := normalizedKey("d", addressCols, key)
+	conds := make([]string, len(key))
+	for i := range key {
+		conds[i] = other[i] + " = " + self[i]
+	}
+	return fmt.Sprintf("SELECT 1 FROM %s d WHERE %s AND d.id <> %s.id", table, strings.Join(conds, " AND "), table)
+}
+
+// findAddressConflicts 查找规范化后唯一键相同的行
+func findAddressConflicts(db *gorm.DB, table string, addressCols, key []string) ([]AddressConflict, error) {
+	exprs := normalizedKey(table, addressCols, key)
+	var rows []struct {
+		Key string `gorm:"column:key"`
+		IDs string `gorm:"column:ids"`
+	}
+	err := db.Raw(fmt.Sprintf(
+		"SELECT CONCAT_WS(',', %s) AS key, STRING_AGG(id::text, ',' ORDER BY id) AS ids FROM %s GROUP BY %s HAVING COUNT(*) > 1",
+		strings.Join(exprs, ", "), table, strings.Join(exprs, ", "))).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s address conflicts: %w", table, err)
+	}
+
+	conflicts := make([]AddressConflict, len(rows))
+	for i, row := range rows {
+		conflicts[i] = AddressConflict{Table: table, Key: row.Key}
+		for _, id := range strings.Split(row.IDs, ",") {
+			if n, err := strconv.ParseUint(id, 10, 64); err == nil {
+				conflicts[i].IDs = append(conflicts[i].IDs, uint(n))
+			}
+		}
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	mixedContract = "0xAbCdEf0123456789abcdef0123456789ABCDEF01"
+	lowerContract = "0xabcdef0123456789abcdef0123456789abcdef01"
+	mixedUser     = "0x00000000000000000000000000000000000000AA"
+	lowerUser     = "0x00000000000000000000000000000000000000aa"
+)
+
+// statement 一次 dry run 生成的 SQL 及参数
+type statement struct {
+	sql  string
+	vars []interface{}
+}
+
+// dryRunDB 只生成 SQL 不连接数据库的 Postgres 连接，返回的切片记录每条语句
+func dryRunDB(t *testing.T) (*gorm.DB, *[]statement) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open dry run db: %v", err)
+	}
+
+	var captured []statement
+	record := func(tx *gorm.DB) {
+		captured = append(captured, statement{sql: tx.Statement.SQL.String(), vars: tx.Statement.Vars})
+	}
+	db.Callback().Query().After("gorm:query").Register("test:record", record)
+	db.Callback().Create().After("gorm:create").Register("test:record", record)
+	db.Callback().Raw().After("gorm:raw").Register("test:record", record)
+	db.Callback().Row().After("gorm:row").Register("test:record", record)
+	return db, &captured
+}
+
+func TestNormalizeAddresses(t *testing.T) {
+	nft := &NFT{ContractAddress: mixedContract, Owner: mixedUser, Creator: mixedUser}
+	listing := &Listing{NFTContract: mixedContract, Seller: mixedUser}
+	tx := &Transaction{NFTContract: mixedContract, FromAddress: mixedUser, ToAddress: mixedUser}
+	offer := &Offer{NFTContract: mixedContract, Offerer: mixedUser}
+	bid := &Bid{Bidder: mixedUser}
+
+	nft.NormalizeAddresses()
+	listing.NormalizeAddresses()
+	tx.NormalizeAddresses()
+	offer.NormalizeAddresses()
+	bid.NormalizeAddresses()
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"nft contract", nft.ContractAddress, lowerContract},
+		{"nft owner", nft.Owner, lowerUser},
+		{"nft creator", nft.Creator, lowerUser},
+		{"listing contract", listing.NFTContract, lowerContract},
+		{"listing seller", listing.Seller, lowerUser},
+		{"transaction contract", tx.NFTContract, lowerContract},
+		{"transaction from", tx.FromAddress, lowerUser},
+		{"transaction to", tx.ToAddress, lowerUser},
+		{"offer contract", offer.NFTContract, lowerContract},
+		{"offer offerer", offer.Offerer, lowerUser},
+		{"bid bidder", bid.Bidder, lowerUser},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestOfferCreateNormalizesAddresses(t *testing.T) {
+	db, captured := dryRunDB(t)
+
+	if err := NewOfferRepository(db).Create(&Offer{NFTContract: mixedContract, TokenID: "1", Offerer: mixedUser, Price: "1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(*captured) != 1 {
+		t.Fatalf("captured %d statements, want 1", len(*captured))
+	}
+	for _, v := range (*captured)[0].vars {
+		if s, ok := v.(string); ok && s != strings.ToLower(s) {
+			t.Errorf("insert var %q is not lowercase", s)
+		}
+	}
+}
+
+// 地址查询须直接比较列（可用索引），参数为规范化后的小写地址
+func TestAddressQueriesUsePlainColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		query func(db *gorm.DB) error
+	}{
+		{"nft by contract and token", func(db *gorm.DB) error {
+			_, err := NewNFTRepository(db).GetByContractAndToken(mixedContract, "1")
+			return err
+		}},
+		{"listings by seller", func(db *gorm.DB) error {
+			_, err := NewListingRepository(db).GetBySeller(mixedUser)
+			return err
+		}},
+		{"best offer", func(db *gorm.DB) error {
+			_, err := NewOfferRepository(db).GetBestActive(mixedContract, "1")
+			return err
+		}},
+		{"offers by token", func(db *gorm.DB) error {
+			_, _, err := NewOfferRepository(db).GetByToken(mixedContract, "1", OfferFilter{}, 1, 10)
+			return err
+		}},
+		{"offers by bidder", func(db *gorm.DB) error {
+			_, _, err := NewOfferRepository(db).GetByBidder(mixedUser, OfferFilter{}, 1, 10)
+			return err
+		}},
+		{"active listings offer summary", func(db *gorm.DB) error {
+			_, _, err := NewListingRepository(db).GetActiveListings(ListingFilter{}, 1, 10, false)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, captured := dryRunDB(t)
+			// dry run 不返回行，First 报 ErrRecordNotFound 属正常
+			_ = tt.query(db)
+
+			if len(*captured) == 0 {
+				t.Fatal("no statements captured")
+			}
+			for _, stmt := range *captured {
+				if strings.Contains(stmt.sql, "LOWER(") {
+					t.Errorf("query wraps a column in LOWER(): %s", stmt.sql)
+				}
+				for _, v := range stmt.vars {
+					if s, ok := v.(string); ok && strings.HasPrefix(s, "0x") && s != strings.ToLower(s) {
+						t.Errorf("address var %q is not lowercase in %s", s, stmt.sql)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUniqueKeyMatch(t *testing.T) {
+	got := uniqueKeyMatch("nfts", []string{"contract_address", "owner", "creator"}, []string{"contract_address", "token_id"})
+	want := "SELECT 1 FROM nfts d WHERE LOWER(d.contract_address) = LOWER(nfts.contract_address) AND d.token_id = nfts.token_id AND d.id <> nfts.id"
+	if got != want {
+		t.Errorf("uniqueKeyMatch =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// 活跃挂单列表与总数都不含已过截止时间的拍卖，其他状态不受影响
+func TestGetActiveListingsExcludesEndedAuctions(t *testing.T) {
+	for _, status := range []string{"", "active", "sold"} {
+		db, captured := dryRunDB(t)
+		if _, _, err := NewListingRepository(db).GetActiveListings(ListingFilter{Status: status}, 1, 10, true); err != nil {
+			t.Fatalf("GetActiveListings(%q): %v", status, err)
+		}
+		if len(*captured) != 2 {
+			t.Fatalf("captured %d statements, want count and page", len(*captured))
+		}
+		for _, stmt := range *captured {
+			filtered := strings.Contains(stmt.sql, "auction_end_at >")
+			if want := status != "sold"; filtered != want {
+				t.Errorf("status %q: auction end filter = %v, want %v in %s", status, filtered, want, stmt.sql)
+			}
+		}
+	}
+}
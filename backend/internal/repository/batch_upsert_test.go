@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestBatchUpsertStatements(t *testing.T) {
+	listings := make([]Listing, 250)
+	for i := range listings {
+		listings[i] = Listing{ItemID: uint64(i + 1), NFTContract: mixedContract, TokenID: fmt.Sprint(i + 1), Seller: mixedUser, Price: "1"}
+	}
+	txs := make([]Transaction, 5)
+	for i := range txs {
+		txs[i] = Transaction{TxHash: "0xaa", LogIndex: i, TxType: "sale"}
+	}
+
+	tests := []struct {
+		name       string
+		upsert     func(db *gorm.DB) error
+		wantStmts  int
+		wantClause string
+	}{
+		{"listings", func(db *gorm.DB) error {
+			return NewListingRepository(db).BatchUpsert(listings, 100)
+		}, 3, `ON CONFLICT ("item_id") DO NOTHING`},
+		{"transactions", func(db *gorm.DB) error {
+			return NewTransactionRepository(db, "").BatchUpsert(txs, 2)
+		}, 3, `ON CONFLICT DO NOTHING`},
+		{"empty", func(db *gorm.DB) error {
+			return NewListingRepository(db).BatchUpsert(nil, 100)
+		}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, captured := dryRunDB(t)
+			if err := tt.upsert(db); err != nil {
+				t.Fatalf("BatchUpsert: %v", err)
+			}
+
+			if len(*captured) != tt.wantStmts {
+				t.Fatalf("got %d statements, want %d", len(*captured), tt.wantStmts)
+			}
+			for _, stmt := range *captured {
+				if !strings.HasPrefix(stmt.sql, "INSERT INTO") || !strings.Contains(stmt.sql, tt.wantClause) {
+					t.Errorf("statement missing %q: %s", tt.wantClause, stmt.sql)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 挂单类型
+const (
+	ListingTypeFixedPrice = "fixed_price" // 一口价
+	ListingTypeAuction    = "auction"     // 英式拍卖，Price 为起拍价
+)
+
+// Bid 拍卖挂单的竞价记录
+type Bid struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ListingID     uint      `gorm:"index;not null" json:"listing_id"`
+	Bidder        string    `gorm:"index;not null" json:"bidder"`
+	Amount        string    `gorm:"not null" json:"amount"`
+	AmountNumeric *string   `gorm:"type:numeric(78,0)" json:"-"` // 用于取最高竞价
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (Bid) TableName() string {
+	return "bids"
+}
+
+// BidRepository 竞价仓储
+type BidRepository struct {
+	db *gorm.DB
+}
+
+// NewBidRepository 创建竞价仓储
+func NewBidRepository(db *gorm.DB) *BidRepository {
+	return &BidRepository{db: db}
+}
+
+// PlaceBid 在锁定挂单行的事务中读取挂单与当前最高竞价并调用 check，check 通过后写入竞价。
+// 同一挂单的并发竞价因行锁串行执行，不会出现两个出价都基于同一最高价通过校验
+func (r *BidRepository) PlaceBid(bid *Bid, check func(listing *Listing, highest *Bid) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var listing Listing
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&listing, bid.ListingID).Error
+		if err != nil {
+			return err
+		}
+
+		highest, err := highestBid(tx, bid.ListingID)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+		if err := check(&listing, highest); err != nil {
+			return err
+		}
+		return tx.Create(bid).Error
+	})
+}
+
+// GetHighest 获取挂单当前最高竞价，没有竞价时返回 ErrRecordNotFound
+func (r *BidRepository) GetHighest(listingID uint) (*Bid, error) {
+	return highestBid(r.db, listingID)
+}
+
+// highestBid 金额最高的竞价，金额相同时先出价者优先
+func highestBid(db *gorm.DB, listingID uint) (*Bid, error) {
+	var bid Bid
+	err := db.Where("listing_id = ?", listingID).
+		Order("amount_numeric DESC NULLS LAST, id").
+		First(&bid).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bid, nil
+}
+
+// GetByListing 分页获取挂单的竞价记录，最新优先
+func (r *BidRepository) GetByListing(listingID uint, page, pageSize int) ([]Bid, int64, error) {
+	var bids []Bid
+	var total int64
+
+	query := r.db.Model(&Bid{}).Where("listing_id = ?", listingID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&bids).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return bids, total, nil
+}
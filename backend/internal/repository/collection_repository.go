@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Collection NFT 系列模型
+type Collection struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	ContractAddress    string    `gorm:"uniqueIndex;not null" json:"contract_address"`
+	Name               string    `gorm:"not null" json:"name"`
+	Symbol             string    `json:"symbol"`
+	Description        string    `json:"description"`
+	LogoURL            string    `json:"logo_url"`
+	CreatorAddress     string    `gorm:"index" json:"creator_address"`
+	FloorPrice         string    `gorm:"default:'0'" json:"floor_price"`
+	TotalVolume        string    `gorm:"default:'0'" json:"total_volume"`
+	IsVerified         bool      `gorm:"index;default:false" json:"is_verified"`
+	Status             string    `gorm:"default:'active'" json:"status"`                  // active, inactive, suspended
+	FeeBpsOverride     *int64    `json:"fee_bps_override"`                                // 覆盖全局平台费率（基点），为空时使用 PlatformFeeBps
+	MinBidIncrementBps *int64    `json:"min_bid_increment_bps"`                           // 覆盖全局最小加价比例（基点）
+	MinBidIncrementWei *string   `gorm:"type:numeric(78,0)" json:"min_bid_increment_wei"` // 覆盖全局最小加价绝对值（wei）
+	ImageCDNBase       *string   `json:"image_cdn_base"`                                  // IPFS 图片改写到的 CDN 前缀，为空时使用全局网关
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Collection) TableName() string {
+	return "collections"
+}
+
+// TrendingCollection 热门系列（来自物化视图 mv_trending_collections）
+type TrendingCollection struct {
+	NFTContract string    `json:"nft_contract"`
+	Name        string    `json:"name"`
+	Volume24h   string    `gorm:"column:volume_24h" json:"volume_24h"`
+	Sales24h    int64     `gorm:"column:sales_24h" json:"sales_24h"`
+	Volume7d    string    `gorm:"column:volume_7d" json:"volume_7d"`
+	Sales7d     int64     `gorm:"column:sales_7d" json:"sales_7d"`
+	Volume30d   string    `gorm:"column:volume_30d" json:"volume_30d"`
+	Sales30d    int64     `gorm:"column:sales_30d" json:"sales_30d"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+// trendingOrderColumns 时间窗口对应的排序列
+var trendingOrderColumns = map[string]string{
+	"24h": "volume_24h",
+	"7d":  "volume_7d",
+	"30d": "volume_30d",
+}
+
+// CollectionRepository 系列仓储
+type CollectionRepository struct {
+	db *gorm.DB
+}
+
+// NewCollectionRepository 创建系列仓储
+func NewCollectionRepository(db *gorm.DB) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+// GetByAddress 根据合约地址获取系列
+func (r *CollectionRepository) GetByAddress(contractAddress string) (*Collection, error) {
+	var collection Collection
+	err := r.db.Where("contract_address = ?", contractAddress).First(&collection).Error
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// GetByAddresses 批量获取系列（合约地址不区分大小写），未登记的合约不返回
+func (r *CollectionRepository) GetByAddresses(contractAddresses []string) ([]Collection, error) {
+	var collections []Collection
+	if len(contractAddresses) == 0 {
+		return collections, nil
+	}
+	err := r.db.Where("LOWER(contract_address) IN ?", lowerAll(contractAddresses)).Find(&collections).Error
+	return collections, err
+}
+
+// lowerAll 转为小写，用于按小写地址的 IN 查询
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
+
+// GetTopCollections 按时间窗口成交额获取热门系列（读取物化视图）
+func (r *CollectionRepository) GetTopCollections(window string, limit int) ([]TrendingCollection, error) {
+	column, ok := trendingOrderColumns[window]
+	if !ok {
+		return nil, fmt.Errorf("unsupported window: %s", window)
+	}
+
+	var results []TrendingCollection
+	err := r.db.Table("mv_trending_collections").
+		Order(column + " DESC").
+		Order("nft_contract").
+		Limit(limit).
+		Find(&results).Error
+	return results, err
+}
+
+// RefreshTrending 刷新热门系列物化视图（不阻塞读取）
+func (r *CollectionRepository) RefreshTrending() error {
+	return r.db.Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY mv_trending_collections").Error
+}
+
+// UpdateBidIncrement 设置或清除（为 nil）系列最小加价覆盖
+func (r *CollectionRepository) UpdateBidIncrement(contractAddress string, bps *int64, wei *string) error {
+	result := r.db.Model(&Collection{}).
+		Where("contract_address = ?", contractAddress).
+		Updates(map[string]interface{}{
+			"min_bid_increment_bps": bps,
+			"min_bid_increment_wei": wei,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateFeeOverride 设置或清除（feeBps 为 nil）系列费率覆盖
+func (r *CollectionRepository) UpdateFeeOverride(contractAddress string, feeBps *int64) error {
+	result := r.db.Model(&Collection{}).
+		Where("contract_address = ?", contractAddress).
+		Update("fee_bps_override", feeBps)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateImageCDNBase 设置或清除（base 为 nil）系列图片 CDN 前缀
+func (r *CollectionRepository) UpdateImageCDNBase(contractAddress string, base *string) error {
+	result := r.db.Model(&Collection{}).
+		Where("contract_address = ?", contractAddress).
+		Update("image_cdn_base", base)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 天数作为绑定参数传入，不能出现在字符串字面量中
+func TestGetDailyVolumeBindsDays(t *testing.T) {
+	db, captured := dryRunDB(t)
+	// dry run 不支持 Scan，只检查生成的语句
+	_, _ = NewTransactionRepository(db, "").GetDailyVolume(7)
+
+	if len(*captured) != 1 {
+		t.Fatalf("got %d statements, want 1", len(*captured))
+	}
+	stmt := (*captured)[0]
+	if strings.Contains(stmt.sql, "INTERVAL '") {
+		t.Errorf("interval is a string literal: %s", stmt.sql)
+	}
+	if !strings.Contains(stmt.sql, "make_interval(days => $1)") {
+		t.Errorf("statement missing bound interval: %s", stmt.sql)
+	}
+	if want := []interface{}{7}; !reflect.DeepEqual(stmt.vars, want) {
+		t.Errorf("vars = %v, want %v", stmt.vars, want)
+	}
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/xiaomait/backend/internal/pii"
+)
+
+var (
+	piiMu      sync.RWMutex
+	piiKeyring *pii.Keyring
+)
+
+// SetPIIKeyring 设置个人信息字段的加密密钥环，应在启动时、访问数据库前调用
+func SetPIIKeyring(k *pii.Keyring) {
+	piiMu.Lock()
+	defer piiMu.Unlock()
+	piiKeyring = k
+}
+
+// currentPIIKeyring 获取加密密钥环，未配置时返回 pii.ErrNoKeyring
+func currentPIIKeyring() (*pii.Keyring, error) {
+	piiMu.RLock()
+	defer piiMu.RUnlock()
+	if piiKeyring == nil {
+		return nil, pii.ErrNoKeyring
+	}
+	return piiKeyring, nil
+}
+
+// EncryptedString 加密存储的个人信息字段：写入时以当前密钥加密，读取时按版本解密。
+// 格式化输出（日志等）时显示为 [REDACTED]，避免泄露明文
+type EncryptedString string
+
+// Value 加密后写入，空值存为 NULL
+func (s EncryptedString) Value() (driver.Value, error) {
+	if s == "" {
+		return nil, nil
+	}
+	keyring, err := currentPIIKeyring()
+	if err != nil {
+		return nil, err
+	}
+	return keyring.Encrypt(string(s))
+}
+
+// Scan 读取并解密；加密上线前写入的明文原样返回，由 RotateEmailKeys 加密
+func (s *EncryptedString) Scan(value interface{}) error {
+	var stored string
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted field", value)
+	}
+	if stored == "" {
+		*s = ""
+		return nil
+	}
+	if !pii.IsCiphertext(stored) {
+		*s = EncryptedString(stored)
+		return nil
+	}
+
+	keyring, err := currentPIIKeyring()
+	if err != nil {
+		return err
+	}
+	plaintext, err := keyring.Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// String 屏蔽明文
+func (s EncryptedString) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// GoString 屏蔽明文（%#v）
+func (s EncryptedString) GoString() string {
+	return s.String()
+}
+
+// Plaintext 返回明文，仅用于实际发送通知等必要场景
+func (s EncryptedString) Plaintext() string {
+	return string(s)
+}
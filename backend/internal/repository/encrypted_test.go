@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xiaomait/backend/internal/pii"
+)
+
+// usePIIKeyring 在测试期间设置密钥环，keys 的版本号即密钥首字节
+func usePIIKeyring(t *testing.T, current uint32, versions ...uint32) *pii.Keyring {
+	t.Helper()
+
+	keys := make(map[uint32][]byte, len(versions))
+	for _, version := range versions {
+		keys[version] = bytes.Repeat([]byte{byte(version)}, pii.KeySize)
+	}
+	keyring, err := pii.NewKeyring(current, keys)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	SetPIIKeyring(keyring)
+	t.Cleanup(func() { SetPIIKeyring(nil) })
+	return keyring
+}
+
+func TestEncryptedStringRoundTrip(t *testing.T) {
+	usePIIKeyring(t, 1, 1)
+
+	stored, err := EncryptedString("alice@example.com").Value()
+	if err != nil {
+		t.Fatalf("Value: %v", err)
+	}
+	if s, _ := stored.(string); !strings.HasPrefix(s, "v1:") || strings.Contains(s, "alice") {
+		t.Fatalf("Value() = %v, want v1 ciphertext", stored)
+	}
+
+	var got EncryptedString
+	if err := got.Scan(stored); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if got.Plaintext() != "alice@example.com" {
+		t.Errorf("Scan() = %q, want alice@example.com", got.Plaintext())
+	}
+}
+
+// 加密上线前写入的明文邮箱原样读出，而不是整行读取失败
+func TestEncryptedStringScanLegacyPlaintext(t *testing.T) {
+	usePIIKeyring(t, 1, 1)
+
+	for _, value := range []interface{}{"bob@example.com", []byte("bob@example.com")} {
+		var got EncryptedString
+		if err := got.Scan(value); err != nil {
+			t.Fatalf("Scan(%T): %v", value, err)
+		}
+		if got.Plaintext() != "bob@example.com" {
+			t.Errorf("Scan(%T) = %q, want bob@example.com", value, got.Plaintext())
+		}
+	}
+}
+
+// 轮换：旧密钥的密文可解密，重新写入时使用当前密钥
+func TestEncryptedStringReencryptsWithCurrentKey(t *testing.T) {
+	old := usePIIKeyring(t, 1, 1)
+	stored, err := old.Encrypt("carol@example.com")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	usePIIKeyring(t, 2, 1, 2)
+	var email EncryptedString
+	if err := email.Scan(stored); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	rotated, err := email.Value()
+	if err != nil {
+		t.Fatalf("Value: %v", err)
+	}
+	if s, _ := rotated.(string); !strings.HasPrefix(s, "v2:") {
+		t.Errorf("Value() = %v, want v2 ciphertext", rotated)
+	}
+}
+
+// 轮换查询选出非当前版本的行，其中包括没有版本前缀的明文
+func TestRotateEmailKeysSelectsStaleRows(t *testing.T) {
+	usePIIKeyring(t, 2, 1, 2)
+	db, captured := dryRunDB(t)
+
+	if _, err := NewUserRepository(db).RotateEmailKeys(100); err != nil {
+		t.Fatalf("RotateEmailKeys: %v", err)
+	}
+	if len(*captured) == 0 {
+		t.Fatal("no query recorded")
+	}
+	stmt := (*captured)[0]
+	if !strings.Contains(stmt.sql, "email NOT LIKE $1") {
+		t.Errorf("sql = %s, want NOT LIKE filter", stmt.sql)
+	}
+	if len(stmt.vars) == 0 || stmt.vars[0] != "v2:%" {
+		t.Errorf("vars = %v, want [v2:%% ...]", stmt.vars)
+	}
+}
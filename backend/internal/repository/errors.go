@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// IsNotFound 判断错误是否为记录不存在（GORM 与内存实现均返回 gorm.ErrRecordNotFound）
+func IsNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FailedEvent 事件监听处理失败的事件（死信）
+type FailedEvent struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	EventName       string    `gorm:"not null" json:"event_name"`
+	TransactionHash string    `gorm:"index" json:"transaction_hash"`
+	LogIndex        uint      `json:"log_index"`
+	BlockNumber     uint64    `json:"block_number"`
+	EventData       *string   `gorm:"type:jsonb" json:"event_data"` // 解码后的事件 JSON，用于人工重放
+	ErrorMessage    string    `gorm:"not null" json:"error_message"`
+	CreatedAt       time.Time `gorm:"index" json:"created_at"`
+}
+
+// FailedEventRepository 死信事件仓储
+type FailedEventRepository struct {
+	db *gorm.DB
+}
+
+// NewFailedEventRepository 创建死信事件仓储
+func NewFailedEventRepository(db *gorm.DB) *FailedEventRepository {
+	return &FailedEventRepository{db: db}
+}
+
+// Create 记录失败事件
+func (r *FailedEventRepository) Create(event *FailedEvent) error {
+	return r.db.Create(event).Error
+}
+
+// CountSince 统计 since 之后记录的失败事件数
+func (r *FailedEventRepository) CountSince(since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&FailedEvent{}).Where("created_at >= ?", since).Count(&count).Error
+	return count, err
+}
+
+// RecentSince 获取 since 之后最近的 limit 条失败事件
+func (r *FailedEventRepository) RecentSince(since time.Time, limit int) ([]FailedEvent, error) {
+	var events []FailedEvent
+	err := r.db.Where("created_at >= ?", since).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// HolderBalance 持有者及其持有的 Token 数量
+type HolderBalance struct {
+	Owner      string `json:"owner"`
+	TokenCount int64  `json:"token_count"`
+}
+
+// HolderRepository 持有者快照仓储
+type HolderRepository struct {
+	db *gorm.DB
+}
+
+// NewHolderRepository 创建持有者快照仓储
+func NewHolderRepository(db *gorm.DB) *HolderRepository {
+	return &HolderRepository{db: db}
+}
+
+// StreamHolders 逐行回调合约当前持有者快照（取自 nfts 表），按持有数量倒序。
+// 交易表只记录市场成交，没有铸造与转移记录，无法重建历史区块的持有关系
+func (r *HolderRepository) StreamHolders(contractAddress string, fn func(HolderBalance) error) error {
+	query := r.db.Raw(`
+		SELECT owner, COUNT(*) AS token_count
+		FROM nfts
+		WHERE contract_address = ? AND status = 'active'
+		GROUP BY owner
+		ORDER BY token_count DESC, owner`, NormalizeAddress(contractAddress))
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var holder HolderBalance
+		if err := r.db.ScanRows(rows, &holder); err != nil {
+			return err
+		}
+		if err := fn(holder); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// DefaultMaxResults 单次非分页查询返回行数的默认上限
+const DefaultMaxResults = 1000
+
+var maxResults atomic.Int64
+
+func init() {
+	maxResults.Store(DefaultMaxResults)
+}
+
+// SetMaxResults 设置单次非分页查询返回行数的硬上限，应在启动时调用；n <= 0 时忽略
+func SetMaxResults(n int) {
+	if n > 0 {
+		maxResults.Store(int64(n))
+	}
+}
+
+// capLimit 将调用方请求的 limit 收紧到硬上限以内，limit <= 0 表示不限（即取硬上限）
+func capLimit(method string, limit int) int {
+	max := int(maxResults.Load())
+	if limit <= 0 || limit > max {
+		if limit > max {
+			log.Printf("%s: requested limit %d exceeds max results %d, capping", method, limit, max)
+		}
+		return max
+	}
+	return limit
+}
+
+// logIfCapped 结果行数达到硬上限时记录日志（结果可能被截断）
+func logIfCapped(method string, n int) {
+	if max := int(maxResults.Load()); n >= max {
+		log.Printf("%s: result truncated at max results %d", method, max)
+	}
+}
@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Listing 挂单模型
@@ -14,12 +18,67 @@ type Listing struct {
 	TokenID     string    `gorm:"index;not null" json:"token_id"`
 	Seller     string    `gorm:"index;not null" json:"seller"`
 	Price       string    `gorm:"not null" json:"price"`
-	Status      string    `gorm:"index;not null;default:'active'" json:"status"` // active, sold, cancelled
+	Status      string    `gorm:"index;not null;default:'active'" json:"status"` // active, pending, sold, cancelled, invalid
+	Unverified  bool      `gorm:"not null;default:false" json:"unverified"`      // 熔断期间未经链上校验创建
 	TxHash      string    `gorm:"index" json:"tx_hash"`
 	ListedAt    time.Time `gorm:"not null" json:"listed_at"`
 	SoldAt      *time.Time `json:"sold_at,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// 解码事件所用的市场合约 ABI 版本（经 API 创建且未收到事件时为空）
+	ContractVersion string `json:"contract_version"`
+
+	// 创建事件所在区块的哈希，用于确认前检测链重组（经 API 创建且未收到事件时为空）
+	BlockHash string `json:"block_hash,omitempty"`
+
+	// 挂单类型：fixed_price 一口价，auction 英式拍卖（Price 为起拍价）
+	ListingType string `gorm:"not null;default:'fixed_price'" json:"listing_type"`
+
+	// 拍卖截止时间与保留价（wei），一口价挂单为空
+	AuctionEndAt *time.Time `json:"auction_end_at,omitempty"`
+	ReservePrice *string    `json:"reserve_price,omitempty"`
+
+	// 取消时间（早于该字段写入的已取消挂单为空，按 updated_at 近似）
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	// 归档时间：已售/已取消超过保留期后由归档任务标记，默认查询不返回
+	ArchivedAt *time.Time `gorm:"index" json:"archived_at,omitempty"`
+
+	// 出价汇总（只读，由 withOfferSummary 查询填充）
+	BestOfferWei       *string    `gorm:"->;-:migration" json:"best_offer_wei"`
+	BestOfferExpiresAt *time.Time `gorm:"->;-:migration" json:"best_offer_expires_at"`
+	OfferCount         int64      `gorm:"->;-:migration" json:"offer_count"`
+
+	// 竞价汇总（只读，由 withOfferSummary 查询填充，一口价挂单为空/0）
+	HighestBidWei *string `gorm:"->;-:migration" json:"highest_bid_wei"`
+	BidCount      int64   `gorm:"->;-:migration" json:"bid_count"`
+}
+
+// withOfferSummary 附加当前最高有效出价及有效出价数量，以及拍卖挂单的最高竞价与竞价次数
+func withOfferSummary(db *gorm.DB) *gorm.DB {
+	return db.Select(`listings.*,
+			best_offer.offer_price AS best_offer_wei,
+			best_offer.offer_expires_at AS best_offer_expires_at,
+			(SELECT MAX(b.amount_numeric)::text FROM bids b
+				WHERE b.listing_id = listings.id) AS highest_bid_wei,
+			(SELECT COUNT(*) FROM bids b
+				WHERE b.listing_id = listings.id) AS bid_count,
+			(SELECT COUNT(*) FROM offers o
+				WHERE o.nft_contract = listings.nft_contract
+				AND o.token_id = listings.token_id
+				AND o.status = 'active'
+				AND o.expires_at > NOW()) AS offer_count`).
+		Joins(`LEFT JOIN LATERAL (
+			SELECT o.price AS offer_price, o.expires_at AS offer_expires_at
+			FROM offers o
+			WHERE o.nft_contract = listings.nft_contract
+			AND o.token_id = listings.token_id
+			AND o.status = 'active'
+			AND o.expires_at > NOW()
+			ORDER BY o.price_numeric DESC
+			LIMIT 1
+		) best_offer ON TRUE`)
 }
 
 // ListingRepository 挂单仓储
@@ -48,7 +107,7 @@ func (r *ListingRepository) CreateIfNotExists(listing *Listing) error {
 // GetByID 根据 ID 获取挂单
 func (r *ListingRepository) GetByID(id uint) (*Listing, error) {
 	var listing Listing
-	err := r.db.First(&listing, id).Error
+	err := r.db.Scopes(withOfferSummary).First(&listing, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -65,25 +124,90 @@ func (r *ListingRepository) GetByItemID(itemID uint64) (*Listing, error) {
 	return &listing, nil
 }
 
-// GetActiveListings 获取活跃挂单（分页）
-func (r *ListingRepository) GetActiveListings(page, pageSize int) ([]Listing, int64, error) {
+// GetByTxHash 根据创建交易哈希获取挂单
+func (r *ListingRepository) GetByTxHash(txHash string) (*Listing, error) {
+	var listing Listing
+	err := r.db.Scopes(withOfferSummary).Where("tx_hash = ?", txHash).First(&listing).Error
+	if err != nil {
+		return nil, err
+	}
+	return &listing, nil
+}
+
+// 挂单列表排序方式
+const (
+	ListingSortRecent    = "recent"     // 最新挂单优先（默认）
+	ListingSortOldest    = "oldest"     // 最早挂单优先
+	ListingSortPriceAsc  = "price_asc"  // 价格从低到高
+	ListingSortPriceDesc = "price_desc" // 价格从高到低
+)
+
+// ListingFilter 挂单列表查询条件
+type ListingFilter struct {
+	Status string // 为空时为 active
+	Sort   string // 为空时为 recent
+}
+
+// listingOrder 排序方式对应的 ORDER BY。价格按数值比较，避免字符串比较时 "9" 排在 "10" 之后
+func listingOrder(sort string) string {
+	switch sort {
+	case ListingSortOldest:
+		return "listings.listed_at ASC, listings.id"
+	case ListingSortPriceAsc:
+		return "CAST(listings.price AS NUMERIC) ASC, listings.id"
+	case ListingSortPriceDesc:
+		return "CAST(listings.price AS NUMERIC) DESC, listings.id"
+	default:
+		return "listings.listed_at DESC, listings.id DESC"
+	}
+}
+
+// GetActiveListings 按状态（默认 active）分页获取挂单，已归档挂单不返回，活跃挂单中不含已过截止时间的拍卖；
+// withCount 为 false 时不查询总数（见 findPage）
+func (r *ListingRepository) GetActiveListings(filter ListingFilter, page, pageSize int, withCount bool) ([]Listing, int64, error) {
+	status := filter.Status
+	if status == "" {
+		status = "active"
+	}
+
+	count := r.db.Model(&Listing{}).Where("status = ? AND archived_at IS NULL", status)
+	data := r.db.Scopes(withOfferSummary).
+		Where("listings.status = ? AND listings.archived_at IS NULL", status).
+		Order(listingOrder(filter.Sort))
+	if status == "active" {
+		now := time.Now()
+		count = count.Where("(auction_end_at IS NULL OR auction_end_at > ?)", now)
+		data = data.Where("(listings.auction_end_at IS NULL OR listings.auction_end_at > ?)", now)
+	}
+
+	return findPage[Listing](count, data, page, pageSize, withCount)
+}
+
+// GetActiveAsOf 获取在 at 时刻处于活跃状态的挂单（分页，含已归档挂单），nftContract 为空时不过滤合约。
+// 没有取消时间的已取消挂单以 updated_at 作为取消时间
+func (r *ListingRepository) GetActiveAsOf(at time.Time, nftContract string, page, pageSize int) ([]Listing, int64, error) {
 	var listings []Listing
 	var total int64
 
 	offset := (page - 1) * pageSize
 
-	// 计算总数
-	if err := r.db.Model(&Listing{}).Where("status = ?", "active").Count(&total).Error; err != nil {
+	query := r.db.Model(&Listing{}).
+		Where("status IN ?", []string{"active", "sold", "cancelled"}).
+		Where("listed_at <= ?", at).
+		Where("(sold_at IS NULL OR sold_at > ?)", at).
+		Where("(status <> 'cancelled' OR COALESCE(cancelled_at, updated_at) > ?)", at)
+	if nftContract != "" {
+		query = query.Where("nft_contract = ?", NormalizeAddress(nftContract))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// 获取数据
-	err := r.db.Where("status = ?", "active").
-		Order("listed_at DESC").
+	err := query.Order("listed_at DESC").
 		Offset(offset).
 		Limit(pageSize).
 		Find(&listings).Error
-
 	if err != nil {
 		return nil, 0, err
 	}
@@ -91,27 +215,36 @@ func (r *ListingRepository) GetActiveListings(page, pageSize int) ([]Listing, in
 	return listings, total, nil
 }
 
-// GetBySeller 根据卖家获取挂单
+// GetBySeller 根据卖家获取挂单（最多返回 SetMaxResults 设置的行数）
 func (r *ListingRepository) GetBySeller(seller string) ([]Listing, error) {
 	var listings []Listing
-	err := r.db.Where("seller = ?", seller).Order("listed_at DESC").Find(&listings).Error
-	return listings, err
+	err := r.db.Where("seller = ?", NormalizeAddress(seller)).Order("listed_at DESC").Limit(capLimit("GetBySeller", 0)).Find(&listings).Error
+	if err != nil {
+		return nil, err
+	}
+	logIfCapped("GetBySeller", len(listings))
+	return listings, nil
 }
 
-// GetBySellerPaginated 根据卖家获取挂单（分页）
-func (r *ListingRepository) GetBySellerPaginated(seller string, page, pageSize int) ([]Listing, int64, error) {
+// GetBySellerPaginated 根据卖家获取挂单（分页），includeArchived 为 false 时不返回已归档挂单
+func (r *ListingRepository) GetBySellerPaginated(seller string, includeArchived bool, page, pageSize int) ([]Listing, int64, error) {
 	var listings []Listing
 	var total int64
 
 	offset := (page - 1) * pageSize
 
+	query := r.db.Model(&Listing{}).Where("seller = ?", NormalizeAddress(seller))
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+
 	// 计算总数
-	if err := r.db.Model(&Listing{}).Where("seller = ?", seller).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// 获取数据
-	err := r.db.Where("seller = ?", seller).
+	err := query.Scopes(withOfferSummary).
 		Order("listed_at DESC").
 		Offset(offset).
 		Limit(pageSize).
@@ -124,20 +257,163 @@ func (r *ListingRepository) GetBySellerPaginated(seller string, page, pageSize i
 	return listings, total, nil
 }
 
+// GetActiveBySeller 获取卖家的活跃挂单（地址不区分大小写），最多 limit 条
+func (r *ListingRepository) GetActiveBySeller(seller string, limit int) ([]Listing, error) {
+	var listings []Listing
+	err := r.db.Where("seller = ? AND status = ?", NormalizeAddress(seller), "active").
+		Order("listed_at DESC").
+		Limit(limit).
+		Find(&listings).Error
+	return listings, err
+}
+
 // UpdateStatus 更新状态
 func (r *ListingRepository) UpdateStatus(id uint, status string) error {
 	updates := map[string]interface{}{
 		"status": status,
 	}
 
-	if status == "sold" {
+	switch status {
+	case "sold":
 		now := time.Now()
 		updates["sold_at"] = &now
+	case "cancelled":
+		now := time.Now()
+		updates["cancelled_at"] = &now
 	}
 
 	return r.db.Model(&Listing{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// BatchUpsert 批量写入挂单（每条语句 batchSize 行），item_id 已存在时跳过，用于回填
+func (r *ListingRepository) BatchUpsert(listings []Listing, batchSize int) error {
+	if len(listings) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "item_id"}},
+		DoNothing: true,
+	}).CreateInBatches(&listings, batchSize).Error
+}
+
+// GetByItemIDs 根据合约 item_id 批量获取挂单
+func (r *ListingRepository) GetByItemIDs(itemIDs []uint64) ([]Listing, error) {
+	var listings []Listing
+	if len(itemIDs) == 0 {
+		return listings, nil
+	}
+	err := r.db.Where("item_id IN ?", itemIDs).Find(&listings).Error
+	return listings, err
+}
+
+// TokenRef 合约地址与 Token ID
+type TokenRef struct {
+	NFTContract string
+	TokenID     string
+}
+
+// GetActiveByTokens 批量获取 Token 的活跃挂单（合约地址不区分大小写），同一 Token 按挂单时间倒序
+func (r *ListingRepository) GetActiveByTokens(tokens []TokenRef) ([]Listing, error) {
+	var listings []Listing
+	if len(tokens) == 0 {
+		return listings, nil
+	}
+
+	pairs := make([][]interface{}, len(tokens))
+	for i, token := range tokens {
+		pairs[i] = []interface{}{NormalizeAddress(token.NFTContract), token.TokenID}
+	}
+
+	err := r.db.Where("status = ?", "active").
+		Where("(nft_contract, token_id) IN ?", pairs).
+		Order("listed_at DESC").
+		Find(&listings).Error
+	return listings, err
+}
+
+// MarkSold 将挂单标记为已售，soldAt 为成交区块时间
+func (r *ListingRepository) MarkSold(id uint, soldAt time.Time) error {
+	return r.db.Model(&Listing{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":  "sold",
+		"sold_at": soldAt,
+	}).Error
+}
+
+// MarkSoldByItemIDs 批量将挂单标记为已售，soldAt 为各 item_id 的成交区块时间
+func (r *ListingRepository) MarkSoldByItemIDs(soldAt map[uint64]time.Time) error {
+	if len(soldAt) == 0 {
+		return nil
+	}
+
+	itemIDs := make([]uint64, 0, len(soldAt))
+	var expr strings.Builder
+	args := make([]interface{}, 0, len(soldAt)*2)
+	expr.WriteString("CASE item_id")
+	for itemID, at := range soldAt {
+		itemIDs = append(itemIDs, itemID)
+		expr.WriteString(" WHEN ? THEN CAST(? AS TIMESTAMPTZ)")
+		args = append(args, itemID, at)
+	}
+	expr.WriteString(" END")
+
+	return r.db.Model(&Listing{}).Where("item_id IN ?", itemIDs).Updates(map[string]interface{}{
+		"status":  "sold",
+		"sold_at": gorm.Expr(expr.String(), args...),
+	}).Error
+}
+
+// ArchiveSettled 将成交/取消时间早于 before 的已售、已取消挂单标记为已归档，单次最多 limit 条，返回归档数量。
+// 已取消挂单没有取消时间，以最后更新时间代替
+func (r *ListingRepository) ArchiveSettled(before time.Time, limit int) (int64, error) {
+	batch := r.db.Model(&Listing{}).
+		Select("id").
+		Where("status IN ? AND archived_at IS NULL", []string{"sold", "cancelled"}).
+		Where("COALESCE(sold_at, cancelled_at, updated_at) < ?", before).
+		Order("id").
+		Limit(limit)
+
+	result := r.db.Model(&Listing{}).Where("id IN (?)", batch).UpdateColumn("archived_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// GetUnverified 获取待链上校验的挂单（按创建顺序）
+func (r *ListingRepository) GetUnverified(limit int) ([]Listing, error) {
+	var listings []Listing
+	err := r.db.Where("unverified = ? AND status IN ?", true, []string{"active", "pending"}).
+		Order("id").
+		Limit(limit).
+		Find(&listings).Error
+	return listings, err
+}
+
+// MarkVerified 标记挂单已通过链上校验，排队中的挂单随之上架
+func (r *ListingRepository) MarkVerified(id uint) error {
+	return r.db.Model(&Listing{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"unverified": false,
+		"status":     gorm.Expr("CASE WHEN status = 'pending' THEN 'active' ELSE status END"),
+	}).Error
+}
+
+// RevertSale 成交被链重组撤销后，将已售挂单恢复为活跃
+func (r *ListingRepository) RevertSale(id uint) error {
+	return r.db.Model(&Listing{}).Where("id = ? AND status = ?", id, "sold").Updates(map[string]interface{}{
+		"status":  "active",
+		"sold_at": nil,
+	}).Error
+}
+
+// SetBlockHash 补记挂单创建事件所在区块的哈希
+func (r *ListingRepository) SetBlockHash(id uint, blockHash string) error {
+	return r.db.Model(&Listing{}).Where("id = ?", id).Update("block_hash", blockHash).Error
+}
+
+// SetContractVersion 补记挂单对应的市场合约 ABI 版本（仅在尚未记录时）
+func (r *ListingRepository) SetContractVersion(id uint, version string) error {
+	return r.db.Model(&Listing{}).
+		Where("id = ? AND (contract_version IS NULL OR contract_version = '')", id).
+		Update("contract_version", version).Error
+}
+
 // CountActiveListings 统计活跃挂单数量
 func (r *ListingRepository) CountActiveListings() (int64, error) {
 	var count int64
@@ -145,6 +421,15 @@ func (r *ListingRepository) CountActiveListings() (int64, error) {
 	return count, err
 }
 
+// CountActiveListingsByContract 统计合约的活跃挂单数量（合约地址不区分大小写）
+func (r *ListingRepository) CountActiveListingsByContract(nftContract string) (int64, error) {
+	var count int64
+	err := r.db.Model(&Listing{}).
+		Where("status = ? AND nft_contract = ?", "active", NormalizeAddress(nftContract)).
+		Count(&count).Error
+	return count, err
+}
+
 // CountTotalListings 统计总挂单数量
 func (r *ListingRepository) CountTotalListings() (int64, error) {
 	var count int64
@@ -224,6 +509,72 @@ func (r *ListingRepository) GetMaxPrice() (string, error) {
 	return result.Max, nil
 }
 
+// GetMinPriceByContract 获取合约活跃挂单的最低价格（地板价，合约地址不区分大小写），无活跃挂单时为 0
+func (r *ListingRepository) GetMinPriceByContract(nftContract string) (string, error) {
+	var result struct {
+		Min string
+	}
+
+	err := r.db.Model(&Listing{}).
+		Select("COALESCE(MIN(CAST(price AS NUMERIC)), 0) as min").
+		Where("status = ? AND nft_contract = ?", "active", NormalizeAddress(nftContract)).
+		Scan(&result).Error
+
+	if err != nil {
+		return "0", err
+	}
+
+	return result.Min, nil
+}
+
+// CollectionFloor 合约当前地板价（活跃挂单最低价，wei）
+type CollectionFloor struct {
+	NFTContract string
+	Floor       string
+}
+
+// GetFloorPrices 获取所有有活跃挂单的合约的地板价（合约地址小写）
+func (r *ListingRepository) GetFloorPrices() ([]CollectionFloor, error) {
+	var floors []CollectionFloor
+	err := r.db.Model(&Listing{}).
+		Select("LOWER(nft_contract) AS nft_contract, MIN(CAST(price AS NUMERIC)) AS floor").
+		Where("status = ?", "active").
+		Group("LOWER(nft_contract)").
+		Scan(&floors).Error
+	return floors, err
+}
+
+// ContractListingStats 合约的活跃挂单数及地板价（wei，合约地址小写）
+type ContractListingStats struct {
+	NFTContract    string
+	ActiveListings int64
+	Floor          string
+}
+
+// GetListingStatsByContracts 按合约分组统计活跃挂单数及地板价（合约地址不区分大小写），
+// 没有活跃挂单的合约不返回
+func (r *ListingRepository) GetListingStatsByContracts(nftContracts []string) ([]ContractListingStats, error) {
+	var stats []ContractListingStats
+	if len(nftContracts) == 0 {
+		return stats, nil
+	}
+	err := r.db.Model(&Listing{}).
+		Select("LOWER(nft_contract) AS nft_contract, COUNT(*) AS active_listings, MIN(CAST(price AS NUMERIC)) AS floor").
+		Where("status = ? AND nft_contract IN ?", "active", lowerAll(nftContracts)).
+		Group("LOWER(nft_contract)").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// GetActivePricesByContract 获取合约所有活跃挂单的价格（wei，合约地址不区分大小写）
+func (r *ListingRepository) GetActivePricesByContract(nftContract string) ([]string, error) {
+	var prices []string
+	err := r.db.Model(&Listing{}).
+		Where("status = ? AND nft_contract = ?", "active", NormalizeAddress(nftContract)).
+		Pluck("price", &prices).Error
+	return prices, err
+}
+
 // GetRecentListings 获取最近挂单
 func (r *ListingRepository) GetRecentListings(limit int) ([]Listing, error) {
 	var listings []Listing
@@ -234,41 +585,64 @@ func (r *ListingRepository) GetRecentListings(limit int) ([]Listing, error) {
 	return listings, err
 }
 
-// SearchListings 搜索挂单
-func (r *ListingRepository) SearchListings(nftContract string, minPrice, maxPrice string, page, pageSize int) ([]Listing, int64, error) {
-	var listings []Listing
-	var total int64
-
-	offset := (page - 1) * pageSize
-
-	query := r.db.Model(&Listing{}).Where("status = ?", "active")
+// ListingSearchFilter 挂单搜索条件，空字段表示不过滤
+type ListingSearchFilter struct {
+	NFTContract string
+	Seller      string // 小写地址
+	MinPrice    string
+	MaxPrice    string
+}
 
-	if nftContract != "" {
-		query = query.Where("nft_contract = ?", nftContract)
+// NormalizePrices 校验价格区间（非负整数 wei，支持科学计数法）并改写为十进制整数，
+// 避免非法输入进入 CAST(price AS NUMERIC) 比较
+func (f *ListingSearchFilter) NormalizePrices() error {
+	min, err := normalizeWeiBound("min_price", &f.MinPrice)
+	if err != nil {
+		return err
 	}
+	max, err := normalizeWeiBound("max_price", &f.MaxPrice)
+	if err != nil {
+		return err
+	}
+	if min != nil && max != nil && min.Cmp(max) > 0 {
+		return fmt.Errorf("min_price must not exceed max_price")
+	}
+	return nil
+}
 
-	if minPrice != "" {
-		query = query.Where("CAST(price AS NUMERIC) >= ?", minPrice)
+// normalizeWeiBound 解析并改写单个价格边界，为空时返回 nil
+func normalizeWeiBound(name string, value *string) (*big.Int, error) {
+	if *value == "" {
+		return nil, nil
+	}
+	wei, err := parseWeiValue(*value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
 	}
+	*value = wei.String()
+	return wei, nil
+}
+
+// SearchListings 搜索挂单，withCount 为 false 时不查询总数（见 findPage）
+func (r *ListingRepository) SearchListings(filter ListingSearchFilter, page, pageSize int, withCount bool) ([]Listing, int64, error) {
+	query := r.db.Model(&Listing{}).Where("status = ?", "active")
 
-	if maxPrice != "" {
-		query = query.Where("CAST(price AS NUMERIC) <= ?", maxPrice)
+	if filter.NFTContract != "" {
+		query = query.Where("nft_contract = ?", NormalizeAddress(filter.NFTContract))
 	}
 
-	// 计算总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if filter.Seller != "" {
+		query = query.Where("seller = ?", NormalizeAddress(filter.Seller))
 	}
 
-	// 获取数据
-	err := query.Order("listed_at DESC").
-		Offset(offset).
-		Limit(pageSize).
-		Find(&listings).Error
+	if filter.MinPrice != "" {
+		query = query.Where("CAST(price AS NUMERIC) >= ?", filter.MinPrice)
+	}
 
-	if err != nil {
-		return nil, 0, err
+	if filter.MaxPrice != "" {
+		query = query.Where("CAST(price AS NUMERIC) <= ?", filter.MaxPrice)
 	}
 
-	return listings, total, nil
+	data := query.Session(&gorm.Session{}).Scopes(withOfferSummary).Order("listed_at DESC")
+	return findPage[Listing](query, data, page, pageSize, withCount)
 }
\ No newline at end of file
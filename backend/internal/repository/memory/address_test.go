@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+const (
+	mixedContract = "0xAbCdEf0123456789abcdef0123456789ABCDEF01"
+	lowerContract = "0xabcdef0123456789abcdef0123456789abcdef01"
+	upperContract = "0xABCDEF0123456789ABCDEF0123456789ABCDEF01"
+	mixedUser     = "0x00000000000000000000000000000000000000Aa"
+	upperUser     = "0x00000000000000000000000000000000000000AA"
+)
+
+// 写入的地址与查询的地址大小写不同也应命中，且存储为小写
+func TestMixedCaseAddressQueries(t *testing.T) {
+	nfts := NewNFTStore()
+	if err := nfts.Create(&repository.NFT{ContractAddress: mixedContract, TokenID: "1", Owner: mixedUser, Creator: mixedUser, Status: "active"}); err != nil {
+		t.Fatalf("create nft: %v", err)
+	}
+	listings := NewListingStore()
+	if err := listings.Create(&repository.Listing{ItemID: 1, NFTContract: mixedContract, TokenID: "1", Seller: mixedUser, Price: "1", Status: "active"}); err != nil {
+		t.Fatalf("create listing: %v", err)
+	}
+	offers := NewOfferStore()
+	if err := offers.Create(&repository.Offer{NFTContract: mixedContract, TokenID: "1", Offerer: mixedUser, Price: "1", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("create offer: %v", err)
+	}
+
+	for _, contract := range []string{lowerContract, upperContract, mixedContract} {
+		for _, user := range []string{upperUser, mixedUser} {
+			t.Run(contract+"/"+user, func(t *testing.T) {
+				nft, err := nfts.GetByContractAndToken(contract, "1")
+				if err != nil {
+					t.Fatalf("GetByContractAndToken: %v", err)
+				}
+				if nft.ContractAddress != lowerContract {
+					t.Errorf("stored nft contract = %s, want %s", nft.ContractAddress, lowerContract)
+				}
+				if _, total, _ := nfts.GetByOwner(user, 1, 10, false); total != 1 {
+					t.Errorf("GetByOwner total = %d, want 1", total)
+				}
+
+				if _, total, _ := listings.GetBySellerPaginated(user, false, 1, 10); total != 1 {
+					t.Errorf("GetBySellerPaginated total = %d, want 1", total)
+				}
+				if found, _ := listings.GetActiveBySeller(user, 10); len(found) != 1 {
+					t.Errorf("GetActiveBySeller = %d listings, want 1", len(found))
+				}
+
+				offer, err := offers.GetBestActive(contract, "1")
+				if err != nil {
+					t.Fatalf("GetBestActive: %v", err)
+				}
+				if offer.NFTContract != lowerContract {
+					t.Errorf("stored offer contract = %s, want %s", offer.NFTContract, lowerContract)
+				}
+				if _, total, _ := offers.GetByToken(contract, "1", repository.OfferFilter{}, 1, 10); total != 1 {
+					t.Errorf("GetByToken total = %d, want 1", total)
+				}
+				if _, total, _ := offers.GetByBidder(user, repository.OfferFilter{}, 1, 10); total != 1 {
+					t.Errorf("GetByBidder total = %d, want 1", total)
+				}
+			})
+		}
+	}
+}
+
+// 竞价方以规范形式存储，与其他表的地址一致
+func TestPlaceBidNormalizesBidder(t *testing.T) {
+	listings := NewListingStore()
+	if err := listings.Create(&repository.Listing{ItemID: 1, NFTContract: mixedContract, TokenID: "1", Seller: mixedUser, Price: "1", Status: "active"}); err != nil {
+		t.Fatalf("create listing: %v", err)
+	}
+	bids := NewBidStore(listings)
+	bid := &repository.Bid{ListingID: 1, Bidder: upperUser, Amount: "1"}
+	if err := bids.PlaceBid(bid, func(*repository.Listing, *repository.Bid) error { return nil }); err != nil {
+		t.Fatalf("PlaceBid: %v", err)
+	}
+
+	highest, err := bids.GetHighest(1)
+	if err != nil {
+		t.Fatalf("GetHighest: %v", err)
+	}
+	if want := repository.NormalizeAddress(upperUser); highest.Bidder != want {
+		t.Errorf("stored bidder = %s, want %s", highest.Bidder, want)
+	}
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// newListings 生成 item_id 从 first 开始的 n 条挂单
+func newListings(first uint64, n int) []repository.Listing {
+	listings := make([]repository.Listing, n)
+	for i := range listings {
+		listings[i] = repository.Listing{
+			ItemID:      first + uint64(i),
+			NFTContract: mixedContract,
+			TokenID:     fmt.Sprint(first + uint64(i)),
+			Seller:      mixedUser,
+			Price:       "1000",
+			Status:      "active",
+		}
+	}
+	return listings
+}
+
+// item_id 冲突时保留已有行（ON CONFLICT (item_id) DO NOTHING）
+func TestListingBatchUpsertOnConflict(t *testing.T) {
+	store := NewListingStore()
+	existing := repository.Listing{ItemID: 1, NFTContract: mixedContract, TokenID: "1", Seller: mixedUser, Price: "100", Status: "sold"}
+	if err := store.Create(&existing); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	batch := newListings(1, 3)
+	if err := store.BatchUpsert(batch, 2); err != nil {
+		t.Fatalf("BatchUpsert: %v", err)
+	}
+	// 重复执行不产生新行
+	if err := store.BatchUpsert(newListings(1, 3), 2); err != nil {
+		t.Fatalf("second BatchUpsert: %v", err)
+	}
+
+	tests := []struct {
+		itemID     uint64
+		wantPrice  string
+		wantStatus string
+	}{
+		{1, "100", "sold"},
+		{2, "1000", "active"},
+		{3, "1000", "active"},
+	}
+	for _, tt := range tests {
+		listing, err := store.GetByItemID(tt.itemID)
+		if err != nil {
+			t.Fatalf("GetByItemID(%d): %v", tt.itemID, err)
+		}
+		if listing.Price != tt.wantPrice || listing.Status != tt.wantStatus {
+			t.Errorf("item %d = (%s, %s), want (%s, %s)", tt.itemID, listing.Price, listing.Status, tt.wantPrice, tt.wantStatus)
+		}
+	}
+
+	if all, _ := store.GetByItemIDs([]uint64{1, 2, 3, 4}); len(all) != 3 {
+		t.Errorf("stored %d listings, want 3", len(all))
+	}
+}
+
+// (tx_hash, log_index) 冲突时保留已有行，同一交易的其他日志照常写入
+func TestTransactionBatchUpsertOnConflict(t *testing.T) {
+	store := NewTransactionStore()
+	if err := store.Create(&repository.Transaction{TxHash: "0xaa", LogIndex: 0, TxType: "sale", Value: "1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	batch := []repository.Transaction{
+		{TxHash: "0xaa", LogIndex: 0, TxType: "sale", Value: "2"},
+		{TxHash: "0xaa", LogIndex: 1, TxType: "sale", Value: "3"},
+		{TxHash: "0xbb", LogIndex: 0, TxType: "sale", Value: "4"},
+		{TxHash: "0xbb", LogIndex: 0, TxType: "sale", Value: "5"},
+	}
+	if err := store.BatchUpsert(batch, 2); err != nil {
+		t.Fatalf("BatchUpsert: %v", err)
+	}
+
+	all, total, err := store.GetAll(1, 10, true)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if total != 3 {
+		t.Fatalf("stored %d transactions, want 3", total)
+	}
+
+	want := map[string]string{"0xaa/0": "1", "0xaa/1": "3", "0xbb/0": "4"}
+	for _, tx := range all {
+		key := fmt.Sprintf("%s/%d", tx.TxHash, tx.LogIndex)
+		if tx.Value != want[key] {
+			t.Errorf("%s value = %s, want %s", key, tx.Value, want[key])
+		}
+	}
+}
+
+func BenchmarkInsertSingle(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		store := NewListingStore()
+		for _, listing := range newListings(1, 500) {
+			listing := listing
+			if err := store.CreateIfNotExists(&listing); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkBatchUpsert(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		store := NewListingStore()
+		if err := store.BatchUpsert(newListings(1, 500), 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// BidStore 竞价内存存储
+type BidStore struct {
+	mu       sync.Mutex
+	listings repository.ListingStore
+	bids     []repository.Bid
+}
+
+var _ repository.BidStore = (*BidStore)(nil)
+
+// NewBidStore 创建竞价内存存储，listings 用于 PlaceBid 读取挂单
+func NewBidStore(listings repository.ListingStore) *BidStore {
+	return &BidStore{listings: listings}
+}
+
+// PlaceBid 串行读取挂单与当前最高竞价并调用 check，通过后写入竞价
+func (s *BidStore) PlaceBid(bid *repository.Bid, check func(listing *repository.Listing, highest *repository.Bid) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listing, err := s.listings.GetByID(bid.ListingID)
+	if err != nil {
+		return err
+	}
+	if err := check(listing, s.highest(bid.ListingID)); err != nil {
+		return err
+	}
+
+	bid.NormalizeAddresses()
+	bid.ID = uint(len(s.bids) + 1)
+	bid.CreatedAt = time.Now()
+	s.bids = append(s.bids, *bid)
+	return nil
+}
+
+// GetHighest 获取挂单当前最高竞价
+func (s *BidStore) GetHighest(listingID uint) (*repository.Bid, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	highest := s.highest(listingID)
+	if highest == nil {
+		return nil, errNotFound
+	}
+	return highest, nil
+}
+
+// GetByListing 分页获取挂单的竞价记录，最新优先
+func (s *BidStore) GetByListing(listingID uint, page, pageSize int) ([]repository.Bid, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := []repository.Bid{}
+	for i := len(s.bids) - 1; i >= 0; i-- {
+		if s.bids[i].ListingID == listingID {
+			matches = append(matches, s.bids[i])
+		}
+	}
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// highest 金额最高的竞价（金额相同时先出价者优先），调用方需持有锁
+func (s *BidStore) highest(listingID uint) *repository.Bid {
+	var best *repository.Bid
+	for i := range s.bids {
+		bid := &s.bids[i]
+		if bid.ListingID != listingID {
+			continue
+		}
+		if best == nil || parseWei(bid.Amount).Cmp(parseWei(best.Amount)) > 0 {
+			best = bid
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	result := *best
+	return &result
+}
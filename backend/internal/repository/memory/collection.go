@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// CollectionStore 系列内存存储
+type CollectionStore struct {
+	mu          sync.RWMutex
+	collections map[string]*repository.Collection
+	trending    []repository.TrendingCollection
+	nextID      uint
+}
+
+var _ repository.CollectionStore = (*CollectionStore)(nil)
+
+// NewCollectionStore 创建系列内存存储
+func NewCollectionStore() *CollectionStore {
+	return &CollectionStore{collections: make(map[string]*repository.Collection)}
+}
+
+// Put 写入系列（测试数据准备用）
+func (s *CollectionStore) Put(collection *repository.Collection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.collections[collection.ContractAddress]; ok {
+		collection.ID = existing.ID
+	} else {
+		s.nextID++
+		collection.ID = s.nextID
+	}
+	collection.UpdatedAt = time.Now()
+
+	stored := *collection
+	s.collections[collection.ContractAddress] = &stored
+}
+
+// GetByAddress 根据合约地址获取系列
+func (s *CollectionStore) GetByAddress(contractAddress string) (*repository.Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collection, ok := s.collections[contractAddress]
+	if !ok {
+		return nil, errNotFound
+	}
+	result := *collection
+	return &result, nil
+}
+
+// GetByAddresses 批量获取系列（合约地址不区分大小写）
+func (s *CollectionStore) GetByAddresses(contractAddresses []string) ([]repository.Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wanted := lowerSet(contractAddresses)
+	result := []repository.Collection{}
+	for address, collection := range s.collections {
+		if wanted[strings.ToLower(address)] {
+			result = append(result, *collection)
+		}
+	}
+	return result, nil
+}
+
+// PutTrending 设置热门系列快照（测试数据准备用）
+func (s *CollectionStore) PutTrending(trending []repository.TrendingCollection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trending = append([]repository.TrendingCollection(nil), trending...)
+}
+
+// GetTopCollections 按时间窗口成交额获取热门系列
+func (s *CollectionStore) GetTopCollections(window string, limit int) ([]repository.TrendingCollection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	volume := map[string]func(t repository.TrendingCollection) string{
+		"24h": func(t repository.TrendingCollection) string { return t.Volume24h },
+		"7d":  func(t repository.TrendingCollection) string { return t.Volume7d },
+		"30d": func(t repository.TrendingCollection) string { return t.Volume30d },
+	}[window]
+	if volume == nil {
+		return nil, fmt.Errorf("unsupported window: %s", window)
+	}
+
+	results := append([]repository.TrendingCollection(nil), s.trending...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return parseWei(volume(results[i])).Cmp(parseWei(volume(results[j]))) > 0
+	})
+	return paginate(results, 1, limit), nil
+}
+
+// RefreshTrending 内存实现无需刷新
+func (s *CollectionStore) RefreshTrending() error {
+	return nil
+}
+
+// UpdateBidIncrement 设置或清除系列最小加价覆盖
+func (s *CollectionStore) UpdateBidIncrement(contractAddress string, bps *int64, wei *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, ok := s.collections[contractAddress]
+	if !ok {
+		return errNotFound
+	}
+	collection.MinBidIncrementBps = bps
+	collection.MinBidIncrementWei = wei
+	collection.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateFeeOverride 设置或清除系列费率覆盖
+func (s *CollectionStore) UpdateFeeOverride(contractAddress string, feeBps *int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, ok := s.collections[contractAddress]
+	if !ok {
+		return errNotFound
+	}
+	collection.FeeBpsOverride = feeBps
+	collection.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateImageCDNBase 设置或清除系列图片 CDN 前缀
+func (s *CollectionStore) UpdateImageCDNBase(contractAddress string, base *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, ok := s.collections[contractAddress]
+	if !ok {
+		return errNotFound
+	}
+	collection.ImageCDNBase = base
+	collection.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+func TestGetDailyVolume(t *testing.T) {
+	store := NewTransactionStore()
+	today := utcDate(time.Now())
+
+	sales := []repository.Transaction{
+		{TxHash: "0x01", TxType: "sale", Status: "confirmed", ValueNumeric: "100", BlockTimestamp: today.Add(time.Minute)},
+		{TxHash: "0x02", TxType: "sale", Status: "confirmed", ValueNumeric: "250", BlockTimestamp: today},
+		// 前一天 23:30 UTC 归入前一天
+		{TxHash: "0x03", TxType: "sale", Status: "confirmed", ValueNumeric: "40", BlockTimestamp: today.Add(-30 * time.Minute)},
+		{TxHash: "0x04", TxType: "sale", Status: "confirmed", ValueNumeric: "7", BlockTimestamp: today.AddDate(0, 0, -3)},
+		// 以下不计入：窗口外、未确认、非成交
+		{TxHash: "0x05", TxType: "sale", Status: "confirmed", ValueNumeric: "1000", BlockTimestamp: today.AddDate(0, 0, -10)},
+		{TxHash: "0x06", TxType: "sale", Status: "pending", ValueNumeric: "1000", BlockTimestamp: today},
+		{TxHash: "0x07", TxType: "sale", Status: "failed", ValueNumeric: "1000", BlockTimestamp: today},
+		{TxHash: "0x08", TxType: "listing", Status: "confirmed", ValueNumeric: "1000", BlockTimestamp: today},
+	}
+	for i := range sales {
+		if err := store.Create(&sales[i]); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		days int
+		want []repository.DailyVolume
+	}{
+		{"week", 7, []repository.DailyVolume{
+			{Date: today, TxCount: 2, Volume: "350"},
+			{Date: today.AddDate(0, 0, -1), TxCount: 1, Volume: "40"},
+			{Date: today.AddDate(0, 0, -3), TxCount: 1, Volume: "7"},
+		}},
+		{"two days", 2, []repository.DailyVolume{
+			{Date: today, TxCount: 2, Volume: "350"},
+			{Date: today.AddDate(0, 0, -1), TxCount: 1, Volume: "40"},
+		}},
+		{"month", 30, []repository.DailyVolume{
+			{Date: today, TxCount: 2, Volume: "350"},
+			{Date: today.AddDate(0, 0, -1), TxCount: 1, Volume: "40"},
+			{Date: today.AddDate(0, 0, -3), TxCount: 1, Volume: "7"},
+			{Date: today.AddDate(0, 0, -10), TxCount: 1, Volume: "1000"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.GetDailyVolume(tt.days)
+			if err != nil {
+				t.Fatalf("GetDailyVolume: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetDailyVolume(%d) = %+v, want %+v", tt.days, got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Date.Equal(tt.want[i].Date) || got[i].TxCount != tt.want[i].TxCount || got[i].Volume != tt.want[i].Volume {
+					t.Errorf("row %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetDailyVolumeEmpty(t *testing.T) {
+	got, err := NewTransactionStore().GetDailyVolume(7)
+	if err != nil {
+		t.Fatalf("GetDailyVolume: %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("GetDailyVolume() = %#v, want empty slice", got)
+	}
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// FailedEventStore 死信事件内存存储
+type FailedEventStore struct {
+	mu     sync.RWMutex
+	events []repository.FailedEvent
+}
+
+var _ repository.FailedEventStore = (*FailedEventStore)(nil)
+
+// NewFailedEventStore 创建死信事件内存存储
+func NewFailedEventStore() *FailedEventStore {
+	return &FailedEventStore{}
+}
+
+// Create 记录失败事件
+func (s *FailedEventStore) Create(event *repository.FailedEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = uint(len(s.events) + 1)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	s.events = append(s.events, *event)
+	return nil
+}
+
+// CountSince 统计 since 之后记录的失败事件数
+func (s *FailedEventStore) CountSince(since time.Time) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, event := range s.events {
+		if !event.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// RecentSince 获取 since 之后最近的 limit 条失败事件
+func (s *FailedEventStore) RecentSince(since time.Time, limit int) ([]repository.FailedEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []repository.FailedEvent{}
+	for i := len(s.events) - 1; i >= 0 && len(result) < limit; i-- {
+		if !s.events[i].CreatedAt.Before(since) {
+			result = append(result, s.events[i])
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// HolderStore 持有者快照内存实现，基于 NFT 内存存储计算
+type HolderStore struct {
+	nfts *NFTStore
+}
+
+var _ repository.HolderStore = (*HolderStore)(nil)
+
+// NewHolderStore 创建持有者快照内存实现
+func NewHolderStore(nfts *NFTStore) *HolderStore {
+	return &HolderStore{nfts: nfts}
+}
+
+// StreamHolders 逐个回调合约当前持有者快照
+func (s *HolderStore) StreamHolders(contractAddress string, fn func(repository.HolderBalance) error) error {
+	counts := make(map[string]int64)
+	for _, n := range s.nfts.filter(func(n *repository.NFT) bool {
+		return strings.EqualFold(n.ContractAddress, contractAddress) && n.Status == "active"
+	}) {
+		counts[n.Owner]++
+	}
+
+	holders := make([]repository.HolderBalance, 0, len(counts))
+	for owner, count := range counts {
+		holders = append(holders, repository.HolderBalance{Owner: owner, TokenCount: count})
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if holders[i].TokenCount != holders[j].TokenCount {
+			return holders[i].TokenCount > holders[j].TokenCount
+		}
+		return holders[i].Owner < holders[j].Owner
+	})
+
+	for _, holder := range holders {
+		if err := fn(holder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
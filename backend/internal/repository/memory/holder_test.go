@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// 只铸造、从未成交的 Token 没有任何交易记录，快照仍须计入其持有者
+func TestStreamHoldersIncludesMintedUnsoldTokens(t *testing.T) {
+	const (
+		minter = "0x00000000000000000000000000000000000000a1"
+		buyer  = "0x00000000000000000000000000000000000000b1"
+	)
+	nfts := NewNFTStore()
+	for _, n := range []repository.NFT{
+		{ContractAddress: mixedContract, TokenID: "1", Owner: minter, Status: "active"}, // 铸造后未出售
+		{ContractAddress: mixedContract, TokenID: "2", Owner: buyer, Status: "active"},
+		{ContractAddress: mixedContract, TokenID: "3", Owner: buyer, Status: "active"},
+		{ContractAddress: mixedContract, TokenID: "4", Owner: minter, Status: "burned"},
+		{ContractAddress: "0x00000000000000000000000000000000000000c2", TokenID: "1", Owner: minter, Status: "active"},
+	} {
+		n := n
+		if err := nfts.Create(&n); err != nil {
+			t.Fatalf("create nft: %v", err)
+		}
+	}
+
+	var got []repository.HolderBalance
+	err := NewHolderStore(nfts).StreamHolders(upperContract, func(holder repository.HolderBalance) error {
+		got = append(got, holder)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamHolders: %v", err)
+	}
+
+	want := []repository.HolderBalance{
+		{Owner: buyer, TokenCount: 2},
+		{Owner: minter, TokenCount: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StreamHolders() = %+v, want %+v", got, want)
+	}
+}
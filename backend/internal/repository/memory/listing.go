@@ -0,0 +1,533 @@
+package memory
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// ListingStore 挂单内存存储
+type ListingStore struct {
+	mu       sync.RWMutex
+	listings map[uint]*repository.Listing
+	nextID   uint
+}
+
+var _ repository.ListingStore = (*ListingStore)(nil)
+
+// NewListingStore 创建挂单内存存储
+func NewListingStore() *ListingStore {
+	return &ListingStore{listings: make(map[uint]*repository.Listing)}
+}
+
+// Create 创建挂单
+func (s *ListingStore) Create(listing *repository.Listing) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.insert(listing)
+	return nil
+}
+
+// CreateIfNotExists 创建挂单（如果 item_id 不存在）
+func (s *ListingStore) CreateIfNotExists(listing *repository.Listing) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.listings {
+		if existing.ItemID == listing.ItemID {
+			*listing = *existing
+			return nil
+		}
+	}
+
+	s.insert(listing)
+	return nil
+}
+
+// GetByID 根据 ID 获取挂单
+func (s *ListingStore) GetByID(id uint) (*repository.Listing, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	listing, ok := s.listings[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	result := *listing
+	return &result, nil
+}
+
+// GetByItemID 根据合约 item_id 获取挂单
+func (s *ListingStore) GetByItemID(itemID uint64) (*repository.Listing, error) {
+	matches := s.filter(func(l *repository.Listing) bool { return l.ItemID == itemID })
+	if len(matches) == 0 {
+		return nil, errNotFound
+	}
+	return &matches[0], nil
+}
+
+// GetByTxHash 根据创建交易哈希获取挂单
+func (s *ListingStore) GetByTxHash(txHash string) (*repository.Listing, error) {
+	matches := s.filter(func(l *repository.Listing) bool { return l.TxHash == txHash })
+	if len(matches) == 0 {
+		return nil, errNotFound
+	}
+	return &matches[0], nil
+}
+
+// GetActiveListings 按状态（默认 active）分页获取未归档挂单
+func (s *ListingStore) GetActiveListings(filter repository.ListingFilter, page, pageSize int, withCount bool) ([]repository.Listing, int64, error) {
+	status := filter.Status
+	if status == "" {
+		status = "active"
+	}
+	now := time.Now()
+	matches := s.filter(func(l *repository.Listing) bool {
+		if status == "active" && l.AuctionEndAt != nil && !l.AuctionEndAt.After(now) {
+			return false
+		}
+		return l.Status == status && l.ArchivedAt == nil
+	})
+
+	switch filter.Sort {
+	case repository.ListingSortOldest:
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].ListedAt.Before(matches[j].ListedAt)
+		})
+	case repository.ListingSortPriceAsc:
+		sort.SliceStable(matches, func(i, j int) bool {
+			return parseWei(matches[i].Price).Cmp(parseWei(matches[j].Price)) < 0
+		})
+	case repository.ListingSortPriceDesc:
+		sort.SliceStable(matches, func(i, j int) bool {
+			return parseWei(matches[i].Price).Cmp(parseWei(matches[j].Price)) > 0
+		})
+	}
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// GetActiveAsOf 获取在 at 时刻处于活跃状态的挂单（分页）
+func (s *ListingStore) GetActiveAsOf(at time.Time, nftContract string, page, pageSize int) ([]repository.Listing, int64, error) {
+	matches := s.filter(func(l *repository.Listing) bool {
+		switch l.Status {
+		case "active", "sold", "cancelled":
+		default:
+			return false
+		}
+		if l.ListedAt.After(at) || (l.SoldAt != nil && !l.SoldAt.After(at)) {
+			return false
+		}
+		if l.Status == "cancelled" {
+			cancelledAt := l.UpdatedAt
+			if l.CancelledAt != nil {
+				cancelledAt = *l.CancelledAt
+			}
+			if !cancelledAt.After(at) {
+				return false
+			}
+		}
+		return nftContract == "" || strings.EqualFold(l.NFTContract, nftContract)
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// GetBySellerPaginated 根据卖家获取挂单（分页）
+func (s *ListingStore) GetBySellerPaginated(seller string, includeArchived bool, page, pageSize int) ([]repository.Listing, int64, error) {
+	matches := s.filter(func(l *repository.Listing) bool {
+		return strings.EqualFold(l.Seller, seller) && (includeArchived || l.ArchivedAt == nil)
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// GetActiveBySeller 获取卖家的活跃挂单
+func (s *ListingStore) GetActiveBySeller(seller string, limit int) ([]repository.Listing, error) {
+	listings := s.filter(func(l *repository.Listing) bool {
+		return l.Status == "active" && strings.EqualFold(l.Seller, seller)
+	})
+	if len(listings) > limit {
+		listings = listings[:limit]
+	}
+	return listings, nil
+}
+
+// SearchListings 搜索挂单
+func (s *ListingStore) SearchListings(filter repository.ListingSearchFilter, page, pageSize int, withCount bool) ([]repository.Listing, int64, error) {
+	matches := s.filter(func(l *repository.Listing) bool {
+		if l.Status != "active" {
+			return false
+		}
+		if filter.NFTContract != "" && !strings.EqualFold(l.NFTContract, filter.NFTContract) {
+			return false
+		}
+		if filter.Seller != "" && strings.ToLower(l.Seller) != filter.Seller {
+			return false
+		}
+		price := parseWei(l.Price)
+		if filter.MinPrice != "" && price.Cmp(parseWei(filter.MinPrice)) < 0 {
+			return false
+		}
+		if filter.MaxPrice != "" && price.Cmp(parseWei(filter.MaxPrice)) > 0 {
+			return false
+		}
+		return true
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// UpdateStatus 更新状态
+func (s *ListingStore) UpdateStatus(id uint, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listing, ok := s.listings[id]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	listing.Status = status
+	listing.UpdatedAt = now
+	switch status {
+	case "sold":
+		listing.SoldAt = &now
+	case "cancelled":
+		listing.CancelledAt = &now
+	}
+	return nil
+}
+
+// BatchUpsert 批量写入挂单，item_id 已存在时跳过
+func (s *ListingStore) BatchUpsert(listings []repository.Listing, batchSize int) error {
+	for i := range listings {
+		if err := s.CreateIfNotExists(&listings[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByItemIDs 根据合约 item_id 批量获取挂单
+func (s *ListingStore) GetByItemIDs(itemIDs []uint64) ([]repository.Listing, error) {
+	wanted := make(map[uint64]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		wanted[id] = true
+	}
+	return s.filter(func(l *repository.Listing) bool { return wanted[l.ItemID] }), nil
+}
+
+// GetActiveByTokens 批量获取 Token 的活跃挂单
+func (s *ListingStore) GetActiveByTokens(tokens []repository.TokenRef) ([]repository.Listing, error) {
+	wanted := make(map[repository.TokenRef]bool, len(tokens))
+	for _, token := range tokens {
+		wanted[repository.TokenRef{NFTContract: strings.ToLower(token.NFTContract), TokenID: token.TokenID}] = true
+	}
+	return s.filter(func(l *repository.Listing) bool {
+		return l.Status == "active" &&
+			wanted[repository.TokenRef{NFTContract: strings.ToLower(l.NFTContract), TokenID: l.TokenID}]
+	}), nil
+}
+
+// MarkSold 将挂单标记为已售
+func (s *ListingStore) MarkSold(id uint, soldAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listing, ok := s.listings[id]
+	if !ok {
+		return nil
+	}
+
+	listing.Status = "sold"
+	listing.SoldAt = &soldAt
+	listing.UpdatedAt = time.Now()
+	return nil
+}
+
+// MarkSoldByItemIDs 批量将挂单标记为已售
+func (s *ListingStore) MarkSoldByItemIDs(soldAt map[uint64]time.Time) error {
+	itemIDs := make([]uint64, 0, len(soldAt))
+	for itemID := range soldAt {
+		itemIDs = append(itemIDs, itemID)
+	}
+
+	listings, _ := s.GetByItemIDs(itemIDs)
+	for _, listing := range listings {
+		if err := s.MarkSold(listing.ID, soldAt[listing.ItemID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchiveSettled 归档成交/取消时间早于 before 的已售、已取消挂单
+func (s *ListingStore) ArchiveSettled(before time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []uint
+	for id, listing := range s.listings {
+		if listing.ArchivedAt != nil || (listing.Status != "sold" && listing.Status != "cancelled") {
+			continue
+		}
+		settledAt := listing.UpdatedAt
+		if listing.SoldAt != nil {
+			settledAt = *listing.SoldAt
+		} else if listing.CancelledAt != nil {
+			settledAt = *listing.CancelledAt
+		}
+		if settledAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	now := time.Now()
+	for _, id := range ids {
+		s.listings[id].ArchivedAt = &now
+	}
+	return int64(len(ids)), nil
+}
+
+// GetUnverified 获取待链上校验的挂单
+func (s *ListingStore) GetUnverified(limit int) ([]repository.Listing, error) {
+	listings := s.filter(func(l *repository.Listing) bool {
+		return l.Unverified && (l.Status == "active" || l.Status == "pending")
+	})
+	sortDesc(listings, func(a, b repository.Listing) bool { return a.ID > b.ID })
+	if len(listings) > limit {
+		listings = listings[:limit]
+	}
+	return listings, nil
+}
+
+// MarkVerified 标记挂单已通过链上校验
+func (s *ListingStore) MarkVerified(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listing, ok := s.listings[id]
+	if !ok {
+		return nil
+	}
+
+	listing.Unverified = false
+	if listing.Status == "pending" {
+		listing.Status = "active"
+	}
+	listing.UpdatedAt = time.Now()
+	return nil
+}
+
+// RevertSale 将已售挂单恢复为活跃
+func (s *ListingStore) RevertSale(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if listing, ok := s.listings[id]; ok && listing.Status == "sold" {
+		listing.Status = "active"
+		listing.SoldAt = nil
+		listing.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// SetBlockHash 补记挂单创建事件所在区块的哈希
+func (s *ListingStore) SetBlockHash(id uint, blockHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if listing, ok := s.listings[id]; ok {
+		listing.BlockHash = blockHash
+	}
+	return nil
+}
+
+// SetContractVersion 补记挂单对应的市场合约 ABI 版本
+func (s *ListingStore) SetContractVersion(id uint, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if listing, ok := s.listings[id]; ok && listing.ContractVersion == "" {
+		listing.ContractVersion = version
+	}
+	return nil
+}
+
+// CountActiveListings 统计活跃挂单数量
+func (s *ListingStore) CountActiveListings() (int64, error) {
+	return int64(len(s.filter(func(l *repository.Listing) bool { return l.Status == "active" }))), nil
+}
+
+// CountActiveListingsByContract 统计合约的活跃挂单数量（合约地址不区分大小写）
+func (s *ListingStore) CountActiveListingsByContract(nftContract string) (int64, error) {
+	return int64(len(s.filter(func(l *repository.Listing) bool {
+		return l.Status == "active" && strings.EqualFold(l.NFTContract, nftContract)
+	}))), nil
+}
+
+// CountTotalListings 统计总挂单数量
+func (s *ListingStore) CountTotalListings() (int64, error) {
+	return int64(len(s.filter(func(l *repository.Listing) bool { return true }))), nil
+}
+
+// GetTotalVolume 获取已售挂单总额
+func (s *ListingStore) GetTotalVolume() (string, error) {
+	total := new(big.Int)
+	for _, l := range s.filter(func(l *repository.Listing) bool { return l.Status == "sold" }) {
+		total.Add(total, parseWei(l.Price))
+	}
+	return total.String(), nil
+}
+
+// GetAveragePrice 获取活跃挂单平均价格
+func (s *ListingStore) GetAveragePrice() (string, error) {
+	active := s.filter(func(l *repository.Listing) bool { return l.Status == "active" })
+	if len(active) == 0 {
+		return "0", nil
+	}
+
+	total := new(big.Int)
+	for _, l := range active {
+		total.Add(total, parseWei(l.Price))
+	}
+	return total.Div(total, big.NewInt(int64(len(active)))).String(), nil
+}
+
+// GetMinPrice 获取最低价格（地板价）
+func (s *ListingStore) GetMinPrice() (string, error) {
+	return s.extremePrice(func(candidate, current *big.Int) bool { return candidate.Cmp(current) < 0 }), nil
+}
+
+// GetMinPriceByContract 获取合约活跃挂单的最低价格（合约地址不区分大小写），无活跃挂单时为 0
+func (s *ListingStore) GetMinPriceByContract(nftContract string) (string, error) {
+	var floor *big.Int
+	for _, l := range s.filter(func(l *repository.Listing) bool {
+		return l.Status == "active" && strings.EqualFold(l.NFTContract, nftContract)
+	}) {
+		if price := parseWei(l.Price); floor == nil || price.Cmp(floor) < 0 {
+			floor = price
+		}
+	}
+	if floor == nil {
+		return "0", nil
+	}
+	return floor.String(), nil
+}
+
+// GetMaxPrice 获取最高价格
+func (s *ListingStore) GetMaxPrice() (string, error) {
+	return s.extremePrice(func(candidate, current *big.Int) bool { return candidate.Cmp(current) > 0 }), nil
+}
+
+// GetListingStatsByContracts 按合约分组统计活跃挂单数及地板价（合约地址小写）
+func (s *ListingStore) GetListingStatsByContracts(nftContracts []string) ([]repository.ContractListingStats, error) {
+	wanted := lowerSet(nftContracts)
+	stats := make(map[string]*repository.ContractListingStats)
+	floors := make(map[string]*big.Int)
+	for _, l := range s.filter(func(l *repository.Listing) bool {
+		return l.Status == "active" && wanted[strings.ToLower(l.NFTContract)]
+	}) {
+		contract := strings.ToLower(l.NFTContract)
+		if stats[contract] == nil {
+			stats[contract] = &repository.ContractListingStats{NFTContract: contract}
+		}
+		stats[contract].ActiveListings++
+
+		price := parseWei(l.Price)
+		if current, ok := floors[contract]; !ok || price.Cmp(current) < 0 {
+			floors[contract] = price
+		}
+	}
+
+	result := make([]repository.ContractListingStats, 0, len(stats))
+	for contract, stat := range stats {
+		stat.Floor = floors[contract].String()
+		result = append(result, *stat)
+	}
+	return result, nil
+}
+
+// GetFloorPrices 获取各合约活跃挂单的最低价
+func (s *ListingStore) GetFloorPrices() ([]repository.CollectionFloor, error) {
+	floors := make(map[string]*big.Int)
+	for _, l := range s.filter(func(l *repository.Listing) bool { return l.Status == "active" }) {
+		contract := strings.ToLower(l.NFTContract)
+		price := parseWei(l.Price)
+		if current, ok := floors[contract]; !ok || price.Cmp(current) < 0 {
+			floors[contract] = price
+		}
+	}
+
+	result := make([]repository.CollectionFloor, 0, len(floors))
+	for contract, floor := range floors {
+		result = append(result, repository.CollectionFloor{NFTContract: contract, Floor: floor.String()})
+	}
+	return result, nil
+}
+
+// GetActivePricesByContract 获取合约所有活跃挂单的价格
+func (s *ListingStore) GetActivePricesByContract(nftContract string) ([]string, error) {
+	var prices []string
+	for _, l := range s.filter(func(l *repository.Listing) bool {
+		return l.Status == "active" && strings.EqualFold(l.NFTContract, nftContract)
+	}) {
+		prices = append(prices, l.Price)
+	}
+	return prices, nil
+}
+
+// extremePrice 计算活跃挂单价格的极值
+func (s *ListingStore) extremePrice(better func(candidate, current *big.Int) bool) string {
+	var result *big.Int
+	for _, l := range s.filter(func(l *repository.Listing) bool { return l.Status == "active" }) {
+		price := parseWei(l.Price)
+		if result == nil || better(price, result) {
+			result = price
+		}
+	}
+	if result == nil {
+		return "0"
+	}
+	return result.String()
+}
+
+// insert 写入新挂单，调用方需持有写锁
+func (s *ListingStore) insert(listing *repository.Listing) {
+	listing.NormalizeAddresses()
+	s.nextID++
+	now := time.Now()
+	listing.ID = s.nextID
+	listing.CreatedAt = now
+	listing.UpdatedAt = now
+
+	stored := *listing
+	s.listings[listing.ID] = &stored
+}
+
+// filter 返回按挂单时间倒序排列的匹配项副本
+func (s *ListingStore) filter(match func(l *repository.Listing) bool) []repository.Listing {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []repository.Listing{}
+	for _, listing := range s.listings {
+		if match(listing) {
+			result = append(result, *listing)
+		}
+	}
+	sortDesc(result, func(a, b repository.Listing) bool {
+		if a.ListedAt.Equal(b.ListedAt) {
+			return a.ID < b.ID
+		}
+		return a.ListedAt.Before(b.ListedAt)
+	})
+	return result
+}
@@ -0,0 +1,57 @@
+// Package memory 提供仓储接口的内存实现，用于不依赖 Postgres 的服务层测试
+package memory
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// errNotFound 与 GORM 保持一致，便于调用方使用 errors.Is 判断
+var errNotFound = gorm.ErrRecordNotFound
+
+// paginate 对内存结果分页。内存实现不区分 withCount，总是返回真实总数（同样满足 total > page*pageSize
+// 即有下一页的约定）
+func paginate[T any](items []T, page, pageSize int) []T {
+	offset := (page - 1) * pageSize
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return []T{}
+	}
+
+	end := offset + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[offset:end]
+}
+
+// parseWei 解析十进制 wei 字符串，无法解析时返回 0
+func parseWei(value string) *big.Int {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return n
+}
+
+// lowerSet 转为小写集合，用于不区分大小写的地址匹配
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[strings.ToLower(value)] = true
+	}
+	return set
+}
+
+// sortDesc 按时间倒序排序
+func sortDesc[T any](items []T, less func(a, b T) bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return less(items[j], items[i])
+	})
+}
@@ -0,0 +1,356 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// NFTStore NFT 内存存储
+type NFTStore struct {
+	mu     sync.RWMutex
+	nfts   map[uint]*repository.NFT
+	nextID uint
+}
+
+var _ repository.NFTStore = (*NFTStore)(nil)
+
+// NewNFTStore 创建 NFT 内存存储
+func NewNFTStore() *NFTStore {
+	return &NFTStore{nfts: make(map[uint]*repository.NFT)}
+}
+
+// Create 创建 NFT
+func (s *NFTStore) Create(nft *repository.NFT) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nft.NormalizeAddresses()
+	s.nextID++
+	now := time.Now()
+	nft.ID = s.nextID
+	nft.CreatedAt = now
+	nft.UpdatedAt = now
+
+	stored := *nft
+	s.nfts[nft.ID] = &stored
+	return nil
+}
+
+// CreateIfNotExists 合约地址（不区分大小写）与 Token ID 对应的 NFT 不存在时创建
+func (s *NFTStore) CreateIfNotExists(nft *repository.NFT) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.nfts {
+		if strings.EqualFold(existing.ContractAddress, nft.ContractAddress) && existing.TokenID == nft.TokenID {
+			*nft = *existing
+			return false, nil
+		}
+	}
+
+	nft.NormalizeAddresses()
+	s.nextID++
+	now := time.Now()
+	nft.ID = s.nextID
+	nft.CreatedAt = now
+	nft.UpdatedAt = now
+
+	stored := *nft
+	s.nfts[nft.ID] = &stored
+	return true, nil
+}
+
+// GetByID 根据 ID 获取 NFT
+func (s *NFTStore) GetByID(id uint) (*repository.NFT, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	nft, ok := s.nfts[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	result := *nft
+	return &result, nil
+}
+
+// GetByContractAndToken 根据合约地址和 Token ID 获取 NFT
+func (s *NFTStore) GetByContractAndToken(contractAddress, tokenID string) (*repository.NFT, error) {
+	matches := s.filter(func(n *repository.NFT) bool {
+		return strings.EqualFold(n.ContractAddress, contractAddress) && n.TokenID == tokenID
+	})
+	if len(matches) == 0 {
+		return nil, errNotFound
+	}
+	return &matches[0], nil
+}
+
+// GetByTokens 批量获取 NFT（合约地址不区分大小写，不含 metadata）
+func (s *NFTStore) GetByTokens(tokens []repository.TokenRef) ([]repository.NFT, error) {
+	wanted := make(map[repository.TokenRef]bool, len(tokens))
+	for _, token := range tokens {
+		wanted[repository.TokenRef{NFTContract: strings.ToLower(token.NFTContract), TokenID: token.TokenID}] = true
+	}
+	matches := s.filter(func(n *repository.NFT) bool {
+		return wanted[repository.TokenRef{NFTContract: strings.ToLower(n.ContractAddress), TokenID: n.TokenID}]
+	})
+	return listColumns(matches, false), nil
+}
+
+// GetByOwner 根据所有者获取 NFT 列表
+func (s *NFTStore) GetByOwner(owner string, page, pageSize int, includeMetadata bool) ([]repository.NFT, int64, error) {
+	matches := s.filter(func(n *repository.NFT) bool {
+		return strings.EqualFold(n.Owner, owner) && n.Status == "active"
+	})
+	return listColumns(paginate(matches, page, pageSize), includeMetadata), int64(len(matches)), nil
+}
+
+// GetByContract 根据合约地址获取 NFT 列表
+func (s *NFTStore) GetByContract(contractAddress string, page, pageSize int, includeMetadata, withCount bool) ([]repository.NFT, int64, error) {
+	matches := s.filter(func(n *repository.NFT) bool {
+		return strings.EqualFold(n.ContractAddress, contractAddress) && n.Status == "active"
+	})
+	return listColumns(paginate(matches, page, pageSize), includeMetadata), int64(len(matches)), nil
+}
+
+// GetStatsByContracts 按合约分组统计有效 NFT 数及不同持有者数（合约地址小写）
+func (s *NFTStore) GetStatsByContracts(contractAddresses []string) ([]repository.ContractNFTStats, error) {
+	wanted := lowerSet(contractAddresses)
+	stats := make(map[string]*repository.ContractNFTStats)
+	owners := make(map[string]map[string]bool)
+	for _, n := range s.filter(func(n *repository.NFT) bool {
+		return n.Status == "active" && wanted[strings.ToLower(n.ContractAddress)]
+	}) {
+		contract := strings.ToLower(n.ContractAddress)
+		if stats[contract] == nil {
+			stats[contract] = &repository.ContractNFTStats{NFTContract: contract}
+			owners[contract] = make(map[string]bool)
+		}
+		stats[contract].TotalItems++
+		owners[contract][strings.ToLower(n.Owner)] = true
+	}
+
+	result := make([]repository.ContractNFTStats, 0, len(stats))
+	for contract, stat := range stats {
+		stat.Owners = int64(len(owners[contract]))
+		result = append(result, *stat)
+	}
+	return result, nil
+}
+
+// CountByContract 统计合约的有效 NFT 数量
+func (s *NFTStore) CountByContract(contractAddress string) (int64, error) {
+	matches := s.filter(func(n *repository.NFT) bool {
+		return strings.EqualFold(n.ContractAddress, contractAddress) && n.Status == "active"
+	})
+	return int64(len(matches)), nil
+}
+
+// CountOwnersByContract 统计合约有效 NFT 的不同持有者数量
+func (s *NFTStore) CountOwnersByContract(contractAddress string) (int64, error) {
+	owners := make(map[string]bool)
+	for _, n := range s.filter(func(n *repository.NFT) bool {
+		return strings.EqualFold(n.ContractAddress, contractAddress) && n.Status == "active"
+	}) {
+		owners[strings.ToLower(n.Owner)] = true
+	}
+	return int64(len(owners)), nil
+}
+
+// GetAll 获取所有 NFT（分页）
+func (s *NFTStore) GetAll(page, pageSize int, includeMetadata, withCount bool) ([]repository.NFT, int64, error) {
+	matches := s.filter(func(n *repository.NFT) bool {
+		return n.Status == "active"
+	})
+	return listColumns(paginate(matches, page, pageSize), includeMetadata), int64(len(matches)), nil
+}
+
+// listColumns 与数据库实现一致，不需要 metadata 时清空该字段
+func listColumns(nfts []repository.NFT, includeMetadata bool) []repository.NFT {
+	if !includeMetadata {
+		for i := range nfts {
+			nfts[i].Metadata = ""
+		}
+	}
+	return nfts
+}
+
+// Search 搜索 NFT
+func (s *NFTStore) Search(query string, page, pageSize int, withCount bool) ([]repository.NFT, int64, error) {
+	query = strings.ToLower(query)
+	matches := s.filter(func(n *repository.NFT) bool {
+		return n.Status == "active" &&
+			(strings.Contains(strings.ToLower(n.Name), query) ||
+				strings.Contains(strings.ToLower(n.Description), query))
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// SearchByAttributes 按属性（AND）及可选的名称/描述关键词搜索 NFT
+func (s *NFTStore) SearchByAttributes(query string, traits []repository.TraitFilter, page, pageSize int, withCount bool) ([]repository.NFT, int64, error) {
+	query = strings.ToLower(query)
+	matches := s.filter(func(n *repository.NFT) bool {
+		if n.Status != "active" {
+			return false
+		}
+		if query != "" && !strings.Contains(strings.ToLower(n.Name), query) &&
+			!strings.Contains(strings.ToLower(n.Description), query) {
+			return false
+		}
+
+		var metadata struct {
+			Attributes []repository.Trait `json:"attributes"`
+		}
+		if len(traits) > 0 && json.Unmarshal([]byte(n.Metadata), &metadata) != nil {
+			return false
+		}
+		for _, filter := range traits {
+			if !hasTrait(metadata.Attributes, filter) {
+				return false
+			}
+		}
+		return true
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// hasTrait 属性列表中是否有满足过滤条件的项，数字值按数值比较
+func hasTrait(attributes []repository.Trait, filter repository.TraitFilter) bool {
+	for _, trait := range attributes {
+		if trait.TraitType != filter.TraitType {
+			continue
+		}
+		switch value := trait.Value.(type) {
+		case string:
+			if value == filter.Value {
+				return true
+			}
+		case float64:
+			if !repository.IsJSONNumber(filter.Value) {
+				continue
+			}
+			if n, err := strconv.ParseFloat(filter.Value, 64); err == nil && n == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetTrending 获取热门 NFT
+func (s *NFTStore) GetTrending(limit int) ([]repository.NFT, error) {
+	matches := s.filter(func(n *repository.NFT) bool {
+		return n.Status == "active"
+	})
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].ViewCount+matches[i].LikeCount*2 > matches[j].ViewCount+matches[j].LikeCount*2
+	})
+	return paginate(matches, 1, limit), nil
+}
+
+// GetSimilarByTraits 获取同系列中与给定属性重合最多的 NFT
+func (s *NFTStore) GetSimilarByTraits(contractAddress string, excludeID uint, traits []repository.Trait, limit int) ([]repository.SimilarNFT, error) {
+	wanted := make(map[string]bool, len(traits))
+	for _, trait := range traits {
+		wanted[traitKey(trait)] = true
+	}
+
+	results := []repository.SimilarNFT{}
+	for _, nft := range s.filter(func(n *repository.NFT) bool {
+		return strings.EqualFold(n.ContractAddress, contractAddress) && n.ID != excludeID && n.Status == "active"
+	}) {
+		var metadata struct {
+			Attributes []repository.Trait `json:"attributes"`
+		}
+		if err := json.Unmarshal([]byte(nft.Metadata), &metadata); err != nil {
+			continue
+		}
+
+		var shared int64
+		for _, trait := range metadata.Attributes {
+			if wanted[traitKey(trait)] {
+				shared++
+			}
+		}
+		if shared > 0 {
+			results = append(results, repository.SimilarNFT{NFT: nft, SharedTraits: shared})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].SharedTraits == results[j].SharedTraits {
+			return results[i].ID < results[j].ID
+		}
+		return results[i].SharedTraits > results[j].SharedTraits
+	})
+	return paginate(results, 1, limit), nil
+}
+
+// traitKey 属性比较键
+func traitKey(trait repository.Trait) string {
+	value, _ := json.Marshal(trait.Value)
+	return trait.TraitType + "\x00" + string(value)
+}
+
+// UpdateOwner 更新所有者
+func (s *NFTStore) UpdateOwner(id uint, newOwner string) error {
+	return s.update(id, func(n *repository.NFT) { n.Owner = repository.NormalizeAddress(newOwner) })
+}
+
+// UpdateMetadata 更新 NFT 的元数据字段
+func (s *NFTStore) UpdateMetadata(id uint, update repository.NFTMetadataUpdate) error {
+	return s.update(id, func(n *repository.NFT) {
+		n.Name = update.Name
+		n.Description = update.Description
+		n.ImageURL = update.ImageURL
+		n.MetadataURI = update.MetadataURI
+		n.Metadata = update.Metadata
+	})
+}
+
+// SetTokenStandard 设置 NFT 合约的代币标准
+func (s *NFTStore) SetTokenStandard(id uint, standard string) error {
+	return s.update(id, func(n *repository.NFT) { n.TokenStandard = standard })
+}
+
+// IncrementViewCount 增加浏览次数
+func (s *NFTStore) IncrementViewCount(id uint) error {
+	return s.update(id, func(n *repository.NFT) { n.ViewCount++ })
+}
+
+// filter 返回按创建时间倒序排列的匹配项副本
+func (s *NFTStore) filter(match func(n *repository.NFT) bool) []repository.NFT {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []repository.NFT{}
+	for _, nft := range s.nfts {
+		if match(nft) {
+			result = append(result, *nft)
+		}
+	}
+	sortDesc(result, func(a, b repository.NFT) bool {
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID < b.ID
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return result
+}
+
+// update 更新指定 NFT，不存在时静默忽略（与 SQL UPDATE 行为一致）
+func (s *NFTStore) update(id uint, apply func(n *repository.NFT)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if nft, ok := s.nfts[id]; ok {
+		apply(nft)
+		nft.UpdatedAt = time.Now()
+	}
+	return nil
+}
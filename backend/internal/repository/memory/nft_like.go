@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// NFTLikeStore 点赞内存存储，点赞记录变化时调整 NFT 内存存储中的 like_count
+type NFTLikeStore struct {
+	mu    sync.Mutex
+	nfts  *NFTStore
+	likes map[uint]map[string]bool // nft_id -> 小写地址集合
+}
+
+var _ repository.NFTLikeStore = (*NFTLikeStore)(nil)
+
+// NewNFTLikeStore 创建点赞内存存储
+func NewNFTLikeStore(nfts *NFTStore) *NFTLikeStore {
+	return &NFTLikeStore{nfts: nfts, likes: make(map[uint]map[string]bool)}
+}
+
+// Like 记录点赞，返回是否新增了记录
+func (s *NFTLikeStore) Like(nftID uint, userAddress string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := strings.ToLower(userAddress)
+	if s.likes[nftID][user] {
+		return false, nil
+	}
+	if s.likes[nftID] == nil {
+		s.likes[nftID] = make(map[string]bool)
+	}
+	s.likes[nftID][user] = true
+	return true, s.nfts.update(nftID, func(n *repository.NFT) { n.LikeCount++ })
+}
+
+// Unlike 删除点赞，返回是否删除了记录
+func (s *NFTLikeStore) Unlike(nftID uint, userAddress string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := strings.ToLower(userAddress)
+	if !s.likes[nftID][user] {
+		return false, nil
+	}
+	delete(s.likes[nftID], user)
+	return true, s.nfts.update(nftID, func(n *repository.NFT) {
+		if n.LikeCount > 0 {
+			n.LikeCount--
+		}
+	})
+}
+
+// HasLiked 用户是否已点赞
+func (s *NFTLikeStore) HasLiked(nftID uint, userAddress string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.likes[nftID][strings.ToLower(userAddress)], nil
+}
+
+// RecomputeLikeCount 按点赞记录重算 NFT 的 like_count，返回重算前后的值
+func (s *NFTLikeStore) RecomputeLikeCount(nftID uint) (old, updated int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nft, err := s.nfts.GetByID(nftID)
+	if err != nil {
+		return 0, 0, err
+	}
+	old = nft.LikeCount
+	count := int64(len(s.likes[nftID]))
+	if err := s.nfts.update(nftID, func(n *repository.NFT) { n.LikeCount = count }); err != nil {
+		return 0, 0, err
+	}
+	return old, count, nil
+}
+
+// RecomputeAllLikeCounts 按点赞记录重算所有 like_count 不一致的 NFT
+func (s *NFTLikeStore) RecomputeAllLikeCounts() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var fixed int64
+	for _, nft := range s.nfts.filter(func(n *repository.NFT) bool { return true }) {
+		count := int64(len(s.likes[nft.ID]))
+		if nft.LikeCount == count {
+			continue
+		}
+		if err := s.nfts.update(nft.ID, func(n *repository.NFT) { n.LikeCount = count }); err != nil {
+			return fixed, err
+		}
+		fixed++
+	}
+	return fixed, nil
+}
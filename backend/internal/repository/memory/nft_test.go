@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// 数字属性只被 JSON 数字语法的过滤值命中，与 Postgres 实现的 @> 匹配一致
+func TestSearchByAttributesNumericValue(t *testing.T) {
+	nfts := NewNFTStore()
+	if err := nfts.Create(&repository.NFT{
+		ContractAddress: mixedContract,
+		TokenID:         "1",
+		Owner:           mixedUser,
+		Status:          "active",
+		Metadata:        `{"attributes":[{"trait_type":"level","value":16},{"trait_type":"rank","value":"Infinity"}]}`,
+	}); err != nil {
+		t.Fatalf("create nft: %v", err)
+	}
+
+	tests := []struct {
+		traitType string
+		value     string
+		want      int
+	}{
+		{"level", "16", 1},
+		{"level", "1.6e1", 1},
+		{"level", "+16", 0},
+		{"level", "0x10", 0},
+		{"level", "0x1p4", 0},
+		{"level", "1_6", 0},
+		{"level", "016", 0},
+		{"rank", "Infinity", 1}, // 字符串属性按原文匹配
+		{"rank", "Inf", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.traitType+"="+tt.value, func(t *testing.T) {
+			got, _, err := nfts.SearchByAttributes("", []repository.TraitFilter{{TraitType: tt.traitType, Value: tt.value}}, 1, 10, false)
+			if err != nil {
+				t.Fatalf("SearchByAttributes: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("SearchByAttributes(%s=%s) = %d results, want %d", tt.traitType, tt.value, len(got), tt.want)
+			}
+		})
+	}
+}
+
+// 单个重算返回重算前后的点赞数，供管理接口报告修正幅度
+func TestRecomputeLikeCount(t *testing.T) {
+	nfts := NewNFTStore()
+	nft := &repository.NFT{ContractAddress: mixedContract, TokenID: "1", Owner: mixedUser, Status: "active", LikeCount: 5}
+	if err := nfts.Create(nft); err != nil {
+		t.Fatalf("create nft: %v", err)
+	}
+	likes := NewNFTLikeStore(nfts)
+	if _, err := likes.Like(nft.ID, mixedUser); err != nil {
+		t.Fatalf("Like: %v", err)
+	}
+
+	old, updated, err := likes.RecomputeLikeCount(nft.ID)
+	if err != nil {
+		t.Fatalf("RecomputeLikeCount: %v", err)
+	}
+	if old != 6 || updated != 1 {
+		t.Errorf("RecomputeLikeCount() = %d, %d, want 6, 1", old, updated)
+	}
+	if _, _, err := likes.RecomputeLikeCount(nft.ID + 1); !repository.IsNotFound(err) {
+		t.Errorf("RecomputeLikeCount(missing) error = %v, want not found", err)
+	}
+}
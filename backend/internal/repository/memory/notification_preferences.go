@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// NotificationPreferenceStore 通知偏好内存存储
+type NotificationPreferenceStore struct {
+	mu    sync.RWMutex
+	prefs map[string]*repository.NotificationPreferences
+}
+
+var _ repository.NotificationPreferenceStore = (*NotificationPreferenceStore)(nil)
+
+// NewNotificationPreferenceStore 创建通知偏好内存存储
+func NewNotificationPreferenceStore() *NotificationPreferenceStore {
+	return &NotificationPreferenceStore{prefs: make(map[string]*repository.NotificationPreferences)}
+}
+
+// GetByAddress 根据地址获取通知偏好
+func (s *NotificationPreferenceStore) GetByAddress(address string) (*repository.NotificationPreferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefs, ok := s.prefs[strings.ToLower(address)]
+	if !ok {
+		return nil, errNotFound
+	}
+	result := *prefs
+	return &result, nil
+}
+
+// Upsert 写入通知偏好（覆盖全部字段）
+func (s *NotificationPreferenceStore) Upsert(prefs *repository.NotificationPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs.Address = strings.ToLower(prefs.Address)
+	prefs.UpdatedAt = time.Now()
+	stored := *prefs
+	s.prefs[prefs.Address] = &stored
+	return nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// OfferStore 出价内存存储
+type OfferStore struct {
+	mu     sync.RWMutex
+	offers []repository.Offer
+}
+
+var _ repository.OfferStore = (*OfferStore)(nil)
+
+// NewOfferStore 创建出价内存存储
+func NewOfferStore() *OfferStore {
+	return &OfferStore{}
+}
+
+// Put 写入出价（测试数据准备用）
+func (s *OfferStore) Put(offer *repository.Offer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offer.NormalizeAddresses()
+	offer.ID = uint(len(s.offers) + 1)
+	if offer.CreatedAt.IsZero() {
+		offer.CreatedAt = time.Now()
+	}
+	s.offers = append(s.offers, *offer)
+}
+
+// Create 创建出价
+func (s *OfferStore) Create(offer *repository.Offer) error {
+	now := time.Now()
+	offer.CreatedAt = now
+	offer.UpdatedAt = now
+	if offer.Status == "" {
+		offer.Status = repository.OfferStatusActive
+	}
+	s.Put(offer)
+	return nil
+}
+
+// GetByID 根据 ID 获取出价
+func (s *OfferStore) GetByID(id uint) (*repository.Offer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := range s.offers {
+		if s.offers[i].ID == id {
+			offer := s.offers[i]
+			return &offer, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// GetBestActive 获取某个 NFT 当前最高的有效出价
+func (s *OfferStore) GetBestActive(nftContract, tokenID string) (*repository.Offer, error) {
+	matches := s.filter(repository.OfferFilter{Status: repository.OfferStatusActive}, func(o *repository.Offer) bool {
+		return strings.EqualFold(o.NFTContract, nftContract) && o.TokenID == tokenID
+	})
+	if len(matches) == 0 {
+		return nil, errNotFound
+	}
+	return &matches[0], nil
+}
+
+// Cancel 取消仍有效的出价
+func (s *OfferStore) Cancel(id uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for i := range s.offers {
+		offer := &s.offers[i]
+		if offer.ID == id && offer.EffectiveStatus(now) == repository.OfferStatusActive {
+			offer.Status = repository.OfferStatusCancelled
+			offer.UpdatedAt = now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetByToken 分页获取某个 NFT 的出价（合约地址不区分大小写）
+func (s *OfferStore) GetByToken(nftContract, tokenID string, filter repository.OfferFilter, page, pageSize int) ([]repository.Offer, int64, error) {
+	matches := s.filter(filter, func(o *repository.Offer) bool {
+		return strings.EqualFold(o.NFTContract, nftContract) && o.TokenID == tokenID
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// GetByBidder 分页获取某个地址发出的出价
+func (s *OfferStore) GetByBidder(offerer string, filter repository.OfferFilter, page, pageSize int) ([]repository.Offer, int64, error) {
+	matches := s.filter(filter, func(o *repository.Offer) bool {
+		return strings.EqualFold(o.Offerer, offerer)
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// filter 按条件与实际状态过滤并排序
+func (s *OfferStore) filter(filter repository.OfferFilter, match func(*repository.Offer) bool) []repository.Offer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	result := []repository.Offer{}
+	for i := range s.offers {
+		offer := &s.offers[i]
+		if !match(offer) || (filter.Status != "" && offer.EffectiveStatus(now) != filter.Status) {
+			continue
+		}
+		result = append(result, *offer)
+	}
+
+	if filter.Sort == repository.OfferSortRecent {
+		sortDesc(result, func(a, b repository.Offer) bool { return a.CreatedAt.Before(b.CreatedAt) })
+	} else {
+		sortDesc(result, func(a, b repository.Offer) bool { return parseWei(a.Price).Cmp(parseWei(b.Price)) < 0 })
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// SyncStateStore 索引进度内存存储
+type SyncStateStore struct {
+	mu     sync.RWMutex
+	blocks map[syncStateKey]uint64
+}
+
+// syncStateKey 小写合约地址与事件名
+type syncStateKey struct {
+	contract string
+	event    string
+}
+
+var _ repository.SyncStateStore = (*SyncStateStore)(nil)
+
+// NewSyncStateStore 创建索引进度内存存储
+func NewSyncStateStore() *SyncStateStore {
+	return &SyncStateStore{blocks: make(map[syncStateKey]uint64)}
+}
+
+// GetLastSyncedBlock 获取合约某个事件流已索引到的区块
+func (s *SyncStateStore) GetLastSyncedBlock(contractAddress, eventName string) (uint64, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	block, ok := s.blocks[syncStateKey{strings.ToLower(contractAddress), eventName}]
+	return block, ok, nil
+}
+
+// SetLastSyncedBlock 推进合约某个事件流的索引进度，只前进不后退
+func (s *SyncStateStore) SetLastSyncedBlock(contractAddress, eventName string, block uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := syncStateKey{strings.ToLower(contractAddress), eventName}
+	if current, ok := s.blocks[key]; !ok || block > current {
+		s.blocks[key] = block
+	}
+	return nil
+}
+
+// IndexTransactor 事务执行器的内存实现：串行执行 fn，但 fn 出错时不回滚已做的写入
+type IndexTransactor struct {
+	mu     sync.Mutex
+	stores repository.IndexStores
+}
+
+var _ repository.IndexTransactor = (*IndexTransactor)(nil)
+
+// NewIndexTransactor 创建内存事务执行器
+func NewIndexTransactor(listings repository.ListingStore, txs repository.TransactionStore, syncState repository.SyncStateStore) *IndexTransactor {
+	return &IndexTransactor{stores: repository.IndexStores{
+		Listings:     listings,
+		Transactions: txs,
+		SyncState:    syncState,
+	}}
+}
+
+// InTx 串行执行 fn
+func (t *IndexTransactor) InTx(fn func(stores repository.IndexStores) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return fn(t.stores)
+}
@@ -0,0 +1,345 @@
+package memory
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// TransactionStore 交易内存存储
+type TransactionStore struct {
+	mu     sync.RWMutex
+	txs    map[uint]*repository.Transaction
+	nextID uint
+
+	// VolumeSource 交易额统计口径，默认按挂单价格
+	VolumeSource string
+}
+
+var _ repository.TransactionStore = (*TransactionStore)(nil)
+
+// NewTransactionStore 创建交易内存存储
+func NewTransactionStore() *TransactionStore {
+	return &TransactionStore{txs: make(map[uint]*repository.Transaction)}
+}
+
+// Create 创建交易记录
+func (s *TransactionStore) Create(tx *repository.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx.NormalizeAddresses()
+	s.nextID++
+	now := time.Now()
+	tx.ID = s.nextID
+	tx.CreatedAt = now
+	tx.UpdatedAt = now
+
+	stored := *tx
+	s.txs[tx.ID] = &stored
+	return nil
+}
+
+// CreateIfNotExists 创建交易记录，(tx_hash, log_index) 已存在时跳过；返回是否实际插入
+func (s *TransactionStore) CreateIfNotExists(tx *repository.Transaction) (bool, error) {
+	if s.exists(tx.TxHash, tx.LogIndex) {
+		return false, nil
+	}
+	return true, s.Create(tx)
+}
+
+// BatchUpsert 批量写入交易，(tx_hash, log_index) 已存在时跳过
+func (s *TransactionStore) BatchUpsert(txs []repository.Transaction, batchSize int) error {
+	for i := range txs {
+		if _, err := s.CreateIfNotExists(&txs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exists 是否已有相同 (tx_hash, log_index) 的交易
+func (s *TransactionStore) exists(txHash string, logIndex int) bool {
+	matches := s.filter(func(t *repository.Transaction) bool {
+		return t.TxHash == txHash && t.LogIndex == logIndex
+	})
+	return len(matches) > 0
+}
+
+// GetByHash 根据交易哈希获取交易
+func (s *TransactionStore) GetByHash(txHash string) (*repository.Transaction, error) {
+	matches := s.filter(func(t *repository.Transaction) bool { return t.TxHash == txHash })
+	if len(matches) == 0 {
+		return nil, errNotFound
+	}
+	return &matches[0], nil
+}
+
+// GetSaleByListingID 获取挂单的成交交易
+func (s *TransactionStore) GetSaleByListingID(listingID uint) (*repository.Transaction, error) {
+	matches := s.filter(func(t *repository.Transaction) bool {
+		return t.TxType == "sale" && t.ListingID != nil && *t.ListingID == listingID
+	})
+	if len(matches) == 0 {
+		return nil, errNotFound
+	}
+	return &matches[0], nil
+}
+
+// GetSaleByItemNear 获取同一市场项在 blockNumber 前后 window 个区块内的成交交易
+func (s *TransactionStore) GetSaleByItemNear(itemID, blockNumber, window uint64) (*repository.Transaction, error) {
+	matches := s.filter(func(t *repository.Transaction) bool {
+		if t.TxType != "sale" || t.Status == "failed" || t.ItemID == nil || *t.ItemID != itemID {
+			return false
+		}
+		if t.BlockNumber > blockNumber {
+			return t.BlockNumber-blockNumber <= window
+		}
+		return blockNumber-t.BlockNumber <= window
+	})
+	if len(matches) == 0 {
+		return nil, errNotFound
+	}
+	return &matches[0], nil
+}
+
+// GetByID 根据 ID 获取交易
+func (s *TransactionStore) GetByID(id uint) (*repository.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tx, ok := s.txs[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	result := *tx
+	return &result, nil
+}
+
+// GetAll 获取所有交易（分页）
+func (s *TransactionStore) GetAll(page, pageSize int, withCount bool) ([]repository.Transaction, int64, error) {
+	matches := s.filter(func(t *repository.Transaction) bool { return true })
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// GetFiltered 按过滤条件获取交易（分页）
+func (s *TransactionStore) GetFiltered(filter repository.TxFilter, page, pageSize int, withCount bool) ([]repository.Transaction, int64, error) {
+	matches := s.filter(filter.Matches)
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// GetFilteredAfterID 按 ID 游标升序获取满足过滤条件的交易
+func (s *TransactionStore) GetFilteredAfterID(filter repository.TxFilter, afterID uint, limit int) ([]repository.Transaction, error) {
+	matches := s.filter(func(t *repository.Transaction) bool { return t.ID > afterID && filter.Matches(t) })
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// GetByAddress 根据地址获取交易（发送或接收）
+func (s *TransactionStore) GetByAddress(address string, page, pageSize int) ([]repository.Transaction, int64, error) {
+	matches := s.filter(func(t *repository.Transaction) bool {
+		return strings.EqualFold(t.FromAddress, address) || strings.EqualFold(t.ToAddress, address)
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// GetByNFT 根据 NFT 获取交易历史
+func (s *TransactionStore) GetByNFT(nftContract, tokenID string, page, pageSize int) ([]repository.Transaction, int64, error) {
+	matches := s.filter(func(t *repository.Transaction) bool {
+		return strings.EqualFold(t.NFTContract, nftContract) && t.TokenID == tokenID
+	})
+	return paginate(matches, page, pageSize), int64(len(matches)), nil
+}
+
+// GetRecent 获取最近的交易
+func (s *TransactionStore) GetRecent(limit int) ([]repository.Transaction, error) {
+	matches := s.filter(func(t *repository.Transaction) bool { return true })
+	return paginate(matches, 1, limit), nil
+}
+
+// GetTotalVolume 获取总交易额
+func (s *TransactionStore) GetTotalVolume() (string, error) {
+	return s.sumSales(func(t *repository.Transaction) bool { return true }), nil
+}
+
+// GetVolumeByContract 获取合约的交易额
+func (s *TransactionStore) GetVolumeByContract(nftContract string) (string, error) {
+	return s.sumSales(func(t *repository.Transaction) bool { return strings.EqualFold(t.NFTContract, nftContract) }), nil
+}
+
+// GetVolumeSplitByContract 获取合约的一级/二级市场交易额
+func (s *TransactionStore) GetVolumeSplitByContract(nftContract string) (primary, secondary string, err error) {
+	primary = s.sumSales(func(t *repository.Transaction) bool {
+		return strings.EqualFold(t.NFTContract, nftContract) && t.IsPrimary
+	})
+	secondary = s.sumSales(func(t *repository.Transaction) bool {
+		return strings.EqualFold(t.NFTContract, nftContract) && !t.IsPrimary
+	})
+	return primary, secondary, nil
+}
+
+// GetVolumeSplitByContracts 按合约分组统计一级/二级市场交易额（合约地址小写）
+func (s *TransactionStore) GetVolumeSplitByContracts(nftContracts []string) ([]repository.ContractVolumeSplit, error) {
+	wanted := lowerSet(nftContracts)
+	seen := make(map[string]bool)
+	result := []repository.ContractVolumeSplit{}
+	for _, t := range s.filter(func(t *repository.Transaction) bool {
+		return t.TxType == "sale" && t.Status == "confirmed" && wanted[strings.ToLower(t.NFTContract)]
+	}) {
+		contract := strings.ToLower(t.NFTContract)
+		if seen[contract] {
+			continue
+		}
+		seen[contract] = true
+
+		inContract := func(t *repository.Transaction) bool { return strings.EqualFold(t.NFTContract, contract) }
+		result = append(result, repository.ContractVolumeSplit{
+			NFTContract: contract,
+			Primary:     s.sumSales(func(t *repository.Transaction) bool { return inContract(t) && t.IsPrimary }),
+			Secondary:   s.sumSales(func(t *repository.Transaction) bool { return inContract(t) && !t.IsPrimary }),
+		})
+	}
+	return result, nil
+}
+
+// GetDailyVolume 按 UTC 日期统计最近 days 天内的已确认成交，日期倒序
+func (s *TransactionStore) GetDailyVolume(days int) ([]repository.DailyVolume, error) {
+	since := time.Now().AddDate(0, 0, -days)
+	inWindow := func(t *repository.Transaction) bool { return !t.BlockTimestamp.Before(since) }
+
+	result := []repository.DailyVolume{}
+	seen := make(map[time.Time]bool)
+	for _, t := range s.filter(func(t *repository.Transaction) bool {
+		return t.TxType == "sale" && t.Status == "confirmed" && inWindow(t)
+	}) {
+		date := utcDate(t.BlockTimestamp)
+		if seen[date] {
+			continue
+		}
+		seen[date] = true
+
+		onDate := func(t *repository.Transaction) bool { return inWindow(t) && utcDate(t.BlockTimestamp).Equal(date) }
+		sales := s.filter(func(t *repository.Transaction) bool {
+			return t.TxType == "sale" && t.Status == "confirmed" && onDate(t)
+		})
+		result = append(result, repository.DailyVolume{
+			Date:    date,
+			TxCount: int64(len(sales)),
+			Volume:  s.sumSales(onDate),
+		})
+	}
+	return result, nil
+}
+
+// utcDate 时间所在的 UTC 日期
+func utcDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// CountByType 统计指定类型的已确认交易数量
+func (s *TransactionStore) CountByType(txType string) (int64, error) {
+	matches := s.filter(func(t *repository.Transaction) bool {
+		return t.TxType == txType && t.Status == "confirmed"
+	})
+	return int64(len(matches)), nil
+}
+
+// DeleteByStatusBefore 删除指定状态且创建时间早于 before 的交易
+func (s *TransactionStore) DeleteByStatusBefore(status string, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, tx := range s.txs {
+		if tx.Status == status && tx.CreatedAt.Before(before) {
+			delete(s.txs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// UpdateStatus 更新交易状态
+func (s *TransactionStore) UpdateStatus(id uint, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tx, ok := s.txs[id]; ok {
+		tx.Status = status
+		tx.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// UpdateBlock 同时更新交易的区块号与区块哈希
+func (s *TransactionStore) UpdateBlock(id uint, blockNumber uint64, blockHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tx, ok := s.txs[id]; ok {
+		tx.BlockNumber = blockNumber
+		tx.BlockHash = blockHash
+		tx.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// GetPendingSales 获取记录了区块哈希的待确认成交交易（按区块顺序）
+func (s *TransactionStore) GetPendingSales(limit int) ([]repository.Transaction, error) {
+	matches := s.filter(func(t *repository.Transaction) bool {
+		return t.TxType == "sale" && t.Status == "pending" && t.BlockHash != ""
+	})
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].BlockNumber == matches[j].BlockNumber {
+			return matches[i].ID < matches[j].ID
+		}
+		return matches[i].BlockNumber < matches[j].BlockNumber
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// sumSales 汇总已确认销售的交易额
+func (s *TransactionStore) sumSales(match func(t *repository.Transaction) bool) string {
+	total := new(big.Int)
+	for _, t := range s.filter(func(t *repository.Transaction) bool {
+		return t.TxType == "sale" && t.Status == "confirmed" && match(t)
+	}) {
+		amount := t.ValueNumeric
+		if s.VolumeSource == repository.VolumeSourceNet && t.NetValueNumeric != nil {
+			amount = *t.NetValueNumeric
+		}
+		total.Add(total, parseWei(amount))
+	}
+	return total.String()
+}
+
+// filter 返回按区块时间倒序排列的匹配项副本
+func (s *TransactionStore) filter(match func(t *repository.Transaction) bool) []repository.Transaction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []repository.Transaction{}
+	for _, tx := range s.txs {
+		if match(tx) {
+			result = append(result, *tx)
+		}
+	}
+	sortDesc(result, func(a, b repository.Transaction) bool {
+		if a.BlockTimestamp.Equal(b.BlockTimestamp) {
+			return a.ID < b.ID
+		}
+		return a.BlockTimestamp.Before(b.BlockTimestamp)
+	})
+	return result
+}
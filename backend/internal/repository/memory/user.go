@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// UserStore 用户内存存储（邮箱不加密）
+type UserStore struct {
+	mu     sync.RWMutex
+	users  map[string]*repository.User
+	nextID uint
+}
+
+var _ repository.UserStore = (*UserStore)(nil)
+
+// NewUserStore 创建用户内存存储
+func NewUserStore() *UserStore {
+	return &UserStore{users: make(map[string]*repository.User)}
+}
+
+// GetByAddress 根据地址获取用户
+func (s *UserStore) GetByAddress(address string) (*repository.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[strings.ToLower(address)]
+	if !ok {
+		return nil, errNotFound
+	}
+	result := *user
+	return &result, nil
+}
+
+// SetEmail 设置用户邮箱，用户不存在时创建
+func (s *UserStore) SetEmail(address string, email repository.EncryptedString) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	address = strings.ToLower(address)
+	now := time.Now()
+	user, ok := s.users[address]
+	if !ok {
+		s.nextID++
+		user = &repository.User{ID: s.nextID, Address: address, EmailNotifications: true, CreatedAt: now}
+		s.users[address] = user
+	}
+	user.Email = email
+	user.UpdatedAt = now
+	return nil
+}
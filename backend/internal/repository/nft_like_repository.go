@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NFTLike 用户对 NFT 的点赞，每个用户对每个 NFT 至多一条
+type NFTLike struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	NFTID       uint      `gorm:"uniqueIndex:idx_nft_likes_nft_user;not null" json:"nft_id"`
+	UserAddress string    `gorm:"uniqueIndex:idx_nft_likes_nft_user;index;not null" json:"user_address"` // 小写地址
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (NFTLike) TableName() string {
+	return "nft_likes"
+}
+
+// NFTLikeRepository 点赞仓储，点赞记录变化时在同一事务中调整 nfts.like_count
+type NFTLikeRepository struct {
+	db *gorm.DB
+}
+
+// NewNFTLikeRepository 创建点赞仓储
+func NewNFTLikeRepository(db *gorm.DB) *NFTLikeRepository {
+	return &NFTLikeRepository{db: db}
+}
+
+// Like 记录点赞，已点赞时不变；返回是否新增了记录
+func (r *NFTLikeRepository) Like(nftID uint, userAddress string) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		like := &NFTLike{NFTID: nftID, UserAddress: strings.ToLower(userAddress)}
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "nft_id"}, {Name: "user_address"}},
+			DoNothing: true,
+		}).Create(like)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+		return tx.Model(&NFT{}).Where("id = ?", nftID).
+			UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error
+	})
+	return created, err
+}
+
+// Unlike 删除点赞，未点赞时不变；返回是否删除了记录
+func (r *NFTLikeRepository) Unlike(nftID uint, userAddress string) (bool, error) {
+	deleted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("nft_id = ? AND user_address = ?", nftID, strings.ToLower(userAddress)).Delete(&NFTLike{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		deleted = true
+		return tx.Model(&NFT{}).Where("id = ? AND like_count > ?", nftID, 0).
+			UpdateColumn("like_count", gorm.Expr("like_count - ?", 1)).Error
+	})
+	return deleted, err
+}
+
+// HasLiked 用户是否已点赞
+func (r *NFTLikeRepository) HasLiked(nftID uint, userAddress string) (bool, error) {
+	var count int64
+	err := r.db.Model(&NFTLike{}).
+		Where("nft_id = ? AND user_address = ?", nftID, strings.ToLower(userAddress)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// likeCountExpr 按点赞表计算的点赞数
+var likeCountExpr = gorm.Expr("(SELECT COUNT(*) FROM nft_likes WHERE nft_likes.nft_id = nfts.id)")
+
+// RecomputeLikeCount 按点赞表重算 NFT 的 like_count，返回重算前后的值，NFT 不存在时返回 ErrRecordNotFound。
+// 旧值在锁定的行上读取，与更新之间不会被并发点赞改变
+func (r *NFTLikeRepository) RecomputeLikeCount(nftID uint) (old, updated int64, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var nft NFT
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "like_count").
+			First(&nft, nftID).Error
+		if err != nil {
+			return err
+		}
+		old = nft.LikeCount
+
+		err = tx.Model(&nft).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "like_count"}}}).
+			Where("id = ?", nftID).
+			UpdateColumn("like_count", likeCountExpr).Error
+		if err != nil {
+			return err
+		}
+		updated = nft.LikeCount
+		return nil
+	})
+	return old, updated, err
+}
+
+// RecomputeAllLikeCounts 按点赞表重算所有 like_count 与之不一致的 NFT，返回修正的数量
+func (r *NFTLikeRepository) RecomputeAllLikeCounts() (int64, error) {
+	result := r.db.Model(&NFT{}).
+		Where("like_count IS DISTINCT FROM ?", likeCountExpr).
+		UpdateColumn("like_count", likeCountExpr)
+	return result.RowsAffected, result.Error
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"encoding/json"
+	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -24,6 +27,9 @@ type NFT struct {
 	MintedAt        time.Time `json:"minted_at"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+
+	// 合约代币标准（ERC-165 检测）：erc721、erc1155 或 unknown，为空表示尚未检测
+	TokenStandard string `gorm:"not null;default:''" json:"token_standard"`
 }
 
 // TableName 指定表名
@@ -31,6 +37,14 @@ func (NFT) TableName() string {
 	return "nfts"
 }
 
+// listColumns 列表查询不需要 metadata 时不读取该 jsonb 列
+func listColumns(db *gorm.DB, includeMetadata bool) *gorm.DB {
+	if includeMetadata {
+		return db
+	}
+	return db.Omit("metadata")
+}
+
 // NFTRepository NFT 仓储
 type NFTRepository struct {
 	db *gorm.DB
@@ -46,6 +60,39 @@ func (r *NFTRepository) Create(nft *NFT) error {
 	return r.db.Create(nft).Error
 }
 
+// CreateIfNotExists 合约地址与 Token ID 对应的 NFT 不存在时创建，
+// 已存在时将已有记录载入 nft；created 表示是否新建
+func (r *NFTRepository) CreateIfNotExists(nft *NFT) (created bool, err error) {
+	result := r.db.Where("contract_address = ? AND token_id = ?", NormalizeAddress(nft.ContractAddress), nft.TokenID).
+		FirstOrCreate(nft)
+	return result.RowsAffected > 0, result.Error
+}
+
+// NFTMetadataUpdate 从 tokenURI 抓取的元数据字段
+type NFTMetadataUpdate struct {
+	Name        string
+	Description string
+	ImageURL    string
+	MetadataURI string
+	Metadata    string // JSON 字符串
+}
+
+// UpdateMetadata 更新 NFT 的元数据字段
+func (r *NFTRepository) UpdateMetadata(id uint, update NFTMetadataUpdate) error {
+	return r.db.Model(&NFT{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":         update.Name,
+		"description":  update.Description,
+		"image_url":    update.ImageURL,
+		"metadata_uri": update.MetadataURI,
+		"metadata":     update.Metadata,
+	}).Error
+}
+
+// SetTokenStandard 设置 NFT 合约的代币标准
+func (r *NFTRepository) SetTokenStandard(id uint, standard string) error {
+	return r.db.Model(&NFT{}).Where("id = ?", id).Update("token_standard", standard).Error
+}
+
 // GetByID 根据 ID 获取 NFT
 func (r *NFTRepository) GetByID(id uint) (*NFT, error) {
 	var nft NFT
@@ -59,53 +106,46 @@ func (r *NFTRepository) GetByID(id uint) (*NFT, error) {
 // GetByContractAndToken 根据合约地址和 Token ID 获取 NFT
 func (r *NFTRepository) GetByContractAndToken(contractAddress, tokenID string) (*NFT, error) {
 	var nft NFT
-	err := r.db.Where("contract_address = ? AND token_id = ?", contractAddress, tokenID).First(&nft).Error
+	err := r.db.Where("contract_address = ? AND token_id = ?", NormalizeAddress(contractAddress), tokenID).First(&nft).Error
 	if err != nil {
 		return nil, err
 	}
 	return &nft, nil
 }
 
-// GetByOwner 根据所有者获取 NFT 列表
-func (r *NFTRepository) GetByOwner(owner string, page, pageSize int) ([]NFT, int64, error) {
+// GetByTokens 批量获取 NFT（合约地址不区分大小写，不含 metadata）
+func (r *NFTRepository) GetByTokens(tokens []TokenRef) ([]NFT, error) {
 	var nfts []NFT
-	var total int64
-
-	offset := (page - 1) * pageSize
-
-	// 计算总数
-	if err := r.db.Model(&NFT{}).Where("owner = ? AND status = ?", owner, "active").Count(&total).Error; err != nil {
-		return nil, 0, err
+	if len(tokens) == 0 {
+		return nfts, nil
 	}
 
-	// 获取数据
-	err := r.db.Where("owner = ? AND status = ?", owner, "active").
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(pageSize).
-		Find(&nfts).Error
-
-	if err != nil {
-		return nil, 0, err
+	pairs := make([][]interface{}, len(tokens))
+	for i, token := range tokens {
+		pairs[i] = []interface{}{NormalizeAddress(token.NFTContract), token.TokenID}
 	}
 
-	return nfts, total, nil
+	err := listColumns(r.db, false).
+		Where("(contract_address, token_id) IN ?", pairs).
+		Find(&nfts).Error
+	return nfts, err
 }
 
-// GetByContract 根据合约地址获取 NFT 列表
-func (r *NFTRepository) GetByContract(contractAddress string, page, pageSize int) ([]NFT, int64, error) {
+// GetByOwner 根据所有者获取 NFT 列表
+func (r *NFTRepository) GetByOwner(owner string, page, pageSize int, includeMetadata bool) ([]NFT, int64, error) {
 	var nfts []NFT
 	var total int64
 
+	owner = NormalizeAddress(owner)
 	offset := (page - 1) * pageSize
 
 	// 计算总数
-	if err := r.db.Model(&NFT{}).Where("contract_address = ? AND status = ?", contractAddress, "active").Count(&total).Error; err != nil {
+	if err := r.db.Model(&NFT{}).Where("owner = ? AND status = ?", owner, "active").Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// 获取数据
-	err := r.db.Where("contract_address = ? AND status = ?", contractAddress, "active").
+	err := listColumns(r.db, includeMetadata).Where("owner = ? AND status = ?", owner, "active").
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
@@ -118,30 +158,23 @@ func (r *NFTRepository) GetByContract(contractAddress string, page, pageSize int
 	return nfts, total, nil
 }
 
-// GetAll 获取所有 NFT（分页）
-func (r *NFTRepository) GetAll(page, pageSize int) ([]NFT, int64, error) {
-	var nfts []NFT
-	var total int64
-
-	offset := (page - 1) * pageSize
-
-	// 计算总数
-	if err := r.db.Model(&NFT{}).Where("status = ?", "active").Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+// GetByContract 根据合约地址获取 NFT 列表，withCount 为 false 时不查询总数（见 findPage）
+func (r *NFTRepository) GetByContract(contractAddress string, page, pageSize int, includeMetadata, withCount bool) ([]NFT, int64, error) {
+	contractAddress = NormalizeAddress(contractAddress)
+	count := r.db.Model(&NFT{}).Where("contract_address = ? AND status = ?", contractAddress, "active")
+	data := listColumns(r.db, includeMetadata).Where("contract_address = ? AND status = ?", contractAddress, "active").
+		Order("created_at DESC")
 
-	// 获取数据
-	err := r.db.Where("status = ?", "active").
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(pageSize).
-		Find(&nfts).Error
+	return findPage[NFT](count, data, page, pageSize, withCount)
+}
 
-	if err != nil {
-		return nil, 0, err
-	}
+// GetAll 获取所有 NFT（分页），withCount 为 false 时不查询总数（见 findPage）
+func (r *NFTRepository) GetAll(page, pageSize int, includeMetadata, withCount bool) ([]NFT, int64, error) {
+	count := r.db.Model(&NFT{}).Where("status = ?", "active")
+	data := listColumns(r.db, includeMetadata).Where("status = ?", "active").
+		Order("created_at DESC")
 
-	return nfts, total, nil
+	return findPage[NFT](count, data, page, pageSize, withCount)
 }
 
 // Update 更新 NFT
@@ -151,7 +184,7 @@ func (r *NFTRepository) Update(nft *NFT) error {
 
 // UpdateOwner 更新所有者
 func (r *NFTRepository) UpdateOwner(id uint, newOwner string) error {
-	return r.db.Model(&NFT{}).Where("id = ?", id).Update("owner", newOwner).Error
+	return r.db.Model(&NFT{}).Where("id = ?", id).Update("owner", NormalizeAddress(newOwner)).Error
 }
 
 // IncrementViewCount 增加浏览次数
@@ -159,49 +192,83 @@ func (r *NFTRepository) IncrementViewCount(id uint) error {
 	return r.db.Model(&NFT{}).Where("id = ?", id).UpdateColumn("view_count", gorm.Expr("view_count + ?", 1)).Error
 }
 
-// IncrementLikeCount 增加点赞次数
-func (r *NFTRepository) IncrementLikeCount(id uint) error {
-	return r.db.Model(&NFT{}).Where("id = ?", id).UpdateColumn("like_count", gorm.Expr("like_count + ?", 1)).Error
-}
-
-// DecrementLikeCount 减少点赞次数
-func (r *NFTRepository) DecrementLikeCount(id uint) error {
-	return r.db.Model(&NFT{}).Where("id = ?", id).Where("like_count > ?", 0).UpdateColumn("like_count", gorm.Expr("like_count - ?", 1)).Error
-}
-
 // Delete 删除 NFT（软删除，更新状态）
 func (r *NFTRepository) Delete(id uint) error {
 	return r.db.Model(&NFT{}).Where("id = ?", id).Update("status", "burned").Error
 }
 
-// Search 搜索 NFT
-func (r *NFTRepository) Search(query string, page, pageSize int) ([]NFT, int64, error) {
-	var nfts []NFT
-	var total int64
+// Search 搜索 NFT，withCount 为 false 时不查询总数（见 findPage）
+func (r *NFTRepository) Search(query string, page, pageSize int, withCount bool) ([]NFT, int64, error) {
+	searchQuery := "%" + query + "%"
 
-	offset := (page - 1) * pageSize
+	count := r.db.Model(&NFT{}).
+		Where("status = ? AND (name ILIKE ? OR description ILIKE ?)", "active", searchQuery, searchQuery)
+	data := r.db.Where("status = ? AND (name ILIKE ? OR description ILIKE ?)", "active", searchQuery, searchQuery).
+		Order("created_at DESC")
 
-	searchQuery := "%" + query + "%"
+	return findPage[NFT](count, data, page, pageSize, withCount)
+}
 
-	// 计算总数
-	if err := r.db.Model(&NFT{}).
-		Where("status = ? AND (name ILIKE ? OR description ILIKE ?)", "active", searchQuery, searchQuery).
-		Count(&total).Error; err != nil {
-		return nil, 0, err
+// TraitFilter 属性过滤条件。Value 以字符串给出，形如数字时同时匹配 JSON 数字值（如 "5" 匹配 5）
+type TraitFilter struct {
+	TraitType string
+	Value     string
+}
+
+// jsonNumberPattern JSON 数字的语法（RFC 8259），不含 Infinity、NaN、+5、1_000、0x1p4 等 Go 可解析的写法
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// IsJSONNumber 判断字符串是否为合法的 JSON 数字
+func IsJSONNumber(value string) bool {
+	return jsonNumberPattern.MatchString(value)
+}
+
+// traitDocs 属性过滤条件对应的 @> 文档，数字形式的值额外生成数字版本
+func traitDocs(filter TraitFilter) ([]string, error) {
+	values := []interface{}{filter.Value}
+	if IsJSONNumber(filter.Value) {
+		values = append(values, json.Number(filter.Value))
 	}
 
-	// 获取数据
-	err := r.db.Where("status = ? AND (name ILIKE ? OR description ILIKE ?)", "active", searchQuery, searchQuery).
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(pageSize).
-		Find(&nfts).Error
+	docs := make([]string, len(values))
+	for i, value := range values {
+		doc, err := json.Marshal(map[string][]Trait{"attributes": {{TraitType: filter.TraitType, Value: value}}})
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = string(doc)
+	}
+	return docs, nil
+}
 
-	if err != nil {
-		return nil, 0, err
+// SearchByAttributes 按属性搜索 NFT：traits 之间为 AND，query 不为空时同时按名称/描述模糊匹配，
+// withCount 为 false 时不查询总数（见 findPage）。属性条件使用 @> 以命中 idx_nfts_metadata_gin
+func (r *NFTRepository) SearchByAttributes(query string, traits []TraitFilter, page, pageSize int, withCount bool) ([]NFT, int64, error) {
+	conditions := []string{"status = ?"}
+	args := []interface{}{"active"}
+	if query != "" {
+		searchQuery := "%" + query + "%"
+		conditions = append(conditions, "(name ILIKE ? OR description ILIKE ?)")
+		args = append(args, searchQuery, searchQuery)
 	}
+	for _, trait := range traits {
+		docs, err := traitDocs(trait)
+		if err != nil {
+			return nil, 0, err
+		}
+		alternatives := make([]string, len(docs))
+		for i, doc := range docs {
+			alternatives[i] = "metadata @> CAST(? AS jsonb)"
+			args = append(args, doc)
+		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+	where := strings.Join(conditions, " AND ")
 
-	return nfts, total, nil
+	count := r.db.Model(&NFT{}).Where(where, args...)
+	data := r.db.Where(where, args...).Order("created_at DESC")
+
+	return findPage[NFT](count, data, page, pageSize, withCount)
 }
 
 // GetTrending 获取热门 NFT（按浏览量和点赞数）
@@ -209,21 +276,108 @@ func (r *NFTRepository) GetTrending(limit int) ([]NFT, error) {
 	var nfts []NFT
 	err := r.db.Where("status = ?", "active").
 		Order("(view_count + like_count * 2) DESC").
-		Limit(limit).
+		Limit(capLimit("GetTrending", limit)).
 		Find(&nfts).Error
 	return nfts, err
 }
 
+// Trait NFT 属性（metadata.attributes 中的一项）
+type Trait struct {
+	TraitType string      `json:"trait_type"`
+	Value     interface{} `json:"value"`
+}
+
+// SimilarNFT 相似 NFT 及共同属性数量
+type SimilarNFT struct {
+	NFT          `gorm:"embedded"`
+	SharedTraits int64 `json:"shared_traits"`
+}
+
+// GetSimilarByTraits 获取同系列中与给定属性重合最多的 NFT
+func (r *NFTRepository) GetSimilarByTraits(contractAddress string, excludeID uint, traits []Trait, limit int) ([]SimilarNFT, error) {
+	if len(traits) == 0 {
+		return []SimilarNFT{}, nil
+	}
+
+	traitsJSON, err := json.Marshal(traits)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个属性一条 @> 条件，命中 idx_nfts_metadata_gin 预筛选候选行
+	containment := make([]string, len(traits))
+	args := []interface{}{string(traitsJSON), NormalizeAddress(contractAddress), excludeID}
+	for i, trait := range traits {
+		doc, err := json.Marshal(map[string][]Trait{"attributes": {trait}})
+		if err != nil {
+			return nil, err
+		}
+		containment[i] = "nfts.metadata @> CAST(? AS jsonb)"
+		args = append(args, string(doc))
+	}
+	args = append(args, limit)
+
+	query := `SELECT nfts.*, COUNT(*) AS shared_traits
+		FROM nfts,
+			jsonb_array_elements(CASE WHEN jsonb_typeof(nfts.metadata->'attributes') = 'array'
+				THEN nfts.metadata->'attributes' ELSE '[]'::jsonb END) AS attr
+		WHERE jsonb_build_object('trait_type', attr->'trait_type', 'value', attr->'value')
+				IN (SELECT jsonb_array_elements(CAST(? AS jsonb)))
+			AND nfts.contract_address = ?
+			AND nfts.id <> ?
+			AND nfts.status = 'active'
+			AND (` + strings.Join(containment, " OR ") + `)
+		GROUP BY nfts.id
+		ORDER BY shared_traits DESC, nfts.id
+		LIMIT ?`
+
+	var results []SimilarNFT
+	err = r.db.Raw(query, args...).Scan(&results).Error
+	return results, err
+}
+
 // CountByOwner 统计用户拥有的 NFT 数量
 func (r *NFTRepository) CountByOwner(owner string) (int64, error) {
 	var count int64
-	err := r.db.Model(&NFT{}).Where("owner = ? AND status = ?", owner, "active").Count(&count).Error
+	err := r.db.Model(&NFT{}).Where("owner = ? AND status = ?", NormalizeAddress(owner), "active").Count(&count).Error
 	return count, err
 }
 
+// ContractNFTStats 合约的有效 NFT 数及持有者数（合约地址小写）
+type ContractNFTStats struct {
+	NFTContract string
+	TotalItems  int64
+	Owners      int64
+}
+
+// GetStatsByContracts 按合约分组统计有效 NFT 数及不同持有者数（合约地址不区分大小写），
+// 没有 NFT 的合约不返回
+func (r *NFTRepository) GetStatsByContracts(contractAddresses []string) ([]ContractNFTStats, error) {
+	var stats []ContractNFTStats
+	if len(contractAddresses) == 0 {
+		return stats, nil
+	}
+	err := r.db.Model(&NFT{}).
+		Select("LOWER(contract_address) AS nft_contract, COUNT(*) AS total_items, COUNT(DISTINCT LOWER(owner)) AS owners").
+		Where("status = ? AND contract_address IN ?", "active", lowerAll(contractAddresses)).
+		Group("LOWER(contract_address)").
+		Scan(&stats).Error
+	return stats, err
+}
+
 // CountByContract 统计合约的 NFT 数量
 func (r *NFTRepository) CountByContract(contractAddress string) (int64, error) {
 	var count int64
-	err := r.db.Model(&NFT{}).Where("contract_address = ? AND status = ?", contractAddress, "active").Count(&count).Error
+	err := r.db.Model(&NFT{}).Where("contract_address = ? AND status = ?", NormalizeAddress(contractAddress), "active").Count(&count).Error
+	return count, err
+}
+
+// CountOwnersByContract 统计合约有效 NFT 的不同持有者数量
+func (r *NFTRepository) CountOwnersByContract(contractAddress string) (int64, error) {
+	var count int64
+	err := r.db.Model(&NFT{}).
+		Select("COUNT(DISTINCT LOWER(owner))").
+		Where("contract_address = ? AND status = ?", NormalizeAddress(contractAddress), "active").
+		Scan(&count).Error
 	return count, err
 }
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 只有 JSON 数字语法的值额外匹配数字属性；Go 能解析但 JSON 中不存在的写法按字符串匹配
+func TestTraitDocs(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"5", []string{`{"attributes":[{"trait_type":"level","value":"5"}]}`, `{"attributes":[{"trait_type":"level","value":5}]}`}},
+		{"-1.5e3", []string{`{"attributes":[{"trait_type":"level","value":"-1.5e3"}]}`, `{"attributes":[{"trait_type":"level","value":-1.5e3}]}`}},
+		{"gold", []string{`{"attributes":[{"trait_type":"level","value":"gold"}]}`}},
+	}
+	for _, value := range []string{"Infinity", "-Inf", "NaN", "+5", "1_000", "0x1p4", "05", ".5", "5.", ""} {
+		tests = append(tests, struct {
+			value string
+			want  []string
+		}{value, []string{`{"attributes":[{"trait_type":"level","value":"` + value + `"}]}`}})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := traitDocs(TraitFilter{TraitType: "level", Value: tt.value})
+			if err != nil {
+				t.Fatalf("traitDocs: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("traitDocs(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationPreferences 按钱包地址保存的通知偏好：某事件只有在事件类型和渠道都开启时才发送
+type NotificationPreferences struct {
+	Address string `gorm:"primaryKey" json:"address"`
+
+	// 事件类型
+	Sales  bool `gorm:"not null" json:"sales"`  // 自己的 NFT 成交 / 买入成交
+	Outbid bool `gorm:"not null" json:"outbid"` // 拍卖出价被超过
+	Offers bool `gorm:"not null" json:"offers"` // 收到新的出价
+
+	// 渠道
+	Email     bool `gorm:"not null" json:"email"`
+	Webhook   bool `gorm:"not null" json:"webhook"`
+	WebSocket bool `gorm:"column:websocket;not null" json:"websocket"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (NotificationPreferences) TableName() string {
+	return "notification_preferences"
+}
+
+// NotificationPreferenceRepository 通知偏好仓储
+type NotificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationPreferenceRepository 创建通知偏好仓储
+func NewNotificationPreferenceRepository(db *gorm.DB) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: db}
+}
+
+// GetByAddress 根据地址获取通知偏好
+func (r *NotificationPreferenceRepository) GetByAddress(address string) (*NotificationPreferences, error) {
+	var prefs NotificationPreferences
+	err := r.db.Where("address = ?", strings.ToLower(address)).First(&prefs).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// Upsert 写入通知偏好（覆盖全部字段）
+func (r *NotificationPreferenceRepository) Upsert(prefs *NotificationPreferences) error {
+	prefs.Address = strings.ToLower(prefs.Address)
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		UpdateAll: true,
+	}).Create(prefs).Error
+}
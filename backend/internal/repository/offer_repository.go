@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 出价状态。active 但已过期的出价在查询和响应中视为 expired
+const (
+	OfferStatusActive    = "active"
+	OfferStatusAccepted  = "accepted"
+	OfferStatusRejected  = "rejected"
+	OfferStatusExpired   = "expired"
+	OfferStatusCancelled = "cancelled"
+)
+
+// 出价排序方式
+const (
+	OfferSortAmount = "amount" // 金额从高到低
+	OfferSortRecent = "recent" // 最新优先
+)
+
+// Offer 出价模型
+type Offer struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	NFTContract    string     `gorm:"index;not null" json:"nft_contract"`
+	TokenID        string     `gorm:"index;not null" json:"token_id"`
+	Offerer        string     `gorm:"index;not null" json:"offerer"`
+	Price          string     `gorm:"not null" json:"price"`
+	PriceNumeric   *string    `gorm:"type:numeric(78,0)" json:"-"` // 用于按金额排序
+	Status         string     `gorm:"index;default:'active'" json:"status"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	TxHash         *string    `json:"tx_hash"`
+	AcceptedTxHash *string    `json:"accepted_tx_hash"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+}
+
+// TableName 指定表名
+func (Offer) TableName() string {
+	return "offers"
+}
+
+// EffectiveStatus 出价的实际状态：未处理但已过期的出价返回 expired
+func (o *Offer) EffectiveStatus(now time.Time) string {
+	if o.Status == OfferStatusActive && !o.ExpiresAt.After(now) {
+		return OfferStatusExpired
+	}
+	return o.Status
+}
+
+// OfferFilter 出价查询条件
+type OfferFilter struct {
+	Status string // 为空时不过滤
+	Sort   string // amount（默认）或 recent
+}
+
+// OfferRepository 出价仓储
+type OfferRepository struct {
+	db *gorm.DB
+}
+
+// NewOfferRepository 创建出价仓储
+func NewOfferRepository(db *gorm.DB) *OfferRepository {
+	return &OfferRepository{db: db}
+}
+
+// Create 创建出价
+func (r *OfferRepository) Create(offer *Offer) error {
+	return r.db.Create(offer).Error
+}
+
+// GetByID 根据 ID 获取出价
+func (r *OfferRepository) GetByID(id uint) (*Offer, error) {
+	var offer Offer
+	if err := r.db.First(&offer, id).Error; err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// GetBestActive 获取某个 NFT 当前最高的有效出价（合约地址不区分大小写），没有时返回 ErrRecordNotFound
+func (r *OfferRepository) GetBestActive(nftContract, tokenID string) (*Offer, error) {
+	var offer Offer
+	err := withOfferStatus(r.db.Model(&Offer{}), OfferStatusActive).
+		Where("nft_contract = ? AND token_id = ?", NormalizeAddress(nftContract), tokenID).
+		Order("price_numeric DESC NULLS LAST, id").
+		First(&offer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// Cancel 取消仍有效的出价，返回是否实际取消（已过期或已处理的出价不变）
+func (r *OfferRepository) Cancel(id uint) (bool, error) {
+	result := withOfferStatus(r.db.Model(&Offer{}), OfferStatusActive).
+		Where("id = ?", id).
+		Update("status", OfferStatusCancelled)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetByToken 分页获取某个 NFT 的出价（合约地址不区分大小写）
+func (r *OfferRepository) GetByToken(nftContract, tokenID string, filter OfferFilter, page, pageSize int) ([]Offer, int64, error) {
+	query := r.db.Model(&Offer{}).Where("nft_contract = ? AND token_id = ?", NormalizeAddress(nftContract), tokenID)
+	return r.paginate(query, filter, page, pageSize)
+}
+
+// GetByBidder 分页获取某个地址发出的出价（地址不区分大小写）
+func (r *OfferRepository) GetByBidder(offerer string, filter OfferFilter, page, pageSize int) ([]Offer, int64, error) {
+	query := r.db.Model(&Offer{}).Where("offerer = ?", NormalizeAddress(offerer))
+	return r.paginate(query, filter, page, pageSize)
+}
+
+// paginate 按过滤条件计数并分页查询
+func (r *OfferRepository) paginate(query *gorm.DB, filter OfferFilter, page, pageSize int) ([]Offer, int64, error) {
+	var offers []Offer
+	var total int64
+
+	query = withOfferStatus(query, filter.Status)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "price_numeric DESC NULLS LAST, id DESC"
+	if filter.Sort == OfferSortRecent {
+		order = "created_at DESC, id DESC"
+	}
+
+	err := query.Order(order).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&offers).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return offers, total, nil
+}
+
+// withOfferStatus 按实际状态过滤：active 仅含未过期的，expired 包含已过期但未处理的
+func withOfferStatus(query *gorm.DB, status string) *gorm.DB {
+	switch status {
+	case "":
+		return query
+	case OfferStatusActive:
+		return query.Where("status = ? AND expires_at > NOW()", OfferStatusActive)
+	case OfferStatusExpired:
+		return query.Where("status = ? OR (status = ? AND expires_at <= NOW())", OfferStatusExpired, OfferStatusActive)
+	default:
+		return query.Where("status = ?", status)
+	}
+}
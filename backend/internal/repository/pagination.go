@@ -0,0 +1,34 @@
+package repository
+
+import "gorm.io/gorm"
+
+// findPage 分页查询 data（已带排序）。withCount 为 true 时先用 count 查询总数；为 false 时跳过
+// COUNT，多取一行判断是否还有下一页，此时返回的 total 为 offset 加实际读到的行数（最多比本页多 1），
+// 不是总数，只保证 total > page*pageSize 当且仅当存在下一页
+func findPage[T any](count, data *gorm.DB, page, pageSize int, withCount bool) ([]T, int64, error) {
+	var rows []T
+	var total int64
+
+	offset := (page - 1) * pageSize
+	limit := pageSize
+
+	if withCount {
+		if err := count.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	} else {
+		limit++
+	}
+
+	if err := data.Offset(offset).Limit(limit).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if !withCount {
+		total = int64(offset + len(rows))
+		if len(rows) > pageSize {
+			rows = rows[:pageSize]
+		}
+	}
+	return rows, total, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// selfTestCheck 启动自检项：一条代表性查询，只校验 SQL 能否执行，不读取数据
+type selfTestCheck struct {
+	name string
+	run  func(db *gorm.DB) error
+}
+
+// selfTestChecks 覆盖依赖 Postgres 特性的查询类型（NUMERIC 转换、jsonb 运算符、INTERVAL、LATERAL、物化视图）
+// 以及各模型映射的列是否存在
+func selfTestChecks(volumeSource string) []selfTestCheck {
+	volumeExpr := (&TransactionRepository{volumeSource: volumeSource}).volumeExpr()
+
+	return []selfTestCheck{
+		{"schema: nfts columns", selectColumns(&[]NFT{})},
+		{"schema: transactions columns", selectColumns(&[]Transaction{})},
+		{"schema: collections columns", selectColumns(&[]Collection{})},
+		{"schema: offers columns", selectColumns(&[]Offer{})},
+		{"schema: users columns", selectColumns(&[]User{})},
+		{"schema: failed_events columns", selectColumns(&[]FailedEvent{})},
+		{"schema: sync_state columns", selectColumns(&[]SyncState{})},
+		{"numeric: listing price sum", func(db *gorm.DB) error {
+			var total string
+			return db.Model(&Listing{}).
+				Select("COALESCE(SUM(CAST(price AS NUMERIC)), 0)").
+				Where("FALSE").
+				Scan(&total).Error
+		}},
+		{"numeric: transaction volume sum", func(db *gorm.DB) error {
+			var total string
+			return db.Model(&Transaction{}).
+				Select("COALESCE(SUM(" + volumeExpr + "), 0)").
+				Where("FALSE").
+				Scan(&total).Error
+		}},
+		{"interval: daily volume window", func(db *gorm.DB) error {
+			var rows []map[string]interface{}
+			return db.Raw(`SELECT DATE(block_timestamp) AS date, COALESCE(SUM(`+volumeExpr+`), 0) AS volume
+				FROM transactions
+				WHERE block_timestamp >= NOW() - ? * INTERVAL '1 day'
+				GROUP BY DATE(block_timestamp)
+				LIMIT 0`, 1).Scan(&rows).Error
+		}},
+		{"jsonb: metadata containment and attributes", func(db *gorm.DB) error {
+			var ids []uint
+			return db.Raw(`SELECT nfts.id
+				FROM nfts,
+					jsonb_array_elements(CASE WHEN jsonb_typeof(nfts.metadata->'attributes') = 'array'
+						THEN nfts.metadata->'attributes' ELSE '[]'::jsonb END) AS attr
+				WHERE nfts.metadata @> CAST(? AS jsonb)
+					AND jsonb_build_object('trait_type', attr->'trait_type', 'value', attr->'value')
+						IN (SELECT jsonb_array_elements(CAST(? AS jsonb)))
+				LIMIT 0`,
+				`{"attributes":[{"trait_type":"selftest","value":"selftest"}]}`,
+				`[{"trait_type":"selftest","value":"selftest"}]`).Scan(&ids).Error
+		}},
+		{"lateral: listing offer summary", func(db *gorm.DB) error {
+			var listings []Listing
+			return db.Scopes(withOfferSummary).Limit(0).Find(&listings).Error
+		}},
+		{"view: mv_trending_collections", func(db *gorm.DB) error {
+			var rows []TrendingCollection
+			return db.Table("mv_trending_collections").Limit(0).Find(&rows).Error
+		}},
+	}
+}
+
+// selectColumns 显式列出模型所有字段查询（LIMIT 0），缺列时报错
+func selectColumns(dest interface{}) func(db *gorm.DB) error {
+	return func(db *gorm.DB) error {
+		return db.Session(&gorm.Session{QueryFields: true}).Limit(0).Find(dest).Error
+	}
+}
+
+// SelfTest 对线上数据库执行各类代表性查询，返回所有失败项（每项注明查询类型），
+// 用于在启动时发现表结构或 Postgres 版本不匹配，而不是等到首个用户请求
+func SelfTest(ctx context.Context, db *gorm.DB, volumeSource string) error {
+	var errs []error
+	for _, check := range selfTestChecks(volumeSource) {
+		if err := check.run(db.WithContext(ctx)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", check.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
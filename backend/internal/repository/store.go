@@ -0,0 +1,180 @@
+package repository
+
+import "time"
+
+// NFTStore NFT 存储接口，由 NFTRepository 实现，测试时可替换为内存实现
+type NFTStore interface {
+	Create(nft *NFT) error
+	CreateIfNotExists(nft *NFT) (created bool, err error)
+	GetByID(id uint) (*NFT, error)
+	GetByContractAndToken(contractAddress, tokenID string) (*NFT, error)
+	GetByTokens(tokens []TokenRef) ([]NFT, error)
+	GetByOwner(owner string, page, pageSize int, includeMetadata bool) ([]NFT, int64, error)
+	GetByContract(contractAddress string, page, pageSize int, includeMetadata, withCount bool) ([]NFT, int64, error)
+	GetAll(page, pageSize int, includeMetadata, withCount bool) ([]NFT, int64, error)
+	Search(query string, page, pageSize int, withCount bool) ([]NFT, int64, error)
+	SearchByAttributes(query string, traits []TraitFilter, page, pageSize int, withCount bool) ([]NFT, int64, error)
+	GetTrending(limit int) ([]NFT, error)
+	GetStatsByContracts(contractAddresses []string) ([]ContractNFTStats, error)
+	CountByContract(contractAddress string) (int64, error)
+	CountOwnersByContract(contractAddress string) (int64, error)
+	GetSimilarByTraits(contractAddress string, excludeID uint, traits []Trait, limit int) ([]SimilarNFT, error)
+	UpdateOwner(id uint, newOwner string) error
+	UpdateMetadata(id uint, update NFTMetadataUpdate) error
+	SetTokenStandard(id uint, standard string) error
+	IncrementViewCount(id uint) error
+}
+
+// NFTLikeStore 点赞存储接口，由 NFTLikeRepository 实现；Like/Unlike 仅在记录实际变化时调整 like_count
+type NFTLikeStore interface {
+	Like(nftID uint, userAddress string) (created bool, err error)
+	Unlike(nftID uint, userAddress string) (deleted bool, err error)
+	HasLiked(nftID uint, userAddress string) (bool, error)
+	RecomputeLikeCount(nftID uint) (old, updated int64, err error)
+	RecomputeAllLikeCounts() (int64, error)
+}
+
+// ListingStore 挂单存储接口，由 ListingRepository 实现
+type ListingStore interface {
+	Create(listing *Listing) error
+	CreateIfNotExists(listing *Listing) error
+	GetByID(id uint) (*Listing, error)
+	GetByItemID(itemID uint64) (*Listing, error)
+	GetByTxHash(txHash string) (*Listing, error)
+	GetActiveListings(filter ListingFilter, page, pageSize int, withCount bool) ([]Listing, int64, error)
+	GetActiveAsOf(at time.Time, nftContract string, page, pageSize int) ([]Listing, int64, error)
+	GetBySellerPaginated(seller string, includeArchived bool, page, pageSize int) ([]Listing, int64, error)
+	GetActiveBySeller(seller string, limit int) ([]Listing, error)
+	SearchListings(filter ListingSearchFilter, page, pageSize int, withCount bool) ([]Listing, int64, error)
+	UpdateStatus(id uint, status string) error
+	BatchUpsert(listings []Listing, batchSize int) error
+	GetByItemIDs(itemIDs []uint64) ([]Listing, error)
+	GetActiveByTokens(tokens []TokenRef) ([]Listing, error)
+	MarkSold(id uint, soldAt time.Time) error
+	MarkSoldByItemIDs(soldAt map[uint64]time.Time) error
+	ArchiveSettled(before time.Time, limit int) (int64, error)
+	GetUnverified(limit int) ([]Listing, error)
+	MarkVerified(id uint) error
+	SetContractVersion(id uint, version string) error
+	SetBlockHash(id uint, blockHash string) error
+	RevertSale(id uint) error
+	CountActiveListings() (int64, error)
+	CountActiveListingsByContract(nftContract string) (int64, error)
+	CountTotalListings() (int64, error)
+	GetTotalVolume() (string, error)
+	GetAveragePrice() (string, error)
+	GetMinPrice() (string, error)
+	GetMinPriceByContract(nftContract string) (string, error)
+	GetMaxPrice() (string, error)
+	GetFloorPrices() ([]CollectionFloor, error)
+	GetListingStatsByContracts(nftContracts []string) ([]ContractListingStats, error)
+	GetActivePricesByContract(nftContract string) ([]string, error)
+}
+
+// TransactionStore 交易存储接口，由 TransactionRepository 实现
+type TransactionStore interface {
+	Create(tx *Transaction) error
+	CreateIfNotExists(tx *Transaction) (bool, error)
+	GetByHash(txHash string) (*Transaction, error)
+	GetSaleByListingID(listingID uint) (*Transaction, error)
+	GetSaleByItemNear(itemID, blockNumber, window uint64) (*Transaction, error)
+	GetByID(id uint) (*Transaction, error)
+	GetAll(page, pageSize int, withCount bool) ([]Transaction, int64, error)
+	GetFiltered(filter TxFilter, page, pageSize int, withCount bool) ([]Transaction, int64, error)
+	GetFilteredAfterID(filter TxFilter, afterID uint, limit int) ([]Transaction, error)
+	GetByAddress(address string, page, pageSize int) ([]Transaction, int64, error)
+	GetByNFT(nftContract, tokenID string, page, pageSize int) ([]Transaction, int64, error)
+	GetRecent(limit int) ([]Transaction, error)
+	GetTotalVolume() (string, error)
+	GetVolumeByContract(nftContract string) (string, error)
+	GetVolumeSplitByContract(nftContract string) (primary, secondary string, err error)
+	GetVolumeSplitByContracts(nftContracts []string) ([]ContractVolumeSplit, error)
+	GetDailyVolume(days int) ([]DailyVolume, error)
+	CountByType(txType string) (int64, error)
+	DeleteByStatusBefore(status string, before time.Time) (int64, error)
+	BatchUpsert(txs []Transaction, batchSize int) error
+	UpdateStatus(id uint, status string) error
+	UpdateBlock(id uint, blockNumber uint64, blockHash string) error
+	GetPendingSales(limit int) ([]Transaction, error)
+}
+
+// CollectionStore 系列存储接口，由 CollectionRepository 实现
+type CollectionStore interface {
+	GetByAddress(contractAddress string) (*Collection, error)
+	GetByAddresses(contractAddresses []string) ([]Collection, error)
+	UpdateFeeOverride(contractAddress string, feeBps *int64) error
+	UpdateBidIncrement(contractAddress string, bps *int64, wei *string) error
+	UpdateImageCDNBase(contractAddress string, base *string) error
+	GetTopCollections(window string, limit int) ([]TrendingCollection, error)
+	RefreshTrending() error
+}
+
+// HolderStore 持有者快照存储接口，由 HolderRepository 实现
+type HolderStore interface {
+	StreamHolders(contractAddress string, fn func(HolderBalance) error) error
+}
+
+// FailedEventStore 死信事件存储接口，由 FailedEventRepository 实现
+type FailedEventStore interface {
+	Create(event *FailedEvent) error
+	CountSince(since time.Time) (int64, error)
+	RecentSince(since time.Time, limit int) ([]FailedEvent, error)
+}
+
+// OfferStore 出价存储接口，由 OfferRepository 实现
+type OfferStore interface {
+	Create(offer *Offer) error
+	GetByID(id uint) (*Offer, error)
+	GetBestActive(nftContract, tokenID string) (*Offer, error)
+	Cancel(id uint) (cancelled bool, err error)
+	GetByToken(nftContract, tokenID string, filter OfferFilter, page, pageSize int) ([]Offer, int64, error)
+	GetByBidder(offerer string, filter OfferFilter, page, pageSize int) ([]Offer, int64, error)
+}
+
+// BidStore 竞价存储接口，由 BidRepository 实现
+type BidStore interface {
+	PlaceBid(bid *Bid, check func(listing *Listing, highest *Bid) error) error
+	GetHighest(listingID uint) (*Bid, error)
+	GetByListing(listingID uint, page, pageSize int) ([]Bid, int64, error)
+}
+
+// UserStore 用户存储接口，由 UserRepository 实现
+type UserStore interface {
+	GetByAddress(address string) (*User, error)
+	SetEmail(address string, email EncryptedString) error
+}
+
+// NotificationPreferenceStore 通知偏好存储接口，由 NotificationPreferenceRepository 实现
+type NotificationPreferenceStore interface {
+	GetByAddress(address string) (*NotificationPreferences, error)
+	Upsert(prefs *NotificationPreferences) error
+}
+
+// SyncStateStore 索引进度存储接口，由 SyncStateRepository 实现
+type SyncStateStore interface {
+	GetLastSyncedBlock(contractAddress, eventName string) (block uint64, ok bool, err error)
+	SetLastSyncedBlock(contractAddress, eventName string, block uint64) error
+}
+
+// IndexTransactor 事件写入与索引进度更新的事务执行器，由 IndexTxRepository 实现，
+// 保证进度不会在事件写入失败（或进程崩溃）时前进
+type IndexTransactor interface {
+	InTx(fn func(stores IndexStores) error) error
+}
+
+var (
+	_ NFTStore         = (*NFTRepository)(nil)
+	_ NFTLikeStore     = (*NFTLikeRepository)(nil)
+	_ ListingStore     = (*ListingRepository)(nil)
+	_ TransactionStore = (*TransactionRepository)(nil)
+	_ CollectionStore  = (*CollectionRepository)(nil)
+	_ HolderStore      = (*HolderRepository)(nil)
+	_ FailedEventStore = (*FailedEventRepository)(nil)
+	_ UserStore        = (*UserRepository)(nil)
+	_ OfferStore       = (*OfferRepository)(nil)
+	_ BidStore         = (*BidRepository)(nil)
+	_ SyncStateStore   = (*SyncStateRepository)(nil)
+	_ IndexTransactor  = (*IndexTxRepository)(nil)
+
+	_ NotificationPreferenceStore = (*NotificationPreferenceRepository)(nil)
+)
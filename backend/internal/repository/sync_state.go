@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SyncState 合约事件的索引进度，每个合约的每个事件流一行（各事件由独立的订阅入库，进度互不相同）
+type SyncState struct {
+	ID              uint      `gorm:"primaryKey" json:"-"`
+	ContractAddress string    `gorm:"uniqueIndex:idx_sync_state_contract_event;not null" json:"contract_address"` // 小写合约地址
+	EventName       string    `gorm:"uniqueIndex:idx_sync_state_contract_event;not null" json:"event_name"`       // 事件名，如 MarketItemSold
+	LastSyncedBlock uint64    `gorm:"not null" json:"last_synced_block"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SyncState) TableName() string {
+	return "sync_state"
+}
+
+// SyncStateRepository 索引进度仓储
+type SyncStateRepository struct {
+	db *gorm.DB
+}
+
+// NewSyncStateRepository 创建索引进度仓储
+func NewSyncStateRepository(db *gorm.DB) *SyncStateRepository {
+	return &SyncStateRepository{db: db}
+}
+
+// GetLastSyncedBlock 获取合约某个事件流已索引到的区块，从未索引过时 ok 为 false
+func (r *SyncStateRepository) GetLastSyncedBlock(contractAddress, eventName string) (block uint64, ok bool, err error) {
+	var state SyncState
+	err = r.db.Where("contract_address = ? AND event_name = ?", strings.ToLower(contractAddress), eventName).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return state.LastSyncedBlock, true, nil
+}
+
+// SetLastSyncedBlock 推进合约某个事件流的索引进度，只前进不后退（重新回填旧区块不会回退游标）
+func (r *SyncStateRepository) SetLastSyncedBlock(contractAddress, eventName string, block uint64) error {
+	state := &SyncState{
+		ContractAddress: strings.ToLower(contractAddress),
+		EventName:       eventName,
+		LastSyncedBlock: block,
+		UpdatedAt:       time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "contract_address"}, {Name: "event_name"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_synced_block": gorm.Expr("GREATEST(sync_state.last_synced_block, EXCLUDED.last_synced_block)"),
+			"updated_at":        gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(state).Error
+}
+
+// IndexStores 事件入库时在同一事务中使用的仓储
+type IndexStores struct {
+	Listings     ListingStore
+	Transactions TransactionStore
+	SyncState    SyncStateStore
+}
+
+// IndexTxRepository 在一个数据库事务中执行事件写入与索引进度更新
+type IndexTxRepository struct {
+	db           *gorm.DB
+	volumeSource string
+}
+
+// NewIndexTxRepository 创建事务执行器，volumeSource 传给事务内的交易仓储
+func NewIndexTxRepository(db *gorm.DB, volumeSource string) *IndexTxRepository {
+	return &IndexTxRepository{db: db, volumeSource: volumeSource}
+}
+
+// InTx 在事务中执行 fn，fn 通过 stores 进行的写入全部提交或全部回滚
+func (r *IndexTxRepository) InTx(fn func(stores IndexStores) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(IndexStores{
+			Listings:     NewListingRepository(tx),
+			Transactions: NewTransactionRepository(tx, r.volumeSource),
+			SyncState:    NewSyncStateRepository(tx),
+		})
+	})
+}
@@ -4,28 +4,34 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Transaction 交易模型
 type Transaction struct {
 	ID               uint      `gorm:"primaryKey" json:"id"`
-	TxHash           string    `gorm:"uniqueIndex;not null" json:"tx_hash"`
+	TxHash           string    `gorm:"index;uniqueIndex:idx_transactions_tx_log;not null" json:"tx_hash"`
 	BlockNumber      uint64    `gorm:"index;not null" json:"block_number"`
+	BlockHash        string    `gorm:"size:66" json:"block_hash"` // 用于确认前检测链重组，早期记录为空
 	BlockTimestamp   time.Time `gorm:"index;not null" json:"block_timestamp"`
 	TxType           string    `gorm:"index;not null" json:"tx_type"` // list, sale, cancel, transfer, mint
 	ListingID        *uint     `gorm:"index" json:"listing_id"`
+	ItemID           *uint64   `gorm:"index" json:"item_id,omitempty"` // 市场合约 item_id（成交交易）
 	NFTContract      string    `gorm:"index;not null" json:"nft_contract"`
 	TokenID          string    `gorm:"index;not null" json:"token_id"`
 	FromAddress      string    `gorm:"index;not null" json:"from_address"`
 	ToAddress        string    `gorm:"index" json:"to_address"`
 	Value            string    `json:"value"`
 	ValueNumeric     string    `gorm:"type:numeric(78,0)" json:"value_numeric"`
+	NetValueNumeric  *string   `gorm:"type:numeric(78,0)" json:"net_value_numeric"` // 实际到账金额，无法推导时为空
 	GasPrice         string    `json:"gas_price"`
 	GasUsed          uint64    `json:"gas_used"`
 	PlatformFee      string    `json:"platform_fee"`
+	IsPrimary        bool      `gorm:"default:false" json:"is_primary"`   // 卖家为 NFT 创作者时为一级市场销售
 	Status           string    `gorm:"default:'confirmed'" json:"status"` // pending, confirmed, failed
-	LogIndex         int       `json:"log_index"`
+	LogIndex         int       `gorm:"uniqueIndex:idx_transactions_tx_log" json:"log_index"`
 	TransactionIndex int       `json:"transaction_index"`
+	ContractVersion  string    `json:"contract_version"` // 解码事件所用的市场合约 ABI 版本
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
@@ -35,14 +41,29 @@ func (Transaction) TableName() string {
 	return "transactions"
 }
 
+// 交易额统计口径
+const (
+	VolumeSourceListed = "listed" // 挂单价格
+	VolumeSourceNet    = "net"    // 实际到账金额（无法推导时回退到挂单价格）
+)
+
 // TransactionRepository 交易仓储
 type TransactionRepository struct {
-	db *gorm.DB
+	db           *gorm.DB
+	volumeSource string
+}
+
+// NewTransactionRepository 创建交易仓储，volumeSource 决定交易额统计口径
+func NewTransactionRepository(db *gorm.DB, volumeSource string) *TransactionRepository {
+	return &TransactionRepository{db: db, volumeSource: volumeSource}
 }
 
-// NewTransactionRepository 创建交易仓储
-func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+// volumeExpr 交易额求和使用的列表达式
+func (r *TransactionRepository) volumeExpr() string {
+	if r.volumeSource == VolumeSourceNet {
+		return "COALESCE(net_value_numeric, CAST(value_numeric AS NUMERIC))"
+	}
+	return "CAST(value_numeric AS NUMERIC)"
 }
 
 // Create 创建交易记录
@@ -50,6 +71,27 @@ func (r *TransactionRepository) Create(tx *Transaction) error {
 	return r.db.Create(tx).Error
 }
 
+// CreateIfNotExists 创建交易记录，(tx_hash, log_index) 已存在时跳过；返回是否实际插入。
+// 用于订阅重连后重复投递的同一日志
+func (r *TransactionRepository) CreateIfNotExists(tx *Transaction) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tx_hash"}, {Name: "log_index"}},
+		DoNothing: true,
+	}).Create(tx)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// BatchUpsert 批量写入交易（每条语句 batchSize 行），冲突时跳过，用于回填
+func (r *TransactionRepository) BatchUpsert(txs []Transaction, batchSize int) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&txs, batchSize).Error
+}
+
 // GetByHash 根据交易哈希获取交易
 func (r *TransactionRepository) GetByHash(txHash string) (*Transaction, error) {
 	var tx Transaction
@@ -60,6 +102,42 @@ func (r *TransactionRepository) GetByHash(txHash string) (*Transaction, error) {
 	return &tx, nil
 }
 
+// GetSaleByListingID 获取挂单的成交交易（多条时取最新区块）
+func (r *TransactionRepository) GetSaleByListingID(listingID uint) (*Transaction, error) {
+	var tx Transaction
+	err := r.db.Where("listing_id = ? AND tx_type = ?", listingID, "sale").
+		Order("block_number DESC").
+		First(&tx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// GetSaleByItemNear 获取同一市场项在 [blockNumber-window, blockNumber+window] 区块范围内的成交交易，
+// 不含已被链重组撤销（failed）的
+func (r *TransactionRepository) GetSaleByItemNear(itemID, blockNumber, window uint64) (*Transaction, error) {
+	from, to := blockRange(blockNumber, window)
+
+	var tx Transaction
+	err := r.db.Where("item_id = ? AND tx_type = ? AND status <> ?", itemID, "sale", "failed").
+		Where("block_number BETWEEN ? AND ?", from, to).
+		Order("block_number DESC").
+		First(&tx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// blockRange 以 blockNumber 为中心、半径为 window 的区块范围，下界不小于 0
+func blockRange(blockNumber, window uint64) (from, to uint64) {
+	if blockNumber > window {
+		from = blockNumber - window
+	}
+	return from, blockNumber + window
+}
+
 // GetByID 根据 ID 获取交易
 func (r *TransactionRepository) GetByID(id uint) (*Transaction, error) {
 	var tx Transaction
@@ -70,11 +148,31 @@ func (r *TransactionRepository) GetByID(id uint) (*Transaction, error) {
 	return &tx, nil
 }
 
+// GetFiltered 按过滤条件获取交易（分页），withCount 为 false 时不查询总数（见 findPage）
+func (r *TransactionRepository) GetFiltered(filter TxFilter, page, pageSize int, withCount bool) ([]Transaction, int64, error) {
+	count := filter.Apply(r.db.Model(&Transaction{}))
+	data := filter.Apply(r.db.Model(&Transaction{})).Order("block_timestamp DESC")
+
+	return findPage[Transaction](count, data, page, pageSize, withCount)
+}
+
+// GetFilteredAfterID 按 ID 游标升序获取满足过滤条件的交易，用于流式导出
+func (r *TransactionRepository) GetFilteredAfterID(filter TxFilter, afterID uint, limit int) ([]Transaction, error) {
+	var txs []Transaction
+	err := filter.Apply(r.db.Model(&Transaction{})).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&txs).Error
+	return txs, err
+}
+
 // GetByAddress 根据地址获取交易（发送或接收）
 func (r *TransactionRepository) GetByAddress(address string, page, pageSize int) ([]Transaction, int64, error) {
 	var txs []Transaction
 	var total int64
 
+	address = NormalizeAddress(address)
 	offset := (page - 1) * pageSize
 
 	// 计算总数
@@ -103,6 +201,7 @@ func (r *TransactionRepository) GetByNFT(nftContract, tokenID string, page, page
 	var txs []Transaction
 	var total int64
 
+	nftContract = NormalizeAddress(nftContract)
 	offset := (page - 1) * pageSize
 
 	// 计算总数
@@ -155,33 +254,16 @@ func (r *TransactionRepository) GetByType(txType string, page, pageSize int) ([]
 // GetRecent 获取最近的交易
 func (r *TransactionRepository) GetRecent(limit int) ([]Transaction, error) {
 	var txs []Transaction
-	err := r.db.Order("block_timestamp DESC").Limit(limit).Find(&txs).Error
+	err := r.db.Order("block_timestamp DESC").Limit(capLimit("GetRecent", limit)).Find(&txs).Error
 	return txs, err
 }
 
-// GetAll 获取所有交易（分页）
-func (r *TransactionRepository) GetAll(page, pageSize int) ([]Transaction, int64, error) {
-	var txs []Transaction
-	var total int64
-
-	offset := (page - 1) * pageSize
-
-	// 计算总数
-	if err := r.db.Model(&Transaction{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+// GetAll 获取所有交易（分页），withCount 为 false 时不查询总数（见 findPage）
+func (r *TransactionRepository) GetAll(page, pageSize int, withCount bool) ([]Transaction, int64, error) {
+	count := r.db.Model(&Transaction{})
+	data := r.db.Order("block_timestamp DESC")
 
-	// 获取数据
-	err := r.db.Order("block_timestamp DESC").
-		Offset(offset).
-		Limit(pageSize).
-		Find(&txs).Error
-
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return txs, total, nil
+	return findPage[Transaction](count, data, page, pageSize, withCount)
 }
 
 // GetTotalVolume 获取总交易额
@@ -191,7 +273,7 @@ func (r *TransactionRepository) GetTotalVolume() (string, error) {
 	}
 
 	err := r.db.Model(&Transaction{}).
-		Select("COALESCE(SUM(CAST(value_numeric AS NUMERIC)), 0) as total").
+		Select("COALESCE(SUM("+r.volumeExpr()+"), 0) as total").
 		Where("tx_type = ? AND status = ?", "sale", "confirmed").
 		Scan(&result).Error
 
@@ -209,8 +291,8 @@ func (r *TransactionRepository) GetVolumeByContract(nftContract string) (string,
 	}
 
 	err := r.db.Model(&Transaction{}).
-		Select("COALESCE(SUM(CAST(value_numeric AS NUMERIC)), 0) as total").
-		Where("nft_contract = ? AND tx_type = ? AND status = ?", nftContract, "sale", "confirmed").
+		Select("COALESCE(SUM("+r.volumeExpr()+"), 0) as total").
+		Where("nft_contract = ? AND tx_type = ? AND status = ?", NormalizeAddress(nftContract), "sale", "confirmed").
 		Scan(&result).Error
 
 	if err != nil {
@@ -227,19 +309,76 @@ func (r *TransactionRepository) CountByType(txType string) (int64, error) {
 	return count, err
 }
 
-// GetDailyVolume 获取每日交易额（最近 N 天）
-func (r *TransactionRepository) GetDailyVolume(days int) ([]map[string]interface{}, error) {
-	var results []map[string]interface{}
+// DeleteByStatusBefore 删除指定状态且创建时间早于 before 的交易，返回删除数量
+func (r *TransactionRepository) DeleteByStatusBefore(status string, before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND created_at < ?", status, before).Delete(&Transaction{})
+	return result.RowsAffected, result.Error
+}
+
+// GetVolumeSplitByContract 获取合约的一级/二级市场交易额
+func (r *TransactionRepository) GetVolumeSplitByContract(nftContract string) (primary, secondary string, err error) {
+	var result struct {
+		Primary   string
+		Secondary string
+	}
+
+	err = r.db.Model(&Transaction{}).
+		Select(`COALESCE(SUM(CASE WHEN is_primary THEN `+r.volumeExpr()+` END), 0) as primary,
+			COALESCE(SUM(CASE WHEN NOT is_primary THEN `+r.volumeExpr()+` END), 0) as secondary`).
+		Where("nft_contract = ? AND tx_type = ? AND status = ?", NormalizeAddress(nftContract), "sale", "confirmed").
+		Scan(&result).Error
+
+	if err != nil {
+		return "0", "0", err
+	}
+
+	return result.Primary, result.Secondary, nil
+}
+
+// ContractVolumeSplit 合约的一级/二级市场交易额（合约地址小写）
+type ContractVolumeSplit struct {
+	NFTContract string
+	Primary     string
+	Secondary   string
+}
+
+// GetVolumeSplitByContracts 按合约分组统计一级/二级市场交易额（合约地址不区分大小写），
+// 没有成交的合约不返回
+func (r *TransactionRepository) GetVolumeSplitByContracts(nftContracts []string) ([]ContractVolumeSplit, error) {
+	var splits []ContractVolumeSplit
+	if len(nftContracts) == 0 {
+		return splits, nil
+	}
+	err := r.db.Model(&Transaction{}).
+		Select(`LOWER(nft_contract) AS nft_contract,
+			COALESCE(SUM(CASE WHEN is_primary THEN `+r.volumeExpr()+` END), 0) as primary,
+			COALESCE(SUM(CASE WHEN NOT is_primary THEN `+r.volumeExpr()+` END), 0) as secondary`).
+		Where("tx_type = ? AND status = ? AND nft_contract IN ?", "sale", "confirmed", lowerAll(nftContracts)).
+		Group("LOWER(nft_contract)").
+		Scan(&splits).Error
+	return splits, err
+}
+
+// DailyVolume 某一天（UTC 日期）的已确认成交笔数与交易额
+type DailyVolume struct {
+	Date    time.Time `json:"date"`
+	TxCount int64     `json:"tx_count"`
+	Volume  string    `json:"volume"`
+}
+
+// GetDailyVolume 按天统计最近 days 天内的已确认成交，日期倒序，没有成交的日期不返回
+func (r *TransactionRepository) GetDailyVolume(days int) ([]DailyVolume, error) {
+	var results []DailyVolume
 
 	query := `
-		SELECT 
+		SELECT
 			DATE(block_timestamp) as date,
 			COUNT(*) as tx_count,
-			COALESCE(SUM(CAST(value_numeric AS NUMERIC)), 0) as volume
+			COALESCE(SUM(` + r.volumeExpr() + `), 0) as volume
 		FROM transactions
-		WHERE tx_type = 'sale' 
+		WHERE tx_type = 'sale'
 		AND status = 'confirmed'
-		AND block_timestamp >= NOW() - INTERVAL '? days'
+		AND block_timestamp >= NOW() - make_interval(days => ?)
 		GROUP BY DATE(block_timestamp)
 		ORDER BY date DESC
 	`
@@ -257,3 +396,21 @@ func (r *TransactionRepository) Update(tx *Transaction) error {
 func (r *TransactionRepository) UpdateStatus(id uint, status string) error {
 	return r.db.Model(&Transaction{}).Where("id = ?", id).Update("status", status).Error
 }
+
+// UpdateBlock 交易因重组被重新打包后，同时更新区块号与区块哈希
+func (r *TransactionRepository) UpdateBlock(id uint, blockNumber uint64, blockHash string) error {
+	return r.db.Model(&Transaction{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"block_number": blockNumber,
+		"block_hash":   blockHash,
+	}).Error
+}
+
+// GetPendingSales 获取待确认的成交交易（按区块顺序），仅包含记录了区块哈希的
+func (r *TransactionRepository) GetPendingSales(limit int) ([]Transaction, error) {
+	var txs []Transaction
+	err := r.db.Where("tx_type = ? AND status = ? AND block_hash <> ''", "sale", "pending").
+		Order("block_number, id").
+		Limit(limit).
+		Find(&txs).Error
+	return txs, err
+}
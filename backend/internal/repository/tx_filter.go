@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+)
+
+// MaxTxFilterTerms 单个交易过滤表达式最多包含的条件数
+const MaxTxFilterTerms = 10
+
+// 过滤操作符
+const (
+	TxFilterEq  = "eq"
+	TxFilterGt  = "gt"
+	TxFilterGte = "gte"
+	TxFilterLt  = "lt"
+	TxFilterLte = "lte"
+)
+
+// txFilterSQLOps 操作符对应的 SQL 比较符
+var txFilterSQLOps = map[string]string{
+	TxFilterEq:  "=",
+	TxFilterGt:  ">",
+	TxFilterGte: ">=",
+	TxFilterLt:  "<",
+	TxFilterLte: "<=",
+}
+
+// txFilterKind 字段值类型
+type txFilterKind int
+
+const (
+	txFilterEnum txFilterKind = iota
+	txFilterAddress
+	txFilterTokenID
+	txFilterWei
+	txFilterBlock
+	txFilterTime
+	txFilterBool
+)
+
+// txFilterField 可过滤字段：列名、值类型及允许的操作符
+type txFilterField struct {
+	column string
+	kind   txFilterKind
+	ops    []string
+	values []string // 枚举字段的可选值
+}
+
+var (
+	txFilterEqOnly  = []string{TxFilterEq}
+	txFilterRangeOp = []string{TxFilterEq, TxFilterGt, TxFilterGte, TxFilterLt, TxFilterLte}
+)
+
+// txFilterFields 可过滤字段白名单，键为过滤表达式中的字段名
+var txFilterFields = map[string]txFilterField{
+	"type":     {column: "tx_type", kind: txFilterEnum, ops: txFilterEqOnly, values: []string{"list", "sale", "cancel", "transfer", "mint"}},
+	"status":   {column: "status", kind: txFilterEnum, ops: txFilterEqOnly, values: []string{"pending", "confirmed", "failed"}},
+	"from":     {column: "from_address", kind: txFilterAddress, ops: txFilterEqOnly},
+	"to":       {column: "to_address", kind: txFilterAddress, ops: txFilterEqOnly},
+	"contract": {column: "nft_contract", kind: txFilterAddress, ops: txFilterEqOnly},
+	"token":    {column: "token_id", kind: txFilterTokenID, ops: txFilterEqOnly},
+	"value":    {column: "value_numeric", kind: txFilterWei, ops: txFilterRangeOp},
+	"block":    {column: "block_number", kind: txFilterBlock, ops: txFilterRangeOp},
+	"time":     {column: "block_timestamp", kind: txFilterTime, ops: txFilterRangeOp},
+	"primary":  {column: "is_primary", kind: txFilterBool, ops: txFilterEqOnly},
+}
+
+// TxCondition 单个过滤条件，Value 已按字段类型解析
+type TxCondition struct {
+	Field string
+	Op    string
+	Value interface{} // string / *big.Int / uint64 / time.Time / bool
+}
+
+// TxFilter 经过校验的交易过滤条件（条件之间为 AND）
+type TxFilter struct {
+	Conditions []TxCondition
+}
+
+// ParseTxFilter 解析过滤表达式（错误信息可直接返回给调用方），如 "type:sale,value_gt:1e18,from:0xabc..."。
+// 每项为 字段[_操作符]:值，省略操作符时为 eq；字段与操作符必须在白名单内
+func ParseTxFilter(raw string) (TxFilter, error) {
+	var filter TxFilter
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return filter, nil
+	}
+
+	terms := strings.Split(raw, ",")
+	if len(terms) > MaxTxFilterTerms {
+		return filter, fmt.Errorf("at most %d terms allowed", MaxTxFilterTerms)
+	}
+
+	for _, term := range terms {
+		key, value, ok := strings.Cut(strings.TrimSpace(term), ":")
+		if !ok || value == "" {
+			return filter, fmt.Errorf("term %q must be field:value", term)
+		}
+
+		name, op, hasOp := strings.Cut(key, "_")
+		if !hasOp {
+			op = TxFilterEq
+		}
+		field, ok := txFilterFields[name]
+		if !ok {
+			return filter, fmt.Errorf("unknown field %q", name)
+		}
+		if !containsString(field.ops, op) {
+			return filter, fmt.Errorf("operator %q not supported for %s", op, name)
+		}
+
+		parsed, err := field.parse(value)
+		if err != nil {
+			return filter, fmt.Errorf("%s: %v", name, err)
+		}
+		filter.Conditions = append(filter.Conditions, TxCondition{Field: name, Op: op, Value: parsed})
+	}
+
+	return filter, nil
+}
+
+// parse 按字段类型解析并校验值
+func (f txFilterField) parse(value string) (interface{}, error) {
+	switch f.kind {
+	case txFilterEnum:
+		if !containsString(f.values, value) {
+			return nil, fmt.Errorf("expected one of %s", strings.Join(f.values, ", "))
+		}
+		return value, nil
+	case txFilterAddress:
+		if !common.IsHexAddress(value) {
+			return nil, fmt.Errorf("invalid address %q", value)
+		}
+		return value, nil
+	case txFilterTokenID:
+		if _, ok := new(big.Int).SetString(value, 10); !ok {
+			return nil, fmt.Errorf("invalid token id %q", value)
+		}
+		return value, nil
+	case txFilterWei:
+		return parseWeiValue(value)
+	case txFilterBlock:
+		block, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block number %q", value)
+		}
+		return block, nil
+	case txFilterTime:
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC(), nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("expected RFC3339 time or Unix seconds")
+		}
+		return t, nil
+	case txFilterBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported field")
+}
+
+// MaxUint256 wei 金额上限
+var MaxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// maxWeiDigits uint256 最大值的十进制位数，用于在展开科学计数法前限制输入规模
+const maxWeiDigits = 78
+
+// parseWeiValue 解析非负整数 wei 金额（不超过 uint256），支持科学计数法（如 1e18、1.5e18）
+func parseWeiValue(value string) (*big.Int, error) {
+	if strings.HasPrefix(value, "-") {
+		return nil, fmt.Errorf("invalid wei amount %q", value)
+	}
+	mantissa, exp := value, ""
+	if i := strings.IndexAny(value, "eE"); i >= 0 {
+		mantissa, exp = value[:i], value[i+1:]
+	}
+	// 位数或指数过大时直接拒绝，避免 1e999999999 这类输入展开成巨大整数
+	if len(strings.TrimLeft(strings.Replace(mantissa, ".", "", 1), "0")) > maxWeiDigits {
+		return nil, fmt.Errorf("invalid wei amount %q: exceeds uint256", value)
+	}
+	if exp != "" {
+		if e, err := strconv.Atoi(exp); err != nil || e > maxWeiDigits-1 {
+			return nil, fmt.Errorf("invalid wei amount %q: exceeds uint256", value)
+		}
+	}
+
+	wei, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		// 精度需容纳 78 位整数部分，否则 uint256 附近的小数会被舍入成整数
+		f, _, err := big.ParseFloat(value, 10, 512, big.ToNearestEven)
+		if err != nil || f.Sign() < 0 || !f.IsInt() {
+			return nil, fmt.Errorf("invalid wei amount %q", value)
+		}
+		wei, _ = f.Int(nil)
+	}
+	if wei.Sign() < 0 {
+		return nil, fmt.Errorf("invalid wei amount %q", value)
+	}
+	if wei.Cmp(MaxUint256) > 0 {
+		return nil, fmt.Errorf("invalid wei amount %q: exceeds uint256", value)
+	}
+	return wei, nil
+}
+
+// Apply 将过滤条件转换为参数化查询条件（列名与比较符均来自白名单）
+func (f TxFilter) Apply(db *gorm.DB) *gorm.DB {
+	for _, cond := range f.Conditions {
+		field := txFilterFields[cond.Field]
+		op := txFilterSQLOps[cond.Op]
+		switch value := cond.Value.(type) {
+		case *big.Int:
+			db = db.Where(fmt.Sprintf("%s %s ?", field.column, op), value.String())
+		case string:
+			if field.kind == txFilterAddress {
+				value = NormalizeAddress(value)
+			}
+			db = db.Where(fmt.Sprintf("%s %s ?", field.column, op), value)
+		default:
+			db = db.Where(fmt.Sprintf("%s %s ?", field.column, op), value)
+		}
+	}
+	return db
+}
+
+// Matches 判断交易是否满足全部过滤条件（供内存存储使用）
+func (f TxFilter) Matches(tx *Transaction) bool {
+	for _, cond := range f.Conditions {
+		var cmp int
+		switch cond.Field {
+		case "type":
+			cmp = strings.Compare(tx.TxType, cond.Value.(string))
+		case "status":
+			cmp = strings.Compare(tx.Status, cond.Value.(string))
+		case "from":
+			cmp = strings.Compare(strings.ToLower(tx.FromAddress), strings.ToLower(cond.Value.(string)))
+		case "to":
+			cmp = strings.Compare(strings.ToLower(tx.ToAddress), strings.ToLower(cond.Value.(string)))
+		case "contract":
+			cmp = strings.Compare(strings.ToLower(tx.NFTContract), strings.ToLower(cond.Value.(string)))
+		case "token":
+			cmp = strings.Compare(tx.TokenID, cond.Value.(string))
+		case "value":
+			value, ok := new(big.Int).SetString(tx.ValueNumeric, 10)
+			if !ok {
+				return false
+			}
+			cmp = value.Cmp(cond.Value.(*big.Int))
+		case "block":
+			cmp = compareUint64(tx.BlockNumber, cond.Value.(uint64))
+		case "time":
+			cmp = tx.BlockTimestamp.Compare(cond.Value.(time.Time))
+		case "primary":
+			if tx.IsPrimary != cond.Value.(bool) {
+				cmp = 1
+			}
+		}
+		if !opSatisfied(cond.Op, cmp) {
+			return false
+		}
+	}
+	return true
+}
+
+// opSatisfied 比较结果是否满足操作符
+func opSatisfied(op string, cmp int) bool {
+	switch op {
+	case TxFilterGt:
+		return cmp > 0
+	case TxFilterGte:
+		return cmp >= 0
+	case TxFilterLt:
+		return cmp < 0
+	case TxFilterLte:
+		return cmp <= 0
+	}
+	return cmp == 0
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
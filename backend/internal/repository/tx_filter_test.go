@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTxFilter(t *testing.T) {
+	oneAndHalfEth, _ := new(big.Int).SetString("1500000000000000000", 10)
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    []TxCondition
+		wantErr string // 为空表示解析成功
+	}{
+		{"empty", "", nil, ""},
+		{"whitespace", "   ", nil, ""},
+		{"implicit eq", "type:sale", []TxCondition{{"type", TxFilterEq, "sale"}}, ""},
+		{"explicit eq", "status_eq:confirmed", []TxCondition{{"status", TxFilterEq, "confirmed"}}, ""},
+		{"multiple terms", "type:sale, block_gte:100", []TxCondition{
+			{"type", TxFilterEq, "sale"},
+			{"block", TxFilterGte, uint64(100)},
+		}, ""},
+		{"address", "from:" + mixedUser, []TxCondition{{"from", TxFilterEq, mixedUser}}, ""},
+		{"token id", "token:12345678901234567890", []TxCondition{{"token", TxFilterEq, "12345678901234567890"}}, ""},
+		{"wei integer", "value_gt:1000", []TxCondition{{"value", TxFilterGt, big.NewInt(1000)}}, ""},
+		{"wei scientific", "value_gte:1e18", []TxCondition{{"value", TxFilterGte, big.NewInt(1e18)}}, ""},
+		{"wei fractional mantissa", "value_lt:1.5e18", []TxCondition{{"value", TxFilterLt, oneAndHalfEth}}, ""},
+		{"wei zero", "value:0", []TxCondition{{"value", TxFilterEq, big.NewInt(0)}}, ""},
+		{"unix time", "time_gte:1700000000", []TxCondition{{"time", TxFilterGte, time.Unix(1700000000, 0).UTC()}}, ""},
+		{"rfc3339 time", "time_lt:2024-01-02T03:04:05Z", []TxCondition{{"time", TxFilterLt, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}}, ""},
+		{"bool", "primary:true", []TxCondition{{"primary", TxFilterEq, true}}, ""},
+
+		{"unknown field", "price:1", nil, `unknown field "price"`},
+		{"unknown field with op", "id_gt:1", nil, `unknown field "id"`},
+		{"unknown operator", "value_ne:1", nil, `operator "ne" not supported`},
+		{"empty operator", "value_:1", nil, `operator "" not supported`},
+		{"range op on eq-only field", "type_gt:sale", nil, `operator "gt" not supported`},
+		{"range op on address", "from_lt:" + mixedUser, nil, `operator "lt" not supported`},
+		{"missing value", "type:", nil, "must be field:value"},
+		{"missing colon", "type", nil, "must be field:value"},
+		{"empty term", "type:sale,", nil, "must be field:value"},
+		{"bad enum", "type:bid", nil, "expected one of"},
+		{"bad address", "from:0x123", nil, "invalid address"},
+		{"bad token id", "token:0x10", nil, "invalid token id"},
+		{"wei negative exponent", "value:1e-3", nil, "invalid wei amount"},
+		{"wei fractional result", "value:1.5e0", nil, "invalid wei amount"},
+		{"wei negative", "value:-1", nil, "invalid wei amount"},
+		{"wei negative scientific", "value:-1e18", nil, "invalid wei amount"},
+		{"wei infinity", "value:Inf", nil, "invalid wei amount"},
+		{"wei hex", "value:0x10", nil, "invalid wei amount"},
+		{"negative block", "block:-1", nil, "invalid block number"},
+		{"bad time", "time:yesterday", nil, "expected RFC3339"},
+		{"bad bool", "primary:maybe", nil, "expected true or false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTxFilter(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseTxFilter(%q) = %v, want error containing %q", tt.raw, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTxFilter(%q) = %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got.Conditions, tt.want) {
+				t.Errorf("ParseTxFilter(%q) = %+v, want %+v", tt.raw, got.Conditions, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTxFilterTermLimit(t *testing.T) {
+	term := "type:sale"
+	atLimit := strings.TrimSuffix(strings.Repeat(term+",", MaxTxFilterTerms), ",")
+	overLimit := atLimit + "," + term
+
+	if _, err := ParseTxFilter(atLimit); err != nil {
+		t.Errorf("%d terms: %v, want nil", MaxTxFilterTerms, err)
+	}
+	if _, err := ParseTxFilter(overLimit); err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Errorf("%d terms: %v, want term limit error", MaxTxFilterTerms+1, err)
+	}
+}
+
+func TestParseWeiValue(t *testing.T) {
+	overMax := new(big.Int).Lsh(big.NewInt(1), 256)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    *big.Int
+		wantErr bool
+	}{
+		{"integer", "1000", big.NewInt(1000), false},
+		{"scientific", "1.5e3", big.NewInt(1500), false},
+		{"max uint256", MaxUint256.String(), MaxUint256, false},
+		{"max exponent", "1e77", new(big.Int).Exp(big.NewInt(10), big.NewInt(77), nil), false},
+		{"leading zeros", strings.Repeat("0", 100) + "1", big.NewInt(1), false},
+
+		{"huge exponent", "1e999999999", nil, true},
+		{"exponent over limit", "1e78", nil, true},
+		{"too many digits", strings.Repeat("1", 79), nil, true},
+		{"2^256", overMax.String(), nil, true},
+		{"2^256 scientific", "1.15792089237316195423570985008687907853269984665640564039457584007913129639936e77", nil, true},
+		{"negative", "-1", nil, true},
+		{"negative zero", "-0", nil, true},
+		{"fraction near max", "1157920892373161954235709850086879078532699846656405640394575840079131296399.5", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWeiValue(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseWeiValue(%q) = %v, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseWeiValue(%q) = %v", tt.value, err)
+			}
+			if got.Cmp(tt.want) != 0 {
+				t.Errorf("parseWeiValue(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// 同一过滤表达式下 Apply 生成的 SQL 条件与 Matches 的内存判断一致
+func TestTxFilterApplyMatchesParity(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	txs := []Transaction{
+		{ID: 1, TxType: "sale", Status: "confirmed", FromAddress: lowerUser, NFTContract: lowerContract, TokenID: "1", ValueNumeric: "1000000000000000000", BlockNumber: 100, BlockTimestamp: base, IsPrimary: true},
+		{ID: 2, TxType: "sale", Status: "confirmed", FromAddress: "0x00000000000000000000000000000000000000bb", NFTContract: lowerContract, TokenID: "2", ValueNumeric: "1500000000000000000", BlockNumber: 200, BlockTimestamp: base.Add(time.Hour)},
+		{ID: 3, TxType: "list", Status: "pending", FromAddress: lowerUser, NFTContract: lowerContract, TokenID: "1", ValueNumeric: "500", BlockNumber: 300, BlockTimestamp: base.Add(2 * time.Hour)},
+		{ID: 4, TxType: "sale", Status: "failed", FromAddress: lowerUser, NFTContract: lowerContract, TokenID: "3", ValueNumeric: "", BlockNumber: 400, BlockTimestamp: base.Add(3 * time.Hour)},
+	}
+
+	tests := []struct {
+		raw      string
+		wantSQL  []string // Apply 生成的 WHERE 条件，按顺序
+		wantVars []interface{}
+		wantIDs  []uint // Matches 命中的交易
+	}{
+		{"type:sale", []string{"tx_type = $1"}, []interface{}{"sale"}, []uint{1, 2, 4}},
+		{"status:confirmed", []string{"status = $1"}, []interface{}{"confirmed"}, []uint{1, 2}},
+		{"from:" + mixedUser, []string{"from_address = $1"}, []interface{}{lowerUser}, []uint{1, 3, 4}},
+		{"contract:" + mixedContract, []string{"nft_contract = $1"}, []interface{}{lowerContract}, []uint{1, 2, 3, 4}},
+		{"token:1", []string{"token_id = $1"}, []interface{}{"1"}, []uint{1, 3}},
+		{"value:1e18", []string{"value_numeric = $1"}, []interface{}{"1000000000000000000"}, []uint{1}},
+		{"value_gt:1e18", []string{"value_numeric > $1"}, []interface{}{"1000000000000000000"}, []uint{2}},
+		{"value_gte:1e18", []string{"value_numeric >= $1"}, []interface{}{"1000000000000000000"}, []uint{1, 2}},
+		{"value_lt:1.5e18", []string{"value_numeric < $1"}, []interface{}{"1500000000000000000"}, []uint{1, 3}},
+		{"value_lte:1.5e18", []string{"value_numeric <= $1"}, []interface{}{"1500000000000000000"}, []uint{1, 2, 3}},
+		{"block_gt:200", []string{"block_number > $1"}, []interface{}{uint64(200)}, []uint{3, 4}},
+		{"block_lte:200", []string{"block_number <= $1"}, []interface{}{uint64(200)}, []uint{1, 2}},
+		{"time_gte:2024-01-01T01:00:00Z", []string{"block_timestamp >= $1"}, []interface{}{base.Add(time.Hour)}, []uint{2, 3, 4}},
+		{"time_lt:1704067200", []string{"block_timestamp < $1"}, []interface{}{base}, nil},
+		{"primary:true", []string{"is_primary = $1"}, []interface{}{true}, []uint{1}},
+		{"primary:false", []string{"is_primary = $1"}, []interface{}{false}, []uint{2, 3, 4}},
+		{"type:sale,status:confirmed,value_gt:1e18", []string{"tx_type = $1", "status = $2", "value_numeric > $3"},
+			[]interface{}{"sale", "confirmed", "1000000000000000000"}, []uint{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			filter, err := ParseTxFilter(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseTxFilter(%q) = %v", tt.raw, err)
+			}
+
+			db, captured := dryRunDB(t)
+			var rows []Transaction
+			filter.Apply(db.Model(&Transaction{})).Find(&rows)
+			if len(*captured) != 1 {
+				t.Fatalf("got %d statements, want 1", len(*captured))
+			}
+			stmt := (*captured)[0]
+			wantWhere := "WHERE " + strings.Join(tt.wantSQL, " AND ")
+			if !strings.Contains(stmt.sql, wantWhere) {
+				t.Errorf("Apply SQL = %s, want %q", stmt.sql, wantWhere)
+			}
+			if !reflect.DeepEqual(stmt.vars, tt.wantVars) {
+				t.Errorf("Apply vars = %v, want %v", stmt.vars, tt.wantVars)
+			}
+
+			var gotIDs []uint
+			for i := range txs {
+				if filter.Matches(&txs[i]) {
+					gotIDs = append(gotIDs, txs[i].ID)
+				}
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("Matches = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
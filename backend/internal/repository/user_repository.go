@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// User 用户模型（仅映射通知相关字段）
+type User struct {
+	ID                 uint            `gorm:"primaryKey" json:"id"`
+	Address            string          `gorm:"uniqueIndex;not null" json:"address"`
+	Username           *string         `json:"username"`
+	Email              EncryptedString `gorm:"type:text" json:"email,omitempty"` // AES-GCM 加密存储
+	EmailNotifications bool            `gorm:"default:true" json:"email_notifications"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (User) TableName() string {
+	return "users"
+}
+
+// UserRepository 用户仓储
+type UserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository 创建用户仓储
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// GetByAddress 根据地址获取用户
+func (r *UserRepository) GetByAddress(address string) (*User, error) {
+	var user User
+	err := r.db.Where("address = ?", strings.ToLower(address)).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetEmail 设置用户邮箱（加密存储），用户不存在时创建
+func (r *UserRepository) SetEmail(address string, email EncryptedString) error {
+	user := User{Address: strings.ToLower(address), Email: email}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"email": email, "updated_at": time.Now()}),
+	}).Create(&user).Error
+}
+
+// RotateEmailKeys 将旧版本密钥加密的邮箱以及加密上线前的明文邮箱用当前密钥（重新）加密，返回处理的行数
+func (r *UserRepository) RotateEmailKeys(batchSize int) (int, error) {
+	keyring, err := currentPIIKeyring()
+	if err != nil {
+		return 0, err
+	}
+	currentPrefix := fmt.Sprintf("v%d:", keyring.CurrentVersion())
+
+	rotated := 0
+	for {
+		var users []User
+		err := r.db.Select("id", "email").
+			Where("email IS NOT NULL AND email <> '' AND email NOT LIKE ?", currentPrefix+"%").
+			Order("id").
+			Limit(batchSize).
+			Find(&users).Error
+		if err != nil {
+			return rotated, fmt.Errorf("failed to load users for key rotation: %w", err)
+		}
+		if len(users) == 0 {
+			return rotated, nil
+		}
+
+		for _, user := range users {
+			if err := r.db.Model(&User{}).Where("id = ?", user.ID).Update("email", user.Email).Error; err != nil {
+				return rotated, fmt.Errorf("failed to re-encrypt email for user %d: %w", user.ID, err)
+			}
+			rotated++
+		}
+	}
+}
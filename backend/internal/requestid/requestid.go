@@ -0,0 +1,56 @@
+// Package requestid 在请求上下文及其触发的后台任务中传递请求 ID，用于关联错误响应与服务端日志
+package requestid
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// Header 请求 ID 的 HTTP 头，请求带有时沿用，否则由服务端生成并在响应中返回
+const Header = "X-Request-ID"
+
+// maxLength 沿用客户端请求 ID 的长度上限，超出或含非法字符时重新生成
+const maxLength = 128
+
+type contextKey struct{}
+
+// New 生成新的请求 ID（UUID v4）
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid 客户端传入的请求 ID 是否可沿用：非空、不超过 maxLength，且只含可打印 ASCII 字符（不含空格）
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext 返回携带请求 ID 的 context
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext 取出 context 中的请求 ID，没有时为空
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logf 输出日志，context 带有请求 ID 时加上 request_id= 前缀
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if id := FromContext(ctx); id != "" {
+		log.Printf("request_id=%s %s", id, fmt.Sprintf(format, args...))
+		return
+	}
+	log.Printf(format, args...)
+}
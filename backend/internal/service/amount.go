@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// weiPerEther 1 ETH = 10^18 wei
+var weiPerEther = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// WeiAmount 经过校验的 wei 金额
+type WeiAmount struct {
+	Wei       string   `json:"wei"`       // 十进制整数
+	Formatted string   `json:"formatted"` // 以 ETH 为单位的十进制
+	Int       *big.Int `json:"-"`
+}
+
+// parseWeiAmount 解析数据库聚合结果（NULL、科学计数法、带零小数均可），非整数或负数返回错误
+func parseWeiAmount(raw string) (*WeiAmount, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" || strings.EqualFold(value, "null") {
+		value = "0"
+	}
+
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		// NUMERIC 可能返回 "1.000" 或 "1.5e+21" 形式
+		f, _, err := big.ParseFloat(value, 10, 512, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wei amount %q: %w", raw, err)
+		}
+		if !f.IsInt() {
+			return nil, fmt.Errorf("invalid wei amount %q: not an integer", raw)
+		}
+		n, _ = f.Int(nil)
+	}
+
+	if n.Sign() < 0 {
+		return nil, fmt.Errorf("invalid wei amount %q: negative", raw)
+	}
+
+	return &WeiAmount{
+		Wei:       n.String(),
+		Formatted: formatEther(n),
+		Int:       n,
+	}, nil
+}
+
+// formatEther 将 wei 格式化为 ETH 十进制字符串（去掉末尾的 0）
+func formatEther(wei *big.Int) string {
+	whole, frac := new(big.Int).QuoRem(wei, weiPerEther, new(big.Int))
+	if frac.Sign() == 0 {
+		return whole.String()
+	}
+
+	fracStr := strings.TrimRight(fmt.Sprintf("%018s", frac.String()), "0")
+	return whole.String() + "." + fracStr
+}
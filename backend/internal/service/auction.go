@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// MaxAuctionDuration 拍卖时长上限
+const MaxAuctionDuration = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidAuctionEnd 拍卖截止时间缺失、不在未来或超过 MaxAuctionDuration
+	ErrInvalidAuctionEnd = errors.New("invalid auction end time")
+	// ErrAuctionFieldsOnFixedPrice 一口价挂单不能指定拍卖截止时间或保留价
+	ErrAuctionFieldsOnFixedPrice = errors.New("auction fields are only allowed on auction listings")
+	// ErrNotAuction 挂单不是拍卖
+	ErrNotAuction = errors.New("listing is not an auction")
+	// ErrAuctionNotActive 拍卖挂单已成交、已取消或未上架
+	ErrAuctionNotActive = errors.New("auction is not active")
+	// ErrAuctionEnded 已过拍卖截止时间
+	ErrAuctionEnded = errors.New("auction has ended")
+	// ErrBidOnOwnListing 卖家不能对自己的拍卖竞价
+	ErrBidOnOwnListing = errors.New("cannot bid on own listing")
+	// ErrInvalidBidAmount 竞价金额不是正整数 wei
+	ErrInvalidBidAmount = errors.New("bid amount must be a positive amount in wei")
+)
+
+// PlaceBidRequest 竞价请求，竞价方取自认证地址
+type PlaceBidRequest struct {
+	Amount string `json:"amount" binding:"required,wei"`
+}
+
+// BidResponse 竞价响应
+type BidResponse struct {
+	ID        uint      `json:"id"`
+	ListingID uint      `json:"listing_id"`
+	Bidder    string    `json:"bidder"`
+	Amount    string    `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// applyListingType 按请求设置挂单类型：拍卖须指定在未来且不超过 MaxAuctionDuration 的截止时间，
+// 一口价不能带拍卖字段
+func applyListingType(listing *repository.Listing, req *CreateListingRequest, now time.Time) error {
+	if req.ListingType != repository.ListingTypeAuction {
+		if req.AuctionEndAt != nil || req.ReservePrice != "" {
+			return ErrAuctionFieldsOnFixedPrice
+		}
+		listing.ListingType = repository.ListingTypeFixedPrice
+		return nil
+	}
+
+	if req.AuctionEndAt == nil || !req.AuctionEndAt.After(now) || req.AuctionEndAt.Sub(now) > MaxAuctionDuration {
+		return ErrInvalidAuctionEnd
+	}
+	endAt := req.AuctionEndAt.UTC()
+	listing.ListingType = repository.ListingTypeAuction
+	listing.AuctionEndAt = &endAt
+	if req.ReservePrice != "" {
+		reserve := req.ReservePrice
+		listing.ReservePrice = &reserve
+	}
+	return nil
+}
+
+// PlaceBid 以 bidder 身份对拍卖挂单竞价：拍卖须仍活跃且未过截止时间，卖家不能竞价。
+// 第一口价不低于起拍价，之后须在当前最高竞价之上满足系列最小加价，且均不低于保留价；
+// 不满足时返回 *BidTooLowError
+func (s *ListingService) PlaceBid(ctx context.Context, listingID uint, bidder string, req *PlaceBidRequest) (*BidResponse, error) {
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, ErrInvalidBidAmount
+	}
+
+	bid := &repository.Bid{
+		ListingID: listingID,
+		Bidder:    repository.NormalizeAddress(bidder),
+		Amount:    amount.String(),
+	}
+	bid.AmountNumeric = &bid.Amount
+
+	err := s.bids.PlaceBid(bid, func(listing *repository.Listing, highest *repository.Bid) error {
+		if listing.ListingType != repository.ListingTypeAuction {
+			return ErrNotAuction
+		}
+		if listing.Status != "active" {
+			return ErrAuctionNotActive
+		}
+		if listing.AuctionEndAt != nil && !time.Now().Before(*listing.AuctionEndAt) {
+			return ErrAuctionEnded
+		}
+		if strings.EqualFold(listing.Seller, bidder) {
+			return ErrBidOnOwnListing
+		}
+		return s.checkBid(ctx, listing, highest, amount)
+	})
+	var tooLow *BidTooLowError
+	switch {
+	case err == nil:
+	case errors.As(err, &tooLow), errors.Is(err, ErrNotAuction), errors.Is(err, ErrAuctionNotActive),
+		errors.Is(err, ErrAuctionEnded), errors.Is(err, ErrBidOnOwnListing):
+		return nil, err
+	default:
+		return nil, fmt.Errorf("failed to place bid: %w", err)
+	}
+
+	// 挂单响应中的最高竞价已变化
+	s.invalidateListings(ctx)
+	return toBidResponse(bid), nil
+}
+
+// checkBid 计算拍卖当前允许的最低竞价并校验 amount
+func (s *ListingService) checkBid(ctx context.Context, listing *repository.Listing, highest *repository.Bid, amount *big.Int) error {
+	current := new(big.Int)
+	minimum, ok := new(big.Int).SetString(listing.Price, 10) // 起拍价
+	if !ok {
+		return fmt.Errorf("invalid starting price for listing %d: %q", listing.ID, listing.Price)
+	}
+
+	if highest != nil {
+		current, ok = new(big.Int).SetString(highest.Amount, 10)
+		if !ok {
+			return fmt.Errorf("invalid highest bid %d: %q", highest.ID, highest.Amount)
+		}
+		rule, err := s.bidPolicy.Effective(ctx, listing.NFTContract)
+		if err != nil {
+			return err
+		}
+		minimum = rule.MinimumBid(current)
+	}
+
+	if listing.ReservePrice != nil {
+		reserve, ok := new(big.Int).SetString(*listing.ReservePrice, 10)
+		if ok && reserve.Cmp(minimum) > 0 {
+			minimum = reserve
+		}
+	}
+
+	if amount.Cmp(minimum) < 0 {
+		return &BidTooLowError{Current: current, MinimumBid: minimum}
+	}
+	return nil
+}
+
+// GetBids 分页获取挂单的竞价记录，最新优先；挂单不存在时返回记录不存在错误
+func (s *ListingService) GetBids(ctx context.Context, listingID uint, page, pageSize int) ([]*BidResponse, int64, error) {
+	if _, err := s.repo.GetByID(listingID); err != nil {
+		return nil, 0, fmt.Errorf("failed to get listing: %w", err)
+	}
+
+	bids, total, err := s.bids.GetByListing(listingID, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get bids: %w", err)
+	}
+
+	responses := make([]*BidResponse, len(bids))
+	for i := range bids {
+		responses[i] = toBidResponse(&bids[i])
+	}
+	return responses, total, nil
+}
+
+// toBidResponse 转换为响应对象
+func toBidResponse(bid *repository.Bid) *BidResponse {
+	return &BidResponse{
+		ID:        bid.ID,
+		ListingID: bid.ListingID,
+		Bidder:    bid.Bidder,
+		Amount:    bid.Amount,
+		CreatedAt: bid.CreatedAt,
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/xiaomait/backend/internal/cache"
+)
+
+var (
+	// ErrInvalidSIWEMessage 消息不符合 EIP-4361 格式，或域名、链 ID、有效期不匹配
+	ErrInvalidSIWEMessage = errors.New("invalid siwe message")
+	// ErrInvalidNonce nonce 不存在、已过期、已使用，或不是签发给该地址的
+	ErrInvalidNonce = errors.New("invalid or expired nonce")
+	// ErrSignatureMismatch 签名无效或恢复出的地址与声明的地址不一致
+	ErrSignatureMismatch = errors.New("signature does not match address")
+)
+
+// siweNoncePrefix nonce 在缓存中的键前缀，值为绑定的小写地址
+const siweNoncePrefix = "siwe:nonce:"
+
+// siweHeaderSuffix EIP-4361 消息首行中域名之后的固定文本
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// SIWENonce 签发的登录 nonce
+type SIWENonce struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AuthService 钱包登录（Sign-In with Ethereum）：签发与地址绑定的一次性 nonce，
+// 校验钱包对 EIP-4361 消息的 personal_sign 签名
+type AuthService struct {
+	nonces   cache.Store
+	nonceTTL time.Duration
+	domain   string // 为空时不校验消息中的域名
+	chainID  int64
+}
+
+// NewAuthService 创建钱包登录服务
+func NewAuthService(nonces cache.Store, nonceTTL time.Duration, domain string, chainID int64) *AuthService {
+	return &AuthService{
+		nonces:   nonces,
+		nonceTTL: nonceTTL,
+		domain:   domain,
+		chainID:  chainID,
+	}
+}
+
+// IssueNonce 为地址签发一次性 nonce，nonceTTL 后过期
+func (s *AuthService) IssueNonce(ctx context.Context, address string) (*SIWENonce, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(buf)
+
+	if err := s.nonces.Set(ctx, siweNoncePrefix+nonce, []byte(strings.ToLower(address)), s.nonceTTL); err != nil {
+		return nil, fmt.Errorf("failed to store nonce: %w", err)
+	}
+	return &SIWENonce{Nonce: nonce, ExpiresAt: time.Now().Add(s.nonceTTL)}, nil
+}
+
+// Verify 校验 SIWE 登录：消息中的地址、nonce、域名、链 ID 和有效期须与请求一致，签名恢复出的地址
+// 须为 address，nonce 须是签发给该地址且未使用的。通过后 nonce 作废，返回小写地址
+func (s *AuthService) Verify(ctx context.Context, address, nonce, message, signature string) (string, error) {
+	msg, err := parseSIWEMessage(message)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSIWEMessage, err)
+	}
+	if err := s.checkMessage(msg, address, nonce); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSIWEMessage, err)
+	}
+
+	signer, err := recoverSigner(message, signature)
+	if err != nil || signer != common.HexToAddress(address) {
+		return "", ErrSignatureMismatch
+	}
+
+	if err := s.consumeNonce(ctx, nonce, address); err != nil {
+		return "", err
+	}
+	return strings.ToLower(signer.Hex()), nil
+}
+
+// checkMessage 校验消息字段与请求及服务配置一致
+func (s *AuthService) checkMessage(msg *siweMessage, address, nonce string) error {
+	switch {
+	case !strings.EqualFold(msg.Address, address):
+		return fmt.Errorf("address %s does not match", msg.Address)
+	case msg.Nonce != nonce:
+		return errors.New("nonce does not match")
+	case msg.Version != "1":
+		return fmt.Errorf("unsupported version %q", msg.Version)
+	case msg.ChainID != s.chainID:
+		return fmt.Errorf("chain id %d does not match", msg.ChainID)
+	case s.domain != "" && !strings.EqualFold(msg.Domain, s.domain):
+		return fmt.Errorf("domain %s does not match", msg.Domain)
+	}
+
+	now := time.Now()
+	if msg.ExpirationTime != nil && !now.Before(*msg.ExpirationTime) {
+		return errors.New("message has expired")
+	}
+	if msg.NotBefore != nil && now.Before(*msg.NotBefore) {
+		return errors.New("message is not yet valid")
+	}
+	return nil
+}
+
+// consumeNonce 原子地取走 nonce，并发校验同一 nonce 时只有一个成功：
+// 不存在（过期或已使用）或绑定的不是该地址时返回 ErrInvalidNonce
+func (s *AuthService) consumeNonce(ctx context.Context, nonce, address string) error {
+	bound, ok, err := s.nonces.GetDel(ctx, siweNoncePrefix+nonce)
+	if err != nil {
+		return fmt.Errorf("failed to consume nonce: %w", err)
+	}
+	if !ok || !strings.EqualFold(string(bound), address) {
+		return ErrInvalidNonce
+	}
+	return nil
+}
+
+// recoverSigner 从 personal_sign 签名恢复签名地址
+func recoverSigner(message, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("invalid signature encoding")
+	}
+	// 钱包签名的 v 为 27/28，恢复公钥需要 0/1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.Ecrecover(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	key, err := crypto.UnmarshalPubkey(pub)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*key), nil
+}
+
+// siweMessage EIP-4361 消息中校验用到的字段
+type siweMessage struct {
+	Domain         string
+	Address        string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+}
+
+// parseSIWEMessage 解析 EIP-4361 消息：首行为域名声明，第二行为地址，其后（可选的 statement 之后）
+// 为 "Key: value" 字段
+func parseSIWEMessage(message string) (*siweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, errors.New("missing sign-in header")
+	}
+
+	msg := &siweMessage{
+		Domain:  strings.TrimSuffix(lines[0], siweHeaderSuffix),
+		Address: strings.TrimSpace(lines[1]),
+	}
+	if msg.Domain == "" || !common.IsHexAddress(msg.Address) {
+		return nil, errors.New("invalid domain or address line")
+	}
+
+	fields := make(map[string]string)
+	for _, line := range lines[2:] {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		if _, dup := fields[key]; !dup {
+			fields[key] = value
+		}
+	}
+
+	msg.Version = fields["Version"]
+	msg.Nonce = fields["Nonce"]
+	if msg.Nonce == "" {
+		return nil, errors.New("missing nonce")
+	}
+
+	chainID, err := strconv.ParseInt(fields["Chain ID"], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid chain id")
+	}
+	msg.ChainID = chainID
+
+	if msg.IssuedAt, err = time.Parse(time.RFC3339, fields["Issued At"]); err != nil {
+		return nil, errors.New("invalid issued at")
+	}
+	if msg.ExpirationTime, err = parseOptionalTime(fields["Expiration Time"]); err != nil {
+		return nil, errors.New("invalid expiration time")
+	}
+	if msg.NotBefore, err = parseOptionalTime(fields["Not Before"]); err != nil {
+		return nil, errors.New("invalid not before")
+	}
+	return msg, nil
+}
+
+// parseOptionalTime 解析可选的 RFC3339 时间，为空时返回 nil
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/xiaomait/backend/internal/cache"
+)
+
+func TestConsumeNonce(t *testing.T) {
+	const address = "0x00000000000000000000000000000000000000aa"
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		address string
+		wantErr error
+	}{
+		{"bound address", address, nil},
+		{"bound address mixed case", "0x00000000000000000000000000000000000000AA", nil},
+		{"other address", "0x00000000000000000000000000000000000000bb", ErrInvalidNonce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAuthService(cache.NewMemoryStore(), time.Minute, "", 1)
+			issued, err := s.IssueNonce(ctx, address)
+			if err != nil {
+				t.Fatalf("IssueNonce: %v", err)
+			}
+
+			if err := s.consumeNonce(ctx, issued.Nonce, tt.address); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("consumeNonce = %v, want %v", err, tt.wantErr)
+			}
+			// nonce 无论校验结果如何都只能使用一次
+			if err := s.consumeNonce(ctx, issued.Nonce, address); !errors.Is(err, ErrInvalidNonce) {
+				t.Fatalf("second consumeNonce = %v, want %v", err, ErrInvalidNonce)
+			}
+		})
+	}
+}
+
+func TestConsumeNonceConcurrent(t *testing.T) {
+	const address = "0x00000000000000000000000000000000000000aa"
+	ctx := context.Background()
+
+	s := NewAuthService(cache.NewMemoryStore(), time.Minute, "", 1)
+	issued, err := s.IssueNonce(ctx, address)
+	if err != nil {
+		t.Fatalf("IssueNonce: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var succeeded int32
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.consumeNonce(ctx, issued.Nonce, address) == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Fatalf("consumeNonce succeeded %d times, want 1", succeeded)
+	}
+}
+
+// 签名有效但消息中的域名不是本站时拒绝（防止其他站点诱导签名后重放），nonce 不被消耗
+func TestVerifyRejectsOtherDomain(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	tests := []struct {
+		name    string
+		domain  string
+		wantErr error
+	}{
+		{"same domain", "market.example", nil},
+		{"same domain mixed case", "Market.Example", nil},
+		{"other domain", "phishing.example", ErrInvalidSIWEMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAuthService(cache.NewMemoryStore(), time.Minute, "market.example", 1)
+			issued, err := s.IssueNonce(ctx, address)
+			if err != nil {
+				t.Fatalf("IssueNonce: %v", err)
+			}
+
+			message := tt.domain + siweHeaderSuffix + "\n" +
+				address + "\n\n" +
+				"URI: https://" + tt.domain + "\n" +
+				"Version: 1\n" +
+				"Chain ID: 1\n" +
+				"Nonce: " + issued.Nonce + "\n" +
+				"Issued At: " + time.Now().UTC().Format(time.RFC3339)
+			sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+
+			_, err = s.Verify(ctx, address, issued.Nonce, message, hexutil.Encode(sig))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if err := s.consumeNonce(ctx, issued.Nonce, address); err != nil {
+					t.Errorf("nonce consumed by rejected message: %v", err)
+				}
+			}
+		})
+	}
+}
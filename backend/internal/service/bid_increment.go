@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xiaomait/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// maxBidIncrementBps 最小加价比例上限（100%）
+const maxBidIncrementBps = 10000
+
+// BidIncrement 最小加价规则：要求的加价为 current*Bps/10000 与 Wei 中的较大者
+type BidIncrement struct {
+	Bps int64    `json:"bps"`
+	Wei *big.Int `json:"wei"`
+}
+
+// BidTooLowError 出价未达到最小加价要求
+type BidTooLowError struct {
+	Current    *big.Int
+	MinimumBid *big.Int
+}
+
+func (e *BidTooLowError) Error() string {
+	return fmt.Sprintf("bid must be at least %s wei (current highest bid %s wei plus minimum increment)",
+		e.MinimumBid, e.Current)
+}
+
+// BidIncrementPolicy 出价/竞拍的最小加价校验，系列可覆盖全局规则
+type BidIncrementPolicy struct {
+	collections repository.CollectionStore
+	defaults    BidIncrement
+}
+
+// NewBidIncrementPolicy 创建最小加价校验
+func NewBidIncrementPolicy(collections repository.CollectionStore, defaultBps int64, defaultWei *big.Int) *BidIncrementPolicy {
+	if defaultWei == nil {
+		defaultWei = new(big.Int)
+	}
+	return &BidIncrementPolicy{
+		collections: collections,
+		defaults:    BidIncrement{Bps: defaultBps, Wei: defaultWei},
+	}
+}
+
+// ValidateBidIncrement 校验加价比例在 [0, 10000] 内且绝对值非负
+func ValidateBidIncrement(bps int64, wei *big.Int) error {
+	if bps < 0 || bps > maxBidIncrementBps {
+		return fmt.Errorf("bid increment must be between 0 and %d bps", maxBidIncrementBps)
+	}
+	if wei != nil && wei.Sign() < 0 {
+		return fmt.Errorf("bid increment must not be negative")
+	}
+	return nil
+}
+
+// Effective 获取系列实际的最小加价规则，未覆盖的部分使用全局配置
+func (p *BidIncrementPolicy) Effective(ctx context.Context, nftContract string) (BidIncrement, error) {
+	rule := p.defaults
+	if nftContract == "" {
+		return rule, nil
+	}
+
+	collection, err := p.collections.GetByAddress(nftContract)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rule, nil
+	}
+	if err != nil {
+		return rule, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	if collection.MinBidIncrementBps != nil {
+		rule.Bps = *collection.MinBidIncrementBps
+	}
+	if collection.MinBidIncrementWei != nil {
+		wei, ok := new(big.Int).SetString(*collection.MinBidIncrementWei, 10)
+		if !ok {
+			return rule, fmt.Errorf("invalid bid increment override for %s: %q", nftContract, *collection.MinBidIncrementWei)
+		}
+		rule.Wei = wei
+	}
+
+	if err := ValidateBidIncrement(rule.Bps, rule.Wei); err != nil {
+		return rule, fmt.Errorf("invalid bid increment for %s: %w", nftContract, err)
+	}
+	return rule, nil
+}
+
+// MinimumBid 计算在当前最高出价之上允许的最低出价；current 为空或为 0 时任何正数出价均可
+func (r BidIncrement) MinimumBid(current *big.Int) *big.Int {
+	if current == nil || current.Sign() == 0 {
+		return big.NewInt(1)
+	}
+
+	increment := computeFee(current, r.Bps)
+	if r.Wei != nil && r.Wei.Cmp(increment) > 0 {
+		increment = r.Wei
+	}
+	return new(big.Int).Add(current, increment)
+}
+
+// Check 校验出价是否满足最小加价要求，不满足时返回 *BidTooLowError
+func (p *BidIncrementPolicy) Check(ctx context.Context, nftContract string, current, bid *big.Int) error {
+	rule, err := p.Effective(ctx, nftContract)
+	if err != nil {
+		return err
+	}
+
+	minimum := rule.MinimumBid(current)
+	if bid.Cmp(minimum) < 0 {
+		if current == nil {
+			current = new(big.Int)
+		}
+		return &BidTooLowError{Current: current, MinimumBid: minimum}
+	}
+	return nil
+}
+
+// SetOverride 设置系列最小加价覆盖，bps/wei 为 nil 时恢复全局配置
+func (p *BidIncrementPolicy) SetOverride(ctx context.Context, nftContract string, bps *int64, wei *big.Int) error {
+	checkBps := int64(0)
+	if bps != nil {
+		checkBps = *bps
+	}
+	if err := ValidateBidIncrement(checkBps, wei); err != nil {
+		return err
+	}
+
+	var weiStr *string
+	if wei != nil {
+		s := wei.String()
+		weiStr = &s
+	}
+
+	if err := p.collections.UpdateBidIncrement(nftContract, bps, weiStr); err != nil {
+		return fmt.Errorf("failed to update bid increment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/repository/memory"
+)
+
+const (
+	defaultCollection  = "0x0000000000000000000000000000000000000c01"
+	bpsOverride        = "0x0000000000000000000000000000000000000c02"
+	weiOverride        = "0x0000000000000000000000000000000000000c03"
+	invalidWeiOverride = "0x0000000000000000000000000000000000000c04"
+)
+
+func newTestBidIncrementPolicy() *BidIncrementPolicy {
+	collections := memory.NewCollectionStore()
+	bps := int64(1000)
+	wei := "50"
+	badWei := "lots"
+	collections.Put(&repository.Collection{ContractAddress: bpsOverride, MinBidIncrementBps: &bps})
+	collections.Put(&repository.Collection{ContractAddress: weiOverride, MinBidIncrementWei: &wei})
+	collections.Put(&repository.Collection{ContractAddress: invalidWeiOverride, MinBidIncrementWei: &badWei})
+
+	// 全局：5% 或 10 wei 取大者
+	return NewBidIncrementPolicy(collections, 500, big.NewInt(10))
+}
+
+func TestBidIncrementCheck(t *testing.T) {
+	policy := newTestBidIncrementPolicy()
+
+	tests := []struct {
+		name        string
+		contract    string
+		current     int64 // 0 表示尚无出价
+		bid         int64
+		wantMinimum int64 // 0 表示出价应通过
+	}{
+		{"first bid", defaultCollection, 0, 1, 0},
+		{"first bid zero", defaultCollection, 0, 0, 1},
+		{"bps just below", defaultCollection, 1000, 1049, 1050},
+		{"bps exactly at", defaultCollection, 1000, 1050, 0},
+		{"bps above", defaultCollection, 1000, 2000, 0},
+		{"wei floor dominates small bids", defaultCollection, 100, 109, 110},
+		{"wei floor exactly at", defaultCollection, 100, 110, 0},
+		{"bps rounds down", defaultCollection, 1019, 1068, 1069},
+		{"bps rounds down exactly at", defaultCollection, 1019, 1069, 0},
+		{"unknown collection uses defaults", "", 1000, 1049, 1050},
+		{"collection bps override", bpsOverride, 1000, 1099, 1100},
+		{"collection bps override at", bpsOverride, 1000, 1100, 0},
+		{"collection wei override", weiOverride, 100, 149, 150},
+		{"collection wei override at", weiOverride, 100, 150, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var current *big.Int
+			if tt.current > 0 {
+				current = big.NewInt(tt.current)
+			}
+
+			err := policy.Check(context.Background(), tt.contract, current, big.NewInt(tt.bid))
+			if tt.wantMinimum == 0 {
+				if err != nil {
+					t.Fatalf("Check() = %v, want nil", err)
+				}
+				return
+			}
+
+			var tooLow *BidTooLowError
+			if !errors.As(err, &tooLow) {
+				t.Fatalf("Check() = %v, want *BidTooLowError", err)
+			}
+			if tooLow.MinimumBid.Cmp(big.NewInt(tt.wantMinimum)) != 0 {
+				t.Errorf("MinimumBid = %s, want %d", tooLow.MinimumBid, tt.wantMinimum)
+			}
+		})
+	}
+}
+
+func TestBidIncrementInvalidOverride(t *testing.T) {
+	policy := newTestBidIncrementPolicy()
+
+	err := policy.Check(context.Background(), invalidWeiOverride, big.NewInt(100), big.NewInt(1000))
+	var tooLow *BidTooLowError
+	if err == nil || errors.As(err, &tooLow) {
+		t.Fatalf("Check() = %v, want override parse error", err)
+	}
+}
+
+func TestValidateBidIncrement(t *testing.T) {
+	tests := []struct {
+		name    string
+		bps     int64
+		wei     *big.Int
+		wantErr bool
+	}{
+		{"zero", 0, nil, false},
+		{"max bps", 10000, big.NewInt(0), false},
+		{"negative bps", -1, nil, true},
+		{"bps above 100%", 10001, nil, true},
+		{"negative wei", 100, big.NewInt(-1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateBidIncrement(tt.bps, tt.wei); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBidIncrement() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/xiaomait/backend/internal/blockchain"
+)
+
+// ChainHead 缓存的链上最新区块号，用于在响应中计算交易是否已达到最终确认数；由 Refresh 定期刷新，
+// 请求路径不直接访问 RPC
+type ChainHead struct {
+	client        blockchain.BlockchainClient
+	confirmations uint64
+	head          atomic.Uint64 // 0 表示尚未取得
+}
+
+// NewChainHead 创建链上最新区块缓存，confirmations 为视为最终确认所需的区块数
+func NewChainHead(client blockchain.BlockchainClient, confirmations uint64) *ChainHead {
+	return &ChainHead{client: client, confirmations: confirmations}
+}
+
+// Refresh 查询并缓存最新区块号
+func (h *ChainHead) Refresh(ctx context.Context) error {
+	head, err := h.client.GetBlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+	h.head.Store(head)
+	return nil
+}
+
+// Head 缓存的最新区块号，尚未取得时为 0
+func (h *ChainHead) Head() uint64 {
+	return h.head.Load()
+}
+
+// IsFinal 区块是否已有足够确认数（head - blockNumber >= confirmations）；尚未取得最新区块时为 false
+func (h *ChainHead) IsFinal(blockNumber uint64) bool {
+	if h == nil {
+		return false
+	}
+	head := h.Head()
+	return head != 0 && head >= blockNumber && head-blockNumber >= h.confirmations
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/xiaomait/backend/internal/blockchain"
+)
+
+// 时间戳来源约定：
+//   - ListedAt / SoldAt / MintedAt / BlockTimestamp 表示业务在链上发生的时间，取区块时间戳；
+//   - CreatedAt / UpdatedAt 表示数据库写入时间，使用服务器时间。
+// 回填或延迟处理的事件因此仍按链上顺序排序。
+
+// chainTime 获取区块时间戳，区块号未知或查询失败时回退到服务器时间
+func chainTime(ctx context.Context, client blockchain.BlockchainClient, blockNumber uint64) time.Time {
+	if blockNumber == 0 {
+		return time.Now()
+	}
+
+	ts, err := client.GetBlockTime(ctx, blockNumber)
+	if err != nil {
+		log.Printf("Failed to get block %d time, falling back to server time: %v", blockNumber, err)
+		return time.Now()
+	}
+	return ts
+}
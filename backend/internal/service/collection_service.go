@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// TrendingWindows 支持的热门系列时间窗口
+var TrendingWindows = []string{"24h", "7d", "30d"}
+
+// CollectionService 系列服务
+type CollectionService struct {
+	repo          repository.CollectionStore
+	holders       repository.HolderStore
+	bidIncrements *BidIncrementPolicy
+}
+
+// NewCollectionService 创建系列服务
+func NewCollectionService(repo repository.CollectionStore, holders repository.HolderStore, bidIncrements *BidIncrementPolicy) *CollectionService {
+	return &CollectionService{
+		repo:          repo,
+		holders:       holders,
+		bidIncrements: bidIncrements,
+	}
+}
+
+// StreamHolderSnapshot 逐行输出当前持有者快照
+func (s *CollectionService) StreamHolderSnapshot(ctx context.Context, nftContract string, fn func(repository.HolderBalance) error) error {
+	err := s.holders.StreamHolders(nftContract, func(holder repository.HolderBalance) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(holder)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build holder snapshot: %w", err)
+	}
+	return nil
+}
+
+// BidIncrementQuote 系列最小加价规则及下一口最低出价
+type BidIncrementQuote struct {
+	NFTContract    string `json:"nft_contract"`
+	IncrementBps   int64  `json:"increment_bps"`
+	IncrementWei   string `json:"increment_wei"`
+	CurrentBid     string `json:"current_bid"`
+	MinimumNextBid string `json:"minimum_next_bid"`
+}
+
+// GetBidIncrement 获取系列最小加价规则，并按当前最高出价计算下一口最低出价
+func (s *CollectionService) GetBidIncrement(ctx context.Context, nftContract string, current *big.Int) (*BidIncrementQuote, error) {
+	rule, err := s.bidIncrements.Effective(ctx, nftContract)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bid increment: %w", err)
+	}
+	if current == nil {
+		current = new(big.Int)
+	}
+
+	return &BidIncrementQuote{
+		NFTContract:    nftContract,
+		IncrementBps:   rule.Bps,
+		IncrementWei:   rule.Wei.String(),
+		CurrentBid:     current.String(),
+		MinimumNextBid: rule.MinimumBid(current).String(),
+	}, nil
+}
+
+// GetTopCollections 获取热门系列（数据来自定时刷新的物化视图）
+func (s *CollectionService) GetTopCollections(ctx context.Context, window string, limit int) ([]repository.TrendingCollection, error) {
+	collections, err := s.repo.GetTopCollections(window, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top collections: %w", err)
+	}
+	return collections, nil
+}
+
+// RefreshTrending 刷新热门系列快照
+func (s *CollectionService) RefreshTrending(ctx context.Context) error {
+	if err := s.repo.RefreshTrending(); err != nil {
+		return fmt.Errorf("failed to refresh trending collections: %w", err)
+	}
+	return nil
+}
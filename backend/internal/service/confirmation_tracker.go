@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/cache"
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// 待确认事件类型
+const (
+	pendingListing = "listing" // MarketItemCreated 写入的挂单
+	pendingSale    = "sale"    // MarketItemSold 写入的 pending 成交
+)
+
+// pendingSaleRestoreLimit 启动时最多重新入队的 pending 成交数
+const pendingSaleRestoreLimit = 10000
+
+// pendingEvent 等待确认的实时事件
+type pendingEvent struct {
+	kind      string
+	id        uint  // 挂单或交易 ID
+	listingID *uint // 成交对应的挂单
+	txHash    common.Hash
+	blockHash common.Hash
+}
+
+// ConfirmationResult 一轮确认的结果
+type ConfirmationResult struct {
+	Confirmed int `json:"confirmed"`
+	Reorged   int `json:"reorged"` // 所在区块被重组且交易已不在链上，成交标记为 failed、挂单标记为 invalid
+	Moved     int `json:"moved"`   // 交易被重新打包到其他区块，按新区块重新等待确认
+}
+
+// ConfirmationTracker 实时事件的确认队列：按区块号排队，区块距最新区块达到确认数后比对区块哈希，
+// 一致则成交转为 confirmed，不一致且交易已不在链上则撤销事件的写入。回填只处理已确认区块，不经过队列
+type ConfirmationTracker struct {
+	client blockchain.BlockchainClient
+	txs    repository.TransactionStore
+	index  repository.IndexTransactor
+	head   *ChainHead // 判断区块是否已达到确认数
+	cache  *cache.SWR // 撤销挂单后失效挂单列表缓存
+
+	mu      sync.Mutex
+	pending map[uint64][]pendingEvent
+}
+
+// NewConfirmationTracker 创建确认队列，确认数取 head 的配置
+func NewConfirmationTracker(
+	client blockchain.BlockchainClient,
+	txs repository.TransactionStore,
+	index repository.IndexTransactor,
+	head *ChainHead,
+	swr *cache.SWR,
+) *ConfirmationTracker {
+	return &ConfirmationTracker{
+		client:  client,
+		txs:     txs,
+		index:   index,
+		head:    head,
+		cache:   swr,
+		pending: make(map[uint64][]pendingEvent),
+	}
+}
+
+// Enabled 是否需要等待确认；未配置或确认数为 0 时事件写入即为最终状态
+func (t *ConfirmationTracker) Enabled() bool {
+	return t != nil && t.head != nil && t.head.confirmations > 0
+}
+
+// TrackListing 将挂单创建事件加入队列
+func (t *ConfirmationTracker) TrackListing(listingID uint, blockNumber uint64, txHash, blockHash common.Hash) {
+	t.track(blockNumber, pendingEvent{kind: pendingListing, id: listingID, txHash: txHash, blockHash: blockHash})
+}
+
+// TrackSale 将 pending 成交加入队列
+func (t *ConfirmationTracker) TrackSale(tx *repository.Transaction) {
+	t.track(tx.BlockNumber, pendingEvent{
+		kind:      pendingSale,
+		id:        tx.ID,
+		listingID: tx.ListingID,
+		txHash:    common.HexToHash(tx.TxHash),
+		blockHash: common.HexToHash(tx.BlockHash),
+	})
+}
+
+// track 按区块号入队
+func (t *ConfirmationTracker) track(blockNumber uint64, event pendingEvent) {
+	if !t.Enabled() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending[blockNumber] = append(t.pending[blockNumber], event)
+}
+
+// Restore 启动时将库中记录了区块哈希的 pending 成交重新入队，返回入队数量。
+// 挂单没有待确认状态，重启前未确认的挂单不再检查
+func (t *ConfirmationTracker) Restore() (int, error) {
+	if !t.Enabled() {
+		return 0, nil
+	}
+	txs, err := t.txs.GetPendingSales(pendingSaleRestoreLimit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending sales: %w", err)
+	}
+	for i := range txs {
+		t.TrackSale(&txs[i])
+	}
+	return len(txs), nil
+}
+
+// Process 处理已达到确认数的区块。RPC 或写库失败时未处理的事件留在队列中，下次重试
+func (t *ConfirmationTracker) Process(ctx context.Context) (*ConfirmationResult, error) {
+	result := &ConfirmationResult{}
+	if !t.Enabled() {
+		return result, nil
+	}
+
+	due := t.takeDue()
+	for i, block := range due {
+		if err := t.processBlock(ctx, block.number, block.events, result); err != nil {
+			for _, rest := range due[i+1:] {
+				t.requeue(rest.number, rest.events)
+			}
+			return result, err
+		}
+	}
+
+	if result.Reorged > 0 {
+		t.cache.Bump(ctx, activeListingsNamespace)
+		t.cache.Invalidate(ctx, marketStatsCacheKey)
+	}
+	return result, nil
+}
+
+// dueBlock 已达到确认数的区块及其事件
+type dueBlock struct {
+	number uint64
+	events []pendingEvent
+}
+
+// takeDue 取出已达到确认数的区块（按区块号升序）
+func (t *ConfirmationTracker) takeDue() []dueBlock {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var due []dueBlock
+	for number, events := range t.pending {
+		if t.head.IsFinal(number) {
+			due = append(due, dueBlock{number: number, events: events})
+			delete(t.pending, number)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].number < due[j].number })
+	return due
+}
+
+// requeue 将事件放回队列
+func (t *ConfirmationTracker) requeue(blockNumber uint64, events []pendingEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending[blockNumber] = append(t.pending[blockNumber], events...)
+}
+
+// processBlock 比对区块哈希并处理区块内的事件，出错时剩余事件（含出错的）放回队列
+func (t *ConfirmationTracker) processBlock(ctx context.Context, blockNumber uint64, events []pendingEvent, result *ConfirmationResult) error {
+	hash, err := t.client.GetBlockHash(ctx, blockNumber)
+	if err != nil {
+		t.requeue(blockNumber, events)
+		return fmt.Errorf("failed to get block hash %d: %w", blockNumber, err)
+	}
+
+	for i, event := range events {
+		if event.blockHash == hash {
+			err = t.confirm(event)
+			if err == nil {
+				result.Confirmed++
+			}
+		} else {
+			err = t.resolveReorg(ctx, event, result)
+		}
+		if err != nil {
+			t.requeue(blockNumber, events[i:])
+			return err
+		}
+	}
+	return nil
+}
+
+// confirm 事件所在区块未被重组：成交转为 confirmed，挂单写入时已是最终状态
+func (t *ConfirmationTracker) confirm(event pendingEvent) error {
+	if event.kind != pendingSale {
+		return nil
+	}
+	if err := t.txs.UpdateStatus(event.id, "confirmed"); err != nil {
+		return fmt.Errorf("failed to confirm transaction %d: %w", event.id, err)
+	}
+	return nil
+}
+
+// resolveReorg 事件所在区块已被重组：交易被重新打包时记下新区块并按新区块重新排队，已不在链上（或执行失败）时撤销写入
+func (t *ConfirmationTracker) resolveReorg(ctx context.Context, event pendingEvent, result *ConfirmationResult) error {
+	receipt, err := t.client.GetTransactionReceipt(ctx, event.txHash)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return fmt.Errorf("failed to get receipt %s: %w", event.txHash.Hex(), err)
+	}
+	if err == nil && receipt.Status == types.ReceiptStatusSuccessful && receipt.BlockNumber != nil {
+		if err := t.moveBlock(event, receipt.BlockNumber.Uint64(), receipt.BlockHash); err != nil {
+			return err
+		}
+		event.blockHash = receipt.BlockHash
+		t.requeue(receipt.BlockNumber.Uint64(), []pendingEvent{event})
+		result.Moved++
+		return nil
+	}
+
+	err = t.index.InTx(func(stores repository.IndexStores) error {
+		if event.kind == pendingListing {
+			return stores.Listings.UpdateStatus(event.id, "invalid")
+		}
+		if err := stores.Transactions.UpdateStatus(event.id, "failed"); err != nil {
+			return err
+		}
+		if event.listingID != nil {
+			return stores.Listings.RevertSale(*event.listingID)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revert reorged %s %d: %w", event.kind, event.id, err)
+	}
+
+	log.Printf("Reorg: %s %d from tx %s is no longer on chain, reverted", event.kind, event.id, event.txHash.Hex())
+	result.Reorged++
+	return nil
+}
+
+// moveBlock 记下被重新打包的事件所在的新区块，重启后 Restore 按新区块比对哈希。
+// 成交的区块号与区块哈希一起更新；挂单只记录区块哈希
+func (t *ConfirmationTracker) moveBlock(event pendingEvent, blockNumber uint64, blockHash common.Hash) error {
+	err := t.index.InTx(func(stores repository.IndexStores) error {
+		if event.kind == pendingListing {
+			return stores.Listings.SetBlockHash(event.id, blockHash.Hex())
+		}
+		return stores.Transactions.UpdateBlock(event.id, blockNumber, blockHash.Hex())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move %s %d to block %d: %w", event.kind, event.id, blockNumber, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiaomait/backend/internal/blockchain/mock"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/repository/memory"
+)
+
+// 交易被重新打包到其他区块：库中的区块号与区块哈希一起更新，重启后按新区块确认
+func TestConfirmationTrackerMovedBlock(t *testing.T) {
+	const (
+		oldBlock = 100
+		newBlock = 105
+	)
+	var (
+		oldHash = common.HexToHash("0xaa")
+		newHash = common.HexToHash("0xbb")
+		txHash  = common.HexToHash("0x01")
+	)
+
+	txs := memory.NewTransactionStore()
+	listings := memory.NewListingStore()
+	listing := &repository.Listing{ItemID: 1, NFTContract: testNFTContract, TokenID: "7", Seller: testSeller, Price: "100", Status: "sold", BlockHash: oldHash.Hex()}
+	if err := listings.Create(listing); err != nil {
+		t.Fatalf("create listing: %v", err)
+	}
+	sale := &repository.Transaction{TxHash: txHash.Hex(), BlockNumber: oldBlock, BlockHash: oldHash.Hex(), TxType: "sale", ListingID: &listing.ID, NFTContract: testNFTContract, TokenID: "7", FromAddress: testSeller, Status: "pending"}
+	if err := txs.Create(sale); err != nil {
+		t.Fatalf("create sale: %v", err)
+	}
+
+	client := &mock.Client{
+		GetBlockHashFunc: func(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+			if blockNumber == newBlock {
+				return newHash, nil
+			}
+			return common.HexToHash("0xcc"), nil // 原区块已被重组
+		},
+		GetTransactionReceiptFunc: func(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+			return &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(newBlock), BlockHash: newHash}, nil
+		},
+	}
+	head := NewChainHead(client, 2)
+	head.head.Store(200)
+	tracker := NewConfirmationTracker(client, txs, memory.NewIndexTransactor(listings, txs, memory.NewSyncStateStore()), head, nil)
+	tracker.TrackListing(listing.ID, oldBlock, txHash, oldHash)
+	tracker.TrackSale(sale)
+
+	result, err := tracker.Process(context.Background())
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if result.Moved != 2 {
+		t.Errorf("Moved = %d, want 2", result.Moved)
+	}
+
+	stored, err := txs.GetByID(sale.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if stored.BlockNumber != newBlock || stored.BlockHash != newHash.Hex() {
+		t.Errorf("sale block = %d %s, want %d %s", stored.BlockNumber, stored.BlockHash, newBlock, newHash.Hex())
+	}
+	if got, _ := listings.GetByID(listing.ID); got.BlockHash != newHash.Hex() {
+		t.Errorf("listing block hash = %s, want %s", got.BlockHash, newHash.Hex())
+	}
+
+	// 重启后从库中恢复，按新区块比对哈希后确认
+	restarted := NewConfirmationTracker(client, txs, memory.NewIndexTransactor(listings, txs, memory.NewSyncStateStore()), head, nil)
+	if _, err := restarted.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if result, err := restarted.Process(context.Background()); err != nil || result.Confirmed != 1 {
+		t.Fatalf("Process after restore = %+v, %v, want 1 confirmed", result, err)
+	}
+	if stored, _ := txs.GetByID(sale.ID); stored.Status != "confirmed" {
+		t.Errorf("sale status = %s, want confirmed", stored.Status)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiaomait/backend/internal/alert"
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// deadLetterSampleSize 告警中附带的错误信息样例数
+const deadLetterSampleSize = 5
+
+// DeadLetterService 记录处理失败的链上事件，并在窗口内累计超过阈值时告警
+type DeadLetterService struct {
+	store     repository.FailedEventStore
+	notifier  alert.Notifier // 为 nil 时只记录不告警
+	threshold int64
+	window    time.Duration
+
+	mu        sync.Mutex
+	lastAlert time.Time
+}
+
+// NewDeadLetterService 创建死信服务，notifier 为 nil 时不告警
+func NewDeadLetterService(store repository.FailedEventStore, notifier alert.Notifier, threshold int64, window time.Duration) *DeadLetterService {
+	return &DeadLetterService{
+		store:     store,
+		notifier:  notifier,
+		threshold: threshold,
+		window:    window,
+	}
+}
+
+// Record 记录失败事件并检查是否需要告警，记录本身失败时仅打印日志
+func (s *DeadLetterService) Record(eventName string, raw types.Log, event interface{}, cause error) {
+	failed := &repository.FailedEvent{
+		EventName:       eventName,
+		TransactionHash: raw.TxHash.Hex(),
+		LogIndex:        raw.Index,
+		BlockNumber:     raw.BlockNumber,
+		ErrorMessage:    cause.Error(),
+	}
+	if data, err := json.Marshal(event); err == nil {
+		payload := string(data)
+		failed.EventData = &payload
+	}
+
+	if err := s.store.Create(failed); err != nil {
+		log.Printf("Failed to record dead-letter %s at %s: %v (original error: %v)",
+			eventName, failed.TransactionHash, err, cause)
+		return
+	}
+
+	if err := s.CheckAlert(); err != nil {
+		log.Printf("Dead-letter alert check failed: %v", err)
+	}
+}
+
+// CheckAlert 窗口内失败事件数达到阈值时告警，同一窗口内最多告警一次
+func (s *DeadLetterService) CheckAlert() error {
+	if s.notifier == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if !s.lastAlert.IsZero() && now.Sub(s.lastAlert) < s.window {
+		return nil
+	}
+
+	since := now.Add(-s.window)
+	count, err := s.store.CountSince(since)
+	if err != nil {
+		return fmt.Errorf("failed to count failed events: %w", err)
+	}
+	if count < s.threshold {
+		return nil
+	}
+
+	recent, err := s.store.RecentSince(since, deadLetterSampleSize)
+	if err != nil {
+		return fmt.Errorf("failed to get failed events: %w", err)
+	}
+	samples := make([]string, len(recent))
+	for i, event := range recent {
+		samples[i] = fmt.Sprintf("%s tx=%s: %s", event.EventName, event.TransactionHash, event.ErrorMessage)
+	}
+
+	s.lastAlert = now
+	a := alert.Alert{
+		Title:   "Chain events failing to process (dead-letter threshold exceeded)",
+		Count:   count,
+		Window:  s.window,
+		Samples: samples,
+		At:      now,
+	}
+
+	// 异步发送，避免 Webhook/SMTP 阻塞事件处理
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := s.notifier.Notify(ctx, a); err != nil {
+			log.Printf("Failed to send dead-letter alert: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/xiaomait/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxFeeBps 平台费率上限（基点），与合约 updatePlatformFee 的限制一致
+	MaxFeeBps = 1000
+	// feeDenominator 基点分母
+	feeDenominator = 10000
+)
+
+// ErrFeeOutOfRange 费率超出允许范围
+var ErrFeeOutOfRange = fmt.Errorf("fee must be between 0 and %d bps", MaxFeeBps)
+
+// FeeService 平台费计算，系列可覆盖全局费率
+type FeeService struct {
+	collections repository.CollectionStore
+	defaultBps  int64
+}
+
+// NewFeeService 创建费率服务
+func NewFeeService(collections repository.CollectionStore, defaultBps int64) *FeeService {
+	return &FeeService{
+		collections: collections,
+		defaultBps:  defaultBps,
+	}
+}
+
+// FeeQuote 卖家到手金额预览
+type FeeQuote struct {
+	NFTContract    string `json:"nft_contract"`
+	Price          string `json:"price"`
+	FeeBps         int64  `json:"fee_bps"`
+	PlatformFee    string `json:"platform_fee"`
+	SellerProceeds string `json:"seller_proceeds"`
+}
+
+// ValidateFeeBps 校验费率在 [0, MaxFeeBps] 范围内
+func ValidateFeeBps(bps int64) error {
+	if bps < 0 || bps > MaxFeeBps {
+		return ErrFeeOutOfRange
+	}
+	return nil
+}
+
+// EffectiveFeeBps 获取系列实际费率：有覆盖时使用覆盖值，否则使用全局费率
+func (s *FeeService) EffectiveFeeBps(ctx context.Context, nftContract string) (int64, error) {
+	if nftContract == "" {
+		return s.defaultBps, nil
+	}
+
+	collection, err := s.collections.GetByAddress(nftContract)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.defaultBps, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	return s.feeBpsOf(collection)
+}
+
+// EffectiveFeeBpsByContracts 批量获取系列实际费率，键为小写合约地址
+func (s *FeeService) EffectiveFeeBpsByContracts(ctx context.Context, nftContracts []string) (map[string]int64, error) {
+	collections, err := s.collections.GetByAddresses(nftContracts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+
+	fees := make(map[string]int64, len(nftContracts))
+	for _, contract := range nftContracts {
+		fees[strings.ToLower(contract)] = s.defaultBps
+	}
+	for i := range collections {
+		bps, err := s.feeBpsOf(&collections[i])
+		if err != nil {
+			return nil, err
+		}
+		fees[strings.ToLower(collections[i].ContractAddress)] = bps
+	}
+	return fees, nil
+}
+
+// feeBpsOf 系列记录对应的实际费率
+func (s *FeeService) feeBpsOf(collection *repository.Collection) (int64, error) {
+	if collection.FeeBpsOverride == nil {
+		return s.defaultBps, nil
+	}
+	if err := ValidateFeeBps(*collection.FeeBpsOverride); err != nil {
+		return 0, fmt.Errorf("invalid fee override for %s: %w", collection.ContractAddress, err)
+	}
+
+	return *collection.FeeBpsOverride, nil
+}
+
+// Quote 计算成交价对应的平台费和卖家到手金额
+func (s *FeeService) Quote(ctx context.Context, nftContract, price string) (*FeeQuote, error) {
+	priceWei, ok := new(big.Int).SetString(price, 10)
+	if !ok || priceWei.Sign() < 0 {
+		return nil, fmt.Errorf("invalid price: %s", price)
+	}
+
+	bps, err := s.EffectiveFeeBps(ctx, nftContract)
+	if err != nil {
+		return nil, err
+	}
+
+	fee := computeFee(priceWei, bps)
+
+	return &FeeQuote{
+		NFTContract:    nftContract,
+		Price:          priceWei.String(),
+		FeeBps:         bps,
+		PlatformFee:    fee.String(),
+		SellerProceeds: new(big.Int).Sub(priceWei, fee).String(),
+	}, nil
+}
+
+// SetFeeOverride 设置系列费率覆盖，feeBps 为 nil 时恢复全局费率
+func (s *FeeService) SetFeeOverride(ctx context.Context, nftContract string, feeBps *int64) error {
+	if feeBps != nil {
+		if err := ValidateFeeBps(*feeBps); err != nil {
+			return err
+		}
+	}
+
+	if err := s.collections.UpdateFeeOverride(nftContract, feeBps); err != nil {
+		return fmt.Errorf("failed to update fee override: %w", err)
+	}
+
+	return nil
+}
+
+// computeFee 按基点计算费用（向下取整，与合约 price * fee / DENOMINATOR 一致）
+func computeFee(price *big.Int, bps int64) *big.Int {
+	fee := new(big.Int).Mul(price, big.NewInt(bps))
+	return fee.Div(fee, big.NewInt(feeDenominator))
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// FloorChange 系列地板价变动
+type FloorChange struct {
+	NFTContract string    `json:"nft_contract"`
+	OldFloor    string    `json:"old_floor"`
+	NewFloor    string    `json:"new_floor"`
+	ChangeBps   int64     `json:"change_bps"` // 相对上次通知时地板价的变动（基点，下跌为负）
+	At          time.Time `json:"at"`
+}
+
+// floorBaseline 上次通知时的地板价
+type floorBaseline struct {
+	floor      *big.Int
+	notifiedAt time.Time
+}
+
+// FloorWatchService 定期比较各系列地板价，相对上次通知变动超过阈值时发出 FloorChange。
+// 基准只在通知时更新，缓慢累积的变动最终也会触发；同一系列两次通知至少间隔 debounce
+type FloorWatchService struct {
+	listings     repository.ListingStore
+	thresholdBps int64
+	debounce     time.Duration
+	publish      func(FloorChange)
+
+	mu        sync.Mutex
+	baselines map[string]floorBaseline
+}
+
+// NewFloorWatchService 创建地板价监控，publish 负责推送变动
+func NewFloorWatchService(listings repository.ListingStore, thresholdBps int64, debounce time.Duration, publish func(FloorChange)) *FloorWatchService {
+	return &FloorWatchService{
+		listings:     listings,
+		thresholdBps: thresholdBps,
+		debounce:     debounce,
+		publish:      publish,
+		baselines:    make(map[string]floorBaseline),
+	}
+}
+
+// Check 检查一次所有系列的地板价，返回发出的变动数。首次见到的系列只记录基准；
+// 暂无活跃挂单的系列保留原基准，重新挂单后与之比较
+func (s *FloorWatchService) Check(ctx context.Context) (int, error) {
+	floors, err := s.listings.GetFloorPrices()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get floor prices: %w", err)
+	}
+
+	s.mu.Lock()
+
+	now := time.Now()
+	var changes []FloorChange
+	for _, f := range floors {
+		floor, ok := new(big.Int).SetString(f.Floor, 10)
+		if !ok {
+			continue
+		}
+
+		baseline, seen := s.baselines[f.NFTContract]
+		if !seen || baseline.floor.Sign() == 0 {
+			s.baselines[f.NFTContract] = floorBaseline{floor: floor}
+			continue
+		}
+		if now.Sub(baseline.notifiedAt) < s.debounce {
+			continue
+		}
+
+		delta := new(big.Int).Sub(floor, baseline.floor)
+		bps := delta.Mul(delta, big.NewInt(feeDenominator)).Quo(delta, baseline.floor)
+		if bps.CmpAbs(big.NewInt(s.thresholdBps)) < 0 {
+			continue
+		}
+		if !bps.IsInt64() {
+			bps.SetInt64(math.MaxInt64) // 地板价从极低值暴涨，基点超出 int64
+		}
+
+		changes = append(changes, FloorChange{
+			NFTContract: f.NFTContract,
+			OldFloor:    baseline.floor.String(),
+			NewFloor:    floor.String(),
+			ChangeBps:   bps.Int64(),
+			At:          now,
+		})
+		s.baselines[f.NFTContract] = floorBaseline{floor: floor, notifiedAt: now}
+	}
+	s.mu.Unlock()
+
+	for _, change := range changes {
+		s.publish(change)
+	}
+	return len(changes), nil
+}
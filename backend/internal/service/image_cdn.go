@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/metadata"
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// imageCDNCacheTTL 系列图片 CDN 前缀的本地缓存时间
+const imageCDNCacheTTL = 5 * time.Minute
+
+// ErrInvalidImageCDNBase 系列图片 CDN 前缀不是合法 URL，或主机不在白名单中
+var ErrInvalidImageCDNBase = errors.New("invalid image cdn base")
+
+// imageCDNEntry 缓存的系列 CDN 前缀，base 为空表示未设置
+type imageCDNEntry struct {
+	base    string
+	expires time.Time
+}
+
+// ImageURLRewriter 响应中的 NFT 图片地址改写：IPFS 图片（ipfs://、裸 CID、任意网关地址）改写到系列
+// 配置的 CDN 前缀，未设置时改写到全局网关；不修改已存储的元数据
+type ImageURLRewriter struct {
+	collections repository.CollectionStore
+	gateway     string
+
+	mu    sync.Mutex
+	cache map[string]imageCDNEntry
+}
+
+// NewImageURLRewriter 创建图片地址改写器，gateway 为全局 IPFS 网关前缀（形如 https://ipfs.io/ipfs/）
+func NewImageURLRewriter(collections repository.CollectionStore, gateway string) *ImageURLRewriter {
+	return &ImageURLRewriter{
+		collections: collections,
+		gateway:     gateway,
+		cache:       make(map[string]imageCDNEntry),
+	}
+}
+
+// Rewrite 按系列配置改写图片地址
+func (r *ImageURLRewriter) Rewrite(nftContract, imageURL string) string {
+	if imageURL == "" {
+		return ""
+	}
+	base := r.cdnBase(nftContract)
+	if base == "" {
+		base = r.gateway
+	}
+	return metadata.NormalizeURI(imageURL, base)
+}
+
+// SetCDNBase 设置或清除（base 为 nil 或空）系列图片 CDN 前缀，前缀须通过抓取白名单校验
+func (r *ImageURLRewriter) SetCDNBase(ctx context.Context, nftContract string, base *string) error {
+	if base != nil && strings.TrimSpace(*base) == "" {
+		base = nil
+	}
+	if base != nil {
+		trimmed := strings.TrimSpace(*base)
+		if err := metadata.CheckBaseURL(trimmed); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidImageCDNBase, err)
+		}
+		base = &trimmed
+	}
+
+	if err := r.collections.UpdateImageCDNBase(nftContract, base); err != nil {
+		return fmt.Errorf("failed to update image cdn base: %w", err)
+	}
+
+	r.mu.Lock()
+	delete(r.cache, strings.ToLower(nftContract))
+	r.mu.Unlock()
+	return nil
+}
+
+// cdnBase 系列的 CDN 前缀（带缓存），系列未登记或查询失败时为空
+func (r *ImageURLRewriter) cdnBase(nftContract string) string {
+	key := strings.ToLower(nftContract)
+
+	r.mu.Lock()
+	entry, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.base
+	}
+
+	entry = imageCDNEntry{expires: time.Now().Add(imageCDNCacheTTL)}
+	collection, err := r.collections.GetByAddress(nftContract)
+	switch {
+	case err == nil && collection.ImageCDNBase != nil:
+		entry.base = *collection.ImageCDNBase
+	case err != nil && !repository.IsNotFound(err):
+		log.Printf("Failed to get image cdn base for %s: %v", nftContract, err)
+		return ""
+	}
+
+	r.mu.Lock()
+	r.cache[key] = entry
+	r.mu.Unlock()
+	return entry.base
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// IndexerService 链上事件回填（批量写入，实时监听仍逐条写入）
+type IndexerService struct {
+	bcClient    blockchain.BlockchainClient
+	index       repository.IndexTransactor // 每个区块段的事件与索引进度在同一事务中提交
+	syncState   repository.SyncStateStore
+	nfts        repository.NFTStore
+	fees        *FeeService
+	blockTime   *blockchain.BlockTimeEstimator
+	marketplace string // 索引进度按市场合约地址与事件流记录
+	chunkSize   uint64 // 每次 eth_getLogs 查询的区块数
+	batchSize   int    // 每条 INSERT 语句的行数
+}
+
+// NewIndexerService 创建索引服务
+func NewIndexerService(
+	bcClient blockchain.BlockchainClient,
+	index repository.IndexTransactor,
+	syncState repository.SyncStateStore,
+	nfts repository.NFTStore,
+	fees *FeeService,
+	blockTime *blockchain.BlockTimeEstimator,
+	marketplace string,
+	chunkSize uint64,
+	batchSize int,
+) *IndexerService {
+	if chunkSize == 0 {
+		chunkSize = 1000
+	}
+	if batchSize < 1 {
+		batchSize = 500
+	}
+
+	return &IndexerService{
+		bcClient:    bcClient,
+		index:       index,
+		syncState:   syncState,
+		nfts:        nfts,
+		fees:        fees,
+		blockTime:   blockTime,
+		marketplace: marketplace,
+		chunkSize:   chunkSize,
+		batchSize:   batchSize,
+	}
+}
+
+// GetLastSyncedBlock 市场合约各事件流中最低的索引进度（续传从这里开始才不会漏掉落后的事件流），
+// 从未索引过时 ok 为 false
+func (s *IndexerService) GetLastSyncedBlock() (block uint64, ok bool, err error) {
+	for _, event := range blockchain.MarketEvents {
+		last, found, err := s.syncState.GetLastSyncedBlock(s.marketplace, event)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to get last synced block of %s: %w", event, err)
+		}
+		if found && (!ok || last < block) {
+			block, ok = last, true
+		}
+	}
+	return block, ok, nil
+}
+
+// StreamCursor 单个事件流已索引到的区块，供监听器重新订阅后补齐；该事件流尚无进度时退回到最低进度
+func (s *IndexerService) StreamCursor(event string) (block uint64, ok bool, err error) {
+	block, ok, err = s.syncState.GetLastSyncedBlock(s.marketplace, event)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get last synced block of %s: %w", event, err)
+	}
+	if ok {
+		return block, true, nil
+	}
+	return s.GetLastSyncedBlock()
+}
+
+// Lag 链上最新区块与市场合约索引进度之差，供就绪检查使用（仅独立索引进程）。
+// 尚无索引进度时返回错误，索引进程在首次回填或首个事件入库前不就绪；
+// 没有事件时进度由 AdvanceIdle 推进到已确认区块，阈值应大于 BLOCK_CONFIRMATIONS
+func (s *IndexerService) Lag(ctx context.Context, head uint64) (uint64, error) {
+	last, ok, err := s.GetLastSyncedBlock()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("indexer has not synced any block")
+	}
+	if head <= last {
+		return 0, nil
+	}
+	return head - last, nil
+}
+
+// AdvanceIdle 将各事件流的索引进度推进到 toBlock（应为已确认区块）中没有该事件的部分：按 chunkSize 查询
+// (进度, toBlock] 的日志，推进到第一个事件之前为止，该事件留给实时监听或回填入库。
+// 返回推进后的最低进度；尚无任何索引进度时不推进（起点未知）
+func (s *IndexerService) AdvanceIdle(ctx context.Context, toBlock uint64) (uint64, error) {
+	for _, event := range blockchain.MarketEvents {
+		if err := s.advanceStream(ctx, event, toBlock); err != nil {
+			return 0, err
+		}
+	}
+
+	last, _, err := s.GetLastSyncedBlock()
+	return last, err
+}
+
+// advanceStream 推进单个事件流的空闲进度
+func (s *IndexerService) advanceStream(ctx context.Context, event string, toBlock uint64) error {
+	last, ok, err := s.StreamCursor(event)
+	if err != nil || !ok {
+		return err
+	}
+
+	for start := last + 1; start <= toBlock; start += s.chunkSize {
+		end := start + s.chunkSize - 1
+		if end > toBlock || end < start {
+			end = toBlock
+		}
+
+		logs, err := s.bcClient.FilterMarketLogs(ctx, event, start, end)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s logs for blocks %d-%d: %w", event, start, end, err)
+		}
+		// 有事件时只推进到第一个事件之前的区块
+		idleTo, found := end, false
+		for _, vLog := range logs {
+			if vLog.BlockNumber <= idleTo {
+				idleTo, found = vLog.BlockNumber-1, true
+			}
+		}
+		if idleTo > last {
+			if err := s.syncState.SetLastSyncedBlock(s.marketplace, event, idleTo); err != nil {
+				return fmt.Errorf("failed to advance %s sync cursor: %w", event, err)
+			}
+			last = idleTo
+		}
+
+		if found || end == toBlock {
+			break
+		}
+	}
+
+	return nil
+}
+
+// BackfillResult 回填结果
+type BackfillResult struct {
+	FromBlock uint64 `json:"from_block"`
+	ToBlock   uint64 `json:"to_block"`
+	Created   int    `json:"created"`
+	Sold      int    `json:"sold"`
+}
+
+// Backfill 回填区块范围 [fromBlock, toBlock] 内的市场事件，可重复执行。
+// 每个区块段的事件写入与创建、售出事件流的索引进度在同一事务中提交，中途失败时进度停在最后一个完整写入的区块段
+func (s *IndexerService) Backfill(ctx context.Context, fromBlock, toBlock uint64) (*BackfillResult, error) {
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("invalid block range: %d > %d", fromBlock, toBlock)
+	}
+
+	result := &BackfillResult{FromBlock: fromBlock, ToBlock: toBlock}
+
+	for start := fromBlock; start <= toBlock; start += s.chunkSize {
+		end := start + s.chunkSize - 1
+		if end > toBlock || end < start {
+			end = toBlock
+		}
+
+		created, sold, err := s.bcClient.FetchMarketEvents(ctx, start, end)
+		if err != nil {
+			return result, fmt.Errorf("failed to fetch events for blocks %d-%d: %w", start, end, err)
+		}
+
+		err = s.index.InTx(func(stores repository.IndexStores) error {
+			if err := s.storeCreated(ctx, stores, created, start, end); err != nil {
+				return fmt.Errorf("failed to store listings for blocks %d-%d: %w", start, end, err)
+			}
+			if err := s.storeSold(ctx, stores, sold); err != nil {
+				return fmt.Errorf("failed to store sales for blocks %d-%d: %w", start, end, err)
+			}
+			if err := stores.SyncState.SetLastSyncedBlock(s.marketplace, blockchain.EventMarketItemCreated, end); err != nil {
+				return err
+			}
+			return stores.SyncState.SetLastSyncedBlock(s.marketplace, blockchain.EventMarketItemSold, end)
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Created += len(created)
+		result.Sold += len(sold)
+
+		if end == toBlock {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// storeCreated 批量写入新挂单，挂单时间按区块范围插值估算
+func (s *IndexerService) storeCreated(ctx context.Context, stores repository.IndexStores, events []*blockchain.MarketItemCreatedEvent, fromBlock, toBlock uint64) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	clock, err := s.blockTime.ForRange(ctx, fromBlock, toBlock)
+	if err != nil {
+		return err
+	}
+
+	listings := make([]repository.Listing, len(events))
+	for i, event := range events {
+		listings[i] = repository.Listing{
+			ItemID:      event.ItemId.Uint64(),
+			NFTContract: event.NftContract.Hex(),
+			TokenID:     event.TokenId.String(),
+			Seller:      event.Seller.Hex(),
+			Price:       event.Price.String(),
+			Status:      "active",
+			ListingType: repository.ListingTypeFixedPrice,
+			TxHash:      event.Raw.TxHash.Hex(),
+			BlockHash:   event.Raw.BlockHash.Hex(),
+			ListedAt:    clock.At(event.Raw.BlockNumber),
+
+			ContractVersion: event.Version,
+		}
+	}
+
+	return stores.Listings.BatchUpsert(listings, s.batchSize)
+}
+
+// storeSold 批量写入销售交易并将对应挂单标记为已售
+func (s *IndexerService) storeSold(ctx context.Context, stores repository.IndexStores, events []*blockchain.MarketItemSoldEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	itemIDs := make([]uint64, len(events))
+	for i, event := range events {
+		itemIDs[i] = event.ItemId.Uint64()
+	}
+
+	listings, err := stores.Listings.GetByItemIDs(itemIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get listings: %w", err)
+	}
+	byItemID := make(map[uint64]*repository.Listing, len(listings))
+	for i := range listings {
+		byItemID[listings[i].ItemID] = &listings[i]
+	}
+
+	txs := make([]repository.Transaction, 0, len(events))
+	soldAt := make(map[uint64]time.Time, len(events))
+	for _, event := range events {
+		// 销售需要精确时间戳（成交额按时间窗口统计）
+		blockTime, err := s.blockTime.Exact(ctx, event.Raw.BlockNumber)
+		if err != nil {
+			return err
+		}
+		itemID := event.ItemId.Uint64()
+		soldAt[itemID] = blockTime
+
+		// ItemID 供实时监听按市场项去重，回填写入的成交同样需要
+		tx := repository.Transaction{
+			TxHash:           event.Raw.TxHash.Hex(),
+			BlockNumber:      event.Raw.BlockNumber,
+			BlockHash:        event.Raw.BlockHash.Hex(),
+			BlockTimestamp:   blockTime,
+			TxType:           "sale",
+			ItemID:           &itemID,
+			ToAddress:        event.Buyer.Hex(),
+			Value:            event.Price.String(),
+			ValueNumeric:     event.Price.String(),
+			NetValueNumeric:  nativeSettledAmount(event.Price),
+			Status:           "confirmed",
+			LogIndex:         int(event.Raw.Index),
+			TransactionIndex: int(event.Raw.TxIndex),
+			ContractVersion:  event.Version,
+		}
+
+		if listing, ok := byItemID[itemID]; ok {
+			tx.ListingID = &listing.ID
+			tx.NFTContract = listing.NFTContract
+			tx.TokenID = listing.TokenID
+			tx.FromAddress = listing.Seller
+
+			tx.IsPrimary, err = isPrimarySale(s.nfts, listing.NFTContract, listing.TokenID, listing.Seller)
+			if err != nil {
+				return err
+			}
+		}
+
+		quote, err := s.fees.Quote(ctx, tx.NFTContract, tx.ValueNumeric)
+		if err != nil {
+			return fmt.Errorf("failed to compute platform fee: %w", err)
+		}
+		tx.PlatformFee = quote.PlatformFee
+
+		txs = append(txs, tx)
+	}
+
+	if err := stores.Transactions.BatchUpsert(txs, s.batchSize); err != nil {
+		return fmt.Errorf("failed to upsert transactions: %w", err)
+	}
+
+	return stores.Listings.MarkSoldByItemIDs(soldAt)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/blockchain/mock"
+	"github.com/xiaomait/backend/internal/repository/memory"
+)
+
+const testMarketplace = "0x00000000000000000000000000000000000000c1"
+
+// newTestIndexerService 基于内存索引进度的索引服务，saleBlocks 为链上有售出事件的区块；
+// 返回的 queried 记录售出事件流查询过的区块段
+func newTestIndexerService(saleBlocks ...uint64) (*IndexerService, *memory.SyncStateStore, *[][2]uint64) {
+	var queried [][2]uint64
+	client := &mock.Client{
+		FilterMarketLogsFunc: func(ctx context.Context, event string, fromBlock, toBlock uint64) ([]types.Log, error) {
+			if event != blockchain.EventMarketItemSold {
+				return nil, nil
+			}
+			queried = append(queried, [2]uint64{fromBlock, toBlock})
+			var logs []types.Log
+			for _, block := range saleBlocks {
+				if block >= fromBlock && block <= toBlock {
+					logs = append(logs, soldEvent(1, block, "0x01", 0).Raw)
+				}
+			}
+			return logs, nil
+		},
+	}
+	syncState := memory.NewSyncStateStore()
+	return NewIndexerService(client, nil, syncState, nil, nil, nil, testMarketplace, 100, 0), syncState, &queried
+}
+
+func TestAdvanceIdle(t *testing.T) {
+	tests := []struct {
+		name       string
+		saleBlocks []uint64
+		toBlock    uint64
+		want       uint64
+		wantChunks int
+	}{
+		{"no events", nil, 350, 350, 3},
+		{"already at head", nil, 100, 100, 0},
+		{"event in first chunk", []uint64{150}, 350, 149, 1},
+		{"event at cursor+1", []uint64{101}, 350, 100, 1},
+		{"event in later chunk", []uint64{260}, 350, 259, 2},
+		{"event after head", []uint64{400}, 350, 350, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, syncState, queried := newTestIndexerService(tt.saleBlocks...)
+			for _, event := range blockchain.MarketEvents {
+				syncState.SetLastSyncedBlock(testMarketplace, event, 100)
+			}
+
+			got, err := s.AdvanceIdle(context.Background(), tt.toBlock)
+			if err != nil {
+				t.Fatalf("AdvanceIdle: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AdvanceIdle() = %d, want %d", got, tt.want)
+			}
+			if stored, _, _ := syncState.GetLastSyncedBlock(testMarketplace, blockchain.EventMarketItemSold); stored != tt.want {
+				t.Errorf("cursor = %d, want %d", stored, tt.want)
+			}
+			if len(*queried) != tt.wantChunks {
+				t.Errorf("queried %v, want %d chunks", *queried, tt.wantChunks)
+			}
+			// 没有事件的流推进到 toBlock
+			if stored, _, _ := syncState.GetLastSyncedBlock(testMarketplace, blockchain.EventMarketItemCreated); stored != tt.toBlock {
+				t.Errorf("created cursor = %d, want %d", stored, tt.toBlock)
+			}
+		})
+	}
+}
+
+// 各事件流进度不同时从最低的续传；尚无进度的事件流从最低进度开始
+func TestStreamCursors(t *testing.T) {
+	s, syncState, _ := newTestIndexerService()
+	syncState.SetLastSyncedBlock(testMarketplace, blockchain.EventMarketItemCreated, 300)
+	syncState.SetLastSyncedBlock(testMarketplace, blockchain.EventMarketItemSold, 120)
+
+	if last, ok, err := s.GetLastSyncedBlock(); err != nil || !ok || last != 120 {
+		t.Errorf("GetLastSyncedBlock() = %d, %v, %v, want 120", last, ok, err)
+	}
+	if block, ok, _ := s.StreamCursor(blockchain.EventMarketItemCreated); !ok || block != 300 {
+		t.Errorf("StreamCursor(created) = %d, %v, want 300", block, ok)
+	}
+	if block, ok, _ := s.StreamCursor(blockchain.EventMarketItemCanceled); !ok || block != 120 {
+		t.Errorf("StreamCursor(canceled) = %d, %v, want lowest 120", block, ok)
+	}
+	if lag, err := s.Lag(context.Background(), 350); err != nil || lag != 230 {
+		t.Errorf("Lag() = %d, %v, want 230", lag, err)
+	}
+}
+
+// 尚无索引进度时不推进，就绪检查保持不就绪
+func TestAdvanceIdleWithoutCursor(t *testing.T) {
+	s, _, queried := newTestIndexerService()
+
+	if _, err := s.AdvanceIdle(context.Background(), 350); err != nil {
+		t.Fatalf("AdvanceIdle: %v", err)
+	}
+	if _, ok, _ := s.GetLastSyncedBlock(); ok {
+		t.Error("cursor created without a starting point")
+	}
+	if len(*queried) != 0 {
+		t.Errorf("queried %v, want none", *queried)
+	}
+	if _, err := s.Lag(context.Background(), 350); err == nil {
+		t.Error("Lag() without cursor = nil error, want not ready")
+	}
+}
+
+func TestAdvanceIdleFetchError(t *testing.T) {
+	syncState := memory.NewSyncStateStore()
+	syncState.SetLastSyncedBlock(testMarketplace, blockchain.EventMarketItemSold, 100)
+	client := &mock.Client{
+		FilterMarketLogsFunc: func(ctx context.Context, event string, fromBlock, toBlock uint64) ([]types.Log, error) {
+			return nil, errors.New("rpc down")
+		},
+	}
+	s := NewIndexerService(client, nil, syncState, nil, nil, nil, testMarketplace, 100, 0)
+
+	if _, err := s.AdvanceIdle(context.Background(), 350); err == nil {
+		t.Fatal("AdvanceIdle() = nil error, want fetch error")
+	}
+	if stored, _, _ := syncState.GetLastSyncedBlock(testMarketplace, blockchain.EventMarketItemSold); stored != 100 {
+		t.Errorf("cursor = %d, want unchanged 100", stored)
+	}
+}
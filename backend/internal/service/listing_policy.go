@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// ErrCollectionNotVerified 开启已验证系列限制时，未验证（或未登记）的系列不允许挂单
+var ErrCollectionNotVerified = errors.New("collection is not verified")
+
+// CollectionListingPolicy 系列挂单准入：requireVerified 开启时只有已验证系列可以挂单
+type CollectionListingPolicy struct {
+	collections     repository.CollectionStore
+	requireVerified bool
+}
+
+// NewCollectionListingPolicy 创建系列挂单准入策略
+func NewCollectionListingPolicy(collections repository.CollectionStore, requireVerified bool) *CollectionListingPolicy {
+	return &CollectionListingPolicy{collections: collections, requireVerified: requireVerified}
+}
+
+// Listable 系列当前是否允许挂单，未登记的系列视为未验证
+func (p *CollectionListingPolicy) Listable(ctx context.Context, nftContract string) (bool, error) {
+	if p == nil || !p.requireVerified {
+		return true, nil
+	}
+
+	collection, err := p.collections.GetByAddress(nftContract)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get collection: %w", err)
+	}
+	return collection.IsVerified, nil
+}
+
+// ListableByContracts 批量判断系列是否允许挂单，键为小写合约地址
+func (p *CollectionListingPolicy) ListableByContracts(ctx context.Context, nftContracts []string) (map[string]bool, error) {
+	listable := make(map[string]bool, len(nftContracts))
+	for _, contract := range nftContracts {
+		listable[strings.ToLower(contract)] = p == nil || !p.requireVerified
+	}
+	if p == nil || !p.requireVerified {
+		return listable, nil
+	}
+
+	collections, err := p.collections.GetByAddresses(nftContracts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+	for i := range collections {
+		listable[strings.ToLower(collections[i].ContractAddress)] = collections[i].IsVerified
+	}
+	return listable, nil
+}
+
+// Check 不允许挂单时返回 ErrCollectionNotVerified
+func (p *CollectionListingPolicy) Check(ctx context.Context, nftContract string) error {
+	listable, err := p.Listable(ctx, nftContract)
+	if err != nil {
+		return err
+	}
+	if !listable {
+		return fmt.Errorf("%w: %s", ErrCollectionNotVerified, nftContract)
+	}
+	return nil
+}
@@ -2,68 +2,175 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"log"
 	"math/big"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/cache"
 	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/requestid"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
 )
 
 // ListingService 挂单服务
 type ListingService struct {
-	repo     *repository.ListingRepository
-	bcClient *blockchain.Client
+	repo     repository.ListingStore
+	txs      repository.TransactionStore
+	nfts     repository.NFTStore
+	index    repository.IndexTransactor // 链上事件写入与索引进度在同一事务中提交
+	bcClient blockchain.BlockchainClient
+	cache    *cache.SWR
+	fees     *FeeService
+	policy   *CollectionListingPolicy
+	stubs    *NFTMetadataService  // 为 nil 时不为缺失的 NFT 创建占位记录
+	head     *ChainHead           // 计算成交记录的 is_final
+	tracker  *ConfirmationTracker // 实时创建事件在达到确认数前检测链重组，nil 表示不检测
+
+	bids      repository.BidStore
+	bidPolicy *BidIncrementPolicy // 拍卖竞价须在当前最高竞价之上满足最小加价
+
+	unverifiedPolicy string
+	refreshWorkers   int
+	refreshLimiter   *rate.Limiter // 卖家触发的链上刷新共用的 RPC 速率
+}
+
+// SellerRefreshOptions 卖家挂单刷新的并发与 RPC 速率
+type SellerRefreshOptions struct {
+	Workers       int     // 并发查询链上市场项的 worker 数
+	RatePerSecond float64 // 所有刷新请求合计每秒最多查询的市场项数
 }
 
-// NewListingService 创建挂单服务
-func NewListingService(repo *repository.ListingRepository, bcClient *blockchain.Client) *ListingService {
+// 区块链熔断时的挂单策略
+const (
+	UnverifiedPolicyReject = "reject" // 拒绝创建，与 RPC 正常时校验失败一致
+	UnverifiedPolicyTrust  = "trust"  // 信任请求数据直接上架，标记 unverified
+	UnverifiedPolicyQueue  = "queue"  // 以 pending 状态入库，校验通过后才上架
+)
+
+// NewListingService 创建挂单服务，swr 为 nil 时不使用缓存；
+// policy 限制哪些系列可以通过 API 挂单（nil 表示不限制）；
+// stubs 不为 nil 时为没有 NFT 记录的挂单创建占位记录并异步抓取元数据；
+// tracker 不为 nil 时实时创建事件入确认队列；bidPolicy 为拍卖竞价的最小加价规则；
+// unverifiedPolicy 决定链上调用熔断时 CreateListing 的行为
+func NewListingService(
+	repo repository.ListingStore,
+	txs repository.TransactionStore,
+	nfts repository.NFTStore,
+	index repository.IndexTransactor,
+	bcClient blockchain.BlockchainClient,
+	swr *cache.SWR,
+	fees *FeeService,
+	policy *CollectionListingPolicy,
+	stubs *NFTMetadataService,
+	head *ChainHead,
+	tracker *ConfirmationTracker,
+	bids repository.BidStore,
+	bidPolicy *BidIncrementPolicy,
+	unverifiedPolicy string,
+	refresh SellerRefreshOptions,
+) *ListingService {
+	if refresh.Workers < 1 {
+		refresh.Workers = 4
+	}
+	if refresh.RatePerSecond <= 0 {
+		refresh.RatePerSecond = 10
+	}
+
 	return &ListingService{
-		repo:     repo,
-		bcClient: bcClient,
+		repo:             repo,
+		txs:              txs,
+		nfts:             nfts,
+		index:            index,
+		bcClient:         bcClient,
+		cache:            swr,
+		fees:             fees,
+		policy:           policy,
+		stubs:            stubs,
+		head:             head,
+		tracker:          tracker,
+		bids:             bids,
+		bidPolicy:        bidPolicy,
+		unverifiedPolicy: unverifiedPolicy,
+		refreshWorkers:   refresh.Workers,
+		refreshLimiter:   rate.NewLimiter(rate.Limit(refresh.RatePerSecond), refresh.Workers),
 	}
 }
 
+// 挂单相关的缓存键
+const (
+	activeListingsNamespace = "listings:active" // 分页键按代号失效
+	marketStatsCacheKey     = "stats:market"
+)
+
+// listingPage 缓存的分页挂单结果
+type listingPage struct {
+	Items []*ListingResponse `json:"items"`
+	Total int64              `json:"total"`
+}
+
 // CreateListingRequest 创建挂单请求
 type CreateListingRequest struct {
 	ItemID      uint64 `json:"item_id" binding:"required"`
-	NFTContract string `json:"nft_contract" binding:"required"`
+	NFTContract string `json:"nft_contract" binding:"required,eth_addr"`
 	TokenID     string `json:"token_id" binding:"required"`
-	Seller      string `json:"seller" binding:"required"`
-	Price       string `json:"price" binding:"required"`
+	Seller      string `json:"seller" binding:"omitempty,eth_addr"` // 须与认证地址一致，为空时取认证地址
+	Price       string `json:"price" binding:"required,wei"`
 	TxHash      string `json:"tx_hash" binding:"required"`
+
+	// 挂单类型，默认 fixed_price；auction 时 Price 为起拍价，须指定 AuctionEndAt
+	ListingType  string     `json:"listing_type" binding:"omitempty,oneof=fixed_price auction"`
+	AuctionEndAt *time.Time `json:"auction_end_at"`
+	ReservePrice string     `json:"reserve_price" binding:"omitempty,wei"` // 保留价（wei），可选
 }
 
 // ListingResponse 挂单响应
 type ListingResponse struct {
-	ID          uint      `json:"id"`
-	ItemID      uint64    `json:"item_id"`
-	NFTContract string    `json:"nft_contract"`
-	TokenID     string    `json:"token_id"`
-	Seller      string    `json:"seller"`
-	Price       string    `json:"price"`
-	Status      string    `json:"status"`
-	ListedAt    time.Time `json:"listed_at"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID              uint      `json:"id"`      // 数据库 ID，用于 /listings/{id} 等接口
+	ItemID          uint64    `json:"item_id"` // 市场合约 itemId，用于链上调用和 /listings/item/{itemId}
+	NFTContract     string    `json:"nft_contract"`
+	TokenID         string    `json:"token_id"`
+	Seller          string    `json:"seller"`
+	Price           string    `json:"price"`
+	Status          string    `json:"status"`
+	Unverified      bool      `json:"unverified"`
+	ContractVersion string    `json:"contract_version,omitempty"`
+	ListedAt        time.Time `json:"listed_at"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// 挂单类型 fixed_price/auction；拍卖挂单附带截止时间、保留价及当前最高竞价
+	ListingType   string     `json:"listing_type"`
+	AuctionEndAt  *time.Time `json:"auction_end_at,omitempty"`
+	ReservePrice  *string    `json:"reserve_price,omitempty"`
+	HighestBidWei *string    `json:"highest_bid_wei,omitempty"`
+	BidCount      int64      `json:"bid_count,omitempty"`
+
+	// 成交/取消时间（未成交、未取消时省略）
+	SoldAt      *time.Time `json:"sold_at,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	// 已归档挂单的归档时间
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	// 当前最高有效出价（无有效出价时为 null）
+	BestOfferWei       *string    `json:"best_offer_wei"`
+	BestOfferExpiresAt *time.Time `json:"best_offer_expires_at"`
+	OfferCount         int64      `json:"offer_count"`
+
+	// 按 ?currencies= 换算的法币价格，汇率获取失败的币种省略
+	Prices map[string]string `json:"prices,omitempty"`
 }
 
 // CreateListing 创建挂单
 func (s *ListingService) CreateListing(ctx context.Context, req *CreateListingRequest) (*ListingResponse, error) {
-	// 验证链上数据
-	itemID := big.NewInt(int64(req.ItemID))
-	itemData, err := s.bcClient.GetMarketItem(ctx, itemID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to verify on-chain data: %w", err)
-	}
-	log.Printf("Market itemData: %+v", itemData)
-
-	chainNFTContract := itemData["nftContract"].(string)
-	reqNFTContract := req.NFTContract
-	// 检查数据一致性
-	if common.HexToAddress(chainNFTContract) != common.HexToAddress(reqNFTContract) {
-		return nil, fmt.Errorf("nft contract mismatch")
+	if err := s.policy.Check(ctx, req.NFTContract); err != nil {
+		return nil, err
 	}
 
 	listing := &repository.Listing{
@@ -76,14 +183,37 @@ func (s *ListingService) CreateListing(ctx context.Context, req *CreateListingRe
 		TxHash:      req.TxHash,
 		ListedAt:    time.Now(),
 	}
+	if err := applyListingType(listing, req, listing.ListedAt); err != nil {
+		return nil, err
+	}
+
+	// 验证链上数据，RPC 熔断时按配置策略降级
+	err := s.verifyOnChain(ctx, listing)
+	switch {
+	case errors.Is(err, blockchain.ErrCircuitOpen) && s.unverifiedPolicy == UnverifiedPolicyTrust:
+		listing.Unverified = true
+	case errors.Is(err, blockchain.ErrCircuitOpen) && s.unverifiedPolicy == UnverifiedPolicyQueue:
+		listing.Unverified = true
+		listing.Status = "pending"
+	case err != nil:
+		return nil, err
+	}
 
 	if err := s.repo.Create(listing); err != nil {
 		return nil, fmt.Errorf("failed to create listing: %w", err)
 	}
+	s.ensureNFT(ctx, listing)
+	s.invalidateListings(ctx)
 
 	return s.toResponse(listing), nil
 }
 
+// invalidateListings 挂单变化后使活跃挂单分页与市场统计缓存失效
+func (s *ListingService) invalidateListings(ctx context.Context) {
+	s.cache.Bump(ctx, activeListingsNamespace)
+	s.cache.Invalidate(ctx, marketStatsCacheKey)
+}
+
 // GetListing 获取挂单
 func (s *ListingService) GetListing(ctx context.Context, id uint) (*ListingResponse, error) {
 	listing, err := s.repo.GetByID(id)
@@ -94,13 +224,112 @@ func (s *ListingService) GetListing(ctx context.Context, id uint) (*ListingRespo
 	return s.toResponse(listing), nil
 }
 
-// GetActiveListings 获取活跃挂单
-func (s *ListingService) GetActiveListings(ctx context.Context, page, pageSize int) ([]*ListingResponse, int64, error) {
-	listings, total, err := s.repo.GetActiveListings(page, pageSize)
+// GetListingByItemID 按市场合约 itemId 获取挂单
+func (s *ListingService) GetListingByItemID(ctx context.Context, itemID uint64) (*ListingResponse, error) {
+	listing, err := s.repo.GetByItemID(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+
+	return s.toResponse(listing), nil
+}
+
+// 按交易哈希查找挂单时命中的记录类型
+const (
+	TxMatchListing = "listing" // 挂单创建交易
+	TxMatchSale    = "sale"    // 成交交易
+)
+
+// ListingTxLookup 按交易哈希查找的挂单及其成交记录
+type ListingTxLookup struct {
+	MatchedBy string               `json:"matched_by"`
+	Listing   *ListingResponse     `json:"listing"` // 成交交易未关联挂单时为 null
+	Sale      *TransactionResponse `json:"sale"`    // 尚未成交时为 null
+}
+
+// GetListingByTx 按交易哈希查找挂单：先匹配挂单创建交易，再匹配成交交易。
+// 均不匹配（含其他类型的交易）时返回记录不存在错误
+func (s *ListingService) GetListingByTx(ctx context.Context, txHash string) (*ListingTxLookup, error) {
+	txHash = strings.ToLower(txHash)
+
+	listing, err := s.repo.GetByTxHash(txHash)
+	if err == nil {
+		lookup := &ListingTxLookup{MatchedBy: TxMatchListing, Listing: s.toResponse(listing)}
+		sale, err := s.txs.GetSaleByListingID(listing.ID)
+		if err != nil && !repository.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get sale for listing %d: %w", listing.ID, err)
+		}
+		if sale != nil {
+			lookup.Sale = toTransactionResponse(sale, s.head)
+		}
+		return lookup, nil
+	}
+	if !repository.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get listing by tx: %w", err)
+	}
+
+	tx, err := s.txs.GetByHash(txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if tx.TxType != "sale" {
+		return nil, fmt.Errorf("transaction %s is not a listing or sale: %w", txHash, gorm.ErrRecordNotFound)
+	}
+	lookup := &ListingTxLookup{MatchedBy: TxMatchSale, Sale: toTransactionResponse(tx, s.head)}
+	if tx.ListingID != nil {
+		listing, err := s.repo.GetByID(*tx.ListingID)
+		if err != nil && !repository.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get listing %d: %w", *tx.ListingID, err)
+		}
+		if listing != nil {
+			lookup.Listing = s.toResponse(listing)
+		}
+	}
+	return lookup, nil
+}
+
+// ListingStatuses 可用于过滤的挂单状态
+var ListingStatuses = []string{"active", "pending", "sold", "cancelled", "invalid"}
+
+// ListingSorts 可用的挂单排序方式
+var ListingSorts = []string{
+	repository.ListingSortRecent,
+	repository.ListingSortOldest,
+	repository.ListingSortPriceAsc,
+	repository.ListingSortPriceDesc,
+}
+
+// GetActiveListings 按状态（默认 active）与排序获取挂单，withCount 为 false 时不查询总数（total 语义见 repository.findPage）
+func (s *ListingService) GetActiveListings(ctx context.Context, filter repository.ListingFilter, page, pageSize int, withCount bool) ([]*ListingResponse, int64, error) {
+	key := fmt.Sprintf("%s:%s:%s:%s:%d:%d:%t", activeListingsNamespace, s.cache.Generation(ctx, activeListingsNamespace),
+		filter.Status, filter.Sort, page, pageSize, withCount)
+	result, err := cache.Fetch(ctx, s.cache, key, func(ctx context.Context) (*listingPage, error) {
+		listings, total, err := s.repo.GetActiveListings(filter, page, pageSize, withCount)
+		if err != nil {
+			return nil, err
+		}
+
+		responses := make([]*ListingResponse, len(listings))
+		for i, listing := range listings {
+			responses[i] = s.toResponse(&listing)
+		}
+
+		return &listingPage{Items: responses, Total: total}, nil
+	})
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get active listings: %w", err)
 	}
 
+	return result.Items, result.Total, nil
+}
+
+// GetListingsAsOf 获取在 at 时刻处于活跃状态的挂单，用于还原历史市场状态
+func (s *ListingService) GetListingsAsOf(ctx context.Context, at time.Time, nftContract string, page, pageSize int) ([]*ListingResponse, int64, error) {
+	listings, total, err := s.repo.GetActiveAsOf(at, nftContract, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get listings as of %s: %w", at.Format(time.RFC3339), err)
+	}
+
 	responses := make([]*ListingResponse, len(listings))
 	for i, listing := range listings {
 		responses[i] = s.toResponse(&listing)
@@ -109,9 +338,9 @@ func (s *ListingService) GetActiveListings(ctx context.Context, page, pageSize i
 	return responses, total, nil
 }
 
-// GetUserListings 获取用户挂单
-func (s *ListingService) GetUserListings(ctx context.Context, address string, page, pageSize int) ([]*ListingResponse, int64, error) {
-	listings, total, err := s.repo.GetBySellerPaginated(address, page, pageSize)
+// GetUserListings 获取用户挂单，includeArchived 为 false 时不返回已归档挂单
+func (s *ListingService) GetUserListings(ctx context.Context, address string, includeArchived bool, page, pageSize int) ([]*ListingResponse, int64, error) {
+	listings, total, err := s.repo.GetBySellerPaginated(address, includeArchived, page, pageSize)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get user listings: %w", err)
 	}
@@ -124,15 +353,85 @@ func (s *ListingService) GetUserListings(ctx context.Context, address string, pa
 	return responses, total, nil
 }
 
-// CancelListing 取消挂单
+// SearchListings 按合约、卖家、价格区间组合搜索活跃挂单，withCount 为 false 时不查询总数
+func (s *ListingService) SearchListings(ctx context.Context, filter repository.ListingSearchFilter, page, pageSize int, withCount bool) ([]*ListingResponse, int64, error) {
+	listings, total, err := s.repo.SearchListings(filter, page, pageSize, withCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search listings: %w", err)
+	}
+
+	responses := make([]*ListingResponse, len(listings))
+	for i, listing := range listings {
+		responses[i] = s.toResponse(&listing)
+	}
+
+	return responses, total, nil
+}
+
+// MaxListingStatusBatch 单次挂单状态查询的最大 Token 数
+const MaxListingStatusBatch = 100
+
+// TokenStatusRequest 挂单状态查询项
+type TokenStatusRequest struct {
+	Contract string `json:"contract" binding:"required,eth_addr"`
+	TokenID  string `json:"token_id" binding:"required"`
+}
+
+// ListingStatus Token 挂单状态
+type ListingStatus struct {
+	IsListed  bool    `json:"is_listed"`
+	ListingID *uint   `json:"listing_id"`
+	PriceWei  *string `json:"price_wei"`
+}
+
+// listingStatusKey 挂单状态结果的键（合约地址小写）
+func listingStatusKey(contract, tokenID string) string {
+	return strings.ToLower(contract) + ":" + tokenID
+}
+
+// GetListingStatuses 批量查询 Token 是否在售，结果以 "contract:token_id" 为键
+func (s *ListingService) GetListingStatuses(ctx context.Context, tokens []TokenStatusRequest) (map[string]*ListingStatus, error) {
+	statuses := make(map[string]*ListingStatus, len(tokens))
+	refs := make([]repository.TokenRef, 0, len(tokens))
+	for _, token := range tokens {
+		key := listingStatusKey(token.Contract, token.TokenID)
+		if _, ok := statuses[key]; ok {
+			continue
+		}
+		statuses[key] = &ListingStatus{}
+		refs = append(refs, repository.TokenRef{NFTContract: token.Contract, TokenID: token.TokenID})
+	}
+
+	listings, err := s.repo.GetActiveByTokens(refs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing statuses: %w", err)
+	}
+
+	for i := range listings {
+		status := statuses[listingStatusKey(listings[i].NFTContract, listings[i].TokenID)]
+		if status == nil || status.IsListed {
+			continue
+		}
+		status.IsListed = true
+		status.ListingID = &listings[i].ID
+		status.PriceWei = &listings[i].Price
+	}
+
+	return statuses, nil
+}
+
+// ErrNotSeller 只有卖家可以取消挂单
+var ErrNotSeller = errors.New("not the seller")
+
+// CancelListing 取消挂单，seller 须为挂单卖家，否则返回 ErrNotSeller
 func (s *ListingService) CancelListing(ctx context.Context, id uint, seller string) error {
 	listing, err := s.repo.GetByID(id)
 	if err != nil {
 		return fmt.Errorf("failed to get listing: %w", err)
 	}
 
-	if listing.Seller != seller {
-		return fmt.Errorf("unauthorized: not the seller")
+	if !strings.EqualFold(listing.Seller, seller) {
+		return ErrNotSeller
 	}
 
 	if listing.Status != "active" {
@@ -142,28 +441,407 @@ func (s *ListingService) CancelListing(ctx context.Context, id uint, seller stri
 	if err := s.repo.UpdateStatus(id, "cancelled"); err != nil {
 		return fmt.Errorf("failed to cancel listing: %w", err)
 	}
+	s.invalidateListings(ctx)
 
 	return nil
 }
 
-// UpdateFromEvent 从区块链事件更新挂单
-func (s *ListingService) UpdateFromEvent(event *blockchain.MarketItemCreatedEvent) error {
+// UpdateFromEvent 从区块链事件更新挂单，返回写入（或已存在）的挂单
+func (s *ListingService) UpdateFromEvent(event *blockchain.MarketItemCreatedEvent) (*ListingResponse, error) {
 	listing := &repository.Listing{
-		ItemID:      event.ItemId.Uint64(),
-		NFTContract: event.NftContract.Hex(),
-		TokenID:     event.TokenId.String(),
-		Seller:      event.Seller.Hex(),
-		Price:       event.Price.String(),
-		Status:      "active",
-		ListedAt:    time.Now(),
+		ItemID:          event.ItemId.Uint64(),
+		NFTContract:     event.NftContract.Hex(),
+		TokenID:         event.TokenId.String(),
+		Seller:          event.Seller.Hex(),
+		Price:           event.Price.String(),
+		Status:          "active",
+		ListingType:     repository.ListingTypeFixedPrice,
+		ContractVersion: event.Version,
+		BlockHash:       event.Raw.BlockHash.Hex(),
+		ListedAt:        chainTime(context.Background(), s.bcClient, event.Raw.BlockNumber),
 	}
 
-	// 使用 CreateIfNotExists 防止并发重复插入
-	return s.repo.CreateIfNotExists(listing)
+	err := s.index.InTx(func(stores repository.IndexStores) error {
+		// 使用 CreateIfNotExists 防止并发重复插入
+		if err := stores.Listings.CreateIfNotExists(listing); err != nil {
+			return err
+		}
+
+		// 经 API 先行创建的挂单补记合约版本
+		if listing.ContractVersion == "" {
+			if err := stores.Listings.SetContractVersion(listing.ID, event.Version); err != nil {
+				return err
+			}
+		}
+
+		// 经 API 先行创建的挂单补记区块哈希
+		if listing.BlockHash == "" {
+			if err := stores.Listings.SetBlockHash(listing.ID, event.Raw.BlockHash.Hex()); err != nil {
+				return err
+			}
+		}
+
+		// 链上事件本身即为校验，熔断期间创建的同一挂单无需再查 RPC
+		if listing.Unverified {
+			if err := stores.Listings.MarkVerified(listing.ID); err != nil {
+				return err
+			}
+		}
+		return stores.SyncState.SetLastSyncedBlock(event.Raw.Address.Hex(), blockchain.EventMarketItemCreated, event.Raw.BlockNumber)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.tracker.TrackListing(listing.ID, event.Raw.BlockNumber, event.Raw.TxHash, event.Raw.BlockHash)
+	s.ensureNFT(context.Background(), listing)
+	s.invalidateListings(context.Background())
+	return s.toResponse(listing), nil
+}
+
+// CancelFromEvent 链上取消事件将对应的活跃（或待上架）挂单标记为已取消。从未收录过创建事件的市场项
+// 不创建记录；已售出、已取消等非活跃挂单保持不变
+func (s *ListingService) CancelFromEvent(event *blockchain.MarketItemCancelledEvent) error {
+	cancelled := false
+	err := s.index.InTx(func(stores repository.IndexStores) error {
+		listing, err := stores.Listings.GetByItemID(event.ItemId.Uint64())
+		switch {
+		case repository.IsNotFound(err):
+			log.Printf("Ignoring cancel event for unknown item %s", event.ItemId)
+		case err != nil:
+			return fmt.Errorf("failed to get listing: %w", err)
+		case listing.Status == "active" || listing.Status == "pending":
+			if event.Seller != (common.Address{}) && !strings.EqualFold(listing.Seller, event.Seller.Hex()) {
+				log.Printf("Cancel event for item %s sent by %s, listing %d seller is %s", event.ItemId, event.Seller.Hex(), listing.ID, listing.Seller)
+			}
+			if err := stores.Listings.UpdateStatus(listing.ID, "cancelled"); err != nil {
+				return fmt.Errorf("failed to cancel listing: %w", err)
+			}
+			cancelled = true
+		}
+		return stores.SyncState.SetLastSyncedBlock(event.Raw.Address.Hex(), blockchain.EventMarketItemCanceled, event.Raw.BlockNumber)
+	})
+	if err != nil {
+		return err
+	}
+
+	if cancelled {
+		s.invalidateListings(context.Background())
+	}
+	return nil
+}
+
+// ensureNFT 挂单的 NFT 没有记录时创建占位记录，失败只记录日志，不影响挂单
+func (s *ListingService) ensureNFT(ctx context.Context, listing *repository.Listing) {
+	if s.stubs == nil {
+		return
+	}
+	if err := s.stubs.EnsureNFT(ctx, listing.NFTContract, listing.TokenID, listing.Seller); err != nil {
+		requestid.Logf(ctx, "Listing %d: %v", listing.ID, err)
+	}
+}
+
+// ErrListingTokenMismatch 请求的 NFT 合约或 Token ID 与链上市场项不一致
+var ErrListingTokenMismatch = errors.New("listing token does not match on-chain item")
+
+// ErrListingSellerMismatch 挂单卖家与链上市场项的卖家不一致
+var ErrListingSellerMismatch = errors.New("listing seller does not match on-chain seller")
+
+// ErrListingPriceMismatch 请求的挂单价格与链上市场项价格不一致
+var ErrListingPriceMismatch = errors.New("listing price does not match on-chain price")
+
+// verifyOnChain 校验挂单与链上市场项一致（NFT 合约、Token ID、卖家与价格），以链上数据为准
+func (s *ListingService) verifyOnChain(ctx context.Context, listing *repository.Listing) error {
+	item, err := s.bcClient.GetMarketItem(ctx, new(big.Int).SetUint64(listing.ItemID))
+	if err != nil {
+		return fmt.Errorf("failed to verify on-chain data: %w", err)
+	}
+
+	tokenID, ok := new(big.Int).SetString(listing.TokenID, 10)
+	if item.NftContract != common.HexToAddress(listing.NFTContract) || !ok || item.TokenId == nil || item.TokenId.Cmp(tokenID) != 0 {
+		return ErrListingTokenMismatch
+	}
+	if item.Seller != common.HexToAddress(listing.Seller) {
+		return ErrListingSellerMismatch
+	}
+
+	if item.Price == nil {
+		return fmt.Errorf("failed to verify on-chain data: missing price")
+	}
+	price, ok := new(big.Int).SetString(listing.Price, 10)
+	if !ok || price.Cmp(item.Price) != 0 {
+		return fmt.Errorf("%w: listed %s, on-chain %s", ErrListingPriceMismatch, listing.Price, item.Price)
+	}
+	return nil
+}
+
+// VerifyResult 未验证挂单的校验结果
+type VerifyResult struct {
+	Verified int `json:"verified"`
+	Invalid  int `json:"invalid"`
+	Failed   int `json:"failed"`
+}
+
+// VerifyUnverifiedListings 对熔断期间创建的挂单补做链上校验：一致的转为已验证（排队的随之上架），
+// 不一致的标记为 invalid。熔断器再次打开时提前结束，剩余挂单留待下次恢复
+func (s *ListingService) VerifyUnverifiedListings(ctx context.Context) (*VerifyResult, error) {
+	const batchSize = 100
+	result := &VerifyResult{}
+
+	for {
+		listings, err := s.repo.GetUnverified(batchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to get unverified listings: %w", err)
+		}
+
+		progressed := false
+		for i := range listings {
+			listing := &listings[i]
+			err := s.verifyOnChain(ctx, listing)
+			switch {
+			case errors.Is(err, blockchain.ErrCircuitOpen):
+				return result, err
+			case errors.Is(err, ErrListingTokenMismatch), errors.Is(err, ErrListingSellerMismatch), errors.Is(err, ErrListingPriceMismatch):
+				if err := s.repo.UpdateStatus(listing.ID, "invalid"); err != nil {
+					return result, fmt.Errorf("failed to invalidate listing: %w", err)
+				}
+				result.Invalid++
+				progressed = true
+			case err != nil:
+				log.Printf("Verify: listing %d (item %d) still unverifiable: %v", listing.ID, listing.ItemID, err)
+				result.Failed++
+			default:
+				if err := s.repo.MarkVerified(listing.ID); err != nil {
+					return result, fmt.Errorf("failed to mark listing verified: %w", err)
+				}
+				result.Verified++
+				progressed = true
+			}
+		}
+
+		// 整批都无法校验时停止，避免反复查询同一批
+		if len(listings) < batchSize || !progressed {
+			return result, nil
+		}
+	}
+}
+
+// ArchiveSettledListings 分批归档成交/取消超过 retention 的挂单，返回归档总数
+func (s *ListingService) ArchiveSettledListings(ctx context.Context, retention time.Duration, batchSize int) (int64, error) {
+	before := time.Now().Add(-retention)
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		archived, err := s.repo.ArchiveSettled(before, batchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to archive listings: %w", err)
+		}
+		total += archived
+		if archived < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// ReconcileResult 挂单与链上状态对账结果
+type ReconcileResult struct {
+	Checked   int `json:"checked"`
+	Sold      int `json:"sold"`
+	Cancelled int `json:"cancelled"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+// ReconcileActiveListings 将数据库中的活跃挂单与链上 fetchActiveItems 对比，
+// 对已不在链上活跃列表中的挂单查询详情并标记为 sold 或 cancelled；
+// 详情显示仍在售的保持不变（活跃列表与详情可能跨区块读取）。
+// maxChecks 限制逐项查询链上数据的次数，避免大目录时压垮 RPC。
+func (s *ListingService) ReconcileActiveListings(ctx context.Context, maxChecks int) (*ReconcileResult, error) {
+	chainIDs, err := s.bcClient.FetchActiveItemIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active items: %w", err)
+	}
+
+	onChain := make(map[uint64]struct{}, len(chainIDs))
+	for _, id := range chainIDs {
+		onChain[id.Uint64()] = struct{}{}
+	}
+
+	// 先收集全部活跃挂单，避免边更新边分页导致漏项
+	const batchSize = 500
+	var stale []repository.Listing
+	for page := 1; ; page++ {
+		listings, _, err := s.repo.GetActiveListings(repository.ListingFilter{}, page, batchSize, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get active listings: %w", err)
+		}
+		for _, listing := range listings {
+			if _, ok := onChain[listing.ItemID]; !ok {
+				stale = append(stale, listing)
+			}
+		}
+		if len(listings) < batchSize {
+			break
+		}
+	}
+
+	result := &ReconcileResult{}
+	for _, listing := range stale {
+		if result.Checked >= maxChecks {
+			result.Skipped = len(stale) - result.Checked
+			break
+		}
+		result.Checked++
+
+		item, err := s.bcClient.GetMarketItem(ctx, new(big.Int).SetUint64(listing.ItemID))
+		if err != nil {
+			log.Printf("Reconcile: failed to get market item %d: %v", listing.ItemID, err)
+			continue
+		}
+
+		status := chainListingStatus(item)
+		if status == "active" {
+			result.Unchanged++
+			continue
+		}
+
+		if err := s.repo.UpdateStatus(listing.ID, status); err != nil {
+			log.Printf("Reconcile: failed to update listing %d: %v", listing.ID, err)
+			continue
+		}
+
+		if status == "sold" {
+			result.Sold++
+		} else {
+			result.Cancelled++
+		}
+	}
+
+	return result, nil
+}
+
+// chainListingStatus 由链上市场项推导挂单状态。
+// 合约取消挂单时同样置 sold=true，但 owner 设回 seller；真正售出时 owner 为买家
+func chainListingStatus(item *blockchain.MarketItem) string {
+	if !item.Sold {
+		return "active"
+	}
+	if item.Owner != item.Seller {
+		return "sold"
+	}
+	return "cancelled"
+}
+
+// MaxSellerRefreshListings 单次卖家刷新最多检查的活跃挂单数
+const MaxSellerRefreshListings = 200
+
+// ListingStatusChange 挂单状态变更
+type ListingStatusChange struct {
+	ListingID uint   `json:"listing_id"`
+	ItemID    uint64 `json:"item_id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+// SellerRefreshResult 卖家挂单刷新结果
+type SellerRefreshResult struct {
+	Checked int                   `json:"checked"`
+	Failed  int                   `json:"failed"`
+	Changes []ListingStatusChange `json:"changes"`
+}
+
+// RefreshSellerListings 立即按链上状态刷新卖家的活跃挂单（已售/已取消），
+// 由 worker 池并发查询 GetMarketItem，所有请求共用一个 RPC 速率限制
+func (s *ListingService) RefreshSellerListings(ctx context.Context, seller string) (*SellerRefreshResult, error) {
+	listings, err := s.repo.GetActiveBySeller(seller, MaxSellerRefreshListings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller listings: %w", err)
+	}
+
+	type outcome struct {
+		listing *repository.Listing
+		status  string
+		err     error
+	}
+
+	jobs := make(chan *repository.Listing)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for i := 0; i < s.refreshWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for listing := range jobs {
+				if err := s.refreshLimiter.Wait(ctx); err != nil {
+					outcomes <- outcome{listing: listing, err: err}
+					continue
+				}
+				item, err := s.bcClient.GetMarketItem(ctx, new(big.Int).SetUint64(listing.ItemID))
+				if err != nil {
+					outcomes <- outcome{listing: listing, err: err}
+					continue
+				}
+				outcomes <- outcome{listing: listing, status: chainListingStatus(item)}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range listings {
+			jobs <- &listings[i]
+		}
+		close(jobs)
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	result := &SellerRefreshResult{Changes: []ListingStatusChange{}}
+	var updateErr error
+	circuitOpen := false
+	for o := range outcomes {
+		result.Checked++
+		if o.err != nil {
+			circuitOpen = circuitOpen || errors.Is(o.err, blockchain.ErrCircuitOpen)
+			log.Printf("Seller refresh: failed to get market item %d: %v", o.listing.ItemID, o.err)
+			result.Failed++
+			continue
+		}
+		if o.status == o.listing.Status || updateErr != nil {
+			continue
+		}
+
+		if err := s.repo.UpdateStatus(o.listing.ID, o.status); err != nil {
+			updateErr = fmt.Errorf("failed to update listing %d: %w", o.listing.ID, err)
+			continue
+		}
+		result.Changes = append(result.Changes, ListingStatusChange{
+			ListingID: o.listing.ID,
+			ItemID:    o.listing.ItemID,
+			From:      o.listing.Status,
+			To:        o.status,
+		})
+	}
+
+	if updateErr != nil {
+		return result, updateErr
+	}
+	// RPC 熔断导致全部失败时返回错误，由调用方提示稍后重试
+	if circuitOpen && result.Failed == result.Checked {
+		return result, fmt.Errorf("failed to refresh listings: %w", blockchain.ErrCircuitOpen)
+	}
+	return result, nil
 }
 
 // GetMarketStats 获取市场统计
 func (s *ListingService) GetMarketStats(ctx context.Context) (map[string]interface{}, error) {
+	return cache.Fetch(ctx, s.cache, marketStatsCacheKey, s.loadMarketStats)
+}
+
+// loadMarketStats 从数据库计算市场统计
+func (s *ListingService) loadMarketStats(ctx context.Context) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
 	// 活跃挂单数量
@@ -184,11 +862,16 @@ func (s *ListingService) GetMarketStats(ctx context.Context) (map[string]interfa
 	stats["sold_listings"] = totalCount - activeCount
 
 	// 总交易额
-	totalVolume, err := s.repo.GetTotalVolume()
+	rawVolume, err := s.repo.GetTotalVolume()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total volume: %w", err)
 	}
-	stats["total_volume"] = totalVolume
+	totalVolume, err := parseWeiAmount(rawVolume)
+	if err != nil {
+		return nil, err
+	}
+	stats["total_volume"] = totalVolume.Wei
+	stats["total_volume_formatted"] = totalVolume.Formatted
 
 	// 平均价格
 	avgPrice, err := s.repo.GetAveragePrice()
@@ -214,17 +897,222 @@ func (s *ListingService) GetMarketStats(ctx context.Context) (map[string]interfa
 	return stats, nil
 }
 
+// PreviewProceeds 预览按指定价格成交时的平台费和卖家到手金额
+func (s *ListingService) PreviewProceeds(ctx context.Context, nftContract, price string) (*FeeQuote, error) {
+	return s.fees.Quote(ctx, nftContract, price)
+}
+
+// GetCollectionStats 获取系列统计
+func (s *ListingService) GetCollectionStats(ctx context.Context, address string) (map[string]interface{}, error) {
+	feeBps, err := s.fees.EffectiveFeeBps(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection fee: %w", err)
+	}
+
+	listable, err := s.policy.Listable(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	totalItems, err := s.nfts.CountByContract(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count collection items: %w", err)
+	}
+
+	owners, err := s.nfts.CountOwnersByContract(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count collection owners: %w", err)
+	}
+
+	activeListings, err := s.repo.CountActiveListingsByContract(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active listings: %w", err)
+	}
+
+	rawFloor, err := s.repo.GetMinPriceByContract(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get floor price: %w", err)
+	}
+	floorPrice, err := parseWeiAmount(rawFloor)
+	if err != nil {
+		return nil, err
+	}
+
+	rawVolume, err := s.txs.GetVolumeByContract(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection volume: %w", err)
+	}
+	totalVolume, err := parseWeiAmount(rawVolume)
+	if err != nil {
+		return nil, err
+	}
+
+	rawPrimary, rawSecondary, err := s.txs.GetVolumeSplitByContract(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume split: %w", err)
+	}
+	primaryVolume, err := parseWeiAmount(rawPrimary)
+	if err != nil {
+		return nil, err
+	}
+	secondaryVolume, err := parseWeiAmount(rawSecondary)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"contract_address":           address,
+		"total_items":                totalItems,
+		"active_listings":            activeListings,
+		"floor_price":                floorPrice.Wei,
+		"total_volume":               totalVolume.Wei,
+		"owners":                     owners,
+		"primary_volume":             primaryVolume.Wei,
+		"secondary_volume":           secondaryVolume.Wei,
+		"primary_volume_formatted":   primaryVolume.Formatted,
+		"secondary_volume_formatted": secondaryVolume.Formatted,
+		"fee_bps":                    feeBps,
+		"listable":                   listable,
+	}, nil
+}
+
+// MaxCollectionStatsBatch 单次批量查询系列统计的最大合约数
+const MaxCollectionStatsBatch = 50
+
+// CollectionStats 系列统计（批量接口）
+type CollectionStats struct {
+	ContractAddress          string `json:"contract_address"`
+	TotalItems               int64  `json:"total_items"`
+	ActiveListings           int64  `json:"active_listings"`
+	FloorPrice               string `json:"floor_price"`
+	TotalVolume              string `json:"total_volume"`
+	Owners                   int64  `json:"owners"`
+	PrimaryVolume            string `json:"primary_volume"`
+	SecondaryVolume          string `json:"secondary_volume"`
+	PrimaryVolumeFormatted   string `json:"primary_volume_formatted"`
+	SecondaryVolumeFormatted string `json:"secondary_volume_formatted"`
+	FeeBps                   int64  `json:"fee_bps"`
+	Listable                 bool   `json:"listable"`
+}
+
+// GetCollectionStatsBatch 批量获取系列统计。每项指标按合约分组一次聚合，查询次数与合约数无关；
+// 结果按请求顺序返回，重复地址（不区分大小写）只返回一次
+func (s *ListingService) GetCollectionStatsBatch(ctx context.Context, addresses []string) ([]*CollectionStats, error) {
+	results := make([]*CollectionStats, 0, len(addresses))
+	byContract := make(map[string]*CollectionStats, len(addresses))
+	contracts := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		key := strings.ToLower(address)
+		if _, ok := byContract[key]; ok {
+			continue
+		}
+		stats := &CollectionStats{
+			ContractAddress: address,
+			FloorPrice:      "0",
+			TotalVolume:     "0",
+		}
+		byContract[key] = stats
+		results = append(results, stats)
+		contracts = append(contracts, address)
+	}
+
+	items, err := s.nfts.GetStatsByContracts(contracts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item stats: %w", err)
+	}
+	for _, item := range items {
+		if stats := byContract[item.NFTContract]; stats != nil {
+			stats.TotalItems = item.TotalItems
+			stats.Owners = item.Owners
+		}
+	}
+
+	listings, err := s.repo.GetListingStatsByContracts(contracts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing stats: %w", err)
+	}
+	for _, listing := range listings {
+		stats := byContract[listing.NFTContract]
+		if stats == nil {
+			continue
+		}
+		floor, err := parseWeiAmount(listing.Floor)
+		if err != nil {
+			return nil, err
+		}
+		stats.ActiveListings = listing.ActiveListings
+		stats.FloorPrice = floor.Wei
+	}
+
+	volumes, err := s.txs.GetVolumeSplitByContracts(contracts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume split: %w", err)
+	}
+	volumeByContract := make(map[string]repository.ContractVolumeSplit, len(volumes))
+	for _, volume := range volumes {
+		volumeByContract[volume.NFTContract] = volume
+	}
+
+	fees, err := s.fees.EffectiveFeeBpsByContracts(ctx, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection fees: %w", err)
+	}
+	listable, err := s.policy.ListableByContracts(ctx, contracts)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, stats := range byContract {
+		volume := volumeByContract[key]
+		primary, err := parseWeiAmount(volume.Primary)
+		if err != nil {
+			return nil, err
+		}
+		secondary, err := parseWeiAmount(volume.Secondary)
+		if err != nil {
+			return nil, err
+		}
+		stats.PrimaryVolume = primary.Wei
+		stats.SecondaryVolume = secondary.Wei
+		stats.PrimaryVolumeFormatted = primary.Formatted
+		stats.SecondaryVolumeFormatted = secondary.Formatted
+		stats.TotalVolume = new(big.Int).Add(primary.Int, secondary.Int).String()
+
+		stats.FeeBps = fees[key]
+		stats.Listable = listable[key]
+	}
+
+	return results, nil
+}
+
 // toResponse 转换为响应对象
 func (s *ListingService) toResponse(listing *repository.Listing) *ListingResponse {
 	return &ListingResponse{
-		ID:          listing.ID,
-		ItemID:      listing.ItemID,
-		NFTContract: listing.NFTContract,
-		TokenID:     listing.TokenID,
-		Seller:      listing.Seller,
-		Price:       listing.Price,
-		Status:      listing.Status,
-		ListedAt:    listing.ListedAt,
-		CreatedAt:   listing.CreatedAt,
+		ID:              listing.ID,
+		ItemID:          listing.ItemID,
+		NFTContract:     listing.NFTContract,
+		TokenID:         listing.TokenID,
+		Seller:          listing.Seller,
+		Price:           listing.Price,
+		Status:          listing.Status,
+		Unverified:      listing.Unverified,
+		ContractVersion: listing.ContractVersion,
+		ListedAt:        listing.ListedAt,
+		CreatedAt:       listing.CreatedAt,
+
+		ListingType:   listing.ListingType,
+		AuctionEndAt:  listing.AuctionEndAt,
+		ReservePrice:  listing.ReservePrice,
+		HighestBidWei: listing.HighestBidWei,
+		BidCount:      listing.BidCount,
+
+		SoldAt:      listing.SoldAt,
+		CancelledAt: listing.CancelledAt,
+
+		ArchivedAt: listing.ArchivedAt,
+
+		BestOfferWei:       listing.BestOfferWei,
+		BestOfferExpiresAt: listing.BestOfferExpiresAt,
+		OfferCount:         listing.OfferCount,
 	}
 }
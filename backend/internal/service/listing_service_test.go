@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/blockchain/mock"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/repository/memory"
+)
+
+const (
+	testNFTContract = "0x00000000000000000000000000000000000000a1"
+	testSeller      = "0x00000000000000000000000000000000000000a2"
+)
+
+// newTestListingService 基于内存存储的挂单服务，链上市场项由 items 按 item_id 提供
+func newTestListingService(items map[uint64]*blockchain.MarketItem) (*ListingService, *memory.ListingStore) {
+	listings := memory.NewListingStore()
+	txs := memory.NewTransactionStore()
+	client := &mock.Client{
+		GetMarketItemFunc: func(ctx context.Context, itemId *big.Int) (*blockchain.MarketItem, error) {
+			item, ok := items[itemId.Uint64()]
+			if !ok {
+				return nil, errors.New("item not found")
+			}
+			return item, nil
+		},
+	}
+	index := memory.NewIndexTransactor(listings, txs, memory.NewSyncStateStore())
+	fees := NewFeeService(memory.NewCollectionStore(), 250)
+	s := NewListingService(listings, txs, memory.NewNFTStore(), index, client, nil, fees, nil, nil, nil, nil, nil, nil, "", SellerRefreshOptions{})
+	return s, listings
+}
+
+// onChainItem 与 testListing 一致的链上市场项
+func onChainItem(itemID uint64, price int64) *blockchain.MarketItem {
+	return &blockchain.MarketItem{
+		ItemId:      new(big.Int).SetUint64(itemID),
+		NftContract: common.HexToAddress(testNFTContract),
+		TokenId:     big.NewInt(7),
+		Seller:      common.HexToAddress(testSeller),
+		Price:       big.NewInt(price),
+	}
+}
+
+func testListing(itemID uint64, price string) *repository.Listing {
+	return &repository.Listing{
+		ItemID:      itemID,
+		NFTContract: testNFTContract,
+		TokenID:     "7",
+		Seller:      testSeller,
+		Price:       price,
+		Status:      "active",
+		Unverified:  true,
+	}
+}
+
+func TestVerifyOnChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		listing *repository.Listing
+		item    *blockchain.MarketItem
+		wantErr error // nil 表示校验通过
+		anyErr  bool  // 期望非哨兵错误
+	}{
+		{"match", testListing(1, "1000"), onChainItem(1, 1000), nil, false},
+		{"price higher on chain", testListing(1, "1000"), onChainItem(1, 1001), ErrListingPriceMismatch, false},
+		{"price lower on chain", testListing(1, "1000"), onChainItem(1, 999), ErrListingPriceMismatch, false},
+		{"non-numeric listing price", testListing(1, "1e3"), onChainItem(1, 1000), ErrListingPriceMismatch, false},
+		{"mixed-case addresses", func() *repository.Listing {
+			l := testListing(1, "1000")
+			l.NFTContract = "0x00000000000000000000000000000000000000A1"
+			return l
+		}(), onChainItem(1, 1000), nil, false},
+		{"seller mismatch", testListing(1, "1000"), func() *blockchain.MarketItem {
+			item := onChainItem(1, 1000)
+			item.Seller = common.HexToAddress("0x00000000000000000000000000000000000000ff")
+			return item
+		}(), ErrListingSellerMismatch, false},
+		{"token mismatch", testListing(1, "1000"), func() *blockchain.MarketItem {
+			item := onChainItem(1, 1000)
+			item.TokenId = big.NewInt(8)
+			return item
+		}(), ErrListingTokenMismatch, false},
+		{"contract mismatch", testListing(1, "1000"), func() *blockchain.MarketItem {
+			item := onChainItem(1, 1000)
+			item.NftContract = common.HexToAddress("0x00000000000000000000000000000000000000ff")
+			return item
+		}(), ErrListingTokenMismatch, false},
+		{"missing on-chain price", testListing(1, "1000"), func() *blockchain.MarketItem {
+			item := onChainItem(1, 1000)
+			item.Price = nil
+			return item
+		}(), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestListingService(map[uint64]*blockchain.MarketItem{1: tt.item})
+
+			err := s.verifyOnChain(context.Background(), tt.listing)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("verifyOnChain() = %v, want %v", err, tt.wantErr)
+				}
+			case tt.anyErr:
+				if err == nil || errors.Is(err, ErrListingPriceMismatch) {
+					t.Fatalf("verifyOnChain() = %v, want non-mismatch error", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("verifyOnChain() = %v, want nil", err)
+				}
+			}
+		})
+	}
+}
+
+// 价格不一致的未验证挂单标记为 invalid，一致的转为已验证，链上查询失败的保留待下次校验
+func TestVerifyUnverifiedListingsPriceMismatch(t *testing.T) {
+	s, store := newTestListingService(map[uint64]*blockchain.MarketItem{
+		1: onChainItem(1, 1000),
+		2: onChainItem(2, 2000),
+	})
+
+	matching := testListing(1, "1000")
+	mismatched := testListing(2, "1500")
+	missing := testListing(3, "1000")
+	for _, l := range []*repository.Listing{matching, mismatched, missing} {
+		if err := store.Create(l); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	result, err := s.VerifyUnverifiedListings(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyUnverifiedListings: %v", err)
+	}
+	if result.Verified != 1 || result.Invalid != 1 || result.Failed != 1 {
+		t.Errorf("result = %+v, want 1 verified, 1 invalid, 1 failed", *result)
+	}
+
+	tests := []struct {
+		id             uint
+		wantStatus     string
+		wantUnverified bool
+	}{
+		{matching.ID, "active", false},
+		{mismatched.ID, "invalid", true},
+		{missing.ID, "active", true},
+	}
+	for _, tt := range tests {
+		got, err := store.GetByID(tt.id)
+		if err != nil {
+			t.Fatalf("GetByID(%d): %v", tt.id, err)
+		}
+		if got.Status != tt.wantStatus || got.Unverified != tt.wantUnverified {
+			t.Errorf("listing %d = (%s, unverified %v), want (%s, unverified %v)",
+				tt.id, got.Status, got.Unverified, tt.wantStatus, tt.wantUnverified)
+		}
+	}
+}
+
+// 截止时间已过的拍卖不再接受竞价，也不出现在活跃挂单列表中；竞价方以小写地址存储
+func TestPlaceBidAuctionEnd(t *testing.T) {
+	const bidder = "0x00000000000000000000000000000000000000BB"
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		endAt      time.Time
+		wantErr    error
+		wantListed bool
+	}{
+		{"running auction", now.Add(time.Hour), nil, true},
+		{"ended auction", now.Add(-time.Second), ErrAuctionEnded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listings := memory.NewListingStore()
+			endAt := tt.endAt
+			listing := &repository.Listing{
+				ItemID:       1,
+				NFTContract:  testNFTContract,
+				TokenID:      "7",
+				Seller:       testSeller,
+				Price:        "100",
+				Status:       "active",
+				ListingType:  repository.ListingTypeAuction,
+				AuctionEndAt: &endAt,
+			}
+			if err := listings.Create(listing); err != nil {
+				t.Fatalf("create listing: %v", err)
+			}
+			bids := memory.NewBidStore(listings)
+			bidPolicy := NewBidIncrementPolicy(memory.NewCollectionStore(), 500, big.NewInt(0))
+			s := NewListingService(listings, memory.NewTransactionStore(), memory.NewNFTStore(), nil, &mock.Client{}, nil, nil, nil, nil, nil, nil, bids, bidPolicy, "", SellerRefreshOptions{})
+
+			bid, err := s.PlaceBid(context.Background(), listing.ID, bidder, &PlaceBidRequest{Amount: "100"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlaceBid() = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && bid.Bidder != strings.ToLower(bidder) {
+				t.Errorf("bidder = %s, want lowercase", bid.Bidder)
+			}
+
+			active, _, err := listings.GetActiveListings(repository.ListingFilter{}, 1, 10, false)
+			if err != nil {
+				t.Fatalf("GetActiveListings: %v", err)
+			}
+			if listed := len(active) == 1; listed != tt.wantListed {
+				t.Errorf("listed = %v, want %v", listed, tt.wantListed)
+			}
+		})
+	}
+}
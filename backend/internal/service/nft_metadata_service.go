@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/cache"
+	"github.com/xiaomait/backend/internal/metadata"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/requestid"
+)
+
+// metadataFetchTimeout 单个 NFT 元数据抓取（含 tokenURI 查询）的超时
+const metadataFetchTimeout = 30 * time.Second
+
+// NFTMetadataService 挂单对应的 NFT 记录缺失时创建占位记录，并通过有界队列异步抓取元数据补全
+// 名称、描述和图片；队列满时丢弃抓取任务（记录仍可之后手动刷新）
+type NFTMetadataService struct {
+	nfts     repository.NFTStore
+	bcClient blockchain.BlockchainClient
+	cache    *cache.SWR // 为 nil 时不使用缓存
+	client   *http.Client
+	gateway  string
+	maxBytes int64
+	queue    chan metadataJob
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewNFTMetadataService 创建 NFT 元数据服务并启动 workers 个抓取 worker；swr 为 NFT 详情缓存，更新元数据后失效
+func NewNFTMetadataService(nfts repository.NFTStore, bcClient blockchain.BlockchainClient, swr *cache.SWR, gateway string, maxBytes int64, queueSize, workers int) *NFTMetadataService {
+	s := &NFTMetadataService{
+		nfts:     nfts,
+		bcClient: bcClient,
+		cache:    swr,
+		client:   &http.Client{Timeout: metadataFetchTimeout},
+		gateway:  gateway,
+		maxBytes: maxBytes,
+		queue:    make(chan metadataJob, queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	return s
+}
+
+// metadataJob 一次排队的元数据抓取，requestID 为触发抓取的请求（链上事件触发时为空）
+type metadataJob struct {
+	id        uint
+	requestID string
+}
+
+// EnsureNFT 确保合约与 Token ID 对应的 NFT 记录存在：不存在时以 owner 创建占位记录并排队抓取元数据，
+// 抓取日志带上 ctx 中的请求 ID
+func (s *NFTMetadataService) EnsureNFT(ctx context.Context, nftContract, tokenID, owner string) error {
+	nft := &repository.NFT{
+		ContractAddress: nftContract,
+		TokenID:         tokenID,
+		Owner:           owner,
+		Metadata:        "{}",
+		Status:          "active",
+	}
+	created, err := s.nfts.CreateIfNotExists(nft)
+	if err != nil {
+		return fmt.Errorf("failed to create stub nft: %w", err)
+	}
+	if created {
+		s.enqueue(metadataJob{id: nft.ID, requestID: requestid.FromContext(ctx)})
+	}
+	return nil
+}
+
+// Refresh 查询 tokenURI 并抓取元数据，更新 NFT 的名称、描述、图片和完整元数据（代币标准未检测时一并检测），
+// 并失效 NFT 详情缓存。tokenURI 查询失败时回退到已保存的 metadata_uri
+func (s *NFTMetadataService) Refresh(ctx context.Context, id uint) error {
+	nft, err := s.nfts.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get nft: %w", err)
+	}
+
+	tokenID, ok := new(big.Int).SetString(nft.TokenID, 10)
+	if !ok {
+		return fmt.Errorf("invalid token id %q", nft.TokenID)
+	}
+
+	if nft.TokenStandard == "" {
+		if standard := detectTokenStandard(ctx, s.bcClient, nft.ContractAddress); standard != "" {
+			if err := s.nfts.SetTokenStandard(id, standard); err != nil {
+				requestid.Logf(ctx, "Failed to save token standard for NFT %d: %v", id, err)
+			}
+		}
+	}
+
+	uri, err := s.bcClient.TokenURI(ctx, common.HexToAddress(nft.ContractAddress), tokenID)
+	if err != nil {
+		if nft.MetadataURI == "" {
+			return fmt.Errorf("failed to get token uri: %w", err)
+		}
+		requestid.Logf(ctx, "Failed to get token uri for NFT %d, using stored metadata uri: %v", id, err)
+		uri = nft.MetadataURI
+	}
+
+	doc, err := metadata.FetchTokenMetadata(ctx, s.client, uri, tokenID, s.gateway, s.maxBytes)
+	if err != nil {
+		return fmt.Errorf("failed to fetch metadata: %w", err)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	name, _ := doc["name"].(string)
+	description, _ := doc["description"].(string)
+	image, _ := doc["image"].(string)
+	if image == "" {
+		image, _ = doc["image_url"].(string)
+	}
+	if image != "" {
+		image = metadata.NormalizeURI(image, s.gateway)
+	}
+
+	if err := s.nfts.UpdateMetadata(id, repository.NFTMetadataUpdate{
+		Name:        name,
+		Description: description,
+		ImageURL:    image,
+		MetadataURI: uri,
+		Metadata:    string(raw),
+	}); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	s.cache.Invalidate(ctx, nftCacheKey(id))
+	return nil
+}
+
+// detectTokenStandard 通过 ERC-165 检测合约代币标准，查询失败时返回空（保持未检测，下次刷新元数据时重试）
+func detectTokenStandard(ctx context.Context, client blockchain.BlockchainClient, contract string) string {
+	standard, err := client.DetectTokenStandard(ctx, common.HexToAddress(contract))
+	if err != nil {
+		requestid.Logf(ctx, "Failed to detect token standard of %s: %v", contract, err)
+		return ""
+	}
+	return string(standard)
+}
+
+// Close 停止接收新任务并等待队列处理完，ctx 结束时放弃剩余任务
+func (s *NFTMetadataService) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		log.Printf("NFT metadata queue shutdown timed out with %d fetches pending", len(s.queue))
+		return ctx.Err()
+	}
+}
+
+// enqueue 排队抓取元数据，不阻塞；队列已满或已关闭时丢弃
+func (s *NFTMetadataService) enqueue(job metadataJob) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- job:
+	default:
+		log.Printf("NFT metadata queue full, skipping fetch for NFT %d", job.id)
+	}
+}
+
+// work 逐个抓取元数据直到队列关闭
+func (s *NFTMetadataService) work() {
+	defer s.wg.Done()
+
+	for job := range s.queue {
+		ctx, cancel := context.WithTimeout(requestid.NewContext(context.Background(), job.requestID), metadataFetchTimeout)
+		if err := s.Refresh(ctx, job.id); err != nil {
+			requestid.Logf(ctx, "Failed to fetch metadata for NFT %d: %v", job.id, err)
+		}
+		cancel()
+	}
+}
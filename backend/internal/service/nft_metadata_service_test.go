@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/blockchain/mock"
+	"github.com/xiaomait/backend/internal/cache"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/repository/memory"
+)
+
+// 占位记录抓取到元数据后，已缓存的 NFT 详情须失效，否则在缓存过期前仍返回空名称
+func TestRefreshInvalidatesNFTCache(t *testing.T) {
+	ctx := context.Background()
+	nfts := memory.NewNFTStore()
+	nft := &repository.NFT{ContractAddress: testNFTContract, TokenID: "7", Owner: testSeller, Metadata: "{}", Status: "active"}
+	if err := nfts.Create(nft); err != nil {
+		t.Fatalf("create nft: %v", err)
+	}
+
+	client := &mock.Client{
+		TokenURIFunc: func(ctx context.Context, nftContract common.Address, tokenId *big.Int) (string, error) {
+			return `data:application/json,{"name":"Fetched"}`, nil
+		},
+		DetectTokenStandardFunc: func(ctx context.Context, contract common.Address) (blockchain.TokenStandard, error) {
+			return "", nil
+		},
+	}
+	swr := cache.NewSWR(cache.NewMemoryStore(), time.Minute, time.Hour)
+	s := NewNFTMetadataService(nfts, client, swr, "", 1<<20, 1, 0)
+
+	name := func() string {
+		t.Helper()
+		got, err := cache.Fetch(ctx, swr, nftCacheKey(nft.ID), func(ctx context.Context) (string, error) {
+			current, err := nfts.GetByID(nft.ID)
+			if err != nil {
+				return "", err
+			}
+			return current.Name, nil
+		})
+		if err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+		return got
+	}
+
+	if got := name(); got != "" {
+		t.Fatalf("cached name = %q, want empty stub", got)
+	}
+	if err := s.Refresh(ctx, nft.ID); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := name(); got != "Fetched" {
+		t.Errorf("name after refresh = %q, want Fetched", got)
+	}
+}
@@ -3,38 +3,62 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/cache"
 	"github.com/xiaomait/backend/internal/repository"
 )
 
+// ErrNotNFTOwner 只有 NFT 持有者可以刷新元数据
+var ErrNotNFTOwner = errors.New("not the nft owner")
+
 // NFTService NFT 服务
 type NFTService struct {
-	repo     *repository.NFTRepository
-	bcClient *blockchain.Client
+	repo     repository.NFTStore
+	likes    repository.NFTLikeStore
+	bcClient blockchain.BlockchainClient
+	views    *ViewCounter
+	images   *ImageURLRewriter // 为 nil 时原样返回图片地址
+	cache    *cache.SWR        // 为 nil 时不使用缓存
+	fetcher  *NFTMetadataService
 }
 
-// NewNFTService 创建 NFT 服务
-func NewNFTService(repo *repository.NFTRepository, bcClient *blockchain.Client) *NFTService {
+// NewNFTService 创建 NFT 服务，swr 为 nil 时不使用缓存；fetcher 用于按 tokenURI 重新抓取元数据
+func NewNFTService(repo repository.NFTStore, likes repository.NFTLikeStore, bcClient blockchain.BlockchainClient, views *ViewCounter, images *ImageURLRewriter, swr *cache.SWR, fetcher *NFTMetadataService) *NFTService {
 	return &NFTService{
 		repo:     repo,
+		likes:    likes,
 		bcClient: bcClient,
+		views:    views,
+		images:   images,
+		cache:    swr,
+		fetcher:  fetcher,
 	}
 }
 
+// nftCacheKey NFT 详情的缓存键
+func nftCacheKey(id uint) string {
+	return fmt.Sprintf("nft:%d", id)
+}
+
 // CreateNFTRequest 创建 NFT 请求
 type CreateNFTRequest struct {
-	ContractAddress string                 `json:"contract_address" binding:"required"`
+	ContractAddress string                 `json:"contract_address" binding:"required,eth_addr"`
 	TokenID         string                 `json:"token_id" binding:"required"`
-	Owner           string                 `json:"owner" binding:"required"`
-	Creator         string                 `json:"creator"`
+	Owner           string                 `json:"owner" binding:"required,eth_addr"`
+	Creator         string                 `json:"creator" binding:"omitempty,eth_addr"`
 	Name            string                 `json:"name"`
 	Description     string                 `json:"description"`
 	ImageURL        string                 `json:"image_url"`
 	MetadataURI     string                 `json:"metadata_uri"`
 	Metadata        map[string]interface{} `json:"metadata"`
+	MintTxHash      string                 `json:"mint_tx_hash"` // 可选，提供时 minted_at 取铸造区块时间
 }
 
 // NFTResponse NFT 响应
@@ -48,8 +72,9 @@ type NFTResponse struct {
 	Description     string                 `json:"description"`
 	ImageURL        string                 `json:"image_url"`
 	MetadataURI     string                 `json:"metadata_uri"`
-	Metadata        map[string]interface{} `json:"metadata"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 	Status          string                 `json:"status"`
+	TokenStandard   string                 `json:"token_standard"` // erc721、erc1155、unknown，为空表示尚未检测
 	ViewCount       int64                  `json:"view_count"`
 	LikeCount       int64                  `json:"like_count"`
 	MintedAt        time.Time              `json:"minted_at"`
@@ -82,90 +107,122 @@ func (s *NFTService) CreateNFT(ctx context.Context, req *CreateNFTRequest) (*NFT
 		MetadataURI:     req.MetadataURI,
 		Metadata:        string(metadataJSON),
 		Status:          "active",
-		MintedAt:        time.Now(),
+		MintedAt:        s.mintedAt(ctx, req.MintTxHash),
+		TokenStandard:   detectTokenStandard(ctx, s.bcClient, req.ContractAddress),
 	}
 
 	if err := s.repo.Create(nft); err != nil {
 		return nil, fmt.Errorf("failed to create NFT: %w", err)
 	}
+	s.cache.Invalidate(ctx, nftCacheKey(nft.ID))
 
 	return s.toResponse(nft), nil
 }
 
-// GetNFT 获取 NFT
-func (s *NFTService) GetNFT(ctx context.Context, id uint) (*NFTResponse, error) {
-	nft, err := s.repo.GetByID(id)
+// mintedAt 根据铸造交易获取区块时间，未提供或查询失败时使用服务器时间
+func (s *NFTService) mintedAt(ctx context.Context, mintTxHash string) time.Time {
+	if mintTxHash == "" {
+		return time.Now()
+	}
+
+	receipt, err := s.bcClient.GetTransactionReceipt(ctx, common.HexToHash(mintTxHash))
+	if err != nil {
+		log.Printf("Failed to get mint receipt %s, falling back to server time: %v", mintTxHash, err)
+		return time.Now()
+	}
+	return chainTime(ctx, s.bcClient, receipt.BlockNumber.Uint64())
+}
+
+// GetNFT 获取 NFT 并为 viewer（认证地址或客户端 IP）记一次浏览（浏览次数、点赞数等在缓存有效期内可能略有滞后）
+func (s *NFTService) GetNFT(ctx context.Context, id uint, viewer string) (*NFTResponse, error) {
+	resp, err := cache.Fetch(ctx, s.cache, nftCacheKey(id), func(ctx context.Context) (*NFTResponse, error) {
+		nft, err := s.repo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return s.toResponse(nft), nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get NFT: %w", err)
 	}
 
-	// 增加浏览次数
-	go s.repo.IncrementViewCount(id)
+	// 增加浏览次数（异步入队）
+	s.views.Add(id, viewer)
 
-	return s.toResponse(nft), nil
+	return resp, nil
 }
 
-// GetNFTByContractAndToken 根据合约和 Token ID 获取 NFT
-func (s *NFTService) GetNFTByContractAndToken(ctx context.Context, contractAddress, tokenID string) (*NFTResponse, error) {
+// GetNFTByContractAndToken 根据合约和 Token ID 获取 NFT 并为 viewer 记一次浏览
+func (s *NFTService) GetNFTByContractAndToken(ctx context.Context, contractAddress, tokenID, viewer string) (*NFTResponse, error) {
 	nft, err := s.repo.GetByContractAndToken(contractAddress, tokenID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get NFT: %w", err)
 	}
 
-	// 增加浏览次数
-	go s.repo.IncrementViewCount(nft.ID)
+	// 增加浏览次数（异步入队）
+	s.views.Add(nft.ID, viewer)
 
 	return s.toResponse(nft), nil
 }
 
-// GetNFTs 获取 NFT 列表
-func (s *NFTService) GetNFTs(ctx context.Context, page, pageSize int) ([]*NFTResponse, int64, error) {
-	nfts, total, err := s.repo.GetAll(page, pageSize)
+// GetNFTs 获取 NFT 列表，withCount 为 false 时不查询总数
+func (s *NFTService) GetNFTs(ctx context.Context, page, pageSize int, includeMetadata, withCount bool) ([]*NFTResponse, int64, error) {
+	nfts, total, err := s.repo.GetAll(page, pageSize, includeMetadata, withCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get NFTs: %w", err)
 	}
 
 	responses := make([]*NFTResponse, len(nfts))
 	for i, nft := range nfts {
-		responses[i] = s.toResponse(&nft)
+		responses[i] = s.toListResponse(&nft, includeMetadata)
 	}
 
 	return responses, total, nil
 }
 
 // GetUserNFTs 获取用户的 NFT
-func (s *NFTService) GetUserNFTs(ctx context.Context, owner string, page, pageSize int) ([]*NFTResponse, int64, error) {
-	nfts, total, err := s.repo.GetByOwner(owner, page, pageSize)
+func (s *NFTService) GetUserNFTs(ctx context.Context, owner string, page, pageSize int, includeMetadata bool) ([]*NFTResponse, int64, error) {
+	nfts, total, err := s.repo.GetByOwner(owner, page, pageSize, includeMetadata)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get user NFTs: %w", err)
 	}
 
 	responses := make([]*NFTResponse, len(nfts))
 	for i, nft := range nfts {
-		responses[i] = s.toResponse(&nft)
+		responses[i] = s.toListResponse(&nft, includeMetadata)
 	}
 
 	return responses, total, nil
 }
 
-// GetNFTsByContract 获取合约的 NFT
-func (s *NFTService) GetNFTsByContract(ctx context.Context, contractAddress string, page, pageSize int) ([]*NFTResponse, int64, error) {
-	nfts, total, err := s.repo.GetByContract(contractAddress, page, pageSize)
+// GetNFTsByContract 获取合约的 NFT，withCount 为 false 时不查询总数
+func (s *NFTService) GetNFTsByContract(ctx context.Context, contractAddress string, page, pageSize int, includeMetadata, withCount bool) ([]*NFTResponse, int64, error) {
+	nfts, total, err := s.repo.GetByContract(contractAddress, page, pageSize, includeMetadata, withCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get NFTs by contract: %w", err)
 	}
 
 	responses := make([]*NFTResponse, len(nfts))
 	for i, nft := range nfts {
-		responses[i] = s.toResponse(&nft)
+		responses[i] = s.toListResponse(&nft, includeMetadata)
 	}
 
 	return responses, total, nil
 }
 
-// SearchNFTs 搜索 NFT
-func (s *NFTService) SearchNFTs(ctx context.Context, query string, page, pageSize int) ([]*NFTResponse, int64, error) {
-	nfts, total, err := s.repo.Search(query, page, pageSize)
+// MaxTraitFilters 单次搜索最多的属性过滤条件数
+const MaxTraitFilters = 10
+
+// SearchNFTs 按关键词和/或属性搜索 NFT，多个属性条件为 AND，withCount 为 false 时不查询总数
+func (s *NFTService) SearchNFTs(ctx context.Context, query string, traits []repository.TraitFilter, page, pageSize int, withCount bool) ([]*NFTResponse, int64, error) {
+	var nfts []repository.NFT
+	var total int64
+	var err error
+	if len(traits) > 0 {
+		nfts, total, err = s.repo.SearchByAttributes(query, traits, page, pageSize, withCount)
+	} else {
+		nfts, total, err = s.repo.Search(query, page, pageSize, withCount)
+	}
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search NFTs: %w", err)
 	}
@@ -193,30 +250,165 @@ func (s *NFTService) GetTrendingNFTs(ctx context.Context, limit int) ([]*NFTResp
 	return responses, nil
 }
 
+// maxSimilarTraits 参与相似度计算的属性数量上限
+const maxSimilarTraits = 20
+
+// SimilarNFTResponse 相似 NFT 响应
+type SimilarNFTResponse struct {
+	*NFTResponse
+	SharedTraits int64 `json:"shared_traits"`
+}
+
+// GetSimilarNFTs 获取同系列中共同属性最多的 NFT
+func (s *NFTService) GetSimilarNFTs(ctx context.Context, id uint, limit int) ([]*SimilarNFTResponse, error) {
+	nft, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NFT: %w", err)
+	}
+
+	var metadata struct {
+		Attributes []repository.Trait `json:"attributes"`
+	}
+	if nft.Metadata != "" {
+		json.Unmarshal([]byte(nft.Metadata), &metadata)
+	}
+
+	traits := make([]repository.Trait, 0, len(metadata.Attributes))
+	for _, trait := range metadata.Attributes {
+		if trait.TraitType == "" || trait.Value == nil {
+			continue
+		}
+		traits = append(traits, trait)
+		if len(traits) == maxSimilarTraits {
+			break
+		}
+	}
+
+	similar, err := s.repo.GetSimilarByTraits(nft.ContractAddress, nft.ID, traits, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get similar NFTs: %w", err)
+	}
+
+	responses := make([]*SimilarNFTResponse, len(similar))
+	for i, item := range similar {
+		responses[i] = &SimilarNFTResponse{
+			NFTResponse:  s.toResponse(&item.NFT),
+			SharedTraits: item.SharedTraits,
+		}
+	}
+
+	return responses, nil
+}
+
+// RefreshMetadata 重新抓取 tokenURI（ipfs://、https://、data:）指向的元数据并更新记录，
+// 整个抓取受 metadataFetchTimeout 限制，返回更新后的 NFT。requester 须为 NFT 持有者
+func (s *NFTService) RefreshMetadata(ctx context.Context, id uint, requester string) (*NFTResponse, error) {
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NFT: %w", err)
+	}
+	if !strings.EqualFold(current.Owner, requester) {
+		return nil, ErrNotNFTOwner
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metadataFetchTimeout)
+	defer cancel()
+
+	if err := s.fetcher.Refresh(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to refresh metadata: %w", err)
+	}
+
+	nft, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NFT: %w", err)
+	}
+	return s.toResponse(nft), nil
+}
+
 // UpdateNFTOwner 更新 NFT 所有者
 func (s *NFTService) UpdateNFTOwner(ctx context.Context, id uint, newOwner string) error {
 	if err := s.repo.UpdateOwner(id, newOwner); err != nil {
 		return fmt.Errorf("failed to update NFT owner: %w", err)
 	}
+	s.cache.Invalidate(ctx, nftCacheKey(id))
 	return nil
 }
 
-// LikeNFT 点赞 NFT
-func (s *NFTService) LikeNFT(ctx context.Context, id uint) error {
-	if err := s.repo.IncrementLikeCount(id); err != nil {
+// LikeNFT 以 user 身份点赞 NFT，重复点赞不重复计数；NFT 不存在时返回记录不存在错误
+func (s *NFTService) LikeNFT(ctx context.Context, id uint, user string) error {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return fmt.Errorf("failed to get NFT: %w", err)
+	}
+
+	created, err := s.likes.Like(id, user)
+	if err != nil {
 		return fmt.Errorf("failed to like NFT: %w", err)
 	}
+	if created {
+		s.cache.Invalidate(ctx, nftCacheKey(id))
+	}
 	return nil
 }
 
-// UnlikeNFT 取消点赞 NFT
-func (s *NFTService) UnlikeNFT(ctx context.Context, id uint) error {
-	if err := s.repo.DecrementLikeCount(id); err != nil {
+// UnlikeNFT 取消 user 的点赞，未点赞时不变
+func (s *NFTService) UnlikeNFT(ctx context.Context, id uint, user string) error {
+	deleted, err := s.likes.Unlike(id, user)
+	if err != nil {
 		return fmt.Errorf("failed to unlike NFT: %w", err)
 	}
+	if deleted {
+		s.cache.Invalidate(ctx, nftCacheKey(id))
+	}
 	return nil
 }
 
+// HasLiked user 是否已点赞 NFT
+func (s *NFTService) HasLiked(ctx context.Context, id uint, user string) (bool, error) {
+	liked, err := s.likes.HasLiked(id, user)
+	if err != nil {
+		return false, fmt.Errorf("failed to get like status: %w", err)
+	}
+	return liked, nil
+}
+
+// LikeRecount 单个 NFT 重算前后的 like_count
+type LikeRecount struct {
+	NFTID uint  `json:"nft_id"`
+	Old   int64 `json:"old"`
+	New   int64 `json:"new"`
+}
+
+// RecomputeLikes 按点赞表重算 NFT 的 like_count，返回重算前后的值
+func (s *NFTService) RecomputeLikes(ctx context.Context, id uint) (*LikeRecount, error) {
+	old, updated, err := s.likes.RecomputeLikeCount(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute likes: %w", err)
+	}
+	s.cache.Invalidate(ctx, nftCacheKey(id))
+	return &LikeRecount{NFTID: id, Old: old, New: updated}, nil
+}
+
+// RecomputeAllLikes 按点赞表重算所有 like_count 不一致的 NFT，返回修正的数量。
+// 不逐个失效详情缓存，已缓存的 NFT 在缓存过期后更新
+func (s *NFTService) RecomputeAllLikes(ctx context.Context) (int64, error) {
+	fixed, err := s.likes.RecomputeAllLikeCounts()
+	if err != nil {
+		return 0, fmt.Errorf("failed to recompute likes: %w", err)
+	}
+	return fixed, nil
+}
+
+// toListResponse 列表项响应，includeMetadata 为 false 时跳过 metadata 解析与返回
+func (s *NFTService) toListResponse(nft *repository.NFT, includeMetadata bool) *NFTResponse {
+	if includeMetadata {
+		return s.toResponse(nft)
+	}
+
+	stripped := *nft
+	stripped.Metadata = ""
+	return s.toResponse(&stripped)
+}
+
 // toResponse 转换为响应对象
 func (s *NFTService) toResponse(nft *repository.NFT) *NFTResponse {
 	var metadata map[string]interface{}
@@ -224,6 +416,11 @@ func (s *NFTService) toResponse(nft *repository.NFT) *NFTResponse {
 		json.Unmarshal([]byte(nft.Metadata), &metadata)
 	}
 
+	imageURL := nft.ImageURL
+	if s.images != nil {
+		imageURL = s.images.Rewrite(nft.ContractAddress, nft.ImageURL)
+	}
+
 	return &NFTResponse{
 		ID:              nft.ID,
 		ContractAddress: nft.ContractAddress,
@@ -232,10 +429,11 @@ func (s *NFTService) toResponse(nft *repository.NFT) *NFTResponse {
 		Creator:         nft.Creator,
 		Name:            nft.Name,
 		Description:     nft.Description,
-		ImageURL:        nft.ImageURL,
+		ImageURL:        imageURL,
 		MetadataURI:     nft.MetadataURI,
 		Metadata:        metadata,
 		Status:          nft.Status,
+		TokenStandard:   nft.TokenStandard,
 		ViewCount:       nft.ViewCount,
 		LikeCount:       nft.LikeCount,
 		MintedAt:        nft.MintedAt,
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/xiaomait/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// 可配置的通知事件类型
+const (
+	NotifySale   = "sale"
+	NotifyOutbid = "outbid"
+	NotifyOffer  = "offer"
+)
+
+// 通知渠道
+const (
+	ChannelEmail     = "email"
+	ChannelWebhook   = "webhook"
+	ChannelWebSocket = "websocket"
+)
+
+// DefaultNotificationPreferences 未设置过偏好的地址使用的默认值：所有事件开启，
+// 只通过 WebSocket 推送；邮件和 Webhook 需要用户主动开启
+func DefaultNotificationPreferences(address string) *repository.NotificationPreferences {
+	return &repository.NotificationPreferences{
+		Address:   strings.ToLower(address),
+		Sales:     true,
+		Outbid:    true,
+		Offers:    true,
+		WebSocket: true,
+	}
+}
+
+// NotificationPreferenceService 通知偏好服务，发送任何面向用户的通知前须经 Allows 检查
+type NotificationPreferenceService struct {
+	repo repository.NotificationPreferenceStore
+}
+
+// NewNotificationPreferenceService 创建通知偏好服务
+func NewNotificationPreferenceService(repo repository.NotificationPreferenceStore) *NotificationPreferenceService {
+	return &NotificationPreferenceService{repo: repo}
+}
+
+// UpdateNotificationPreferencesRequest 更新通知偏好请求，未提供的字段保持不变
+type UpdateNotificationPreferencesRequest struct {
+	Sales     *bool `json:"sales"`
+	Outbid    *bool `json:"outbid"`
+	Offers    *bool `json:"offers"`
+	Email     *bool `json:"email"`
+	Webhook   *bool `json:"webhook"`
+	WebSocket *bool `json:"websocket"`
+}
+
+// Get 获取地址的通知偏好，未设置时返回默认值
+func (s *NotificationPreferenceService) Get(ctx context.Context, address string) (*repository.NotificationPreferences, error) {
+	prefs, err := s.repo.GetByAddress(address)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultNotificationPreferences(address), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// Update 按请求修改地址的通知偏好并保存
+func (s *NotificationPreferenceService) Update(ctx context.Context, address string, req *UpdateNotificationPreferencesRequest) (*repository.NotificationPreferences, error) {
+	prefs, err := s.Get(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		value  *bool
+		target *bool
+	}{
+		{req.Sales, &prefs.Sales},
+		{req.Outbid, &prefs.Outbid},
+		{req.Offers, &prefs.Offers},
+		{req.Email, &prefs.Email},
+		{req.Webhook, &prefs.Webhook},
+		{req.WebSocket, &prefs.WebSocket},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+
+	if err := s.repo.Upsert(prefs); err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// Allows 地址是否接收某类事件在某渠道的通知；读取偏好失败时按默认值判断
+func (s *NotificationPreferenceService) Allows(ctx context.Context, address, event, channel string) bool {
+	prefs, err := s.Get(ctx, address)
+	if err != nil {
+		log.Printf("Notification preferences for %s unavailable, using defaults: %v", address, err)
+		prefs = DefaultNotificationPreferences(address)
+	}
+
+	var eventOn, channelOn bool
+	switch event {
+	case NotifySale:
+		eventOn = prefs.Sales
+	case NotifyOutbid:
+		eventOn = prefs.Outbid
+	case NotifyOffer:
+		eventOn = prefs.Offers
+	}
+	switch channel {
+	case ChannelEmail:
+		channelOn = prefs.Email
+	case ChannelWebhook:
+		channelOn = prefs.Webhook
+	case ChannelWebSocket:
+		channelOn = prefs.WebSocket
+	}
+	return eventOn && channelOn
+}
+
+// Recipients 从 addresses 中筛出接收该事件在该渠道通知的地址（去重、忽略空地址）
+func (s *NotificationPreferenceService) Recipients(ctx context.Context, event, channel string, addresses ...string) []string {
+	seen := make(map[string]bool, len(addresses))
+	var allowed []string
+	for _, address := range addresses {
+		key := strings.ToLower(address)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if s.Allows(ctx, key, event, channel) {
+			allowed = append(allowed, address)
+		}
+	}
+	return allowed
+}
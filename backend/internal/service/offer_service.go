@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// MaxOfferDuration 出价有效期上限
+const MaxOfferDuration = 180 * 24 * time.Hour
+
+var (
+	// ErrInvalidOfferPrice 出价金额不是正整数 wei
+	ErrInvalidOfferPrice = errors.New("offer price must be a positive amount in wei")
+	// ErrInvalidOfferExpiry 过期时间不在未来或超过 MaxOfferDuration
+	ErrInvalidOfferExpiry = errors.New("invalid offer expiry")
+	// ErrOfferOnOwnNFT 不能对自己持有的 NFT 出价
+	ErrOfferOnOwnNFT = errors.New("cannot make an offer on own nft")
+	// ErrNotOfferer 只有出价方可以取消出价
+	ErrNotOfferer = errors.New("not the offerer")
+	// ErrOfferNotActive 出价已过期、已取消或已处理
+	ErrOfferNotActive = errors.New("offer is not active")
+)
+
+// OfferStatuses 可用于过滤的出价状态
+var OfferStatuses = []string{
+	repository.OfferStatusActive,
+	repository.OfferStatusExpired,
+	repository.OfferStatusAccepted,
+	repository.OfferStatusRejected,
+	repository.OfferStatusCancelled,
+}
+
+// OfferSorts 可用的出价排序方式
+var OfferSorts = []string{repository.OfferSortAmount, repository.OfferSortRecent}
+
+// OfferService 出价服务
+type OfferService struct {
+	repo     repository.OfferStore
+	listings repository.ListingStore
+	nfts     repository.NFTStore
+	bids     *BidIncrementPolicy // 新出价须在当前最高有效出价之上满足最小加价
+}
+
+// NewOfferService 创建出价服务
+func NewOfferService(repo repository.OfferStore, listings repository.ListingStore, nfts repository.NFTStore, bids *BidIncrementPolicy) *OfferService {
+	return &OfferService{repo: repo, listings: listings, nfts: nfts, bids: bids}
+}
+
+// CreateOfferRequest 创建出价请求，出价方取自认证地址
+type CreateOfferRequest struct {
+	NFTContract string    `json:"nft_contract" binding:"required,eth_addr"`
+	TokenID     string    `json:"token_id" binding:"required"`
+	Price       string    `json:"price" binding:"required,wei"`
+	ExpiresAt   time.Time `json:"expires_at" binding:"required"`
+}
+
+// OfferResponse 出价响应
+type OfferResponse struct {
+	ID          uint       `json:"id"`
+	NFTContract string     `json:"nft_contract"`
+	TokenID     string     `json:"token_id"`
+	Offerer     string     `json:"offerer"`
+	Price       string     `json:"price"`
+	Status      string     `json:"status"` // 已过期但未处理的出价为 expired
+	ExpiresAt   time.Time  `json:"expires_at"`
+	TxHash      *string    `json:"tx_hash,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+}
+
+// BidderOfferResponse 出价方视角的出价，附带 NFT 与当前活跃挂单预览
+type BidderOfferResponse struct {
+	*OfferResponse
+	NFT     *OfferNFTPreview     `json:"nft"`     // NFT 未被索引时为 null
+	Listing *OfferListingPreview `json:"listing"` // 当前无活跃挂单时为 null
+}
+
+// OfferNFTPreview 出价对应 NFT 的预览
+type OfferNFTPreview struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url"`
+}
+
+// OfferListingPreview 出价对应 NFT 当前活跃挂单的预览
+type OfferListingPreview struct {
+	ID     uint   `json:"id"`
+	Price  string `json:"price"`
+	Seller string `json:"seller"`
+}
+
+// CreateOffer 以 offerer 身份对 NFT 出价：金额须为正数且满足最小加价，过期时间须在未来且不超过 MaxOfferDuration，
+// 不能对自己持有的 NFT 出价。出价金额低于要求时返回 *BidTooLowError
+func (s *OfferService) CreateOffer(ctx context.Context, offerer string, req *CreateOfferRequest) (*OfferResponse, error) {
+	price, ok := new(big.Int).SetString(req.Price, 10)
+	if !ok || price.Sign() <= 0 {
+		return nil, ErrInvalidOfferPrice
+	}
+
+	now := time.Now()
+	if !req.ExpiresAt.After(now) || req.ExpiresAt.Sub(now) > MaxOfferDuration {
+		return nil, ErrInvalidOfferExpiry
+	}
+
+	nftContract := common.HexToAddress(req.NFTContract).Hex()
+	nft, err := s.nfts.GetByContractAndToken(nftContract, req.TokenID)
+	if err != nil && !repository.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get nft: %w", err)
+	}
+	if nft != nil && strings.EqualFold(nft.Owner, offerer) {
+		return nil, ErrOfferOnOwnNFT
+	}
+
+	var current *big.Int
+	best, err := s.repo.GetBestActive(nftContract, req.TokenID)
+	if err != nil && !repository.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get best offer: %w", err)
+	}
+	if best != nil {
+		current, _ = new(big.Int).SetString(best.Price, 10)
+	}
+	if err := s.bids.Check(ctx, nftContract, current, price); err != nil {
+		return nil, err
+	}
+
+	priceNumeric := price.String()
+	offer := &repository.Offer{
+		NFTContract:  nftContract,
+		TokenID:      req.TokenID,
+		Offerer:      common.HexToAddress(offerer).Hex(),
+		Price:        priceNumeric,
+		PriceNumeric: &priceNumeric,
+		Status:       repository.OfferStatusActive,
+		ExpiresAt:    req.ExpiresAt.UTC(),
+	}
+	if err := s.repo.Create(offer); err != nil {
+		return nil, fmt.Errorf("failed to create offer: %w", err)
+	}
+	return toOfferResponse(offer, now), nil
+}
+
+// CancelOffer 出价方取消仍有效的出价
+func (s *OfferService) CancelOffer(ctx context.Context, id uint, offerer string) error {
+	offer, err := s.repo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get offer: %w", err)
+	}
+	if !strings.EqualFold(offer.Offerer, offerer) {
+		return ErrNotOfferer
+	}
+
+	cancelled, err := s.repo.Cancel(id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel offer: %w", err)
+	}
+	if !cancelled {
+		return ErrOfferNotActive
+	}
+	return nil
+}
+
+// GetBestOffer 获取 NFT 当前最高的有效出价，没有时返回 nil
+func (s *OfferService) GetBestOffer(ctx context.Context, nftContract, tokenID string) (*OfferResponse, error) {
+	offer, err := s.repo.GetBestActive(nftContract, tokenID)
+	if repository.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get best offer: %w", err)
+	}
+	return toOfferResponse(offer, time.Now()), nil
+}
+
+// GetTokenOffers 分页获取 NFT 的出价
+func (s *OfferService) GetTokenOffers(ctx context.Context, nftContract, tokenID string, filter repository.OfferFilter, page, pageSize int) ([]*OfferResponse, int64, error) {
+	offers, total, err := s.repo.GetByToken(common.HexToAddress(nftContract).Hex(), tokenID, filter, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get offers: %w", err)
+	}
+
+	now := time.Now()
+	responses := make([]*OfferResponse, len(offers))
+	for i := range offers {
+		responses[i] = toOfferResponse(&offers[i], now)
+	}
+	return responses, total, nil
+}
+
+// GetListingOffers 分页获取挂单对应 NFT 的出价
+func (s *OfferService) GetListingOffers(ctx context.Context, listingID uint, filter repository.OfferFilter, page, pageSize int) ([]*OfferResponse, int64, error) {
+	listing, err := s.listings.GetByID(listingID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get listing: %w", err)
+	}
+
+	offers, total, err := s.repo.GetByToken(listing.NFTContract, listing.TokenID, filter, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get offers: %w", err)
+	}
+
+	now := time.Now()
+	responses := make([]*OfferResponse, len(offers))
+	for i := range offers {
+		responses[i] = toOfferResponse(&offers[i], now)
+	}
+	return responses, total, nil
+}
+
+// GetBidderOffers 分页获取某个地址发出的出价，附带 NFT 与活跃挂单预览
+func (s *OfferService) GetBidderOffers(ctx context.Context, offerer string, filter repository.OfferFilter, page, pageSize int) ([]*BidderOfferResponse, int64, error) {
+	offers, total, err := s.repo.GetByBidder(offerer, filter, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get offers: %w", err)
+	}
+
+	refs := make([]repository.TokenRef, 0, len(offers))
+	seen := make(map[repository.TokenRef]bool, len(offers))
+	for _, offer := range offers {
+		ref := tokenRef(offer.NFTContract, offer.TokenID)
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	nfts, err := s.nfts.GetByTokens(refs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get offer NFTs: %w", err)
+	}
+	nftByToken := make(map[repository.TokenRef]*OfferNFTPreview, len(nfts))
+	for _, nft := range nfts {
+		nftByToken[tokenRef(nft.ContractAddress, nft.TokenID)] = &OfferNFTPreview{ID: nft.ID, Name: nft.Name, ImageURL: nft.ImageURL}
+	}
+
+	listings, err := s.listings.GetActiveByTokens(refs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get offer listings: %w", err)
+	}
+	listingByToken := make(map[repository.TokenRef]*OfferListingPreview, len(listings))
+	for _, listing := range listings {
+		ref := tokenRef(listing.NFTContract, listing.TokenID)
+		if _, ok := listingByToken[ref]; !ok { // 按挂单时间倒序，取最新一条
+			listingByToken[ref] = &OfferListingPreview{ID: listing.ID, Price: listing.Price, Seller: listing.Seller}
+		}
+	}
+
+	now := time.Now()
+	responses := make([]*BidderOfferResponse, len(offers))
+	for i := range offers {
+		ref := tokenRef(offers[i].NFTContract, offers[i].TokenID)
+		responses[i] = &BidderOfferResponse{
+			OfferResponse: toOfferResponse(&offers[i], now),
+			NFT:           nftByToken[ref],
+			Listing:       listingByToken[ref],
+		}
+	}
+	return responses, total, nil
+}
+
+// tokenRef 合约地址统一小写的 Token 引用，用于批量查询结果的匹配
+func tokenRef(nftContract, tokenID string) repository.TokenRef {
+	return repository.TokenRef{NFTContract: strings.ToLower(nftContract), TokenID: tokenID}
+}
+
+// toOfferResponse 转换为响应
+func toOfferResponse(offer *repository.Offer, now time.Time) *OfferResponse {
+	return &OfferResponse{
+		ID:          offer.ID,
+		NFTContract: offer.NFTContract,
+		TokenID:     offer.TokenID,
+		Offerer:     offer.Offerer,
+		Price:       offer.Price,
+		Status:      offer.EffectiveStatus(now),
+		ExpiresAt:   offer.ExpiresAt,
+		TxHash:      offer.TxHash,
+		CreatedAt:   offer.CreatedAt,
+		AcceptedAt:  offer.AcceptedAt,
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xiaomait/backend/internal/cache"
+)
+
+// 价格分布刻度
+const (
+	PriceBandScaleLinear = "linear"
+	PriceBandScaleLog    = "log"
+)
+
+// PriceBandScales 支持的价格分布刻度
+var PriceBandScales = []string{PriceBandScaleLinear, PriceBandScaleLog}
+
+// MaxPriceBands 价格分布的最大区间数
+const MaxPriceBands = 50
+
+// PriceBand 价格区间 [Min, Max)，最后一个区间包含 Max
+type PriceBand struct {
+	Min   string `json:"min"`
+	Max   string `json:"max"`
+	Count int    `json:"count"`
+}
+
+// PriceBands 系列活跃挂单价格分布
+type PriceBands struct {
+	NFTContract string      `json:"nft_contract"`
+	Scale       string      `json:"scale"`
+	Total       int         `json:"total"`
+	Bands       []PriceBand `json:"bands"`
+}
+
+// GetPriceBands 将合约活跃挂单价格按 buckets 个区间统计分布。无挂单时 Bands 为空，
+// 所有价格相同时只返回一个区间
+func (s *ListingService) GetPriceBands(ctx context.Context, nftContract string, buckets int, scale string) (*PriceBands, error) {
+	key := fmt.Sprintf("listings:price-bands:%s:%d:%s", strings.ToLower(nftContract), buckets, scale)
+	result, err := cache.Fetch(ctx, s.cache, key, func(ctx context.Context) (*PriceBands, error) {
+		raw, err := s.repo.GetActivePricesByContract(nftContract)
+		if err != nil {
+			return nil, err
+		}
+
+		prices := make([]*big.Int, 0, len(raw))
+		for _, value := range raw {
+			if price, ok := new(big.Int).SetString(value, 10); ok && price.Sign() >= 0 {
+				prices = append(prices, price)
+			}
+		}
+
+		return &PriceBands{
+			NFTContract: nftContract,
+			Scale:       scale,
+			Total:       len(prices),
+			Bands:       priceBands(prices, buckets, scale == PriceBandScaleLog),
+		}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price bands: %w", err)
+	}
+	return result, nil
+}
+
+// priceBands 按区间边界统计价格数量
+func priceBands(prices []*big.Int, buckets int, logScale bool) []PriceBand {
+	if len(prices) == 0 {
+		return []PriceBand{}
+	}
+
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Cmp(prices[j]) < 0 })
+	min, max := prices[0], prices[len(prices)-1]
+	if min.Cmp(max) == 0 {
+		return []PriceBand{{Min: min.String(), Max: max.String(), Count: len(prices)}}
+	}
+
+	var edges []*big.Int
+	if logScale {
+		edges = logEdges(min, max, buckets)
+	} else {
+		edges = linearEdges(min, max, buckets)
+	}
+
+	bands := make([]PriceBand, len(edges)-1)
+	for i := range bands {
+		bands[i] = PriceBand{Min: edges[i].String(), Max: edges[i+1].String()}
+	}
+	for _, price := range prices {
+		i := sort.Search(len(bands), func(i int) bool { return price.Cmp(edges[i+1]) < 0 })
+		if i == len(bands) {
+			i-- // 最大值落在最后一个区间
+		}
+		bands[i].Count++
+	}
+	return bands
+}
+
+// linearEdges 等宽区间边界，按整数 wei 精确计算
+func linearEdges(min, max *big.Int, buckets int) []*big.Int {
+	span := new(big.Int).Sub(max, min)
+	n := big.NewInt(int64(buckets))
+
+	edges := make([]*big.Int, buckets+1)
+	for i := range edges {
+		edge := new(big.Int).Mul(span, big.NewInt(int64(i)))
+		edges[i] = edge.Quo(edge, n).Add(edge, min)
+	}
+	return edges
+}
+
+// logEdges 对数刻度区间边界，首尾精确等于最低价和最高价，中间边界保留 6 位有效数字以消除浮点误差。
+// 最低价为 0 时从 1 wei 起算；相邻边界取整后相同时合并区间
+func logEdges(min, max *big.Int, buckets int) []*big.Int {
+	low := math.Log(weiFloat(min))
+	if min.Sign() == 0 {
+		low = 0
+	}
+	high := math.Log(weiFloat(max))
+
+	edges := []*big.Int{min}
+	for i := 1; i < buckets; i++ {
+		value := math.Exp(low + (high-low)*float64(i)/float64(buckets))
+		rounded, _, err := big.ParseFloat(strconv.FormatFloat(value, 'g', 6, 64), 10, 256, big.ToNearestEven)
+		if err != nil {
+			continue
+		}
+		edge, _ := rounded.Int(nil)
+		if edge.Cmp(edges[len(edges)-1]) > 0 && edge.Cmp(max) < 0 {
+			edges = append(edges, edge)
+		}
+	}
+	return append(edges, max)
+}
+
+// weiFloat wei 金额转为 float64（仅用于对数刻度计算）
+func weiFloat(wei *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(wei).Float64()
+	return f
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/xiaomait/backend/internal/cache"
+	"github.com/xiaomait/backend/internal/pricing"
+)
+
+// UnsupportedCurrencyError 请求了未配置的法币币种
+type UnsupportedCurrencyError struct {
+	Currency string
+}
+
+func (e *UnsupportedCurrencyError) Error() string {
+	return fmt.Sprintf("unsupported currency %s", e.Currency)
+}
+
+// ethRatesCacheKey 所有支持币种的汇率作为一个缓存项，一次上游请求刷新全部币种
+const ethRatesCacheKey = "prices:eth"
+
+// FiatRates 1 ETH 兑各法币的汇率
+type FiatRates map[string]*big.Float
+
+// Convert 将 wei 金额换算为各法币价格（保留两位小数），金额无效时返回 nil
+func (r FiatRates) Convert(wei string) map[string]string {
+	if len(r) == 0 {
+		return nil
+	}
+	amount, ok := new(big.Float).SetString(wei)
+	if !ok {
+		return nil
+	}
+	eth := new(big.Float).Quo(amount, new(big.Float).SetInt(weiPerEther))
+
+	prices := make(map[string]string, len(r))
+	for currency, rate := range r {
+		prices[currency] = new(big.Float).Mul(eth, rate).Text('f', 2)
+	}
+	return prices
+}
+
+// PriceService 法币价格服务
+type PriceService struct {
+	source     pricing.Source
+	cache      *cache.SWR
+	currencies []string
+	supported  map[string]bool
+}
+
+// NewPriceService 创建法币价格服务，currencies 为允许请求的币种
+func NewPriceService(source pricing.Source, rates *cache.SWR, currencies []string) *PriceService {
+	s := &PriceService{
+		source:    source,
+		cache:     rates,
+		supported: make(map[string]bool, len(currencies)),
+	}
+	for _, currency := range currencies {
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if currency != "" && !s.supported[currency] {
+			s.supported[currency] = true
+			s.currencies = append(s.currencies, currency)
+		}
+	}
+	return s
+}
+
+// ParseCurrencies 解析逗号分隔的币种参数（不区分大小写，去重），包含未配置币种时返回 *UnsupportedCurrencyError
+func (s *PriceService) ParseCurrencies(raw string) ([]string, error) {
+	var currencies []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		currency := strings.ToUpper(strings.TrimSpace(part))
+		if currency == "" || seen[currency] {
+			continue
+		}
+		if !s.supported[currency] {
+			return nil, &UnsupportedCurrencyError{Currency: currency}
+		}
+		seen[currency] = true
+		currencies = append(currencies, currency)
+	}
+	return currencies, nil
+}
+
+// EthRates 获取指定币种的 ETH 汇率，获取失败的币种不出现在结果中
+func (s *PriceService) EthRates(ctx context.Context, currencies []string) FiatRates {
+	all, err := cache.Fetch(ctx, s.cache, ethRatesCacheKey, func(ctx context.Context) (map[string]string, error) {
+		return s.source.EthRates(ctx, s.currencies)
+	})
+	if err != nil {
+		log.Printf("Failed to get ETH fiat rates: %v", err)
+		return nil
+	}
+
+	rates := make(FiatRates, len(currencies))
+	for _, currency := range currencies {
+		value, ok := all[currency]
+		if !ok {
+			continue
+		}
+		rate, ok := new(big.Float).SetString(value)
+		if !ok {
+			continue
+		}
+		rates[currency] = rate
+	}
+	return rates
+}
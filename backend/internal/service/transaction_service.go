@@ -2,45 +2,84 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"github.com/xiaomait/backend/internal/blockchain"
 	"github.com/xiaomait/backend/internal/repository"
+	"gorm.io/gorm"
 )
 
 // TransactionService 交易服务
 type TransactionService struct {
-	repo     *repository.TransactionRepository
-	bcClient *blockchain.Client
+	repo     repository.TransactionStore
+	listings repository.ListingStore
+	nfts     repository.NFTStore
+	index    repository.IndexTransactor // 成交写入、挂单状态与索引进度在同一事务中提交
+	bcClient blockchain.BlockchainClient
+	fees     *FeeService
+	head     *ChainHead           // 计算响应中的 is_final
+	tracker  *ConfirmationTracker // 不为 nil 时实时成交先以 pending 写入，达到确认数后转为 confirmed
+
+	saleDedupeWindow uint64 // 同一市场项在该区块半径内的成交视为重复事件
 }
 
+// ErrDuplicateSale 同一市场项在去重窗口内已记录成交，或同一日志已写入（事件重放或重组重放）
+var ErrDuplicateSale = errors.New("duplicate sale event")
+
 // NewTransactionService 创建交易服务
-func NewTransactionService(repo *repository.TransactionRepository, bcClient *blockchain.Client) *TransactionService {
+func NewTransactionService(
+	repo repository.TransactionStore,
+	listings repository.ListingStore,
+	nfts repository.NFTStore,
+	index repository.IndexTransactor,
+	bcClient blockchain.BlockchainClient,
+	fees *FeeService,
+	head *ChainHead,
+	tracker *ConfirmationTracker,
+	saleDedupeWindow uint64,
+) *TransactionService {
 	return &TransactionService{
 		repo:     repo,
+		listings: listings,
+		nfts:     nfts,
+		index:    index,
 		bcClient: bcClient,
+		fees:     fees,
+		head:     head,
+		tracker:  tracker,
+
+		saleDedupeWindow: saleDedupeWindow,
 	}
 }
 
 // TransactionResponse 交易响应
 type TransactionResponse struct {
-	ID             uint      `json:"id"`
-	TxHash         string    `json:"tx_hash"`
-	BlockNumber    uint64    `json:"block_number"`
-	BlockTimestamp time.Time `json:"block_timestamp"`
-	TxType         string    `json:"tx_type"`
-	ListingID      *uint     `json:"listing_id,omitempty"`
-	NFTContract    string    `json:"nft_contract"`
-	TokenID        string    `json:"token_id"`
-	FromAddress    string    `json:"from_address"`
-	ToAddress      string    `json:"to_address"`
-	Value          string    `json:"value"`
-	GasPrice       string    `json:"gas_price"`
-	GasUsed        uint64    `json:"gas_used"`
-	PlatformFee    string    `json:"platform_fee"`
-	Status         string    `json:"status"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID              uint      `json:"id"`
+	TxHash          string    `json:"tx_hash"`
+	BlockNumber     uint64    `json:"block_number"`
+	BlockTimestamp  time.Time `json:"block_timestamp"`
+	TxType          string    `json:"tx_type"`
+	ListingID       *uint     `json:"listing_id,omitempty"`
+	NFTContract     string    `json:"nft_contract"`
+	TokenID         string    `json:"token_id"`
+	FromAddress     string    `json:"from_address"`
+	ToAddress       string    `json:"to_address"`
+	Value           string    `json:"value"`
+	GasPrice        string    `json:"gas_price"`
+	GasUsed         uint64    `json:"gas_used"`
+	PlatformFee     string    `json:"platform_fee"`
+	IsPrimary       bool      `json:"is_primary"`
+	Status          string    `json:"status"`
+	IsFinal         bool      `json:"is_final"` // 距最新区块已达到确认数，不会再被重组
+	ContractVersion string    `json:"contract_version,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// 按 ?currencies= 换算的法币价格，汇率获取失败的币种省略
+	Prices map[string]string `json:"prices,omitempty"`
 }
 
 // GetTransaction 获取交易
@@ -50,7 +89,7 @@ func (s *TransactionService) GetTransaction(ctx context.Context, txHash string)
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 
-	return s.toResponse(tx), nil
+	return toTransactionResponse(tx, s.head), nil
 }
 
 // GetTransactionByID 根据 ID 获取交易
@@ -60,24 +99,54 @@ func (s *TransactionService) GetTransactionByID(ctx context.Context, id uint) (*
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 
-	return s.toResponse(tx), nil
+	return toTransactionResponse(tx, s.head), nil
 }
 
-// GetTransactions 获取交易列表
-func (s *TransactionService) GetTransactions(ctx context.Context, page, pageSize int) ([]*TransactionResponse, int64, error) {
-	txs, total, err := s.repo.GetAll(page, pageSize)
+// GetTransactions 获取交易列表，withCount 为 false 时不查询总数
+func (s *TransactionService) GetTransactions(ctx context.Context, page, pageSize int, withCount bool) ([]*TransactionResponse, int64, error) {
+	txs, total, err := s.repo.GetAll(page, pageSize, withCount)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get transactions: %w", err)
 	}
 
 	responses := make([]*TransactionResponse, len(txs))
 	for i, tx := range txs {
-		responses[i] = s.toResponse(&tx)
+		responses[i] = toTransactionResponse(&tx, s.head)
+	}
+
+	return responses, total, nil
+}
+
+// GetFilteredTransactions 按过滤条件获取交易列表，withCount 为 false 时不查询总数
+func (s *TransactionService) GetFilteredTransactions(ctx context.Context, filter repository.TxFilter, page, pageSize int, withCount bool) ([]*TransactionResponse, int64, error) {
+	txs, total, err := s.repo.GetFiltered(filter, page, pageSize, withCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get filtered transactions: %w", err)
+	}
+
+	responses := make([]*TransactionResponse, len(txs))
+	for i, tx := range txs {
+		responses[i] = toTransactionResponse(&tx, s.head)
 	}
 
 	return responses, total, nil
 }
 
+// ExportTransactions 按 ID 游标获取满足过滤条件的下一批交易（升序），用于流式导出
+func (s *TransactionService) ExportTransactions(ctx context.Context, filter repository.TxFilter, afterID uint, limit int) ([]*TransactionResponse, error) {
+	txs, err := s.repo.GetFilteredAfterID(filter, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export transactions: %w", err)
+	}
+
+	responses := make([]*TransactionResponse, len(txs))
+	for i, tx := range txs {
+		responses[i] = toTransactionResponse(&tx, s.head)
+	}
+
+	return responses, nil
+}
+
 // GetUserTransactions 获取用户的交易
 func (s *TransactionService) GetUserTransactions(ctx context.Context, address string, page, pageSize int) ([]*TransactionResponse, int64, error) {
 	txs, total, err := s.repo.GetByAddress(address, page, pageSize)
@@ -87,7 +156,7 @@ func (s *TransactionService) GetUserTransactions(ctx context.Context, address st
 
 	responses := make([]*TransactionResponse, len(txs))
 	for i, tx := range txs {
-		responses[i] = s.toResponse(&tx)
+		responses[i] = toTransactionResponse(&tx, s.head)
 	}
 
 	return responses, total, nil
@@ -102,7 +171,7 @@ func (s *TransactionService) GetNFTTransactions(ctx context.Context, nftContract
 
 	responses := make([]*TransactionResponse, len(txs))
 	for i, tx := range txs {
-		responses[i] = s.toResponse(&tx)
+		responses[i] = toTransactionResponse(&tx, s.head)
 	}
 
 	return responses, total, nil
@@ -117,92 +186,264 @@ func (s *TransactionService) GetRecentTransactions(ctx context.Context, limit in
 
 	responses := make([]*TransactionResponse, len(txs))
 	for i, tx := range txs {
-		responses[i] = s.toResponse(&tx)
+		responses[i] = toTransactionResponse(&tx, s.head)
 	}
 
 	return responses, nil
 }
 
-// RecordSale 记录销售事件
-func (s *TransactionService) RecordSale(event *blockchain.MarketItemSoldEvent) error {
-	// 检查是否已存在
-	// existing, _ := s.repo.GetByHash(event.TxHash)
-	// if existing != nil {
-	// 	return nil // 已存在，跳过
-	// }
+// RecordSale 记录销售事件，返回写入的交易。同一市场项在去重窗口内已有成交，或同一日志
+// （tx_hash, log_index）已写入时返回 ErrDuplicateSale
+func (s *TransactionService) RecordSale(event *blockchain.MarketItemSoldEvent) (*TransactionResponse, error) {
+	itemID := event.ItemId.Uint64()
+	existing, err := s.repo.GetSaleByItemNear(itemID, event.Raw.BlockNumber, s.saleDedupeWindow)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check duplicate sale: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: item %d already sold in block %d (tx %d)", ErrDuplicateSale, itemID, existing.BlockNumber, existing.ID)
+	}
 
 	tx := &repository.Transaction{
-		TxHash:         "", // 需要从事件中获取
-		BlockNumber:    0,  // 需要从事件中获取
-		BlockTimestamp: time.Now(),
-		TxType:         "sale",
-		FromAddress:    event.Buyer.Hex(),
-		ToAddress:      event.Buyer.Hex(),
-		Value:          event.Price.String(),
-		ValueNumeric:   event.Price.String(),
-		Status:         "confirmed",
+		TxHash:           event.Raw.TxHash.Hex(),
+		BlockNumber:      event.Raw.BlockNumber,
+		BlockHash:        event.Raw.BlockHash.Hex(),
+		BlockTimestamp:   chainTime(context.Background(), s.bcClient, event.Raw.BlockNumber),
+		TxType:           "sale",
+		ItemID:           &itemID,
+		FromAddress:      event.Buyer.Hex(),
+		ToAddress:        event.Buyer.Hex(),
+		Value:            event.Price.String(),
+		ValueNumeric:     event.Price.String(),
+		NetValueNumeric:  nativeSettledAmount(event.Price),
+		Status:           "confirmed",
+		LogIndex:         int(event.Raw.Index),
+		TransactionIndex: int(event.Raw.TxIndex),
+		ContractVersion:  event.Version,
+	}
+
+	// 关联挂单以获取 NFT 和卖家
+	listing, err := s.listings.GetByItemID(itemID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get listing: %w", err)
+	}
+	if listing != nil {
+		tx.ListingID = &listing.ID
+		tx.NFTContract = listing.NFTContract
+		tx.TokenID = listing.TokenID
+		tx.FromAddress = listing.Seller
+
+		tx.IsPrimary, err = isPrimarySale(s.nfts, listing.NFTContract, listing.TokenID, listing.Seller)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return s.repo.Create(tx)
+	// 按系列费率计算平台费
+	quote, err := s.fees.Quote(context.Background(), tx.NFTContract, tx.ValueNumeric)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute platform fee: %w", err)
+	}
+	tx.PlatformFee = quote.PlatformFee
+
+	// 未达到确认数的成交不计入交易额统计，由确认队列转为 confirmed 或在重组后标记为 failed
+	if s.tracker.Enabled() {
+		tx.Status = "pending"
+	}
+
+	err = s.index.InTx(func(stores repository.IndexStores) error {
+		inserted, err := stores.Transactions.CreateIfNotExists(tx)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			return fmt.Errorf("%w: log %s#%d already recorded", ErrDuplicateSale, tx.TxHash, tx.LogIndex)
+		}
+
+		if listing != nil {
+			if err := stores.Listings.MarkSold(listing.ID, tx.BlockTimestamp); err != nil {
+				return fmt.Errorf("failed to mark listing sold: %w", err)
+			}
+		}
+		return stores.SyncState.SetLastSyncedBlock(event.Raw.Address.Hex(), blockchain.EventMarketItemSold, event.Raw.BlockNumber)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.Status == "pending" {
+		s.tracker.TrackSale(tx)
+	}
+	return toTransactionResponse(tx, s.head), nil
+}
+
+// nativeSettledAmount 原生币结算的实际到账金额：原生币转账不收手续费，与成交价相同。
+// 支持 ERC-20 支付后应改用 blockchain.NetTransferred 从交易回执推导
+func nativeSettledAmount(price *big.Int) *string {
+	amount := price.String()
+	return &amount
+}
+
+// PurgeResult 交易清理结果
+type PurgeResult struct {
+	Pending int64
+	Failed  int64
+}
+
+// PurgeStaleTransactions 删除超过保留期的 pending 和 failed 交易
+func (s *TransactionService) PurgeStaleTransactions(ctx context.Context, pendingRetention, failedRetention time.Duration) (*PurgeResult, error) {
+	now := time.Now()
+	result := &PurgeResult{}
+
+	pending, err := s.repo.DeleteByStatusBefore("pending", now.Add(-pendingRetention))
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge pending transactions: %w", err)
+	}
+	result.Pending = pending
+
+	failed, err := s.repo.DeleteByStatusBefore("failed", now.Add(-failedRetention))
+	if err != nil {
+		return result, fmt.Errorf("failed to purge failed transactions: %w", err)
+	}
+	result.Failed = failed
+
+	return result, nil
+}
+
+// isPrimarySale 卖家为 NFT 创作者时视为一级市场销售，NFT 未入库时视为二级市场
+func isPrimarySale(nfts repository.NFTStore, nftContract, tokenID, seller string) (bool, error) {
+	nft, err := nfts.GetByContractAndToken(nftContract, tokenID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get NFT: %w", err)
+	}
+
+	return nft.Creator != "" && strings.EqualFold(nft.Creator, seller), nil
 }
 
 // GetTotalVolume 获取总交易额
-func (s *TransactionService) GetTotalVolume(ctx context.Context) (string, error) {
+func (s *TransactionService) GetTotalVolume(ctx context.Context) (*WeiAmount, error) {
 	volume, err := s.repo.GetTotalVolume()
 	if err != nil {
-		return "0", fmt.Errorf("failed to get total volume: %w", err)
+		return nil, fmt.Errorf("failed to get total volume: %w", err)
 	}
-	return volume, nil
+	return parseWeiAmount(volume)
 }
 
 // GetVolumeByContract 获取合约的交易额
-func (s *TransactionService) GetVolumeByContract(ctx context.Context, nftContract string) (string, error) {
+func (s *TransactionService) GetVolumeByContract(ctx context.Context, nftContract string) (*WeiAmount, error) {
 	volume, err := s.repo.GetVolumeByContract(nftContract)
 	if err != nil {
-		return "0", fmt.Errorf("failed to get volume by contract: %w", err)
+		return nil, fmt.Errorf("failed to get volume by contract: %w", err)
 	}
-	return volume, nil
+	return parseWeiAmount(volume)
 }
 
-// GetTransactionStats 获取交易统计
-func (s *TransactionService) GetTransactionStats(ctx context.Context) (map[string]interface{}, error) {
-	stats := make(map[string]interface{})
+// 每日交易额统计的天数
+const (
+	DefaultVolumeDays = 30
+	MaxVolumeDays     = 365
+)
 
-	// 总交易数
-	listCount, _ := s.repo.CountByType("list")
-	saleCount, _ := s.repo.CountByType("sale")
-	cancelCount, _ := s.repo.CountByType("cancel")
+// TransactionStats 交易汇总统计，交易额与均价仅统计已确认的成交
+type TransactionStats struct {
+	TotalListings             int64  `json:"total_listings"`
+	TotalSales                int64  `json:"total_sales"`
+	TotalCancellations        int64  `json:"total_cancellations"`
+	TotalTransactions         int64  `json:"total_transactions"`
+	TotalVolume               string `json:"total_volume"`
+	TotalVolumeFormatted      string `json:"total_volume_formatted"`
+	AverageSalePrice          string `json:"average_sale_price"` // 总交易额 / 成交笔数（向下取整），无成交时为 0
+	AverageSalePriceFormatted string `json:"average_sale_price_formatted"`
+}
 
-	stats["total_listings"] = listCount
-	stats["total_sales"] = saleCount
-	stats["total_cancellations"] = cancelCount
-	stats["total_transactions"] = listCount + saleCount + cancelCount
+// DailyVolumeResponse 某一天（UTC）的成交笔数与交易额
+type DailyVolumeResponse struct {
+	Date            string `json:"date"` // YYYY-MM-DD
+	TxCount         int64  `json:"tx_count"`
+	Volume          string `json:"volume"`
+	VolumeFormatted string `json:"volume_formatted"`
+}
 
-	// 总交易额
-	totalVolume, _ := s.repo.GetTotalVolume()
-	stats["total_volume"] = totalVolume
+// GetTransactionStats 获取交易汇总统计
+func (s *TransactionService) GetTransactionStats(ctx context.Context) (*TransactionStats, error) {
+	stats := &TransactionStats{}
+	for txType, count := range map[string]*int64{
+		"list":   &stats.TotalListings,
+		"sale":   &stats.TotalSales,
+		"cancel": &stats.TotalCancellations,
+	} {
+		n, err := s.repo.CountByType(txType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s transactions: %w", txType, err)
+		}
+		*count = n
+	}
+	stats.TotalTransactions = stats.TotalListings + stats.TotalSales + stats.TotalCancellations
+
+	totalVolume, err := s.GetTotalVolume(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalVolume = totalVolume.Wei
+	stats.TotalVolumeFormatted = totalVolume.Formatted
+
+	average := new(big.Int)
+	if stats.TotalSales > 0 {
+		average.Quo(totalVolume.Int, big.NewInt(stats.TotalSales))
+	}
+	stats.AverageSalePrice = average.String()
+	stats.AverageSalePriceFormatted = formatEther(average)
 
 	return stats, nil
 }
 
-// toResponse 转换为响应对象
-func (s *TransactionService) toResponse(tx *repository.Transaction) *TransactionResponse {
+// GetDailyVolume 按天获取最近 days 天的成交笔数与交易额，日期倒序，没有成交的日期不返回
+func (s *TransactionService) GetDailyVolume(ctx context.Context, days int) ([]*DailyVolumeResponse, error) {
+	rows, err := s.repo.GetDailyVolume(days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily volume: %w", err)
+	}
+
+	responses := make([]*DailyVolumeResponse, len(rows))
+	for i, row := range rows {
+		volume, err := parseWeiAmount(row.Volume)
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = &DailyVolumeResponse{
+			Date:            row.Date.UTC().Format("2006-01-02"),
+			TxCount:         row.TxCount,
+			Volume:          volume.Wei,
+			VolumeFormatted: volume.Formatted,
+		}
+	}
+	return responses, nil
+}
+
+// toTransactionResponse 转换为响应对象，head 为 nil 时 is_final 为 false
+func toTransactionResponse(tx *repository.Transaction, head *ChainHead) *TransactionResponse {
 	return &TransactionResponse{
-		ID:             tx.ID,
-		TxHash:         tx.TxHash,
-		BlockNumber:    tx.BlockNumber,
-		BlockTimestamp: tx.BlockTimestamp,
-		TxType:         tx.TxType,
-		ListingID:      tx.ListingID,
-		NFTContract:    tx.NFTContract,
-		TokenID:        tx.TokenID,
-		FromAddress:    tx.FromAddress,
-		ToAddress:      tx.ToAddress,
-		Value:          tx.Value,
-		GasPrice:       tx.GasPrice,
-		GasUsed:        tx.GasUsed,
-		PlatformFee:    tx.PlatformFee,
-		Status:         tx.Status,
-		CreatedAt:      tx.CreatedAt,
+		ID:              tx.ID,
+		TxHash:          tx.TxHash,
+		BlockNumber:     tx.BlockNumber,
+		BlockTimestamp:  tx.BlockTimestamp,
+		TxType:          tx.TxType,
+		ListingID:       tx.ListingID,
+		NFTContract:     tx.NFTContract,
+		TokenID:         tx.TokenID,
+		FromAddress:     tx.FromAddress,
+		ToAddress:       tx.ToAddress,
+		Value:           tx.Value,
+		GasPrice:        tx.GasPrice,
+		GasUsed:         tx.GasUsed,
+		PlatformFee:     tx.PlatformFee,
+		IsPrimary:       tx.IsPrimary,
+		Status:          tx.Status,
+		IsFinal:         head.IsFinal(tx.BlockNumber),
+		ContractVersion: tx.ContractVersion,
+		CreatedAt:       tx.CreatedAt,
 	}
 }
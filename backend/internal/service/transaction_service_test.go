@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/xiaomait/backend/internal/blockchain"
+	"github.com/xiaomait/backend/internal/blockchain/mock"
+	"github.com/xiaomait/backend/internal/repository"
+	"github.com/xiaomait/backend/internal/repository/memory"
+)
+
+const testSaleDedupeWindow = 64
+
+// newTestTransactionService 基于内存存储的交易服务，不启用确认数跟踪
+func newTestTransactionService() (*TransactionService, *memory.TransactionStore, *memory.ListingStore) {
+	txs := memory.NewTransactionStore()
+	listings := memory.NewListingStore()
+	client := &mock.Client{
+		GetBlockTimeFunc: func(ctx context.Context, blockNumber uint64) (time.Time, error) {
+			return time.Unix(int64(blockNumber)*12, 0), nil
+		},
+	}
+	index := memory.NewIndexTransactor(listings, txs, memory.NewSyncStateStore())
+	fees := NewFeeService(memory.NewCollectionStore(), 250)
+	return NewTransactionService(txs, listings, memory.NewNFTStore(), index, client, fees, nil, nil, testSaleDedupeWindow), txs, listings
+}
+
+// soldEvent 构造销售事件，txHash 与 logIndex 决定日志唯一键
+func soldEvent(itemID int64, block uint64, txHash string, logIndex uint) *blockchain.MarketItemSoldEvent {
+	return &blockchain.MarketItemSoldEvent{
+		ItemId: big.NewInt(itemID),
+		Buyer:  common.HexToAddress("0x00000000000000000000000000000000000000b1"),
+		Price:  big.NewInt(1e18),
+		Raw: types.Log{
+			Address:     common.HexToAddress("0x00000000000000000000000000000000000000c1"),
+			BlockNumber: block,
+			TxHash:      common.HexToHash(txHash),
+			Index:       logIndex,
+		},
+	}
+}
+
+func TestRecordSaleDedupe(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   *blockchain.MarketItemSoldEvent
+		wantDup bool
+	}{
+		{"replay of same log", soldEvent(1, 1000, "0x01", 0), true},
+		{"same item same block other tx", soldEvent(1, 1000, "0x02", 0), true},
+		{"same item at window end", soldEvent(1, 1000+testSaleDedupeWindow, "0x03", 0), true},
+		{"same item before block within window", soldEvent(1, 1000-testSaleDedupeWindow, "0x04", 0), true},
+		{"same item after window", soldEvent(1, 1000+testSaleDedupeWindow+1, "0x05", 0), false},
+		{"same item before window", soldEvent(1, 1000-testSaleDedupeWindow-1, "0x06", 0), false},
+		{"other item same block", soldEvent(2, 1000, "0x07", 0), false},
+		{"other item same log", soldEvent(2, 1000+testSaleDedupeWindow+1, "0x01", 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, txs, listings := newTestTransactionService()
+			for itemID := uint64(1); itemID <= 2; itemID++ {
+				listing := &repository.Listing{ItemID: itemID, NFTContract: testNFTContract, TokenID: "1", Seller: testSeller, Status: "active"}
+				if err := listings.Create(listing); err != nil {
+					t.Fatalf("create listing: %v", err)
+				}
+			}
+			if _, err := s.RecordSale(soldEvent(1, 1000, "0x01", 0)); err != nil {
+				t.Fatalf("first RecordSale: %v", err)
+			}
+
+			_, err := s.RecordSale(tt.event)
+			if tt.wantDup != errors.Is(err, ErrDuplicateSale) {
+				t.Fatalf("RecordSale() = %v, want duplicate %v", err, tt.wantDup)
+			}
+			if !tt.wantDup && err != nil {
+				t.Fatalf("RecordSale() = %v", err)
+			}
+
+			wantRows := int64(2)
+			if tt.wantDup {
+				wantRows = 1
+			}
+			if count, _ := txs.CountByType("sale"); count != wantRows {
+				t.Errorf("sale rows = %d, want %d", count, wantRows)
+			}
+
+			// 重复的成交不计入日成交额与系列统计
+			wantVolume := new(big.Int).Mul(big.NewInt(wantRows), big.NewInt(1e18)).String()
+			if volume, _ := txs.GetVolumeByContract(testNFTContract); volume != wantVolume {
+				t.Errorf("collection volume = %s, want %s", volume, wantVolume)
+			}
+			// 测试区块时间在 1970 年，统计窗口需覆盖到那时
+			days, _ := txs.GetDailyVolume(int(time.Since(time.Unix(0, 0)).Hours()/24) + 1)
+			var dailyCount int64
+			dailyVolume := new(big.Int)
+			for _, day := range days {
+				dailyCount += day.TxCount
+				v, _ := new(big.Int).SetString(day.Volume, 10)
+				dailyVolume.Add(dailyVolume, v)
+			}
+			if dailyCount != wantRows || dailyVolume.String() != wantVolume {
+				t.Errorf("daily volume = %d sales / %s, want %d / %s", dailyCount, dailyVolume, wantRows, wantVolume)
+			}
+		})
+	}
+}
+
+// 重组后标记为 failed 的成交不阻止同一市场项的重新记录
+func TestRecordSaleIgnoresFailedSale(t *testing.T) {
+	s, txs, _ := newTestTransactionService()
+	recorded, err := s.RecordSale(soldEvent(1, 1000, "0x01", 0))
+	if err != nil {
+		t.Fatalf("RecordSale: %v", err)
+	}
+	if err := txs.UpdateStatus(recorded.ID, "failed"); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	if _, err := s.RecordSale(soldEvent(1, 1001, "0x02", 0)); err != nil {
+		t.Fatalf("RecordSale after reorg = %v, want nil", err)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/xiaomait/backend/internal/cache"
+	"github.com/xiaomait/backend/internal/metrics"
+	"github.com/xiaomait/backend/internal/repository"
+)
+
+// viewRetryBackoff 浏览计数写入失败后的首次重试间隔，之后每次翻倍
+const viewRetryBackoff = 100 * time.Millisecond
+
+// viewDedupPrefix 浏览去重标记在缓存中的键前缀，完整键为 前缀+NFT ID+":"+浏览者
+const viewDedupPrefix = "view:seen:"
+
+// viewEvent 一次待计数的浏览
+type viewEvent struct {
+	nftID  uint
+	viewer string
+}
+
+// ViewCounter 有界队列异步累加 NFT 浏览次数：读请求不等待写库，队列满时丢弃计数而不阻塞请求，
+// 固定数量的 worker 写库并在失败时重试，关闭时处理完队列中剩余的计数。
+// 同一浏览者（认证地址或 IP）在 window 内对同一 NFT 只计一次，去重标记存于 dedup
+type ViewCounter struct {
+	store   repository.NFTStore
+	dedup   cache.Store
+	window  time.Duration
+	queue   chan viewEvent
+	retries int
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewViewCounter 创建浏览计数队列并启动 workers 个写库 worker；dedup 为 nil 或 window 为 0 时不去重
+func NewViewCounter(store repository.NFTStore, dedup cache.Store, window time.Duration, queueSize, workers, retries int) *ViewCounter {
+	v := &ViewCounter{
+		store:   store,
+		dedup:   dedup,
+		window:  window,
+		queue:   make(chan viewEvent, queueSize),
+		retries: retries,
+	}
+	for i := 0; i < workers; i++ {
+		v.wg.Add(1)
+		go v.work()
+	}
+	return v
+}
+
+// Add 记录 viewer 的一次浏览，不阻塞；队列已满或已关闭时丢弃。viewer 为空时不去重
+func (v *ViewCounter) Add(nftID uint, viewer string) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.closed {
+		metrics.NFTViewIncrementsDropped.Inc()
+		return
+	}
+	select {
+	case v.queue <- viewEvent{nftID: nftID, viewer: viewer}:
+	default:
+		metrics.NFTViewIncrementsDropped.Inc()
+	}
+}
+
+// Close 停止接收新计数并等待队列处理完，ctx 结束时放弃剩余计数
+func (v *ViewCounter) Close(ctx context.Context) error {
+	v.mu.Lock()
+	if !v.closed {
+		v.closed = true
+		close(v.queue)
+	}
+	v.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		v.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		log.Printf("View counter shutdown timed out with %d increments pending", len(v.queue))
+		return ctx.Err()
+	}
+}
+
+// work 逐个写入浏览计数直到队列关闭
+func (v *ViewCounter) work() {
+	defer v.wg.Done()
+
+	for event := range v.queue {
+		if v.seen(event) {
+			continue
+		}
+		v.increment(event.nftID)
+	}
+}
+
+// seen 浏览者在去重窗口内是否已计过数，未计过时写入标记；缓存出错时照常计数
+func (v *ViewCounter) seen(event viewEvent) bool {
+	if v.dedup == nil || v.window <= 0 || event.viewer == "" {
+		return false
+	}
+
+	key := fmt.Sprintf("%s%d:%s", viewDedupPrefix, event.nftID, event.viewer)
+	added, err := v.dedup.SetIfAbsent(context.Background(), key, []byte{1}, v.window)
+	if err != nil {
+		log.Printf("Failed to check view dedup for NFT %d: %v", event.nftID, err)
+		return false
+	}
+	return !added
+}
+
+// increment 写入一次浏览计数，失败时按指数退避重试
+func (v *ViewCounter) increment(nftID uint) {
+	backoff := viewRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := v.store.IncrementViewCount(nftID)
+		if err == nil {
+			return
+		}
+		if attempt >= v.retries {
+			metrics.NFTViewIncrementsFailed.Inc()
+			log.Printf("Failed to increment view count for NFT %d after %d attempts: %v", nftID, attempt+1, err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,54 @@
+// Package webhook 以 JSON POST 向外部订阅方推送市场事件
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Payload 推送内容
+type Payload struct {
+	Event  string      `json:"event"`
+	Data   interface{} `json:"data"`
+	SentAt time.Time   `json:"sent_at"`
+}
+
+// Sender 事件推送目标
+type Sender struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewSender 创建事件推送目标
+func NewSender(url string) *Sender {
+	return &Sender{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send 推送事件，非 2xx 响应视为失败
+func (s *Sender) Send(ctx context.Context, event string, data interface{}) error {
+	body, err := json.Marshal(Payload{Event: event, Data: data, SentAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post %s webhook: %w", event, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s webhook returned status %d", event, resp.StatusCode)
+	}
+	return nil
+}
@@ -30,6 +30,7 @@ CREATE TABLE IF NOT EXISTS nfts (
     
     -- 索引字段
     status VARCHAR(20) DEFAULT 'active', -- active, burned, transferred
+    token_standard VARCHAR(10) NOT NULL DEFAULT '', -- erc721, erc1155, unknown；为空表示尚未检测
     
     -- 统计字段
     view_count BIGINT DEFAULT 0,
@@ -57,6 +58,21 @@ COMMENT ON TABLE nfts IS 'NFT 元数据表';
 COMMENT ON COLUMN nfts.contract_address IS 'NFT 合约地址';
 COMMENT ON COLUMN nfts.token_id IS 'NFT Token ID（大数字字符串）';
 COMMENT ON COLUMN nfts.metadata IS 'NFT 完整元数据 JSON';
+COMMENT ON COLUMN nfts.token_standard IS '合约代币标准，创建或抓取元数据时通过 ERC-165 supportsInterface 检测';
+COMMENT ON COLUMN nfts.like_count IS '点赞数，随 nft_likes 增删调整，可由管理接口按 nft_likes 重算';
+
+-- NFT Likes 表 - 用户点赞
+CREATE TABLE IF NOT EXISTS nft_likes (
+    id BIGSERIAL PRIMARY KEY,
+    nft_id BIGINT NOT NULL REFERENCES nfts(id) ON DELETE CASCADE,
+    user_address VARCHAR(42) NOT NULL, -- 小写地址
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_nft_likes_nft_user ON nft_likes(nft_id, user_address);
+CREATE INDEX idx_nft_likes_user_address ON nft_likes(user_address);
+
+COMMENT ON TABLE nft_likes IS 'NFT 点赞表，每个用户对每个 NFT 至多一条';
 
 -- ============================================
 -- 2. Listings 表 - 市场挂单
@@ -72,17 +88,26 @@ CREATE TABLE IF NOT EXISTS listings (
     price_numeric NUMERIC(78, 0), -- 用于排序和计算的数值类型
     
     -- 状态管理
-    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, sold, cancelled
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, pending, sold, cancelled, invalid
+    unverified BOOLEAN NOT NULL DEFAULT FALSE, -- 熔断期间未经链上校验创建
+    
+    -- 拍卖
+    listing_type VARCHAR(20) NOT NULL DEFAULT 'fixed_price', -- fixed_price, auction
+    auction_end_at TIMESTAMP WITH TIME ZONE, -- 拍卖截止时间
+    reserve_price VARCHAR(78), -- 保留价（wei）
     
     -- 交易信息
     tx_hash VARCHAR(66), -- 创建交易哈希
     sale_tx_hash VARCHAR(66), -- 成交交易哈希
+    contract_version VARCHAR(20), -- 解码事件所用的市场合约 ABI 版本（经 API 创建且未收到事件时为空）
+    block_hash VARCHAR(66), -- 创建事件所在区块哈希，确认前用于检测链重组
     
     -- 时间戳
     listed_at TIMESTAMP WITH TIME ZONE NOT NULL,
     sold_at TIMESTAMP WITH TIME ZONE,
     cancelled_at TIMESTAMP WITH TIME ZONE,
     expires_at TIMESTAMP WITH TIME ZONE, -- 可选的过期时间
+    archived_at TIMESTAMP WITH TIME ZONE, -- 已售/已取消超过保留期后归档
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
@@ -98,20 +123,27 @@ CREATE INDEX idx_listings_listed_at ON listings(listed_at DESC);
 CREATE INDEX idx_listings_price ON listings(price_numeric);
 CREATE INDEX idx_listings_active_price ON listings(status, price_numeric) WHERE status = 'active'; -- 部分索引
 CREATE INDEX idx_listings_contract_status ON listings(nft_contract, status);
+CREATE INDEX idx_listings_seller_unarchived ON listings(seller, listed_at DESC) WHERE archived_at IS NULL; -- 部分索引
 
 -- Listings 表注释
 COMMENT ON TABLE listings IS '市场挂单表';
 COMMENT ON COLUMN listings.item_id IS '链上市场项 ID';
 COMMENT ON COLUMN listings.price_numeric IS '价格数值类型（用于排序）';
-COMMENT ON COLUMN listings.status IS '挂单状态：active-活跃, sold-已售, cancelled-已取消';
+COMMENT ON COLUMN listings.status IS '挂单状态：active-活跃, pending-待链上校验, sold-已售, cancelled-已取消, invalid-校验失败';
+COMMENT ON COLUMN listings.unverified IS 'RPC 不可用时按请求数据创建，待熔断恢复后校验';
+COMMENT ON COLUMN listings.cancelled_at IS '取消时间，状态变为 cancelled 时写入（早期取消的挂单为空）';
+COMMENT ON COLUMN listings.archived_at IS '归档时间，已归档挂单默认不出现在列表查询中（仍可按 ID 查询）';
+COMMENT ON COLUMN listings.listing_type IS '挂单类型：fixed_price-一口价, auction-英式拍卖（price 为起拍价）';
+COMMENT ON COLUMN listings.reserve_price IS '拍卖保留价，竞价不得低于该价格';
 
 -- ============================================
 -- 3. Transactions 表 - 交易记录
 -- ============================================
 CREATE TABLE IF NOT EXISTS transactions (
     id BIGSERIAL PRIMARY KEY,
-    tx_hash VARCHAR(66) NOT NULL UNIQUE,
+    tx_hash VARCHAR(66) NOT NULL,
     block_number BIGINT NOT NULL,
+    block_hash VARCHAR(66), -- 确认前用于检测链重组
     block_timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
     
     -- 交易类型
@@ -119,6 +151,7 @@ CREATE TABLE IF NOT EXISTS transactions (
     
     -- 关联信息
     listing_id BIGINT REFERENCES listings(id),
+    item_id BIGINT, -- 市场合约 item_id（成交交易），用于成交事件去重
     nft_contract VARCHAR(42) NOT NULL,
     token_id VARCHAR(78) NOT NULL,
     
@@ -129,6 +162,7 @@ CREATE TABLE IF NOT EXISTS transactions (
     -- 金额信息
     value VARCHAR(78), -- Wei 单位
     value_numeric NUMERIC(78, 0),
+    net_value_numeric NUMERIC(78, 0), -- 卖家实际到账金额（支付代币收取转账手续费时小于 value）
     gas_price VARCHAR(78),
     gas_used BIGINT,
     
@@ -136,12 +170,16 @@ CREATE TABLE IF NOT EXISTS transactions (
     platform_fee VARCHAR(78),
     platform_fee_numeric NUMERIC(78, 0),
     
+    -- 一级市场（卖家为创作者）/ 二级市场
+    is_primary BOOLEAN DEFAULT FALSE,
+    
     -- 交易状态
     status VARCHAR(20) DEFAULT 'confirmed', -- pending, confirmed, failed
     
     -- 元数据
     log_index INTEGER,
     transaction_index INTEGER,
+    contract_version VARCHAR(20), -- 解码事件所用的市场合约 ABI 版本
     
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
@@ -149,9 +187,12 @@ CREATE TABLE IF NOT EXISTS transactions (
 
 -- Transactions 索引
 CREATE INDEX idx_transactions_tx_hash ON transactions(tx_hash);
+CREATE UNIQUE INDEX idx_transactions_tx_log ON transactions(tx_hash, log_index); -- 同一日志只记录一次
 CREATE INDEX idx_transactions_block ON transactions(block_number DESC);
 CREATE INDEX idx_transactions_type ON transactions(tx_type);
 CREATE INDEX idx_transactions_listing ON transactions(listing_id);
+CREATE INDEX idx_transactions_item_block ON transactions(item_id, block_number) WHERE tx_type = 'sale';
+CREATE INDEX idx_transactions_pending_sales ON transactions(block_number) WHERE tx_type = 'sale' AND status = 'pending'; -- 待确认成交
 CREATE INDEX idx_transactions_nft ON transactions(nft_contract, token_id);
 CREATE INDEX idx_transactions_from ON transactions(from_address);
 CREATE INDEX idx_transactions_to ON transactions(to_address);
@@ -169,7 +210,7 @@ CREATE TABLE IF NOT EXISTS users (
     id BIGSERIAL PRIMARY KEY,
     address VARCHAR(42) NOT NULL UNIQUE,
     username VARCHAR(50),
-    email VARCHAR(255),
+    email TEXT, -- AES-256-GCM 加密存储，格式 v<密钥版本>:<base64(nonce|密文)>
     bio TEXT,
     avatar_url TEXT,
     banner_url TEXT,
@@ -208,6 +249,7 @@ CREATE INDEX idx_users_username_trgm ON users USING gin(username gin_trgm_ops);
 
 -- Users 表注释
 COMMENT ON TABLE users IS '用户信息表';
+COMMENT ON COLUMN users.email IS '加密后的邮箱，明文不落库；密钥轮换时按版本号解密并用当前密钥重新加密';
 
 -- ============================================
 -- 5. Collections 表 - NFT 系列
@@ -254,6 +296,14 @@ CREATE TABLE IF NOT EXISTS collections (
     royalty_percentage NUMERIC(5, 2) DEFAULT 0.00, -- 0.00 - 100.00
     royalty_recipient VARCHAR(42),
     
+    -- 平台费率覆盖（基点），为空时使用全局 PLATFORM_FEE_BPS
+    fee_bps_override INTEGER CHECK (fee_bps_override BETWEEN 0 AND 1000),
+    -- 出价最小加价幅度（覆盖全局配置，取比例与绝对值中较大者）
+    min_bid_increment_bps INTEGER CHECK (min_bid_increment_bps BETWEEN 0 AND 10000),
+    min_bid_increment_wei NUMERIC(78, 0) CHECK (min_bid_increment_wei >= 0),
+    -- 图片 CDN 前缀：IPFS 图片地址在响应中改写到该前缀，为空时使用全局 IPFS 网关
+    image_cdn_base TEXT,
+    
     -- 元数据
     metadata JSONB DEFAULT '{}',
     
@@ -315,10 +365,26 @@ CREATE INDEX idx_offers_status ON offers(status);
 CREATE INDEX idx_offers_expires ON offers(expires_at);
 CREATE INDEX idx_offers_price ON offers(price_numeric DESC);
 CREATE INDEX idx_offers_created ON offers(created_at DESC);
+CREATE INDEX idx_offers_nft_best ON offers(nft_contract, token_id, status, price_numeric DESC); -- 挂单最高出价查询
 
 -- Offers 表注释
 COMMENT ON TABLE offers IS '出价表';
 
+-- Bids 表 - 拍卖竞价记录
+CREATE TABLE IF NOT EXISTS bids (
+    id BIGSERIAL PRIMARY KEY,
+    listing_id BIGINT NOT NULL REFERENCES listings(id),
+    bidder VARCHAR(42) NOT NULL,
+    amount VARCHAR(78) NOT NULL, -- Wei 单位的竞价金额
+    amount_numeric NUMERIC(78, 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_bids_listing_amount ON bids(listing_id, amount_numeric DESC); -- 最高竞价查询
+CREATE INDEX idx_bids_bidder ON bids(bidder);
+
+COMMENT ON TABLE bids IS '拍卖竞价表';
+
 -- ============================================
 -- 7. Activities 表 - 活动记录
 -- ============================================
@@ -437,6 +503,80 @@ CREATE INDEX idx_sync_state_status ON sync_state(sync_status);
 -- Sync_State 表注释
 COMMENT ON TABLE sync_state IS '区块链同步状态表';
 
+-- ============================================
+-- 11. Failed_Events 表 - 处理失败的事件（死信）
+-- ============================================
+CREATE TABLE IF NOT EXISTS failed_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_name VARCHAR(50) NOT NULL,
+    transaction_hash VARCHAR(66),
+    log_index INTEGER,
+    block_number BIGINT,
+    event_data JSONB, -- 解码后的事件，用于人工重放
+    error_message TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Failed_Events 索引
+CREATE INDEX idx_failed_events_created_at ON failed_events(created_at DESC);
+CREATE INDEX idx_failed_events_tx_hash ON failed_events(transaction_hash);
+
+-- Failed_Events 表注释
+COMMENT ON TABLE failed_events IS '事件监听处理失败的事件（死信队列）';
+
+-- ============================================
+-- 12. Notification_Preferences 表 - 通知偏好
+-- ============================================
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    address VARCHAR(42) PRIMARY KEY, -- 小写钱包地址
+
+    -- 事件类型
+    sales BOOLEAN NOT NULL DEFAULT TRUE,
+    outbid BOOLEAN NOT NULL DEFAULT TRUE,
+    offers BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- 渠道（邮件、Webhook 需用户主动开启）
+    email BOOLEAN NOT NULL DEFAULT FALSE,
+    webhook BOOLEAN NOT NULL DEFAULT FALSE,
+    websocket BOOLEAN NOT NULL DEFAULT TRUE,
+
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Notification_Preferences 表注释
+COMMENT ON TABLE notification_preferences IS '按地址的通知偏好，事件类型与渠道均开启时才发送；无记录时使用默认值';
+
+-- ============================================
+-- 13. Sync_State 迁移 - 事件索引进度（复用第 10 节的表）
+-- ============================================
+ALTER TABLE sync_state ADD COLUMN IF NOT EXISTS last_synced_block BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE sync_state ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- 进度按事件流记录：每个合约的每个事件一行
+ALTER TABLE sync_state ADD COLUMN IF NOT EXISTS event_name VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE sync_state DROP CONSTRAINT IF EXISTS sync_state_contract_address_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sync_state_contract_event ON sync_state(contract_address, event_name);
+
+-- 旧的合约级进度复制给每个事件流后删除
+INSERT INTO sync_state (contract_address, event_name, last_synced_block, updated_at)
+SELECT s.contract_address, e.event_name, s.last_synced_block, s.updated_at
+FROM sync_state s
+CROSS JOIN (VALUES ('MarketItemCreated'), ('MarketItemSold'), ('MarketItemCanceled')) AS e(event_name)
+WHERE s.event_name = ''
+ON CONFLICT (contract_address, event_name) DO NOTHING;
+DELETE FROM sync_state WHERE event_name = '';
+
+-- Sync_State 列注释
+COMMENT ON COLUMN sync_state.contract_address IS '小写合约地址';
+COMMENT ON COLUMN sync_state.event_name IS '事件名（MarketItemCreated / MarketItemSold / MarketItemCanceled），每个合约的每个事件一行';
+COMMENT ON COLUMN sync_state.last_synced_block IS '已入库事件的最高区块，与事件写入在同一事务中推进，回填从此处续传';
+
+-- ============================================
+-- 14. Users 迁移 - 邮箱加密存储（复用第 4 节的表）
+-- ============================================
+-- 密文比原 VARCHAR(255) 长；加密上线前的明文邮箱读取时原样返回，启动时由密钥轮换任务用当前密钥加密
+ALTER TABLE users ALTER COLUMN email TYPE TEXT;
+
 -- ============================================
 -- 视图：活跃挂单统计
 -- ============================================
@@ -493,6 +633,32 @@ GROUP BY c.id, c.contract_address, c.name, c.floor_price, c.total_volume;
 
 COMMENT ON VIEW v_collection_stats IS '系列统计视图';
 
+-- ============================================
+-- 物化视图：热门系列（按时间窗口的成交额/成交数，定时刷新）
+-- ============================================
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_trending_collections AS
+SELECT 
+    t.nft_contract,
+    COALESCE(c.name, '') as name,
+    COALESCE(SUM(t.value_numeric) FILTER (WHERE t.block_timestamp >= NOW() - INTERVAL '24 hours'), 0) as volume_24h,
+    COUNT(*) FILTER (WHERE t.block_timestamp >= NOW() - INTERVAL '24 hours') as sales_24h,
+    COALESCE(SUM(t.value_numeric) FILTER (WHERE t.block_timestamp >= NOW() - INTERVAL '7 days'), 0) as volume_7d,
+    COUNT(*) FILTER (WHERE t.block_timestamp >= NOW() - INTERVAL '7 days') as sales_7d,
+    COALESCE(SUM(t.value_numeric), 0) as volume_30d,
+    COUNT(*) as sales_30d,
+    NOW() as refreshed_at
+FROM transactions t
+LEFT JOIN collections c ON c.contract_address = t.nft_contract
+WHERE t.tx_type = 'sale'
+  AND t.status = 'confirmed'
+  AND t.block_timestamp >= NOW() - INTERVAL '30 days'
+GROUP BY t.nft_contract, c.name;
+
+-- REFRESH ... CONCURRENTLY 需要唯一索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mv_trending_collections_contract ON mv_trending_collections(nft_contract);
+
+COMMENT ON MATERIALIZED VIEW mv_trending_collections IS '热门系列物化视图（由应用定时刷新）';
+
 -- ============================================
 -- 触发器：自动更新 updated_at
 -- ============================================
@@ -542,6 +708,9 @@ $$ LANGUAGE plpgsql;
 CREATE TRIGGER sync_listings_price_numeric BEFORE INSERT OR UPDATE ON listings
     FOR EACH ROW EXECUTE FUNCTION sync_price_numeric();
 
+CREATE TRIGGER sync_offers_price_numeric BEFORE INSERT OR UPDATE ON offers
+    FOR EACH ROW EXECUTE FUNCTION sync_price_numeric();
+
 CREATE TRIGGER sync_transactions_value_numeric BEFORE INSERT OR UPDATE ON transactions
     FOR EACH ROW WHEN (NEW.value IS NOT NULL)
     EXECUTE FUNCTION sync_price_numeric();
@@ -586,6 +755,66 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+-- ============================================
+-- 迁移：历史记录的业务时间改为链上区块时间（可重复执行）
+-- 此前 listed_at / sold_at / minted_at 记录的是索引时的服务器时间，
+-- 仅能修正有对应链上交易记录的行
+-- ============================================
+UPDATE listings l
+SET listed_at = t.block_timestamp
+FROM transactions t
+WHERE t.tx_hash = l.tx_hash
+  AND t.tx_type = 'list'
+  AND t.block_number > 0
+  AND l.listed_at IS DISTINCT FROM t.block_timestamp;
+
+UPDATE listings l
+SET sold_at = t.block_timestamp
+FROM transactions t
+WHERE t.listing_id = l.id
+  AND t.tx_type = 'sale'
+  AND t.status = 'confirmed'
+  AND t.block_number > 0
+  AND l.status = 'sold'
+  AND l.sold_at IS DISTINCT FROM t.block_timestamp;
+
+UPDATE nfts n
+SET minted_at = t.block_timestamp
+FROM transactions t
+WHERE t.nft_contract = n.contract_address
+  AND t.token_id = n.token_id
+  AND t.tx_type = 'mint'
+  AND t.status = 'confirmed'
+  AND t.block_number > 0
+  AND n.minted_at IS DISTINCT FROM t.block_timestamp;
+
+-- ============================================
+-- 迁移：地址列统一为小写（可重复执行，与 repository.NormalizeAddressCase 相同）
+-- 此前事件写入的是 EIP-55 校验和地址，API 写入的是客户端原样传入的地址。
+-- 同一 Token 同时存在大小写不同的多行时跳过这些行（保持原样），可用下面的查询找出后人工合并：
+-- SELECT LOWER(contract_address), token_id, STRING_AGG(id::text, ',') FROM nfts
+-- GROUP BY LOWER(contract_address), token_id HAVING COUNT(*) > 1;
+-- ============================================
+UPDATE nfts
+SET contract_address = LOWER(contract_address), owner = LOWER(owner), creator = LOWER(creator)
+WHERE (contract_address <> LOWER(contract_address) OR owner <> LOWER(owner) OR creator <> LOWER(creator))
+  AND NOT EXISTS (
+    SELECT 1 FROM nfts d
+    WHERE LOWER(d.contract_address) = LOWER(nfts.contract_address) AND d.token_id = nfts.token_id AND d.id <> nfts.id
+  );
+
+UPDATE listings
+SET nft_contract = LOWER(nft_contract), seller = LOWER(seller)
+WHERE nft_contract <> LOWER(nft_contract) OR seller <> LOWER(seller);
+
+UPDATE transactions
+SET nft_contract = LOWER(nft_contract), from_address = LOWER(from_address), to_address = LOWER(to_address)
+WHERE nft_contract <> LOWER(nft_contract) OR from_address <> LOWER(from_address) OR to_address <> LOWER(to_address);
+
+UPDATE offers
+SET nft_contract = LOWER(nft_contract), offerer = LOWER(offerer)
+WHERE nft_contract <> LOWER(nft_contract) OR offerer <> LOWER(offerer);
+
 -- ============================================
 -- 插入测试数据（可选）
 -- ============================================